@@ -0,0 +1,48 @@
+package zapdriver
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AgentStackTraces writes the stack trace of entries with level error or
+// above, or with an `ErrorReport()` field, to the local output under the
+// "stack_trace" key, preceded by the message, in the format of a Go panic. This
+// is what the Error Reporting parser of entries collected from standard output
+// by the Cloud Logging agent (or the runtime of Cloud Run, Cloud Functions or
+// Kubernetes Engine) expects:
+//
+//	{"severity": "ERROR", "message": "failed", "stack_trace": "failed\n\ngoroutine 1 [running]:\n..."}
+//
+// The stack trace is taken from the entry (see `zap.AddStacktrace`), or from an
+// error created by `github.com/pkg/errors`, and replaces zap's "stacktrace" key.
+// `NewAgent` enables it.
+func AgentStackTraces() func(*core) {
+	return func(c *core) {
+		c.config.AgentStackTraces = true
+	}
+}
+
+// withAgentStackTrace moves the stack trace of an error entry to the
+// "stack_trace" field, see `AgentStackTraces`.
+func (c *core) withAgentStackTrace(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+	if !zapcore.ErrorLevel.Enabled(ent.Level) && !c.reportsError(ent, fields) {
+		return ent, fields
+	}
+
+	for _, set := range [][]zapcore.Field{c.fields, fields} {
+		for i := range set {
+			if set[i].Key == stackTraceKey {
+				return ent, fields
+			}
+		}
+	}
+
+	stack := errorStack(ent, c.fields, fields)
+	if stack == "" {
+		return ent, fields
+	}
+
+	ent.Stack = ""
+	return ent, append(fields, zap.String(stackTraceKey, stack))
+}
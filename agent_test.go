@@ -0,0 +1,64 @@
+package zapdriver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAgentStackTraces(t *testing.T) {
+	t.Parallel()
+
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, zap.AddStacktrace(zapcore.WarnLevel), WrapCore(AgentStackTraces()))
+
+	logger.Error("failed")
+	logger.Warn("careful")
+	logger.Error("wrapped", zap.Error(errors.New("boom")))
+	logger.Error("explicit", zap.String("stack_trace", "custom"))
+
+	entries := logs.All()
+	require.Len(t, entries, 4)
+
+	stack := entries[0].ContextMap()[stackTraceKey].(string)
+	assert.True(t, strings.HasPrefix(stack, "failed\n\ngoroutine 1 [running]:\n"), stack)
+	assert.Contains(t, stack, "zapdriver.TestAgentStackTraces")
+	assert.Empty(t, entries[0].Stack)
+
+	assert.NotContains(t, entries[1].ContextMap(), stackTraceKey)
+	assert.NotEmpty(t, entries[1].Stack)
+
+	stack = entries[2].ContextMap()[stackTraceKey].(string)
+	assert.True(t, strings.HasPrefix(stack, "wrapped\n\ngoroutine 1 [running]:\n"), stack)
+
+	assert.Equal(t, "custom", entries[3].ContextMap()[stackTraceKey])
+	assert.NotEmpty(t, entries[3].Stack)
+}
+
+func TestAgentStackTraces_ErrorField(t *testing.T) {
+	t.Parallel()
+
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, WrapCore(AgentStackTraces()))
+
+	logger.Error("wrapped", zap.Error(errors.New("boom")))
+	logger.Error("plain", zap.Error(fmt.Errorf("boom")))
+	logger.Info("done")
+
+	entries := logs.All()
+	require.Len(t, entries, 3)
+
+	stack := entries[0].ContextMap()[stackTraceKey].(string)
+	assert.True(t, strings.HasPrefix(stack, "boom\n\ngoroutine 1 [running]:\n"), stack)
+	assert.Contains(t, stack, "zapdriver.TestAgentStackTraces_ErrorField")
+
+	assert.NotContains(t, entries[1].ContextMap(), stackTraceKey)
+	assert.NotContains(t, entries[2].ContextMap(), stackTraceKey)
+}
@@ -0,0 +1,247 @@
+package zapdriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultAnyDepth is the maximum nesting depth up to which `Any` encodes
+// values.
+const DefaultAnyDepth = 10
+
+const (
+	maxDepthValue = "[max depth exceeded]"
+	cycleValue    = "[cycle]"
+)
+
+// Any adds an arbitrary value to the payload. Contrary to `zap.Reflect`, the
+// value is first converted into a structure that is always safe to encode as
+// JSON:
+//
+//   - nesting is limited to `DefaultAnyDepth` levels,
+//   - cyclic references are replaced with "[cycle]",
+//   - struct members are renamed using the `log` or `json` struct tags, and
+//     redacted using the `log` struct tag, as in `log:"name,redact"` or
+//     `log:"-"`,
+//   - values that can't be represented in JSON (channels, functions) are
+//     replaced with their type name.
+func Any(key string, value interface{}) zap.Field {
+	return AnyDepth(key, value, DefaultAnyDepth)
+}
+
+// AnyDepth is the same as `Any`, but with a configurable maximum depth.
+func AnyDepth(key string, value interface{}, maxDepth int) zap.Field {
+	return zap.Reflect(key, encodeAny(value, maxDepth))
+}
+
+// encodeAny converts v into a JSON-safe structure of maps, slices and
+// primitive values.
+func encodeAny(v interface{}, maxDepth int) interface{} {
+	enc := &anyEncoder{maxDepth: maxDepth, path: map[anyRef]bool{}}
+
+	return enc.encode(reflect.ValueOf(v), 0)
+}
+
+// anyRef identifies a reference type value on the path being encoded.
+type anyRef struct {
+	kind reflect.Kind
+	ptr  uintptr
+}
+
+type anyEncoder struct {
+	maxDepth int
+	path     map[anyRef]bool
+}
+
+func (e *anyEncoder) encode(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+	}
+
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case time.Time:
+			return x.Format(time.RFC3339Nano)
+		case time.Duration:
+			return x.String()
+		case json.Marshaler:
+			return e.encodeJSON(x)
+		case error:
+			return x.Error()
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(v.Complex())
+	case reflect.Interface:
+		return e.encode(v.Elem(), depth)
+	}
+
+	if depth >= e.maxDepth {
+		return maxDepthValue
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		ref := anyRef{kind: v.Kind(), ptr: v.Pointer()}
+		if e.path[ref] {
+			return cycleValue
+		}
+
+		e.path[ref] = true
+		defer delete(e.path, ref)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		return e.encode(v.Elem(), depth)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			return v.Bytes()
+		}
+
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = e.encode(v.Index(i), depth+1)
+		}
+
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			out[fmt.Sprint(k.Interface())] = e.encode(v.MapIndex(k), depth+1)
+		}
+
+		return out
+	case reflect.Struct:
+		out := map[string]interface{}{}
+		e.encodeStruct(v, depth, out)
+
+		return out
+	}
+
+	return v.Type().String()
+}
+
+func (e *anyEncoder) encodeStruct(v reflect.Value, depth int, out map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := parseLogTag(sf)
+
+		// Embedded structs without an explicit name are flattened, like
+		// encoding/json does.
+		if sf.Anonymous && !tag.named && !tag.skip {
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+
+			if fv.Kind() == reflect.Struct {
+				e.encodeStruct(fv, depth, out)
+				continue
+			}
+		}
+
+		if sf.PkgPath != "" || tag.skip {
+			continue
+		}
+
+		if tag.redact {
+			out[tag.name] = redactedValue
+			continue
+		}
+
+		out[tag.name] = e.encode(v.Field(i), depth+1)
+	}
+}
+
+func (e *anyEncoder) encodeJSON(m json.Marshaler) interface{} {
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return err.Error()
+	}
+
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return err.Error()
+	}
+
+	return out
+}
+
+// logTag is the parsed representation of the `log` (and `json`) struct tags of
+// a struct member.
+type logTag struct {
+	name   string
+	named  bool
+	skip   bool
+	redact bool
+}
+
+// parseLogTag parses the `log` struct tag, which is formatted as
+// `log:"name,redact"`. The name falls back to the one in the `json` struct
+// tag, and then to the name of the member. A tag of `log:"-"` skips the
+// member, and a bare `log:"redact"` masks it under its default name.
+func parseLogTag(sf reflect.StructField) logTag {
+	tag := logTag{name: sf.Name}
+
+	if name := strings.Split(sf.Tag.Get("json"), ",")[0]; name == "-" {
+		tag.skip = true
+	} else if name != "" {
+		tag.name, tag.named = name, true
+	}
+
+	l, ok := sf.Tag.Lookup("log")
+	if !ok {
+		return tag
+	}
+
+	switch l {
+	case "-":
+		tag.skip = true
+		return tag
+	case "redact":
+		tag.redact = true
+		return tag
+	}
+
+	parts := strings.Split(l, ",")
+	if parts[0] != "" {
+		tag.name, tag.named, tag.skip = parts[0], true, false
+	}
+
+	for _, opt := range parts[1:] {
+		if opt == "redact" {
+			tag.redact = true
+		}
+	}
+
+	return tag
+}
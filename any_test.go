@@ -0,0 +1,75 @@
+package zapdriver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type anyNode struct {
+	Name     string   `log:"node_name"`
+	Children []string `json:"children,omitempty"`
+	Secret   string   `log:"secret,redact"`
+	Next     *anyNode `json:"next"`
+	Done     chan struct{}
+	At       time.Time
+	Err      error
+
+	anyEmbedded
+}
+
+type anyEmbedded struct {
+	Kind string `json:"kind"`
+}
+
+func TestAny(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2020, 2, 22, 12, 0, 0, 0, time.UTC)
+	node := &anyNode{
+		Name:        "root",
+		Children:    []string{"a"},
+		Secret:      "hunter2",
+		Done:        make(chan struct{}),
+		At:          at,
+		Err:         errors.New("failed"),
+		anyEmbedded: anyEmbedded{Kind: "tree"},
+	}
+
+	want := map[string]interface{}{
+		"node_name": "root",
+		"children":  []interface{}{"a"},
+		"secret":    redactedValue,
+		"next":      nil,
+		"Done":      "chan struct {}",
+		"At":        "2020-02-22T12:00:00Z",
+		"Err":       "failed",
+		"kind":      "tree",
+	}
+
+	assert.Equal(t, zap.Reflect("node", want), Any("node", node))
+}
+
+func TestAny_Cycle(t *testing.T) {
+	t.Parallel()
+
+	node := &anyNode{Name: "root"}
+	node.Next = node
+
+	got := encodeAny(node, DefaultAnyDepth).(map[string]interface{})
+
+	assert.Equal(t, cycleValue, got["next"])
+}
+
+func TestAnyDepth(t *testing.T) {
+	t.Parallel()
+
+	value := map[string]interface{}{"a": map[string]interface{}{"b": map[string]int{"c": 1}}}
+
+	want := map[string]interface{}{"a": map[string]interface{}{"b": maxDepthValue}}
+
+	assert.Equal(t, zap.Reflect("deep", want), AnyDepth("deep", value, 2))
+}
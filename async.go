@@ -0,0 +1,295 @@
+package zapdriver
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultAsyncQueueSize is the number of entries the async writer queues
+// before writes block.
+const defaultAsyncQueueSize = 4096
+
+// QueuePolicy decides what happens to entries written while the async queue
+// (see `Async`) is full.
+type QueuePolicy int
+
+const (
+	// QueueBlock blocks the writing goroutine until the queue has room. This is
+	// the default.
+	QueueBlock QueuePolicy = iota
+
+	// QueueDropNewest drops the entry being written.
+	QueueDropNewest
+
+	// QueueDropOldest drops the oldest queued entry, to make room for the entry
+	// being written.
+	QueueDropOldest
+)
+
+// Async moves the work of writing entries, to Cloud Logging as well as to the
+// wrapped core, off the calling goroutine. Entries are queued, and written by
+// `workers` background goroutines, each taking up to `batchSize` queued entries
+// at once. More workers increase the throughput of very chatty services.
+//
+// The workers are started when the first entry is written. `Sync` waits for
+// all queued entries to be written, and `Close` stops the workers. Entries of
+// level DPanic or higher are written synchronously, as the process might not
+// survive them.
+func Async(workers, batchSize int) func(*core) {
+	return func(c *core) {
+		c.config.Async = &asyncWriter{
+			workers:   workers,
+			batchSize: batchSize,
+		}
+		c.config.Async.idle = sync.NewCond(&c.config.Async.pendingMutex)
+	}
+}
+
+// AsyncQueue configures the size of the async queue (see `Async`), and what
+// happens to entries written while it's full. With a drop policy, writes never
+// block, and dropped entries are written neither to Cloud Logging nor to the
+// wrapped core; `DroppedEntries` returns how many were dropped.
+func AsyncQueue(size int, policy QueuePolicy) func(*core) {
+	return func(c *core) {
+		c.config.AsyncQueueSize = size
+		c.config.AsyncQueuePolicy = policy
+	}
+}
+
+// DroppedEntries returns the number of entries the async queue of the logger
+// dropped because it was full (see `AsyncQueue`). It returns 0 if the logger
+// doesn't use the zapdriver core, or doesn't write asynchronously.
+func DroppedEntries(logger *zap.Logger) uint64 {
+	c, ok := logger.Core().(*core)
+	if !ok || c.config.Async == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&c.config.Async.dropped)
+}
+
+// asyncWriter writes queued entries in the background.
+type asyncWriter struct {
+	// dropped counts the entries dropped because the queue was full, or
+	// abandoned by `Shutdown`. It's accessed atomically, and kept first for
+	// alignment.
+	dropped uint64
+
+	// abandoned is set atomically when the queued entries are to be dropped
+	// instead of written.
+	abandoned uint32
+
+	workers   int
+	batchSize int
+	policy    QueuePolicy
+
+	startOnce sync.Once
+	done      sync.WaitGroup
+
+	// mutex guards sending to, and closing, the queue.
+	mutex  sync.RWMutex
+	closed bool
+	queue  chan asyncEntry
+
+	// pending counts the entries that are queued or being written.
+	pendingMutex sync.Mutex
+	pending      int
+	idle         *sync.Cond
+}
+
+type asyncEntry struct {
+	core   *core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// write queues the entry. It returns false if the entry should be written
+// synchronously instead.
+func (a *asyncWriter) write(c *core, ent zapcore.Entry, fields []zapcore.Field) bool {
+	if ent.Level >= zapcore.DPanicLevel {
+		a.wait()
+		return false
+	}
+
+	a.startOnce.Do(func() {
+		if c.config.Order != nil {
+			// Several workers would write the entries out of order.
+			a.workers = 1
+		}
+		a.start(c.config.AsyncQueueSize, c.config.AsyncQueuePolicy)
+	})
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	if a.closed {
+		return false
+	}
+
+	a.pendingMutex.Lock()
+	a.pending++
+	a.pendingMutex.Unlock()
+
+	if len(c.config.Watermarks) > 0 {
+		utilization := float64(len(a.queue)) / float64(cap(a.queue))
+		for _, w := range c.config.Watermarks {
+			w.observe(utilization)
+		}
+	}
+
+	e := asyncEntry{
+		core:   c,
+		ent:    ent,
+		fields: append([]zapcore.Field(nil), fields...),
+	}
+
+	switch a.policy {
+	case QueueDropNewest:
+		select {
+		case a.queue <- e:
+		default:
+			a.drop(1)
+			c.config.Metrics.drop(dropQueue)
+		}
+	case QueueDropOldest:
+		for {
+			select {
+			case a.queue <- e:
+				return true
+			default:
+			}
+
+			select {
+			case <-a.queue:
+				a.drop(1)
+				c.config.Metrics.drop(dropQueue)
+			default:
+			}
+		}
+	default:
+		a.queue <- e
+	}
+
+	return true
+}
+
+// drop records that n queued entries were dropped.
+func (a *asyncWriter) drop(n int) {
+	atomic.AddUint64(&a.dropped, uint64(n))
+	a.finish(n)
+}
+
+// finish records that n queued entries were written or dropped.
+func (a *asyncWriter) finish(n int) {
+	a.pendingMutex.Lock()
+	a.pending -= n
+	if a.pending == 0 {
+		a.idle.Broadcast()
+	}
+	a.pendingMutex.Unlock()
+}
+
+// start creates the queue, and starts the workers.
+func (a *asyncWriter) start(size int, policy QueuePolicy) {
+	if size <= 0 {
+		size = defaultAsyncQueueSize
+	}
+
+	a.mutex.Lock()
+	a.queue = make(chan asyncEntry, size)
+	a.policy = policy
+	a.mutex.Unlock()
+
+	a.done.Add(a.workers)
+	for i := 0; i < a.workers; i++ {
+		go a.run()
+	}
+}
+
+func (a *asyncWriter) run() {
+	defer a.done.Done()
+
+	batch := make([]asyncEntry, 0, a.batchSize)
+	for e := range a.queue {
+		batch = append(batch[:0], e)
+
+	drain:
+		for len(batch) < a.batchSize {
+			select {
+			case e, ok := <-a.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, e)
+			default:
+				break drain
+			}
+		}
+
+		written := 0
+		for _, e := range batch {
+			if atomic.LoadUint32(&a.abandoned) == 1 {
+				a.drop(1)
+				continue
+			}
+
+			_ = e.core.write(e.ent, e.fields, true)
+			written++
+		}
+
+		a.finish(written)
+	}
+}
+
+// wait blocks until all queued entries are written.
+func (a *asyncWriter) wait() {
+	a.pendingMutex.Lock()
+	for a.pending > 0 {
+		a.idle.Wait()
+	}
+	a.pendingMutex.Unlock()
+}
+
+// abandon drops the queued entries, instead of writing them. The entries
+// still in the queue are dropped right away, the ones the workers already
+// took once they get to them.
+func (a *asyncWriter) abandon() {
+	atomic.StoreUint32(&a.abandoned, 1)
+
+	// The queue is read without the mutex: `close` may be waiting for it,
+	// behind writers blocked on a full queue, which the drain below unblocks.
+	// Once startOnce is done, the queue is no longer modified.
+	a.startOnce.Do(func() {})
+
+	for {
+		select {
+		case _, ok := <-a.queue:
+			if !ok {
+				return
+			}
+			a.drop(1)
+		default:
+			return
+		}
+	}
+}
+
+// close writes all queued entries, and stops the workers. Entries written
+// afterwards are written synchronously.
+func (a *asyncWriter) close() {
+	a.startOnce.Do(func() {})
+
+	a.mutex.Lock()
+	if !a.closed {
+		a.closed = true
+		if a.queue != nil {
+			close(a.queue)
+		}
+	}
+	a.mutex.Unlock()
+
+	a.done.Wait()
+}
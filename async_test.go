@@ -0,0 +1,117 @@
+package zapdriver
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWriteAsync(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	Async(4, 8)(core)
+
+	logger := zap.New(core)
+	defer func() { _ = Close(logger) }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				logger.Info(strconv.Itoa(i*10+j), Label("worker", strconv.Itoa(i)))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.NoError(t, logger.Sync())
+
+	assert.Equal(t, 100, logs.Len())
+	assert.Len(t, server.Entries(), 100)
+}
+
+func TestWriteAsync_DPanic(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	Async(1, 1)(core)
+
+	logger := zap.New(core)
+	defer func() { _ = Close(logger) }()
+
+	logger.Info("one")
+	logger.DPanic("two")
+
+	require.Equal(t, 2, logs.Len())
+	assert.Equal(t, "one", logs.All()[0].Message)
+	assert.Equal(t, "two", logs.All()[1].Message)
+}
+
+func TestWriteAsync_AfterClose(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	Async(2, 4)(core)
+
+	logger := zap.New(core)
+	logger.Info("queued")
+	require.NoError(t, Close(logger))
+	assert.Equal(t, 1, logs.Len())
+
+	logger.Info("direct")
+	assert.Equal(t, 2, logs.Len())
+}
+
+func TestWriteAsync_QueuePolicy(t *testing.T) {
+	tests := []struct {
+		policy QueuePolicy
+		want   []string
+	}{
+		{QueueDropNewest, []string{"one", "two"}},
+		{QueueDropOldest, []string{"one", "three"}},
+	}
+
+	for _, tt := range tests {
+		debugcore, logs := observer.New(zapcore.DebugLevel)
+		blocking := &blockingCore{Core: debugcore, release: make(chan struct{}), started: make(chan struct{}, 3)}
+
+		core := &core{Core: blocking, permLabels: newLabels()}
+		Async(1, 1)(core)
+		AsyncQueue(1, tt.policy)(core)
+
+		logger := zap.New(core)
+
+		// The worker blocks on the first entry, so the second one fills the queue.
+		logger.Info("one")
+		<-blocking.started
+		logger.Info("two")
+		logger.Info("three")
+
+		close(blocking.release)
+		require.NoError(t, Close(logger))
+
+		var messages []string
+		for _, e := range logs.All() {
+			messages = append(messages, e.Message)
+		}
+		assert.Equal(t, tt.want, messages)
+		assert.Equal(t, uint64(1), DroppedEntries(logger))
+	}
+}
+
+func TestDroppedEntries_Sync(t *testing.T) {
+	assert.Zero(t, DroppedEntries(zap.New(&core{Core: zapcore.NewNopCore(), permLabels: newLabels()})))
+	assert.Zero(t, DroppedEntries(zap.NewNop()))
+}
@@ -0,0 +1,152 @@
+// Package audit writes the audit trail of an application as entries shaped
+// like Cloud Audit Logs, to a dedicated log:
+//
+//	auditor := audit.NewLogger(logger)
+//	auditor.Log(audit.Entry{
+//		ServiceName:  "orders.example.com",
+//		MethodName:   "orders.Cancel",
+//		ResourceName: "orders/1234",
+//		Principal:    "alice@example.com",
+//		Authorization: []audit.Authorization{
+//			{Resource: "orders/1234", Permission: "orders.cancel", Granted: true},
+//		},
+//	})
+//
+// The entries are written with a `zapdriver.LogName` field, so the logger must
+// use `zapdriver.WithLogNameRouting` for them to end up in the dedicated log.
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/logging"
+	"github.com/blendle/zapdriver"
+	"go.uber.org/zap"
+)
+
+// DefaultLogName is the log audit entries are written to by default.
+const DefaultLogName = "audit"
+
+// auditLogType is the type URL of Cloud Audit Logs entries.
+const auditLogType = "type.googleapis.com/google.cloud.audit.AuditLog"
+
+// Entry is an audited operation, in the shape of the `AuditLog` payload of
+// Cloud Audit Logs.
+//
+// see: https://cloud.google.com/logging/docs/reference/audit/auditlog/rest/Shared.Types/AuditLog
+type Entry struct {
+	// ServiceName is the name of the API service performing the operation.
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// MethodName is the name of the service method or operation.
+	MethodName string `json:"methodName,omitempty"`
+
+	// ResourceName is the resource or collection that is the target of the
+	// operation.
+	ResourceName string `json:"resourceName,omitempty"`
+
+	// Principal is the email address of the authenticated user making the
+	// request.
+	Principal string `json:"-"`
+
+	// Authorization holds the authorization checks of the operation.
+	Authorization []Authorization `json:"authorizationInfo,omitempty"`
+
+	// RequestMetadata describes the caller of the operation.
+	RequestMetadata *RequestMetadata `json:"requestMetadata,omitempty"`
+
+	// Request and Response are the operation request and response.
+	Request  map[string]interface{} `json:"request,omitempty"`
+	Response map[string]interface{} `json:"response,omitempty"`
+
+	// Status is the outcome of the operation, nil if it succeeded.
+	Status *Status `json:"status,omitempty"`
+}
+
+// Authorization is an authorization check of an operation.
+type Authorization struct {
+	Resource   string `json:"resource"`
+	Permission string `json:"permission"`
+	Granted    bool   `json:"granted"`
+}
+
+// RequestMetadata describes the caller of an operation.
+type RequestMetadata struct {
+	CallerIP        string `json:"callerIp,omitempty"`
+	CallerUserAgent string `json:"callerSuppliedUserAgent,omitempty"`
+}
+
+// RequestMetadataFromHTTP returns the request metadata of an HTTP request,
+// using the first address of the "X-Forwarded-For" header as caller IP if set.
+func RequestMetadataFromHTTP(r *http.Request) *RequestMetadata {
+	ip := r.RemoteAddr
+	if i := strings.LastIndex(ip, ":"); i >= 0 {
+		ip = ip[:i]
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		ip = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+
+	return &RequestMetadata{CallerIP: ip, CallerUserAgent: r.UserAgent()}
+}
+
+// Status is the outcome of a failed operation, using the canonical gRPC codes.
+type Status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Logger writes audit entries to a dedicated log.
+type Logger struct {
+	logger  *zap.Logger
+	logName string
+}
+
+// NewLogger returns a Logger writing to the `DefaultLogName` log.
+func NewLogger(logger *zap.Logger) *Logger {
+	return NewLoggerWithLogName(logger, DefaultLogName)
+}
+
+// NewLoggerWithLogName returns a Logger writing to the given log.
+func NewLoggerWithLogName(logger *zap.Logger, logName string) *Logger {
+	return &Logger{logger: logger.WithOptions(zap.AddCallerSkip(1)), logName: logName}
+}
+
+// Log writes the entry with notice severity, like the admin activity entries
+// of Cloud Audit Logs, or warning severity if it has a status. The message of
+// the entry is its method name.
+func (l *Logger) Log(e Entry) {
+	if e.Status != nil {
+		l.logger.Warn(e.MethodName, zapdriver.LogName(l.logName), Payload(e))
+		return
+	}
+
+	l.logger.Info(e.MethodName, zapdriver.LogName(l.logName), zapdriver.Severity(logging.Notice), Payload(e))
+}
+
+// Payload returns the field replacing the payload of an entry with the audit
+// entry, see `zapdriver.RawPayload`.
+func Payload(e Entry) zap.Field {
+	payload := struct {
+		Type string `json:"@type"`
+		Entry
+		AuthenticationInfo *authenticationInfo `json:"authenticationInfo,omitempty"`
+	}{Type: auditLogType, Entry: e}
+	if e.Principal != "" {
+		payload.AuthenticationInfo = &authenticationInfo{PrincipalEmail: e.Principal}
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return zap.Error(err)
+	}
+
+	return zapdriver.RawPayload(b)
+}
+
+// authenticationInfo is the authentication information of an audit entry.
+type authenticationInfo struct {
+	PrincipalEmail string `json:"principalEmail"`
+}
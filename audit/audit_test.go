@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/blendle/zapdriver"
+	"github.com/blendle/zapdriver/zapdrivertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggerLog(t *testing.T) {
+	t.Parallel()
+
+	rec := zapdrivertest.NewRecorder()
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, zapdriver.WrapCore(zapdriver.WithEntryHook(rec.Record)))
+
+	NewLogger(logger).Log(Entry{
+		ServiceName:  "orders.example.com",
+		MethodName:   "orders.Cancel",
+		ResourceName: "orders/1234",
+		Principal:    "alice@example.com",
+		Authorization: []Authorization{
+			{Resource: "orders/1234", Permission: "orders.cancel", Granted: true},
+		},
+		RequestMetadata: &RequestMetadata{CallerIP: "10.0.0.1", CallerUserAgent: "curl"},
+		Request:         map[string]interface{}{"reason": "duplicate"},
+	})
+
+	entries := rec.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logging.Notice, entries[0].Severity)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(entries[0].Payload.(json.RawMessage), &payload))
+	assert.Equal(t, map[string]interface{}{
+		"@type":              auditLogType,
+		"serviceName":        "orders.example.com",
+		"methodName":         "orders.Cancel",
+		"resourceName":       "orders/1234",
+		"authenticationInfo": map[string]interface{}{"principalEmail": "alice@example.com"},
+		"authorizationInfo": []interface{}{
+			map[string]interface{}{"resource": "orders/1234", "permission": "orders.cancel", "granted": true},
+		},
+		"requestMetadata": map[string]interface{}{"callerIp": "10.0.0.1", "callerSuppliedUserAgent": "curl"},
+		"request":         map[string]interface{}{"reason": "duplicate"},
+	}, payload)
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "orders.Cancel", logs.All()[0].Message)
+	assert.Equal(t, DefaultLogName, logs.All()[0].ContextMap()["logging.googleapis.com/logName"])
+}
+
+func TestLoggerLog_Status(t *testing.T) {
+	t.Parallel()
+
+	logger, rec := zapdrivertest.NewLogger(t)
+
+	NewLoggerWithLogName(logger, "security").Log(Entry{
+		MethodName: "orders.Cancel",
+		Status:     &Status{Code: 7, Message: "permission denied"},
+	})
+
+	entries := rec.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logging.Warning, entries[0].Severity)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(entries[0].Payload.(json.RawMessage), &payload))
+	assert.Equal(t, map[string]interface{}{"code": float64(7), "message": "permission denied"}, payload["status"])
+	assert.NotContains(t, payload, "authenticationInfo")
+}
+
+func TestRequestMetadataFromHTTP(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("User-Agent", "curl")
+	assert.Equal(t, &RequestMetadata{CallerIP: "192.0.2.1", CallerUserAgent: "curl"}, RequestMetadataFromHTTP(r))
+
+	r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	assert.Equal(t, "203.0.113.7", RequestMetadataFromHTTP(r).CallerIP)
+}
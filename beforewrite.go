@@ -0,0 +1,37 @@
+package zapdriver
+
+import (
+	"errors"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrDropEntry can be returned by a `WithBeforeWrite` hook to drop the entry
+// silently.
+var ErrDropEntry = errors.New("zapdriver: entry dropped")
+
+// WithBeforeWrite calls `hook` with every entry just before it's sent to Cloud
+// Logging, along with the zap entry it was built from. The hook can modify the
+// entry, for example to set its insert ID, labels (nil if it has none) or
+// resource, which makes it the escape hatch for everything the core doesn't
+// support:
+//
+//	zapdriver.WithBeforeWrite(func(e *logging.Entry, ent zapcore.Entry) error {
+//	  if ent.LoggerName == "health" {
+//	    return zapdriver.ErrDropEntry
+//	  }
+//	  e.InsertID = requestID(ent)
+//	  return nil
+//	})
+//
+// If the hook returns an error, the entry is not sent. `ErrDropEntry` drops it
+// silently, other errors are reported like delivery failures (see
+// `WithErrorHandler`). Hooks run in the order they were added, and only for the
+// primary logger, not for sinks (see `WithSink`). Entries are still written to
+// the wrapped core.
+func WithBeforeWrite(hook func(*logging.Entry, zapcore.Entry) error) func(*core) {
+	return func(c *core) {
+		c.config.BeforeWrite = append(c.config.BeforeWrite, hook)
+	}
+}
@@ -0,0 +1,61 @@
+package zapdriver
+
+import (
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+func TestWithBeforeWrite(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	var errs []error
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithErrorHandler(func(err error) { errs = append(errs, err) })(core)
+	WithBeforeWrite(func(e *logging.Entry, ent zapcore.Entry) error {
+		if ent.LoggerName == "health" {
+			return ErrDropEntry
+		}
+		if ent.Message == "invalid" {
+			return errors.New("invalid entry")
+		}
+
+		e.InsertID = "id-" + ent.Message
+		e.Resource = &mrpb.MonitoredResource{Type: "global"}
+		return nil
+	})(core)
+	WithBeforeWrite(func(e *logging.Entry, ent zapcore.Entry) error {
+		e.Labels = map[string]string{"insert_id": e.InsertID}
+		return nil
+	})(core)
+
+	logger := zap.New(core)
+	logger.Info("hello")
+	logger.Named("health").Info("ok")
+	logger.Info("invalid")
+	require.NoError(t, logger.Sync())
+
+	// Entries are written locally, even if a hook drops them.
+	assert.Equal(t, 3, logs.Len())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "id-hello", entries[0].InsertId)
+	assert.Equal(t, "global", entries[0].Resource.Type)
+	assert.Equal(t, map[string]string{"insert_id": "id-hello"}, entries[0].Labels)
+
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "invalid entry")
+}
@@ -0,0 +1,87 @@
+package zapdriver
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func benchmarkFields() []zap.Field {
+	return []zap.Field{
+		zap.String("order_id", "order-1"),
+		zap.Int("items", 3),
+		zap.Bool("paid", true),
+		zap.Error(errors.New("boom")),
+	}
+}
+
+func BenchmarkWrite_Local(b *testing.B) {
+	logger := zap.New(&core{Core: zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(discard{}), zapcore.DebugLevel), permLabels: newLabels()})
+	fields := benchmarkFields()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("order placed", fields...)
+	}
+}
+
+func BenchmarkWrite_Cloud(b *testing.B) {
+	client, _ := newFakeClient(b)
+
+	logger := zap.New(&core{
+		Core:       zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(discard{}), zapcore.DebugLevel),
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	})
+	fields := benchmarkFields()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("order placed", fields...)
+	}
+	b.StopTimer()
+	_ = logger.Sync()
+}
+
+func BenchmarkWrite_CloudLabels(b *testing.B) {
+	client, _ := newFakeClient(b)
+
+	logger := zap.New(&core{
+		Core:       zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(discard{}), zapcore.DebugLevel),
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}).With(Label("tenant", "acme"))
+	fields := append(benchmarkFields(), Label("region", "eu"))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("order placed", fields...)
+	}
+	b.StopTimer()
+	_ = logger.Sync()
+}
+
+func BenchmarkWrite_LocalLabelsParallel(b *testing.B) {
+	logger := zap.New(&core{Core: zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(discard{}), zapcore.DebugLevel), permLabels: newLabels()}).
+		With(Label("tenant", "acme"))
+	fields := append(benchmarkFields(), Label("region", "eu"))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Info("order placed", fields...)
+		}
+	})
+}
+
+// discard is a zapcore.WriteSyncer discarding everything.
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
+func (discard) Sync() error                 { return nil }
@@ -0,0 +1,95 @@
+package zapdriver
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// BufferRequest returns a logger for a single request, which holds all entries
+// (including those of its children) until the returned function is called at
+// the end of the request. All entries are then written at once, but they are
+// only sent to Cloud Logging if at least one of them has a severity of
+// `threshold` or higher. Happy-path requests are only logged locally, while
+// failing requests keep their full detail in Cloud Logging.
+//
+//	logger, done := zapdriver.BufferRequest(logger, zap.ErrorLevel)
+//	defer done()
+//
+// Entries of level DPanic or higher are never held, as the process might not
+// survive them; they cause all held entries to be written immediately.
+//
+// The logger is returned as-is if it doesn't use the zapdriver core.
+func BufferRequest(logger *zap.Logger, threshold zapcore.Level) (*zap.Logger, func()) {
+	c, ok := logger.Core().(*core)
+	if !ok {
+		return logger, func() {}
+	}
+
+	b := &requestBuffer{threshold: threshold, max: zapcore.DebugLevel - 1}
+
+	clone := *c
+	clone.config.Buffer = b
+
+	return logger.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return &clone
+	})), b.flush
+}
+
+// requestBuffer holds the entries of a request.
+type requestBuffer struct {
+	threshold zapcore.Level
+
+	mutex   sync.Mutex
+	entries []bufferedEntry
+	max     zapcore.Level
+	done    bool
+}
+
+type bufferedEntry struct {
+	core   *core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// add holds the entry until the request ends. It returns false if the request
+// already ended, in which case the entry should be written directly.
+func (b *requestBuffer) add(c *core, ent zapcore.Entry, fields []zapcore.Field) bool {
+	b.mutex.Lock()
+	if b.done {
+		b.mutex.Unlock()
+		return false
+	}
+
+	b.entries = append(b.entries, bufferedEntry{
+		core:   c,
+		ent:    ent,
+		fields: append([]zapcore.Field(nil), fields...),
+	})
+	if ent.Level > b.max {
+		b.max = ent.Level
+	}
+	b.mutex.Unlock()
+
+	if ent.Level >= zapcore.DPanicLevel {
+		b.flush()
+	}
+
+	return true
+}
+
+// flush writes all held entries, and ends the request. Entries written
+// afterwards are no longer held.
+func (b *requestBuffer) flush() {
+	b.mutex.Lock()
+	entries := b.entries
+	cloud := b.max >= b.threshold
+	b.entries = nil
+	b.done = true
+	b.mutex.Unlock()
+
+	for _, e := range entries {
+		_ = e.core.write(e.ent, e.fields, cloud)
+	}
+}
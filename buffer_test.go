@@ -0,0 +1,90 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newBufferTestLogger(t *testing.T) (*zap.Logger, *observer.ObservedLogs, *fakeServer) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+
+	return zap.New(core), logs, server
+}
+
+func TestBufferRequest_HappyPath(t *testing.T) {
+	logger, logs, server := newBufferTestLogger(t)
+
+	request, done := BufferRequest(logger, zapcore.ErrorLevel)
+	request.Info("one")
+	request.With(zap.String("child", "yes")).Warn("two")
+	assert.Zero(t, logs.Len())
+
+	done()
+	require.NoError(t, logger.Sync())
+
+	require.Equal(t, 2, logs.Len())
+	assert.Equal(t, "one", logs.All()[0].Message)
+	assert.Equal(t, "two", logs.All()[1].Message)
+	assert.Equal(t, "yes", logs.All()[1].ContextMap()["child"])
+	assert.Empty(t, server.Entries())
+}
+
+func TestBufferRequest_Failing(t *testing.T) {
+	logger, logs, server := newBufferTestLogger(t)
+
+	request, done := BufferRequest(logger, zapcore.ErrorLevel)
+	request.Info("one")
+	request.Error("two")
+	assert.Zero(t, logs.Len())
+
+	done()
+	require.NoError(t, logger.Sync())
+
+	assert.Equal(t, 2, logs.Len())
+	assert.Len(t, server.Entries(), 2)
+}
+
+func TestBufferRequest_AfterDone(t *testing.T) {
+	logger, logs, server := newBufferTestLogger(t)
+
+	request, done := BufferRequest(logger, zapcore.ErrorLevel)
+	done()
+
+	request.Info("late")
+	require.NoError(t, logger.Sync())
+
+	assert.Equal(t, 1, logs.Len())
+	assert.Len(t, server.Entries(), 1)
+}
+
+func TestBufferRequest_DPanic(t *testing.T) {
+	logger, logs, _ := newBufferTestLogger(t)
+
+	request, _ := BufferRequest(logger, zapcore.ErrorLevel)
+	request.Info("one")
+	request.DPanic("two")
+
+	assert.Equal(t, 2, logs.Len())
+}
+
+func TestBufferRequest_OtherCore(t *testing.T) {
+	t.Parallel()
+
+	logger := zap.NewNop()
+	request, done := BufferRequest(logger, zapcore.ErrorLevel)
+	done()
+
+	assert.Equal(t, logger, request)
+}
@@ -0,0 +1,35 @@
+//go:build go1.18
+// +build go1.18
+
+package zapdriver
+
+import "runtime/debug"
+
+// buildInfoVersion returns the module version of the main module, or the VCS
+// revision it was built from, shortened to 12 characters and suffixed with
+// "-dirty" for modified working trees.
+func buildInfoVersion(info *debug.BuildInfo) string {
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+
+	var revision string
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if revision != "" && modified {
+		revision += "-dirty"
+	}
+
+	return revision
+}
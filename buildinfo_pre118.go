@@ -0,0 +1,16 @@
+//go:build !go1.18
+// +build !go1.18
+
+package zapdriver
+
+import "runtime/debug"
+
+// buildInfoVersion returns the module version of the main module. The VCS
+// revision is only embedded in the build info since Go 1.18.
+func buildInfoVersion(info *debug.BuildInfo) string {
+	if v := info.Main.Version; v != "(devel)" {
+		return v
+	}
+
+	return ""
+}
@@ -0,0 +1,49 @@
+//go:build go1.18
+// +build go1.18
+
+package zapdriver
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInfoVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		info *debug.BuildInfo
+		want string
+	}{
+		"module version": {
+			&debug.BuildInfo{Main: debug.Module{Version: "v1.4.0"}},
+			"v1.4.0",
+		},
+		"revision": {
+			&debug.BuildInfo{
+				Main:     debug.Module{Version: "(devel)"},
+				Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "0123456789abcdef0123"}},
+			},
+			"0123456789ab",
+		},
+		"modified": {
+			&debug.BuildInfo{Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+				{Key: "vcs.modified", Value: "true"},
+			}},
+			"0123456789ab-dirty",
+		},
+		"unknown": {
+			&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}},
+			"",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildInfoVersion(tt.info))
+		})
+	}
+}
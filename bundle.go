@@ -0,0 +1,63 @@
+package zapdriver
+
+import (
+	"time"
+
+	"cloud.google.com/go/logging"
+)
+
+// BundleSettings configure how the Cloud Logging logger buffers entries before
+// sending them. Zero values keep the defaults of the client.
+type BundleSettings struct {
+	// DelayThreshold is the maximum time entries are buffered. Lower it in
+	// low-traffic services, so entries show up sooner.
+	DelayThreshold time.Duration
+
+	// EntryCountThreshold is the maximum number of entries sent in a request.
+	EntryCountThreshold int
+
+	// EntryByteThreshold is the maximum size of the entries sent in a request.
+	EntryByteThreshold int
+
+	// BufferedByteLimit is the maximum size of the buffered entries, beyond
+	// which entries are dropped. Raise it in high-throughput services.
+	BufferedByteLimit int
+
+	// ConcurrentWriteLimit is the number of requests sent concurrently.
+	ConcurrentWriteLimit int
+}
+
+// Bundling configures how the Cloud Logging logger created by the constructors
+// of this package (see `NewCloudLogger`) buffers entries:
+//
+//	zapdriver.Bundling(zapdriver.BundleSettings{DelayThreshold: 100 * time.Millisecond})
+//
+// Loggers passed using `WithLogger` are configured when they're created, using
+// the options of `Client.Logger`.
+func Bundling(settings BundleSettings) func(*core) {
+	return func(c *core) {
+		c.config.ClientOptions = append(c.config.ClientOptions, WithLoggerOptions(settings.loggerOptions()...))
+	}
+}
+
+// loggerOptions returns the logger options for the non-zero settings.
+func (s BundleSettings) loggerOptions() []logging.LoggerOption {
+	var opts []logging.LoggerOption
+	if s.DelayThreshold > 0 {
+		opts = append(opts, logging.DelayThreshold(s.DelayThreshold))
+	}
+	if s.EntryCountThreshold > 0 {
+		opts = append(opts, logging.EntryCountThreshold(s.EntryCountThreshold))
+	}
+	if s.EntryByteThreshold > 0 {
+		opts = append(opts, logging.EntryByteThreshold(s.EntryByteThreshold))
+	}
+	if s.BufferedByteLimit > 0 {
+		opts = append(opts, logging.BufferedByteLimit(s.BufferedByteLimit))
+	}
+	if s.ConcurrentWriteLimit > 0 {
+		opts = append(opts, logging.ConcurrentWriteLimit(s.ConcurrentWriteLimit))
+	}
+
+	return opts
+}
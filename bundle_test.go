@@ -0,0 +1,60 @@
+package zapdriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+func TestBundleSettings(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, BundleSettings{}.loggerOptions())
+
+	settings := BundleSettings{
+		DelayThreshold:       time.Second,
+		EntryCountThreshold:  10,
+		EntryByteThreshold:   1024,
+		BufferedByteLimit:    1 << 20,
+		ConcurrentWriteLimit: 2,
+	}
+	assert.Equal(t, []logging.LoggerOption{
+		logging.DelayThreshold(time.Second),
+		logging.EntryCountThreshold(10),
+		logging.EntryByteThreshold(1024),
+		logging.BufferedByteLimit(1 << 20),
+		logging.ConcurrentWriteLimit(2),
+	}, settings.loggerOptions())
+}
+
+func TestBundling(t *testing.T) {
+	addr, server := newFakeServer(t)
+
+	scratch := &core{}
+	Bundling(BundleSettings{DelayThreshold: 10 * time.Millisecond})(scratch)
+
+	opts := append(scratch.config.ClientOptions,
+		WithEndpoint(addr),
+		WithClientOptions(
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithInsecure()),
+		),
+	)
+	client, lg, err := NewCloudLogger(context.Background(), "test-project", "app", opts...)
+	require.NoError(t, err)
+	defer client.Close()
+
+	// The entry is sent after the delay, without flushing.
+	lg.Log(logging.Entry{Payload: "hello"})
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.Entries()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Len(t, server.Entries(), 1)
+}
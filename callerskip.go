@@ -0,0 +1,28 @@
+package zapdriver
+
+import (
+	"go.uber.org/zap"
+)
+
+// WrapCoreWithCallerSkip returns the `zap.Option`s wrapping the default core
+// with the zapdriver one, like `WrapCore`, for loggers used through a facade of
+// the application. The caller is added to entries, skipping `skip` additional
+// stack frames, so the source location and error report of entries point at
+// the code calling the facade instead of the facade itself:
+//
+//	logger, err := zapdriver.NewProductionConfig().Build(zapdriver.WrapCoreWithCallerSkip(1)...)
+//
+//	func (l *Log) Info(msg string, fields ...zap.Field) {
+//		l.logger.Info(msg, fields...)
+//	}
+//
+// The skip adds up with any other `zap.AddCallerSkip` option. Don't pass the
+// options to `NewProduction` or `NewDevelopment`, which already wrap the core,
+// but use their configurations instead.
+func WrapCoreWithCallerSkip(skip int, options ...func(*core)) []zap.Option {
+	return []zap.Option{
+		WrapCore(options...),
+		zap.AddCaller(),
+		zap.AddCallerSkip(skip),
+	}
+}
@@ -0,0 +1,44 @@
+package zapdriver
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// facade is a logging facade of an application, wrapping a logger.
+type facade struct {
+	logger *zap.Logger
+}
+
+func (f *facade) Error(msg string) {
+	f.logger.Error(msg)
+}
+
+func TestWrapCoreWithCallerSkip(t *testing.T) {
+	t.Parallel()
+
+	observed, logs := observer.New(zapcore.DebugLevel)
+	options := WrapCoreWithCallerSkip(1, ReportAllErrors(true), ServiceName("service"))
+	f := &facade{logger: zap.New(observed, options...)}
+
+	_, file, line, _ := runtime.Caller(0)
+	f.Error("failed")
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, file, entry.Caller.File)
+	assert.Equal(t, line+1, entry.Caller.Line)
+
+	fields := entry.ContextMap()
+	location := fields[sourceKey].(map[string]interface{})
+	assert.Equal(t, "github.com/blendle/zapdriver.TestWrapCoreWithCallerSkip", location["function"])
+
+	report := fields[contextKey].(map[string]interface{})["reportLocation"].(map[string]interface{})
+	assert.Equal(t, "github.com/blendle/zapdriver.TestWrapCoreWithCallerSkip", report["functionName"])
+}
@@ -0,0 +1,100 @@
+package zapdriver
+
+import (
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// CardinalityAction decides what happens to new values of a label key once it
+// exceeds its cardinality limit.
+type CardinalityAction int
+
+const (
+	// CardinalityWarn keeps using new values as labels, but logs a single
+	// warning for every label key exceeding the limit.
+	CardinalityWarn CardinalityAction = iota
+
+	// CardinalityDemote moves new values of a label key exceeding the limit from
+	// the labels into a payload field with the same key, and logs a single
+	// warning for the key.
+	CardinalityDemote
+)
+
+// WithLabelCardinalityLimit tracks the distinct values used for every label
+// key, and applies `action` to new values once a key has more than `limit`
+// distinct values. This prevents label explosions, which hurt Logs Explorer
+// performance and increase cost.
+func WithLabelCardinalityLimit(limit int, action CardinalityAction) func(*core) {
+	return func(c *core) {
+		c.config.Cardinality = &cardinalityGuard{
+			limit:    limit,
+			action:   action,
+			values:   map[string]map[string]struct{}{},
+			exceeded: map[string]bool{},
+		}
+	}
+}
+
+// cardinalityGuard keeps track of the distinct values of every label key, up
+// to the limit.
+type cardinalityGuard struct {
+	limit  int
+	action CardinalityAction
+
+	mutex    sync.Mutex
+	values   map[string]map[string]struct{}
+	exceeded map[string]bool
+}
+
+// check records the values of the labels. It removes the labels that are
+// demoted, and returns them as fields, together with the keys that exceeded
+// their limit for the first time.
+func (g *cardinalityGuard) check(lbls *labels) (demoted []zapcore.Field, exceeded []string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for k, v := range lbls.store {
+		values, ok := g.values[k]
+		if !ok {
+			values = map[string]struct{}{}
+			g.values[k] = values
+		}
+
+		if _, ok := values[v]; ok {
+			continue
+		}
+
+		if len(values) < g.limit {
+			values[v] = struct{}{}
+			continue
+		}
+
+		if !g.exceeded[k] {
+			g.exceeded[k] = true
+			exceeded = append(exceeded, k)
+		}
+
+		if g.action == CardinalityDemote {
+			delete(lbls.store, k)
+			demoted = append(demoted, zap.String(k, v))
+		}
+	}
+
+	sort.Strings(exceeded)
+
+	return demoted, exceeded
+}
+
+// warnCardinality logs a warning about the label key exceeding its cardinality
+// limit to the wrapped core.
+func (c *core) warnCardinality(ent zapcore.Entry, key string) {
+	_ = c.Core.Write(zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       ent.Time,
+		LoggerName: ent.LoggerName,
+		Message:    "zapdriver: label exceeded its cardinality limit",
+	}, []zapcore.Field{zap.String("label", key), zap.Int("limit", c.config.Cardinality.limit)})
+}
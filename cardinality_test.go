@@ -0,0 +1,83 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCardinalityGuard(t *testing.T) {
+	t.Parallel()
+
+	core := &core{}
+	WithLabelCardinalityLimit(2, CardinalityDemote)(core)
+	g := core.config.Cardinality
+
+	for _, user := range []string{"alice", "bob", "alice"} {
+		lbls := newLabels()
+		lbls.store = map[string]string{"user": user}
+
+		demoted, exceeded := g.check(lbls)
+		assert.Empty(t, demoted)
+		assert.Empty(t, exceeded)
+		assert.Equal(t, map[string]string{"user": user}, lbls.store)
+	}
+
+	lbls := newLabels()
+	lbls.store = map[string]string{"user": "carol", "env": "prod"}
+
+	demoted, exceeded := g.check(lbls)
+	assert.Equal(t, []zapcore.Field{zap.String("user", "carol")}, demoted)
+	assert.Equal(t, []string{"user"}, exceeded)
+	assert.Equal(t, map[string]string{"env": "prod"}, lbls.store)
+
+	lbls.store = map[string]string{"user": "dave"}
+
+	demoted, exceeded = g.check(lbls)
+	assert.Equal(t, []zapcore.Field{zap.String("user", "dave")}, demoted)
+	assert.Empty(t, exceeded)
+}
+
+func TestWriteLabelCardinalityLimit_Warn(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+	}
+	WithLabelCardinalityLimit(1, CardinalityWarn)(core)
+
+	logger := zap.New(core)
+	logger.Info("one", Label("user", "alice"))
+	logger.Info("two", Label("user", "bob"))
+	logger.Info("three", Label("user", "carol"))
+
+	require.Equal(t, 4, logs.Len())
+
+	warning := logs.FilterMessage("zapdriver: label exceeded its cardinality limit").All()
+	require.Len(t, warning, 1)
+	assert.Equal(t, "user", warning[0].ContextMap()["label"])
+
+	labels := logs.FilterMessage("three").All()[0].ContextMap()[labelsKey]
+	assert.Equal(t, map[string]interface{}{"user": "carol"}, labels)
+}
+
+func TestWriteLabelCardinalityLimit_Demote(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+	}
+	WithLabelCardinalityLimit(1, CardinalityDemote)(core)
+
+	logger := zap.New(core)
+	logger.Info("one", Label("user", "alice"))
+	logger.Info("two", Label("user", "bob"))
+
+	entry := logs.FilterMessage("two").All()[0]
+	assert.Equal(t, "bob", entry.ContextMap()["user"])
+	assert.Empty(t, entry.ContextMap()[labelsKey])
+}
@@ -0,0 +1,208 @@
+package zapdriver
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+
+	// Registers the gzip compressor for use with `WithCompression("gzip")`.
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+// Defaults of the loggers created by `NewCloudLogger`.
+const (
+	defaultConcurrentWriteLimit = 4
+	defaultWriteTimeout         = 30 * time.Second
+)
+
+// ClientOption configures the Cloud Logging client created by `NewClient`.
+type ClientOption func(*clientConfig)
+
+// clientConfig collects the options used to create a Cloud Logging client.
+type clientConfig struct {
+	options       []option.ClientOption
+	deadLetter    *deadLetter
+	spool         *spool
+	scopes        []string
+	impersonation *impersonatedTokenSource
+	onError       []func(error)
+
+	// Options of the logger created by `NewCloudLogger`.
+	loggerOptions []logging.LoggerOption
+	writeTimeout  time.Duration
+}
+
+// NewClient creates a Cloud Logging client for the given project (or any other
+// parent supported by `logging.NewClient`), configured with the given options.
+func NewClient(ctx context.Context, projectID string, opts ...ClientOption) (*logging.Client, error) {
+	client, _, err := newClient(ctx, projectID, opts)
+
+	return client, err
+}
+
+// NewCloudLogger creates a Cloud Logging client, like `NewClient`, and a logger
+// writing to the log `logID` of the project, for use with `WithLogger`. The
+// logger has recommended defaults: it sends up to 4 requests concurrently, and
+// gives up on a request after 30 seconds, instead of 10 minutes. Use
+// `WithConcurrentWriteLimit`, `WithWriteTimeout` and `WithLoggerOptions` to
+// change its configuration.
+//
+// Closing the client flushes the logger.
+func NewCloudLogger(ctx context.Context, projectID, logID string, opts ...ClientOption) (*logging.Client, *logging.Logger, error) {
+	client, config, err := newClient(ctx, projectID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeout := config.writeTimeout
+	if timeout <= 0 {
+		timeout = defaultWriteTimeout
+	}
+
+	options := []logging.LoggerOption{
+		logging.ConcurrentWriteLimit(defaultConcurrentWriteLimit),
+		logging.ContextFunc(func() (context.Context, func()) {
+			return context.WithTimeout(context.Background(), timeout)
+		}),
+	}
+
+	return client, client.Logger(logID, append(options, config.loggerOptions...)...), nil
+}
+
+func newClient(ctx context.Context, projectID string, opts []ClientOption) (*logging.Client, *clientConfig, error) {
+	config := &clientConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	options := append(config.credentialOptions(), config.options...)
+	client, err := logging.NewClient(ctx, projectID, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if d := config.deadLetter; d != nil {
+		onError := client.OnError
+		client.OnError = func(err error) {
+			d.onError(err)
+			onError(err)
+		}
+	}
+	if sp := config.spool; sp != nil {
+		onError := client.OnError
+		client.OnError = func(err error) {
+			sp.onError(err)
+			onError(err)
+		}
+	}
+	if handlers := config.onError; len(handlers) > 0 {
+		onError := client.OnError
+		client.OnError = func(err error) {
+			onError(err)
+			for _, handler := range handlers {
+				handler(err)
+			}
+		}
+	}
+
+	return client, config, nil
+}
+
+// WithOnError calls `handler` from the `OnError` callback of the client, with
+// the errors of writes that failed, in addition to the default callback, which
+// logs them using the standard library logger.
+func WithOnError(handler func(error)) ClientOption {
+	return func(c *clientConfig) {
+		c.onError = append(c.onError, handler)
+	}
+}
+
+// WithLoggerOptions passes the given options through to `Client.Logger`, for
+// the logger created by `NewCloudLogger`.
+func WithLoggerOptions(opts ...logging.LoggerOption) ClientOption {
+	return func(c *clientConfig) {
+		c.loggerOptions = append(c.loggerOptions, opts...)
+	}
+}
+
+// WithConcurrentWriteLimit configures the number of requests the logger created
+// by `NewCloudLogger` sends concurrently.
+func WithConcurrentWriteLimit(n int) ClientOption {
+	return WithLoggerOptions(logging.ConcurrentWriteLimit(n))
+}
+
+// WithWriteTimeout configures the time after which the logger created by
+// `NewCloudLogger` gives up on a request.
+func WithWriteTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.writeTimeout = timeout
+	}
+}
+
+// WithClientOptions passes the given options through to `logging.NewClient`.
+func WithClientOptions(opts ...option.ClientOption) ClientOption {
+	return func(c *clientConfig) {
+		c.options = append(c.options, opts...)
+	}
+}
+
+// WithEndpoint makes the client send its requests to the given endpoint, for
+// example "europe-west1-logging.googleapis.com". The port defaults to 443.
+func WithEndpoint(endpoint string) ClientOption {
+	if _, _, err := net.SplitHostPort(endpoint); err != nil {
+		endpoint = net.JoinHostPort(endpoint, "443")
+	}
+
+	return WithClientOptions(option.WithEndpoint(endpoint))
+}
+
+// WithEmulator makes the client send its requests to a local Cloud Logging
+// emulator or mock server listening on `addr`, for example "localhost:8085",
+// without TLS and without credentials. It's meant for integration tests.
+func WithEmulator(addr string) ClientOption {
+	return WithClientOptions(
+		option.WithEndpoint(addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithInsecure()),
+	)
+}
+
+// WithRegion makes the client send its requests to the regional Cloud Logging
+// endpoint of the given region, for data residency requirements.
+func WithRegion(region string) ClientOption {
+	return WithEndpoint(RegionalEndpoint(region))
+}
+
+// RegionalEndpoint returns the Cloud Logging endpoint of the given region.
+func RegionalEndpoint(region string) string {
+	return region + "-logging.googleapis.com:443"
+}
+
+// WithKeepalive configures gRPC keepalive pings on the connections of the
+// client, which keeps idle connections of low-traffic loggers healthy.
+func WithKeepalive(params keepalive.ClientParameters) ClientOption {
+	return WithClientOptions(option.WithGRPCDialOption(grpc.WithKeepaliveParams(params)))
+}
+
+// WithConnectionPool spreads the requests of the client over `size` gRPC
+// connections, which increases the throughput of very chatty loggers.
+func WithConnectionPool(size int) ClientOption {
+	return WithClientOptions(option.WithGRPCConnectionPool(size))
+}
+
+// WithUserAgent sets the user agent the client identifies itself with.
+func WithUserAgent(ua string) ClientOption {
+	return WithClientOptions(option.WithUserAgent(ua))
+}
+
+// WithCompression compresses all requests of the client with the named gRPC
+// compressor. The "gzip" compressor is always available, others need to be
+// registered using `encoding.RegisterCompressor`.
+func WithCompression(name string) ClientOption {
+	return WithClientOptions(option.WithGRPCDialOption(grpc.WithDefaultCallOptions(grpc.UseCompressor(name))))
+}
@@ -0,0 +1,103 @@
+package zapdriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+func TestNewClient(t *testing.T) {
+	addr, server := newFakeServer(t)
+
+	client, err := NewClient(context.Background(), "test-project",
+		WithEndpoint(addr),
+		WithClientOptions(
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithInsecure()),
+		),
+		WithKeepalive(keepalive.ClientParameters{Time: time.Minute}),
+		WithConnectionPool(2),
+		WithUserAgent("zapdriver-test"),
+		WithCompression("gzip"),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	err = client.Logger("app").LogSync(context.Background(), logging.Entry{Payload: "hello"})
+	require.NoError(t, err)
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "projects/test-project/logs/app", entries[0].LogName)
+}
+
+func TestWithEndpoint(t *testing.T) {
+	t.Parallel()
+
+	var tests = map[string]string{
+		"europe-west1-logging.googleapis.com":     "europe-west1-logging.googleapis.com:443",
+		"europe-west1-logging.googleapis.com:443": "europe-west1-logging.googleapis.com:443",
+		"localhost:8085":                          "localhost:8085",
+	}
+
+	for endpoint, want := range tests {
+		config := &clientConfig{}
+		WithEndpoint(endpoint)(config)
+
+		assert.Equal(t, []option.ClientOption{option.WithEndpoint(want)}, config.options)
+	}
+}
+
+func TestWithEmulator(t *testing.T) {
+	addr, server := newFakeServer(t)
+
+	client, err := NewClient(context.Background(), "test-project", WithEmulator(addr))
+	require.NoError(t, err)
+	defer client.Close()
+
+	err = client.Logger("app").LogSync(context.Background(), logging.Entry{Payload: "hello"})
+	require.NoError(t, err)
+
+	assert.Len(t, server.Entries(), 1)
+}
+
+func TestWithRegion(t *testing.T) {
+	t.Parallel()
+
+	config := &clientConfig{}
+	WithRegion("europe-west1")(config)
+
+	assert.Equal(t, []option.ClientOption{option.WithEndpoint("europe-west1-logging.googleapis.com:443")}, config.options)
+}
+
+func TestNewCloudLogger(t *testing.T) {
+	addr, server := newFakeServer(t)
+
+	client, lg, err := NewCloudLogger(context.Background(), "test-project", "app",
+		WithEndpoint(addr),
+		WithClientOptions(
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithInsecure()),
+		),
+		WithLoggerOptions(logging.CommonLabels(map[string]string{"env": "test"})),
+		WithConcurrentWriteLimit(2),
+		WithWriteTimeout(time.Second),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	lg.Log(logging.Entry{Payload: "hello", Labels: map[string]string{"one": "1"}})
+	require.NoError(t, lg.Flush())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "projects/test-project/logs/app", entries[0].LogName)
+	assert.Equal(t, map[string]string{"env": "test", "one": "1"}, entries[0].Labels)
+}
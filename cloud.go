@@ -0,0 +1,89 @@
+package zapdriver
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// NewCloudProduction builds a production logger (see `NewProductionConfig`),
+// that also sends its entries to the Cloud Logging API, in the log `logID` of
+// the given project.
+//
+// The Cloud Logging client and logger are created using `NewCloudLogger`, and
+// configured using the `ClientOptions` core option. If the client can't be
+// created, for example because there are no credentials available on a
+// developer's machine, the logger falls back to writing entries to standard
+// output only (see `NewAgentConfig`), and logs a single warning explaining why.
+//
+// The returned function flushes all buffered entries, and closes the client.
+// It should be called before the application exits, or use `Shutdown` to do so
+// within a deadline.
+func NewCloudProduction(ctx context.Context, projectID, logID string, options ...func(*core)) (*zap.Logger, func(), error) {
+	return newCloudLogger(ctx, NewProductionConfig(), projectID, logID, options)
+}
+
+// NewCloudDevelopment is the same as NewCloudProduction, but builds a
+// development logger (see `NewDevelopmentConfig`).
+func NewCloudDevelopment(ctx context.Context, projectID, logID string, options ...func(*core)) (*zap.Logger, func(), error) {
+	return newCloudLogger(ctx, NewDevelopmentConfig(), projectID, logID, options)
+}
+
+// ClientOptions configures the Cloud Logging client created by the
+// constructors of this package.
+func ClientOptions(opts ...ClientOption) func(*core) {
+	return func(c *core) {
+		c.config.ClientOptions = append(c.config.ClientOptions, opts...)
+	}
+}
+
+func newCloudLogger(ctx context.Context, config zap.Config, projectID, logID string, options []func(*core)) (*zap.Logger, func(), error) {
+	// The project of the client is the default, options can override it.
+	if id := strings.TrimPrefix(projectID, "projects/"); !strings.Contains(id, "/") {
+		options = append([]func(*core){ProjectID(id)}, options...)
+	}
+
+	c := newCore(options)
+	if level := c.config.Level; level != nil {
+		config.Level = *level
+	}
+
+	client, lg, clientErr := NewCloudLogger(ctx, projectID, logID, c.config.ClientOptions...)
+	if clientErr != nil {
+		// Without a client, entries are written to standard output for the
+		// logging agent to collect, at the level of the requested logger.
+		agent := NewAgentConfig()
+		agent.Level = config.Level
+
+		logger, err := validateLogger(agent.Build(zap.WrapCore(c.wrap)))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		logger.Warn("zapdriver: unable to create Cloud Logging client, only logging locally", zap.Error(clientErr))
+
+		return logger, func() { _ = Close(logger) }, nil
+	}
+
+	owned := &ownedClient{client: client}
+	c.lg = nilLogger(lg)
+	c.config.Client = owned
+
+	logger, err := validateLogger(config.Build(zap.WrapCore(c.wrap)))
+	if err != nil {
+		_ = owned.close()
+		return nil, nil, err
+	}
+
+	onError := client.OnError
+	client.OnError = func(err error) {
+		onError(err)
+		c.config.Stats.error(err)
+	}
+
+	return logger, func() {
+		_ = Close(logger)
+		_ = owned.close()
+	}, nil
+}
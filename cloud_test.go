@@ -0,0 +1,78 @@
+package zapdriver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+func TestNewCloudProduction(t *testing.T) {
+	addr, server := newFakeServer(t)
+
+	logger, cleanup, err := NewCloudProduction(context.Background(), "test-project", "app", ClientOptions(
+		WithEndpoint(addr),
+		WithClientOptions(option.WithoutAuthentication(), option.WithGRPCDialOption(grpc.WithInsecure())),
+	))
+	require.NoError(t, err)
+
+	logger.Info("hello")
+	cleanup()
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "projects/test-project/logs/app", entries[0].LogName)
+}
+
+func TestNewCloudDevelopment_FallsBackWithoutCredentials(t *testing.T) {
+	defer os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"))
+	os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "/does/not/exist.json")
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	logger, cleanup, err := NewCloudDevelopment(context.Background(), "test-project", "app")
+	require.NoError(t, err)
+	defer cleanup()
+
+	require.NotNil(t, logger)
+	assert.Nil(t, logger.Core().(*core).lg)
+	assert.True(t, logger.Core().Enabled(zapcore.DebugLevel))
+
+	// The fallback uses the agent configuration, writing to standard output.
+	require.NoError(t, w.Close())
+	out, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"time":`)
+	assert.Contains(t, string(out), "unable to create Cloud Logging client")
+}
+
+func TestNewCloudProduction_AppliesOptionsOnce(t *testing.T) {
+	addr, server := newFakeServer(t)
+
+	var applied int
+	logger, cleanup, err := NewCloudProduction(context.Background(), "test-project", "app",
+		ClientOptions(
+			WithEndpoint(addr),
+			WithClientOptions(option.WithoutAuthentication(), option.WithGRPCDialOption(grpc.WithInsecure())),
+		),
+		func(*core) { applied++ },
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, applied)
+
+	logger.Info("hello")
+	cleanup()
+
+	assert.Len(t, server.Entries(), 1)
+}
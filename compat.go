@@ -0,0 +1,183 @@
+package zapdriver
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap/zapcore"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+)
+
+// entryFieldKeys are the keys of the special fields that are mapped onto the
+// fields of entries sent to the API, and removed from their payload. The output
+// of the wrapped core keeps them, for the Cloud Logging agent.
+var entryFieldKeys = map[string]bool{
+	traceKey:        true,
+	spanKey:         true,
+	traceSampledKey: true,
+	httpRequestKey:  true,
+	operationKey:    true,
+	sourceKey:       true,
+	insertIDKey:     true,
+	severityKey:     true,
+}
+
+// mapSpecialFields sets the fields of the entry corresponding to its special
+// fields, and removes the mapped fields from the payload.
+func (c *core) mapSpecialFields(ent *logging.Entry, fields []zapcore.Field) {
+	payload, _ := ent.Payload.(map[string]interface{})
+
+	for _, set := range [][]zapcore.Field{c.fields, fields} {
+		for _, f := range set {
+			if !entryFieldKeys[f.Key] || !mapSpecialField(ent, f) {
+				continue
+			}
+
+			if payload != nil {
+				delete(payload, f.Key)
+			}
+		}
+	}
+}
+
+// mapSpecialField sets the field of the entry corresponding to a special
+// field, and reports whether it did.
+func mapSpecialField(ent *logging.Entry, f zapcore.Field) bool {
+	switch f.Key {
+	case traceKey, spanKey, insertIDKey:
+		if f.Type != zapcore.StringType {
+			return false
+		}
+		switch f.Key {
+		case traceKey:
+			ent.Trace = f.String
+		case spanKey:
+			ent.SpanID = f.String
+		default:
+			ent.InsertID = f.String
+		}
+	case severityKey:
+		severity, ok := f.Interface.(logging.Severity)
+		if !ok {
+			return false
+		}
+		ent.Severity = severity
+	case traceSampledKey:
+		if f.Type != zapcore.BoolType {
+			return false
+		}
+		ent.TraceSampled = f.Integer == 1
+	case httpRequestKey:
+		obj, ok := marshalObject(f)
+		if !ok {
+			return false
+		}
+		ent.HTTPRequest = httpRequestFromObject(obj)
+	case operationKey:
+		obj, ok := marshalObject(f)
+		if !ok {
+			return false
+		}
+		ent.Operation = &logpb.LogEntryOperation{
+			Id:       objString(obj, "id"),
+			Producer: objString(obj, "producer"),
+			First:    objBool(obj, "first"),
+			Last:     objBool(obj, "last"),
+		}
+	case sourceKey:
+		obj, ok := marshalObject(f)
+		if !ok {
+			return false
+		}
+		ent.SourceLocation = &logpb.LogEntrySourceLocation{
+			File:     objString(obj, "file"),
+			Line:     objInt64(obj, "line"),
+			Function: objString(obj, "function"),
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// marshalObject returns the contents of an object field.
+func marshalObject(f zapcore.Field) (map[string]interface{}, bool) {
+	obj, ok := f.Interface.(zapcore.ObjectMarshaler)
+	if !ok || f.Type != zapcore.ObjectMarshalerType {
+		return nil, false
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	if err := obj.MarshalLogObject(enc); err != nil {
+		return nil, false
+	}
+
+	return enc.Fields, true
+}
+
+// httpRequestFromObject converts the contents of an "httpRequest" field.
+func httpRequestFromObject(obj map[string]interface{}) *logging.HTTPRequest {
+	u, err := url.Parse(objString(obj, "requestUrl"))
+	if err != nil {
+		u = &url.URL{}
+	}
+
+	req := &http.Request{
+		Method: objString(obj, "requestMethod"),
+		URL:    u,
+		Proto:  objString(obj, "protocol"),
+		Header: http.Header{},
+	}
+	if ua := objString(obj, "userAgent"); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	if referer := objString(obj, "referer"); referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+
+	latency, _ := time.ParseDuration(objString(obj, "latency"))
+
+	return &logging.HTTPRequest{
+		Request:                        req,
+		RequestSize:                    objInt64(obj, "requestSize"),
+		Status:                         int(objInt64(obj, "status")),
+		ResponseSize:                   objInt64(obj, "responseSize"),
+		Latency:                        latency,
+		LocalIP:                        objString(obj, "serverIp"),
+		RemoteIP:                       objString(obj, "remoteIp"),
+		CacheHit:                       objBool(obj, "cacheHit"),
+		CacheValidatedWithOriginServer: objBool(obj, "cacheValidatedWithOriginServer"),
+	}
+}
+
+func objString(obj map[string]interface{}, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
+
+func objBool(obj map[string]interface{}, key string) bool {
+	b, _ := obj[key].(bool)
+	return b
+}
+
+// objInt64 returns an integer value, which might also be encoded as string.
+func objInt64(obj map[string]interface{}, key string) int64 {
+	switch v := obj[key].(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
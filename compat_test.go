@@ -0,0 +1,133 @@
+package zapdriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWriteOriginalSpecialFields(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core).With(TraceContext("abc", "def", true, "my-project")...)
+	logger.Info("request",
+		HTTP(&HTTPPayload{
+			RequestMethod: "GET",
+			RequestURL:    "https://example.com/hello",
+			RequestSize:   "12",
+			Status:        404,
+			UserAgent:     "curl",
+			RemoteIP:      "10.0.0.1",
+			Latency:       "1.5s",
+		}),
+		OperationStart("op", "producer"),
+		Labels(Label("hello", "world")),
+	)
+	require.NoError(t, logger.Sync())
+
+	local := logs.All()[0].ContextMap()
+	assert.Contains(t, local, "httpRequest")
+	assert.Equal(t, map[string]interface{}{"hello": "world"}, local[labelsKey])
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	e := entries[0]
+
+	assert.Equal(t, "projects/my-project/traces/abc", e.Trace)
+	assert.Equal(t, "def", e.SpanId)
+	assert.True(t, e.TraceSampled)
+
+	require.NotNil(t, e.HttpRequest)
+	assert.Equal(t, "GET", e.HttpRequest.RequestMethod)
+	assert.Equal(t, "https://example.com/hello", e.HttpRequest.RequestUrl)
+	assert.Equal(t, int64(12), e.HttpRequest.RequestSize)
+	assert.Equal(t, int32(404), e.HttpRequest.Status)
+	assert.Equal(t, "curl", e.HttpRequest.UserAgent)
+	assert.Equal(t, "10.0.0.1", e.HttpRequest.RemoteIp)
+	assert.Equal(t, int64(1), e.HttpRequest.Latency.Seconds)
+	assert.Equal(t, int32(500*time.Millisecond), e.HttpRequest.Latency.Nanos)
+
+	require.NotNil(t, e.Operation)
+	assert.Equal(t, "op", e.Operation.Id)
+	assert.True(t, e.Operation.First)
+
+	assert.Equal(t, map[string]string{"hello": "world"}, e.Labels)
+
+	payload := e.GetJsonPayload().Fields
+	for _, key := range []string{"httpRequest", traceKey, spanKey, traceSampledKey, operationKey} {
+		assert.NotContains(t, payload, key)
+	}
+	assert.Equal(t, "request", payload["message"].GetStringValue())
+}
+
+func TestWriteOriginalSpecialFields_SourceLocation(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	logger.Info("hello", SourceLocation(0, "main.go", 42, true))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "main.go", entries[0].SourceLocation.File)
+	assert.Equal(t, int64(42), entries[0].SourceLocation.Line)
+}
+
+func TestWriteSpecialFields(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	c := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(c)
+	logger.Info("request",
+		HTTP(&HTTPPayload{RequestMethod: "POST", Status: 201}),
+		Label("hello", "world"),
+		OperationCont("op", "producer"),
+		SourceLocation(0, "main.go", 42, true),
+		zap.String("user", "alice"),
+	)
+	logger.Info("typed", TraceContext("abc", "def", true, "my-project")...)
+	logger.Info("mistyped", zap.Int(traceKey, 12), zap.String(traceSampledKey, "yes"))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 3)
+
+	e := entries[0]
+	assert.Equal(t, "POST", e.HttpRequest.RequestMethod)
+	assert.Equal(t, int32(201), e.HttpRequest.Status)
+	assert.Equal(t, map[string]string{"hello": "world"}, e.Labels)
+	assert.Equal(t, "op", e.Operation.Id)
+	assert.False(t, e.Operation.First)
+	assert.Equal(t, "main.go", e.SourceLocation.File)
+	assert.Equal(t, int64(42), e.SourceLocation.Line)
+
+	payload := e.GetJsonPayload().Fields
+	assert.Len(t, payload, 2)
+	assert.Equal(t, "alice", payload["user"].GetStringValue())
+
+	e = entries[1]
+	assert.Equal(t, "projects/my-project/traces/abc", e.Trace)
+	assert.Equal(t, "def", e.SpanId)
+	assert.True(t, e.TraceSampled)
+	assert.Len(t, e.GetJsonPayload().Fields, 1)
+
+	e = entries[2]
+	assert.Empty(t, e.Trace)
+	assert.False(t, e.TraceSampled)
+	payload = e.GetJsonPayload().Fields
+	assert.Equal(t, float64(12), payload[traceKey].GetNumberValue())
+	assert.Equal(t, "yes", payload[traceSampledKey].GetStringValue())
+}
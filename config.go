@@ -17,6 +17,24 @@ func NewDevelopmentEncoderConfig() zapcore.EncoderConfig {
 	return encoderConfig
 }
 
+// NewAgentEncoderConfig returns an EncoderConfig whose output the Cloud Logging
+// agent (or the runtime of Cloud Run, Cloud Functions or Kubernetes Engine)
+// parses as structured entries, for plain Zap cores writing to standard output
+// next to the zapdriver core.
+//
+// It's the same as `NewProductionEncoderConfig`, but writes the timestamp as the
+// "time" key, and the caller as the "logging.googleapis.com/sourceLocation"
+// object (see `SourceLocationCallerEncoder`). Don't use it with the zapdriver
+// core, which adds the source location itself.
+func NewAgentEncoderConfig() zapcore.EncoderConfig {
+	config := NewProductionEncoderConfig()
+	config.TimeKey = "time"
+	config.CallerKey = sourceKey
+	config.EncodeCaller = SourceLocationCallerEncoder
+
+	return config
+}
+
 // NewProductionConfig is a reasonable production logging configuration.
 // Logging is enabled at InfoLevel and above.
 //
@@ -53,3 +71,19 @@ func NewDevelopmentConfig() zap.Config {
 		ErrorOutputPaths: []string{"stderr"},
 	}
 }
+
+// NewAgentConfig is a production logging configuration for environments where
+// the Cloud Logging agent (or the runtime of Cloud Run, Cloud Functions or
+// Kubernetes Engine) collects structured log lines from standard output, and no
+// Cloud Logging client is used.
+//
+// It's the same as `NewProductionConfig`, but writes to standard output, and
+// writes the timestamp as the "time" key, which the agent parses as the
+// timestamp of the entry.
+func NewAgentConfig() zap.Config {
+	config := NewProductionConfig()
+	config.EncoderConfig.TimeKey = "time"
+	config.OutputPaths = []string{"stdout"}
+
+	return config
+}
@@ -1,14 +1,16 @@
 package zapdriver
 
 import (
-	"fmt"
-	"math"
-	"strings"
+	"context"
+	"sync"
 	"time"
 
+	"cloud.google.com/go/errorreporting"
 	"cloud.google.com/go/logging"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
 	logpb "google.golang.org/genproto/googleapis/logging/v2"
 )
 
@@ -20,6 +22,250 @@ type driverConfig struct {
 
 	// ServiceName is added as `ServiceContext()` to all logs when set
 	ServiceName string
+
+	// ServiceVersion is added to the `ServiceContext()` of all logs when set
+	ServiceVersion string
+
+	// Destinations are the loggers entries with a `Destination()` field are
+	// written to, by destination name
+	Destinations map[string]*logging.Logger
+
+	// Sinks are the additional Cloud Logging loggers entries are written to
+	Sinks []*sink
+
+	// ErrorReporting is the Error Reporting client entries with level error
+	// or above are also reported to
+	ErrorReporting *errorreporting.Client
+
+	// Failovers are the alternative Cloud Logging destinations used when the
+	// primary logger keeps failing
+	Failovers []*Failover
+
+	// LogNameSampling keeps a configured fraction of entries per logger name
+	LogNameSampling *nameSampler
+
+	// Sampler samples entries per level and message
+	Sampler *levelSampler
+
+	// Dedup collapses identical error entries
+	Dedup *deduplicator
+
+	// RateLimit limits the entries per combination of label values
+	RateLimit *rateLimiter
+
+	// Router selects the log an entry is written to, based on its fields
+	Router *fieldRouter
+
+	// LogNames selects the log an entry is written to, based on its
+	// `LogName()` field
+	LogNames *fieldRouter
+
+	// LoggerNames selects the log an entry is written to, based on the name of
+	// its zap logger
+	LoggerNames *fieldRouter
+
+	// LoggerNameLabel adds the name of the zap logger as the "logger" label
+	LoggerNameLabel bool
+
+	// Tenants selects the log an entry is written to, based on its tenant
+	Tenants *fieldRouter
+
+	// TenantLabels returns the labels added to the entries of a tenant
+	TenantLabels func(tenant string) map[string]string
+
+	// TenantQuota limits the entries and bytes sent per tenant
+	TenantQuota *tenantQuota
+
+	// Resource is the monitored resource attached to entries sent to the Cloud
+	// Logging API
+	Resource *mrpb.MonitoredResource
+
+	// Fields are added to every entry of the logger, see `WithFields`
+	Fields []zap.Field
+
+	// Level gates the entries of both the local output and the Cloud Logging
+	// API, and can be changed at runtime
+	Level *zap.AtomicLevel
+
+	// LevelOverrides enable lower levels for entries with matching labels or
+	// fields
+	LevelOverrides levelOverrides
+
+	// CloudLevel decides which entries are sent to the Cloud Logging API,
+	// independently of the level of the wrapped core
+	CloudLevel zapcore.LevelEnabler
+
+	// SeverityMapper maps levels to the severities of entries sent to the
+	// Cloud Logging API
+	SeverityMapper func(zapcore.Level) logging.Severity
+
+	// MessageKey is the key of the message in the payload, "message" if empty
+	MessageKey string
+
+	// PayloadNamespace nests the fields of entries under this key when set
+	PayloadNamespace string
+
+	// StructuredErrors writes error fields to the local output as objects
+	StructuredErrors bool
+
+	// AgentStackTraces writes the stack traces of errors to the local output
+	// under the key the Error Reporting log parser expects
+	AgentStackTraces bool
+
+	// OmitEmpty prunes zero values from the Cloud Logging payload, except for
+	// the keys in OmitEmptyExcept
+	OmitEmpty       bool
+	OmitEmptyExcept map[string]bool
+
+	// LabelPrecedence decides between inherited and per-entry labels sharing a
+	// key
+	LabelPrecedence LabelPrecedence
+
+	// CommonLabels are added to every entry, below inherited and per-entry
+	// labels
+	CommonLabels *labels
+
+	// Order makes the timestamps of entries strictly increasing, so they are
+	// displayed in the order they were produced
+	Order *sequencer
+
+	// InsertIDs generates a unique insert ID for every entry, making retried
+	// writes idempotent
+	InsertIDs *insertIDGenerator
+
+	// InsertIDHasher derives the insert ID of every entry from its contents,
+	// collapsing duplicate emissions
+	InsertIDHasher *insertIDHasher
+
+	// RequestInsertIDs derives the insert ID of entries carrying a request ID
+	// from it
+	RequestInsertIDs *requestInsertIDs
+
+	// UTC normalizes all timestamps to UTC
+	UTC bool
+
+	// Flusher periodically flushes the Cloud Logging loggers in the background
+	Flusher *flusher
+
+	// ClientOptions configure the Cloud Logging client created by the
+	// constructors
+	ClientOptions []ClientOption
+
+	// Client is the Cloud Logging client created by the constructors, closed by
+	// `Shutdown`
+	Client *ownedClient
+
+	// Cardinality limits the number of distinct values per label key
+	Cardinality *cardinalityGuard
+
+	// SchemaVersion is added as label to every entry
+	SchemaVersion string
+
+	// Buffer holds the entries of a request until it ends
+	Buffer *requestBuffer
+
+	// DryRun receives the violations of entries that are validated, but not
+	// sent to Cloud Logging
+	DryRun func(error)
+
+	// EntryHook receives the entries instead of Cloud Logging
+	EntryHook func(logging.Entry)
+
+	// Metrics instruments the delivery of entries
+	Metrics *Metrics
+
+	// Stats counts the entries, label merges, flushes and errors of the core
+	Stats *stats
+
+	// BeforeWrite modify or veto the entries before they are sent
+	BeforeWrite []func(*logging.Entry, zapcore.Entry) error
+
+	// Transformers decorate the entries, the default pipeline is used if nil
+	Transformers Transformers
+
+	// LabelLimits enforces the label limits of Cloud Logging when set
+	LabelLimits *LabelLimitPolicy
+
+	// LabelKeySanitizer sanitizes the keys of labels added as fields,
+	// `SanitizeLabelKey` is used if nil
+	LabelKeySanitizer func(string) string
+
+	// PromoteLabels selects the fields that are turned into labels
+	PromoteLabels func(zapcore.Field) bool
+
+	// SourcePathPrefixes are trimmed from the file paths of source locations
+	SourcePathPrefixes []string
+
+	// OmitUnsampledTraces removes the trace fields of entries of unsampled
+	// traces
+	OmitUnsampledTraces bool
+
+	// HealthClient is the client pinged by `HealthCheck`
+	HealthClient *logging.Client
+
+	// InstanceID is the instance ID label of entries, which also get sequence
+	// numbers when set
+	InstanceID string
+
+	// ProjectID is the project bare trace IDs are prefixed with
+	ProjectID string
+
+	// AutoSpanID generates span IDs for entries with a trace but no span ID
+	AutoSpanID bool
+
+	// Async writes entries in the background
+	Async *asyncWriter
+
+	// AsyncQueueSize and AsyncQueuePolicy configure the queue of Async
+	AsyncQueueSize   int
+	AsyncQueuePolicy QueuePolicy
+
+	// Masker masks sensitive values of fields and labels
+	Masker *masker
+
+	// OversizePolicy handles entries exceeding the size limit of Cloud Logging
+	OversizePolicy OversizePolicy
+
+	// ErrorHandler is called with failures to deliver entries to Cloud Logging
+	ErrorHandler func(error)
+
+	// SynchronousWrites sends every entry to Cloud Logging before Write returns
+	SynchronousWrites bool
+
+	// SyncWriteTimeout bounds every synchronous write
+	SyncWriteTimeout time.Duration
+
+	// Retry retries synchronous sends that failed with a transient error
+	Retry *retryPolicy
+
+	// Fallback writes entries to a local writer while Cloud Logging is failing
+	Fallback *Fallback
+
+	// Watermarks are notified of the utilization of the async queue
+	Watermarks []*watermark
+
+	// SeverityRules change the level of entries per logger name and level
+	SeverityRules map[string]map[zapcore.Level]zapcore.Level
+
+	// FieldSizeLimit is the maximum size in bytes of the message and string
+	// fields
+	FieldSizeLimit int
+
+	// FieldSizes records the encoded size of payload keys
+	FieldSizes *fieldSizes
+
+	// StructuredStacks adds stack traces as arrays of frames
+	StructuredStacks bool
+
+	// ErrorSeverity is the minimum level of entries with an error field
+	ErrorSeverity *zapcore.Level
+
+	// TraceQuota caps the number of entries per trace sent to Cloud Logging
+	TraceQuota *traceQuota
+
+	// LocalOnly keeps entries out of Cloud Logging, they're only written to the
+	// wrapped core
+	LocalOnly bool
 }
 
 // Core is a zapdriver specific core wrapped around the default zap core. It
@@ -28,23 +274,31 @@ type core struct {
 	zapcore.Core
 
 	fields []zap.Field
-	lg     *logging.Logger
+	lg     EntryLogger
 
 	// permLabels is a collection of labels that have been added to the logger
-	// through the use of `With()`. These labels should never be cleared after
-	// logging a single entry, unlike `tempLabel`.
-	permLabels *labels
-
-	// tempLabels keeps a record of all the labels that need to be applied to the
-	// current log entry. Zap serializes log fields at different parts of the
-	// stack, one such location is when calling `core.With` and the other one is
-	// when calling `core.Write`. This makes it impossible to (for example) take
-	// all `labels.xxx` fields, and wrap them in the `labels` namespace in one go.
+	// through the use of `With()`. Every derived core gets its own copy, so the
+	// labels of a child logger never leak into its parent.
+	//
+	// Zap serializes log fields at different parts of the stack, one such
+	// location is when calling `core.With` and the other one is when calling
+	// `core.Write`. This makes it impossible to (for example) take all
+	// `labels.xxx` fields, and wrap them in the `labels` namespace in one go.
 	//
 	// Instead, we have to filter out these labels at both locations, and then add
 	// them back in the proper format right before we call `Write` on the original
-	// Zap core.
-	tempLabels *labels
+	// Zap core. The set is never modified once the core is created: the labels of
+	// a single entry are merged with these labels into a new set, only when the
+	// entry has labels of its own, so concurrent writes never share mutable state.
+	permLabels *labels
+
+	// override is the level enabled by the level overrides matching the labels
+	// and fields of the core, if any.
+	override zapcore.LevelEnabler
+
+	// operation is the operation of the `OperationLogger` the core belongs to,
+	// added to every entry without an operation field of its own.
+	operation *operationState
 
 	// Configuration for the zapdriver core
 	config driverConfig
@@ -59,53 +313,109 @@ func ReportAllErrors(report bool) func(*core) {
 }
 
 // zapdriver core option to add `ServiceContext()` to all logs with `name` as
-// service name
+// service name. Without `ServiceVersion`, the version is read from the build
+// info of the binary, and added as the "version" label too
 func ServiceName(name string) func(*core) {
 	return func(c *core) {
 		c.config.ServiceName = name
 	}
 }
 
+// zapdriver core option to add `version` as service version to the
+// `ServiceContext()` of all logs, so Error Reporting groups errors per release
+func ServiceVersion(version string) func(*core) {
+	return func(c *core) {
+		c.config.ServiceVersion = version
+	}
+}
+
+// WithFields adds the fields, including labels, to every entry of the logger,
+// as if they were added using `With` right after it was built. This keeps the
+// context of loggers that are built in one place, and handed to the code using
+// them, together with the rest of their configuration.
+func WithFields(fields ...zap.Field) func(*core) {
+	return func(c *core) {
+		c.config.Fields = append(c.config.Fields, fields...)
+	}
+}
+
 // WrapCore returns a `zap.Option` that wraps the default core with the
 // zapdriver one.
 func WrapCore(options ...func(*core)) zap.Option {
 	return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
-		newcore := &core{
-			Core:       c,
-			permLabels: newLabels(),
-			tempLabels: newLabels(),
-		}
-		for _, option := range options {
-			option(newcore)
-		}
-		return newcore
+		return newCore(options).wrap(c)
 	})
 }
 
+// newCore returns a zapdriver core configured by the options, which still has
+// to wrap a core (see `wrap`). Constructors use it to read the configuration
+// before the wrapped core is built, without applying the options twice.
+func newCore(options []func(*core)) *core {
+	newcore := &core{permLabels: newLabels()}
+	newcore.config.Stats = &stats{}
+	for _, option := range options {
+		option(newcore)
+	}
+
+	return newcore
+}
+
+// wrap makes the core wrap c. It's called once per core.
+func (c *core) wrap(wrapped zapcore.Core) zapcore.Core {
+	c.Core = wrapped
+	c.useBuildVersion(buildVersion)
+	if len(c.config.Fields) > 0 {
+		// The fields are added once all options are applied, so they're
+		// treated like the fields of any other `With` call.
+		return c.With(c.config.Fields)
+	}
+	return c
+}
+
 // With adds structured context to the Core.
 func (c *core) With(fields []zap.Field) zapcore.Core {
 	var lbls *labels
 	lbls, fields = c.extractLabels(fields)
+	if m := c.config.Masker; m != nil {
+		m.labels(lbls)
+		fields = m.fields(fields)
+	}
+	permLabels := c.allLabels(lbls)
+	releaseLabels(lbls)
 
-	lbls.mutex.RLock()
-	c.permLabels.mutex.Lock()
-	for k, v := range lbls.store {
-		c.permLabels.store[k] = v
+	fields = redactFields(fields)
+	if c.config.UTC {
+		fields = utcFields(fields)
+	}
+	if c.config.OmitUnsampledTraces {
+		fields = omitUnsampledTrace(fields)
+	}
+
+	localFields := fields
+	if c.config.StructuredErrors {
+		localFields = structuredErrorFields(fields)
 	}
-	c.permLabels.mutex.Unlock()
-	lbls.mutex.RUnlock()
 
 	fieldsCopy := make([]zap.Field, len(c.fields), len(c.fields)+len(fields))
 	copy(fieldsCopy, c.fields)
 	fieldsCopy = append(fieldsCopy, fields...)
-	return &core{
+	if c.config.PayloadNamespace != "" {
+		// The other fields are nested under the namespace by Write.
+		localFields, _ = splitTopLevel(localFields)
+	}
+	child := &core{
 		fields:     fieldsCopy,
 		lg:         c.lg,
-		Core:       c.Core.With(fields),
-		permLabels: c.permLabels,
-		tempLabels: newLabels(),
+		Core:       c.Core.With(localFields),
+		permLabels: permLabels,
+		operation:  c.operation,
 		config:     c.config,
 	}
+	if c.config.LevelOverrides != nil {
+		child.override = child.contextOverride()
+	}
+
+	return child
 }
 
 // Check determines whether the supplied Entry should be logged (using the
@@ -115,11 +425,20 @@ func (c *core) With(fields []zap.Field) zapcore.Core {
 //
 // Callers must use Check before calling Write.
 func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
-	if c.Enabled(ent.Level) {
-		return ce.AddCore(ent, c)
+	if !c.Enabled(ent.Level) && !c.config.LevelOverrides.mayEnable(ent.Level) {
+		return ce
+	}
+
+	if c.config.LogNameSampling != nil && !c.config.LogNameSampling.sample(ent) {
+		c.config.Metrics.drop(dropSampler)
+		return ce
+	}
+	if c.config.Sampler != nil && !c.config.Sampler.sample(ent) {
+		c.config.Metrics.drop(dropSampler)
+		return ce
 	}
 
-	return ce
+	return ce.AddCore(ent, c)
 }
 
 var logLevelSeverityGoogle = map[zapcore.Level]logging.Severity{
@@ -130,109 +449,258 @@ var logLevelSeverityGoogle = map[zapcore.Level]logging.Severity{
 	zapcore.DPanicLevel: logging.Critical,
 	zapcore.PanicLevel:  logging.Alert,
 	zapcore.FatalLevel:  logging.Emergency,
+	noticeLevel:         logging.Notice,
+	defaultLevel:        logging.Default,
 }
 
-func WithLogger(logger *logging.Logger) func(c *core) {
+// EntryLogger writes entries to Cloud Logging. It's implemented by
+// *logging.Logger, and can be implemented by other backends, like a batching
+// proxy or a recording fake in tests. Entries handed to other implementations
+// are theirs to keep.
+type EntryLogger interface {
+	// Log buffers the entry for sending.
+	Log(e logging.Entry)
+
+	// Flush sends all buffered entries, and reports an error if that failed.
+	Flush() error
+}
+
+// syncEntryLogger is an EntryLogger that can also send an entry synchronously,
+// like *logging.Logger.
+type syncEntryLogger interface {
+	EntryLogger
+
+	LogSync(ctx context.Context, e logging.Entry) error
+}
+
+// WithLogger sets the logger entries are written to, usually a Cloud Logging
+// logger created using `client.Logger(logID)`.
+func WithLogger(logger EntryLogger) func(c *core) {
+	logger = nilLogger(logger)
+
 	return func(c *core) {
 		c.lg = logger
 	}
 }
 
-func ToInterface(f zapcore.Field) interface{} {
-	switch f.Type {
-	case zapcore.ArrayMarshalerType:
-		return f.Interface.(zapcore.ArrayMarshaler)
-	case zapcore.ObjectMarshalerType:
-		return f.Interface.(zapcore.ObjectMarshaler)
-	case zapcore.BinaryType:
-		return f.Interface.([]byte)
-	case zapcore.BoolType:
-		return f.Integer == 1
-	case zapcore.ByteStringType:
-		return f.Interface.([]byte)
-	case zapcore.Complex128Type:
-		return f.Interface.(complex128)
-	case zapcore.Complex64Type:
-		return f.Interface.(complex64)
-	case zapcore.DurationType:
-		return time.Duration(f.Integer)
-	case zapcore.Float64Type:
-		return math.Float64frombits(uint64(f.Integer))
-	case zapcore.Float32Type:
-		return math.Float32frombits(uint32(f.Integer))
-	case zapcore.Int64Type:
-		return f.Integer
-	case zapcore.Int32Type:
-		return int32(f.Integer)
-	case zapcore.Int16Type:
-		return int16(f.Integer)
-	case zapcore.Int8Type:
-		return int8(f.Integer)
-	case zapcore.StringType:
-		return f.String
-	case zapcore.TimeType:
-		if f.Interface != nil {
-			return time.Unix(0, f.Integer).In(f.Interface.(*time.Location))
-		} else {
-			// Fall back to UTC if location is nil.
-			return time.Unix(0, f.Integer)
-		}
-	case zapcore.Uint64Type:
-		return uint64(f.Integer)
-	case zapcore.Uint32Type:
-		return uint32(f.Integer)
-	case zapcore.Uint16Type:
-		return uint16(f.Integer)
-	case zapcore.Uint8Type:
-		return uint8(f.Integer)
-	case zapcore.UintptrType:
-		return uintptr(f.Integer)
-	case zapcore.ReflectType:
-		return f.Interface
-	case zapcore.NamespaceType:
+// nilLogger returns nil for a nil *logging.Logger, which would otherwise make a
+// non-nil EntryLogger.
+func nilLogger(logger EntryLogger) EntryLogger {
+	if lg, ok := logger.(*logging.Logger); ok && lg == nil {
 		return nil
-	case zapcore.StringerType:
-		return f.Interface
-	case zapcore.ErrorType:
-		return f.Interface
-	case zapcore.SkipType:
-		break
-	default:
-		return fmt.Sprintf("unknown field type: %v", f)
 	}
-	return nil
+
+	return logger
+}
+
+// ToInterface converts the value of a field into a value that can be encoded as
+// JSON. The field is encoded using its own `AddTo` method, so every field type
+// is encoded like the local encoder does it. Reflected values are converted
+// like the values of `Any`, so nested structs, maps holding times, channels and
+// cyclic references are always safe to encode, and stringers are converted
+// using their `String` method.
+func ToInterface(f zapcore.Field) interface{} {
+	enc := &payloadEncoder{fields: make(map[string]interface{}, 1)}
+	enc.addField(f)
+
+	return enc.fields[f.Key]
 }
 
 func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
-	//fmt.Printf("%#v | %v\n", ent, c.fields)
+	if c.operation != nil {
+		fields = c.operation.add(fields)
+	}
+	if c.config.Order != nil {
+		// The timestamp is fixed before the entry is held or queued, as async
+		// workers write entries in another order than they were produced.
+		ent.Time = c.config.Order.next(ent.Time)
+	}
+	if b := c.config.Buffer; b != nil && b.add(c, ent, fields) {
+		return nil
+	}
+	if a := c.config.Async; a != nil && a.write(c, ent, fields) {
+		return nil
+	}
+
+	return c.write(ent, fields, true)
+}
+
+// write writes the entry to the wrapped core and, if `cloud` is set, to Cloud
+// Logging.
+func (c *core) write(ent zapcore.Entry, fields []zapcore.Field, cloud bool) error {
+	c.config.Stats.entry()
+	cloud = cloud && !c.config.LocalOnly
+
+	if d := c.config.Dedup; d != nil {
+		ok, collapsed := d.record(c, ent, fields)
+		writeCollapsed(collapsed)
+		if !ok {
+			return nil
+		}
+	}
+
+	level := ent.Level
+	if c.config.ErrorSeverity != nil {
+		ent.Level = c.bumpErrorSeverity(ent.Level, fields)
+	}
+	if c.config.SeverityRules != nil {
+		ent.Level = c.applySeverityRules(ent)
+	}
+	if c.config.Flusher != nil {
+		c.config.Flusher.start(c)
+	}
+
 	var lbls *labels
 	lbls, fields = c.extractLabels(fields)
+	overridden := c.levelOverridden(level, lbls, fields)
+	if c.config.LevelOverrides != nil && !overridden && !c.enabled(level) {
+		// The entry was only checked to match its fields against the level
+		// overrides.
+		releaseLabels(lbls)
+		return nil
+	}
+	if m := c.config.Masker; m != nil {
+		m.labels(lbls)
+		fields = m.fields(fields)
+		ent.Message = m.maskString(ent.Message)
+	}
+	fields = redactFields(fields)
+	fields = withReportLocation(ent, fields)
+	if c.config.UTC {
+		fields = utcFields(fields)
+		ent.Time = ent.Time.UTC()
+	}
+	if c.config.OmitUnsampledTraces {
+		fields = omitUnsampledTrace(fields)
+	}
+	if c.config.AutoSpanID {
+		fields = c.withSpanID(fields)
+	}
+	if c.config.FieldSizeLimit > 0 {
+		fields = truncateFields(&ent, fields, c.config.FieldSizeLimit, c.messageKey())
+	}
+	if c.config.StructuredStacks && ent.Stack != "" {
+		fields = append(fields, stackFramesField(ent.Stack))
+	}
+
+	entryLabels := lbls
+	lbls = c.withCommonLabels(c.allLabels(entryLabels))
+	releaseLabels(entryLabels)
+	if r := c.config.RateLimit; r != nil {
+		ok, summaries := r.allow(ent, lbls)
+		if len(summaries) > 0 {
+			c.reportSuppressed(ent, summaries)
+		}
+		if !ok {
+			return nil
+		}
+	}
+	if (lbls == c.permLabels || lbls == c.config.CommonLabels) && c.modifiesLabels(ent, lbls) {
+		// The permanent and common labels are shared, copy them before they're modified.
+		lbls = lbls.clone()
+	}
+	c.stampSchemaVersion(lbls)
+	c.stampLoggerName(lbls, ent)
+	c.stampTenantLabels(lbls)
+	fields = c.stampSequence(lbls, fields)
+	if c.config.Cardinality != nil {
+		demoted, exceeded := c.config.Cardinality.check(lbls)
+		for _, key := range exceeded {
+			c.warnCardinality(ent, key)
+		}
+		fields = append(fields, demoted...)
+	}
+	if c.config.LabelLimits != nil {
+		lbls = c.enforceLabelLimits(lbls)
+	}
+
+	e := pendingPool.Get().(*PendingEntry)
+	*e = PendingEntry{Entry: ent, Fields: fields, core: c, labels: lbls, LocalFields: e.LocalFields[:0]}
+	c.transform(e)
+	ent, fields, lbls = e.Entry, e.Fields, e.labels
 
-	lbls.mutex.RLock()
-	c.tempLabels.mutex.Lock()
-	for k, v := range lbls.store {
-		c.tempLabels.store[k] = v
+	var cloudErr error
+	send := cloud && (overridden || c.cloudEnabled(level)) && c.hasCloudDestination()
+	sinks := cloud && c.sinksEnabled(ent.Level)
+	if send || sinks || c.config.FieldSizes != nil {
+		cloudErr = c.writeCloud(ent, fields, lbls, send, sinks)
+		c.handleError(cloudErr)
 	}
-	c.tempLabels.mutex.Unlock()
-	lbls.mutex.RUnlock()
 
-	payload := map[string]interface{}{}
+	fields = append(fields, e.LocalFields...)
+	releasePending(e)
 
-	for _, f := range c.fields {
-		payload[f.Key] = ToInterface(f)
+	if cloud && c.config.ErrorReporting != nil && zapcore.ErrorLevel.Enabled(ent.Level) {
+		c.reportError(ent, fields)
+	}
+
+	if !overridden && !c.Core.Enabled(level) {
+		return cloudErr
+	}
+	if c.config.StructuredErrors {
+		fields = structuredErrorFields(fields)
+	}
+	if c.config.PayloadNamespace != "" {
+		fields = c.namespacedLocalFields(fields)
+	}
+	if c.config.AgentStackTraces {
+		ent, fields = c.withAgentStackTrace(ent, fields)
+	}
+	if severity, ok := severityOverride(c.fields, fields); ok {
+		if level, ok := severityLevels[severity]; ok {
+			ent.Level = level
+		}
+	}
+
+	err := multierr.Append(cloudErr, c.Core.Write(ent, fields))
+	return err
+}
+
+// modifiesLabels reports whether the merged labels of an entry are modified
+// before it's written.
+func (c *core) modifiesLabels(ent zapcore.Entry, lbls *labels) bool {
+	return c.config.SchemaVersion != "" || c.config.Cardinality != nil ||
+		c.config.InstanceID != "" || c.stampsLoggerName(ent) || c.stampsTenantLabels(lbls)
+}
+
+// writeCloud builds the Cloud Logging entry, and sends it if `send` is set, and
+// to the enabled sinks if `sinks` is set. It only returns an error with
+// `SynchronousWrites`.
+func (c *core) writeCloud(ent zapcore.Entry, fields []zapcore.Field, lbls *labels, send, sinks bool) error {
+	payload := payloadPool.Get().(map[string]interface{})
+	defer releasePayload(payload)
+
+	if c.config.PayloadNamespace != "" {
+		addNamespacedPayloadFields(payload, c.config.PayloadNamespace, c.fields, fields)
+	} else {
+		addPayloadFields(payload, c.fields, fields)
+	}
+	payload[c.messageKey()] = ent.Message
+	if c.config.StructuredStacks && ent.Stack != "" {
+		if _, ok := payload[stacktraceKey]; !ok {
+			payload[stacktraceKey] = ent.Stack
+		}
+	}
+	if c.reportsError(ent, fields) {
+		c.addErrorEvent(payload, ent, fields)
+	}
+	if c.config.OmitEmpty {
+		pruneEmpty(payload, c.config.OmitEmptyExcept)
+	}
+	if c.config.FieldSizes != nil && c.config.FieldSizes.record(payload) {
+		c.reportFieldSizes(ent)
 	}
-	payload["message"] = ent.Message
 
 	glog := logging.Entry{
 		Timestamp:    ent.Time,
-		Severity:     logLevelSeverityGoogle[ent.Level],
+		Severity:     c.severity(ent.Level),
 		Payload:      payload,
-		Labels:       c.allLabels().store,
+		Labels:       nil,
 		InsertID:     "",
 		HTTPRequest:  nil,
 		Operation:    nil,
 		LogName:      "",
-		Resource:     nil,
+		Resource:     c.config.Resource,
 		Trace:        "",
 		SpanID:       "",
 		TraceSampled: false,
@@ -241,87 +709,328 @@ func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
 			Line: int64(ent.Caller.Line),
 		},
 	}
-	//fmt.Printf("glog: %#v\n", glog)
-	c.lg.Log(glog)
+	if ent.Caller.Defined {
+		glog.SourceLocation.Function = functionName(ent.Caller.PC)
+	}
+	if len(lbls.store) > 0 {
+		glog.Labels = lbls.snapshot()
+	}
+	c.mapSpecialFields(&glog, fields)
+	if glog.Trace != "" {
+		glog.Trace = c.traceName(glog.Trace)
+	}
+	if glog.SourceLocation != nil {
+		glog.SourceLocation.File = c.sourceFile(glog.SourceLocation.File)
+	}
+	if raw, ok := findRawPayload(fields); ok {
+		glog.Payload = raw
+	}
+	if g := c.config.RequestInsertIDs; g != nil && glog.InsertID == "" && glog.Labels[requestIDKey] != "" {
+		glog.InsertID = g.next(glog.Labels[requestIDKey])
+	}
+	if c.config.InsertIDs != nil || c.config.InsertIDHasher != nil || glog.InsertID != "" {
+		if glog.Timestamp.IsZero() {
+			// The timestamp is part of the deduplication key, so it has to be fixed
+			// before the entry is handed to the client.
+			glog.Timestamp = time.Now()
+		}
+		if glog.InsertID == "" && c.config.InsertIDHasher != nil {
+			c.config.InsertIDHasher.hash(&glog, ent.Message)
+		} else if glog.InsertID == "" {
+			glog.InsertID = c.config.InsertIDs.next()
+		}
+	}
+
+	if send && c.config.TraceQuota != nil {
+		send = c.allowTrace(ent, &glog, fields)
+	}
 
-	fields = append(fields, labelsField(c.allLabels()))
-	fields = c.withSourceLocation(ent, fields)
-	if c.config.ServiceName != "" {
-		fields = c.withServiceContext(c.config.ServiceName, fields)
+	if send && c.config.TenantQuota != nil {
+		send = c.allowTenant(&glog)
 	}
-	if c.config.ReportAllErrors && zapcore.ErrorLevel.Enabled(ent.Level) {
-		fields = c.withErrorReport(ent, fields)
-		if c.config.ServiceName == "" {
-			// A service name was not set but error report needs it
-			// So attempt to add a generic service name
-			fields = c.withServiceContext("unknown", fields)
+
+	if !send && !sinks {
+		return nil
+	}
+
+	if c.config.OversizePolicy != OversizeIgnore {
+		if parts := c.fitEntry(&glog); parts != nil {
+			var err error
+			for i := range parts {
+				err = multierr.Append(err, c.deliver(ent, &parts[i], fields, send, sinks))
+			}
+			return err
 		}
 	}
 
-	c.tempLabels.reset()
+	return c.deliver(ent, &glog, fields, send, sinks)
+}
+
+// deliver sends the entry to the enabled sinks if `sinks` is set, and to the
+// primary destination if `send` is set.
+func (c *core) deliver(ent zapcore.Entry, glog *logging.Entry, fields []zapcore.Field, send, sinks bool) error {
+	var err error
+	if sinks {
+		// The sinks go first, as the primary destination can modify the entry.
+		err = c.sendSinks(ent.Level, glog)
+	}
+	if send {
+		err = multierr.Append(err, c.send(ent, glog, fields))
+	}
 
-	err := c.Core.Write(ent, fields)
 	return err
 }
 
+// send sends the entry to Cloud Logging, or validates it in dry run mode.
+func (c *core) send(ent zapcore.Entry, glog *logging.Entry, fields []zapcore.Field) error {
+	for _, hook := range c.config.BeforeWrite {
+		if err := hook(glog, ent); err != nil {
+			if err == ErrDropEntry {
+				return nil
+			}
+			return err
+		}
+	}
+
+	if c.config.DryRun != nil {
+		c.dryRun(glog)
+		return nil
+	}
+	if c.config.EntryHook != nil {
+		c.runHook(glog)
+		return nil
+	}
+
+	if c.config.Fallback != nil && c.config.Fallback.Active() {
+		return c.config.Fallback.write(glog)
+	}
+
+	if lg := c.cloudLogger(ent.LoggerName, glog, fields); lg != nil {
+		defer c.config.Metrics.sent(glog)
+
+		if _, ok := lg.(*logging.Logger); !ok {
+			// Other loggers may keep the entry after it's written.
+			e := copyEntry(glog)
+			glog = &e
+		}
+
+		if c.config.SynchronousWrites {
+			err := c.logSync(lg, *glog)
+			if err == nil {
+				c.config.Stats.sent()
+			}
+			if c.config.Fallback != nil && err == nil {
+				c.config.Fallback.succeeded()
+			}
+			return err
+		}
+		lg.Log(*glog)
+		c.config.Stats.logged()
+	}
+
+	return nil
+}
+
+// hasCloudDestination reports whether entries can be sent anywhere, so the
+// Cloud Logging entry isn't built for nothing.
+func (c *core) hasCloudDestination() bool {
+	return c.lg != nil || len(c.config.Failovers) > 0 || c.config.Router != nil ||
+		c.config.LogNames != nil || c.config.LoggerNames != nil || c.config.Tenants != nil ||
+		c.config.Destinations != nil || c.config.DryRun != nil || c.config.EntryHook != nil
+}
+
+// payloadPool holds payload maps, which are reused once the client converted
+// the entry, to reduce the allocations per entry.
+var payloadPool = sync.Pool{
+	New: func() interface{} { return make(map[string]interface{}, 16) },
+}
+
+func releasePayload(payload map[string]interface{}) {
+	for k := range payload {
+		delete(payload, k)
+	}
+	payloadPool.Put(payload)
+}
+
 // Sync flushes buffered logs (if any).
 func (c *core) Sync() error {
-	_ = c.lg.Flush()
+	_ = c.flush()
+
 	return c.Core.Sync()
 }
 
-func (c *core) allLabels() *labels {
-	lbls := newLabels()
+// flush writes all queued and collapsed entries, and flushes the Cloud Logging
+// loggers of the core.
+func (c *core) flush() error {
+	if c.config.Async != nil {
+		c.config.Async.wait()
+	}
+	if c.config.Dedup != nil {
+		writeCollapsed(c.config.Dedup.flush())
+	}
 
-	lbls.mutex.Lock()
-	c.permLabels.mutex.RLock()
-	for k, v := range c.permLabels.store {
-		lbls.store[k] = v
+	return c.flushCloud()
+}
+
+// flushCloud flushes the entries buffered by all Cloud Logging loggers of the
+// core, and returns the errors of the flushes.
+func (c *core) flushCloud() error {
+	c.config.Stats.flush()
+
+	var err error
+	if c.lg != nil {
+		err = multierr.Append(err, c.lg.Flush())
+	}
+	for _, f := range c.config.Failovers {
+		err = multierr.Append(err, f.secondary.Flush())
+	}
+	if c.config.Router != nil {
+		err = multierr.Append(err, c.config.Router.flush())
+	}
+	if c.config.LogNames != nil {
+		err = multierr.Append(err, c.config.LogNames.flush())
+	}
+	if c.config.LoggerNames != nil {
+		err = multierr.Append(err, c.config.LoggerNames.flush())
+	}
+	if c.config.Tenants != nil {
+		err = multierr.Append(err, c.config.Tenants.flush())
+	}
+	if c.config.ErrorReporting != nil {
+		c.config.ErrorReporting.Flush()
+	}
+	for _, s := range c.config.Sinks {
+		err = multierr.Append(err, s.logger.Flush())
+	}
+	for _, lg := range c.config.Destinations {
+		err = multierr.Append(err, lg.Flush())
+	}
+	c.config.Stats.flushed(err)
+
+	return err
+}
+
+// cloudLogger returns the Cloud Logging logger the entry of the named zap logger
+// should be written to, taking any active failover and routing into account. It
+// returns nil if no Cloud Logging logger is configured.
+func (c *core) cloudLogger(name string, ent *logging.Entry, fields []zapcore.Field) EntryLogger {
+	if c.config.Destinations != nil {
+		if lg := c.destination(c.fields, fields); lg != nil {
+			return lg
+		}
+	}
+
+	for _, f := range c.config.Failovers {
+		if !f.Active() {
+			continue
+		}
+
+		for k, v := range f.labels {
+			if ent.Labels == nil {
+				ent.Labels = map[string]string{}
+			}
+			ent.Labels[k] = v
+		}
+
+		if f.secondary == nil {
+			return nil
+		}
+		return f.secondary
+	}
+
+	if c.config.LogNames != nil {
+		if lg := c.config.LogNames.route(c.fields, fields); lg != nil {
+			return lg
+		}
+	}
+
+	if c.config.LoggerNames != nil && name != "" {
+		if lg := c.config.LoggerNames.logger(name); lg != nil {
+			return lg
+		}
 	}
-	c.permLabels.mutex.RUnlock()
 
-	c.tempLabels.mutex.RLock()
-	for k, v := range c.tempLabels.store {
+	if c.config.Tenants != nil && ent.Labels[tenantLabel] != "" {
+		if lg := c.config.Tenants.logger(ent.Labels[tenantLabel]); lg != nil {
+			return lg
+		}
+	}
+
+	if c.config.Router != nil {
+		if lg := c.config.Router.route(c.fields, fields); lg != nil {
+			return lg
+		}
+	}
+
+	return c.lg
+}
+
+// allLabels returns the set of labels containing both the permanent labels of
+// the core and the given labels of a single entry. The permanent labels are
+// returned as-is if the entry has no labels of its own, so they must not be
+// modified.
+func (c *core) allLabels(entry *labels) *labels {
+	if entry == nil || len(entry.store) == 0 {
+		return c.permLabels
+	}
+
+	c.config.Stats.labelMerge()
+	lbls := &labels{store: make(map[string]string, len(c.permLabels.store)+len(entry.store))}
+
+	// Labels merged last take precedence.
+	first, last := c.permLabels, entry
+	if c.config.LabelPrecedence == InheritedLabelsWin {
+		first, last = last, first
+	}
+
+	for k, v := range first.store {
+		lbls.store[k] = v
+	}
+	for k, v := range last.store {
 		lbls.store[k] = v
 	}
-	c.tempLabels.mutex.RUnlock()
-	lbls.mutex.Unlock()
 
 	return lbls
 }
 
+// extractLabels collects the labels of the fields in a pooled scratch set,
+// which is released by the caller, and returns the remaining fields. The set
+// is nil if none of the fields holds labels.
 func (c *core) extractLabels(fields []zapcore.Field) (*labels, []zapcore.Field) {
-	lbls := newLabels()
-	out := []zapcore.Field{}
+	if !hasLabelFields(fields) && !c.hasPromotedFields(fields) {
+		// Most entries carry no labels, so the fields are returned as-is. The
+		// capacity is capped, so appending to them never writes to the backing
+		// array of the caller.
+		return nil, fields[:len(fields):len(fields)]
+	}
+
+	lbls := getLabels()
+	key := c.labelKeySanitizer()
+
+	// Leave room for the labels, source location and service context fields,
+	// which are appended later on.
+	out := make([]zapcore.Field, 0, len(fields)+3)
 
-	lbls.mutex.Lock()
 	for i := range fields {
-		if !isLabelField(fields[i]) {
+		if !c.addLabel(lbls.store, fields[i], key) {
 			out = append(out, fields[i])
-			continue
 		}
-
-		lbls.store[strings.Replace(fields[i].Key, "labels.", "", 1)] = fields[i].String
 	}
-	lbls.mutex.Unlock()
 
 	return lbls, out
 }
 
 func (c *core) withLabels(fields []zapcore.Field) []zapcore.Field {
 	lbls := newLabels()
-	out := []zapcore.Field{}
+	out := make([]zapcore.Field, 0, len(fields)+1)
 
-	lbls.mutex.Lock()
 	for i := range fields {
-		if isLabelField(fields[i]) {
-			lbls.store[strings.Replace(fields[i].Key, "labels.", "", 1)] = fields[i].String
+		if c.addLabel(lbls.store, fields[i], c.labelKeySanitizer()) {
 			continue
 		}
 
 		out = append(out, fields[i])
 	}
-	lbls.mutex.Unlock()
 
 	return append(out, labelsField(lbls))
 }
@@ -338,7 +1047,7 @@ func (c *core) withSourceLocation(ent zapcore.Entry, fields []zapcore.Field) []z
 		return fields
 	}
 
-	return append(fields, SourceLocation(ent.Caller.PC, ent.Caller.File, ent.Caller.Line, true))
+	return append(fields, SourceLocation(ent.Caller.PC, c.sourceFile(ent.Caller.File), ent.Caller.Line, true))
 }
 
 func (c *core) withServiceContext(name string, fields []zapcore.Field) []zapcore.Field {
@@ -349,7 +1058,7 @@ func (c *core) withServiceContext(name string, fields []zapcore.Field) []zapcore
 		}
 	}
 
-	return append(fields, ServiceContext(name))
+	return append(fields, ServiceContextVersion(name, c.config.ServiceVersion))
 }
 
 func (c *core) withErrorReport(ent zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
@@ -366,3 +1075,15 @@ func (c *core) withErrorReport(ent zapcore.Entry, fields []zapcore.Field) []zapc
 
 	return append(fields, ErrorReport(ent.Caller.PC, ent.Caller.File, ent.Caller.Line, true))
 }
+
+// addPayloadFields adds the fields to the payload, see `payloadEncoder`. Fields
+// following a namespace field (see `zap.Namespace`) are nested in an object,
+// like the local encoder does.
+func addPayloadFields(payload map[string]interface{}, sets ...[]zapcore.Field) {
+	enc := &payloadEncoder{fields: payload}
+	for _, fields := range sets {
+		for i := range fields {
+			enc.addField(fields[i])
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package zapdriver
 
 import (
+	"errors"
 	"runtime"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/logging"
 	"github.com/stretchr/testify/require"
 
 	"github.com/stretchr/testify/assert"
@@ -38,7 +41,6 @@ func TestExtractLabels(t *testing.T) {
 	c := &core{
 		Core:       zapcore.NewNopCore(),
 		permLabels: newLabels(),
-		tempLabels: newLabels(),
 	}
 
 	fields := []zap.Field{
@@ -50,11 +52,8 @@ func TestExtractLabels(t *testing.T) {
 	lbls, fields = c.extractLabels(fields)
 
 	require.Len(t, lbls.store, 2)
-
-	lbls.mutex.RLock()
 	assert.Equal(t, "world", lbls.store["one"])
 	assert.Equal(t, "worlds", lbls.store["two"])
-	lbls.mutex.RUnlock()
 
 	require.Len(t, fields, 1)
 	assert.Equal(t, zap.String("hello", "world"), fields[0])
@@ -158,14 +157,11 @@ func TestWithServiceContext_DoesNotOverwrite(t *testing.T) {
 }
 
 func TestWrite(t *testing.T) {
-	temp := newLabels()
-	temp.store = map[string]string{"one": "1", "two": "2"}
 
 	debugcore, logs := observer.New(zapcore.DebugLevel)
 	core := &core{
 		Core:       debugcore,
 		permLabels: newLabels(),
-		tempLabels: temp,
 	}
 
 	fields := []zap.Field{
@@ -181,8 +177,6 @@ func TestWrite(t *testing.T) {
 }
 
 func TestWriteConcurrent(t *testing.T) {
-	temp := newLabels()
-	temp.store = map[string]string{"one": "1", "two": "2"}
 	goRoutines := 8
 	counter := int32(10000)
 
@@ -190,7 +184,6 @@ func TestWriteConcurrent(t *testing.T) {
 	core := &core{
 		Core:       debugcore,
 		permLabels: newLabels(),
-		tempLabels: temp,
 	}
 
 	fields := []zap.Field{
@@ -220,7 +213,6 @@ func TestWithAndWrite(t *testing.T) {
 	core := zapcore.Core(&core{
 		Core:       debugcore,
 		permLabels: newLabels(),
-		tempLabels: newLabels(),
 	})
 
 	core = core.With([]zapcore.Field{Label("one", "world")})
@@ -238,7 +230,6 @@ func TestWithAndWrite_MultipleEntries(t *testing.T) {
 	core := zapcore.Core(&core{
 		Core:       debugcore,
 		permLabels: newLabels(),
-		tempLabels: newLabels(),
 	})
 
 	core = core.With([]zapcore.Field{Label("one", "world")})
@@ -266,7 +257,6 @@ func TestWriteReportAllErrors(t *testing.T) {
 	core := zapcore.Core(&core{
 		Core:       debugcore,
 		permLabels: newLabels(),
-		tempLabels: newLabels(),
 		config: driverConfig{
 			ReportAllErrors: true,
 		},
@@ -297,7 +287,6 @@ func TestWriteServiceContext(t *testing.T) {
 	core := zapcore.Core(&core{
 		Core:       debugcore,
 		permLabels: newLabels(),
-		tempLabels: newLabels(),
 		config: driverConfig{
 			ServiceName: "test service",
 		},
@@ -316,7 +305,6 @@ func TestWriteReportAllErrors_WithServiceContext(t *testing.T) {
 	core := zapcore.Core(&core{
 		Core:       debugcore,
 		permLabels: newLabels(),
-		tempLabels: newLabels(),
 		config: driverConfig{
 			ReportAllErrors: true,
 			ServiceName:     "test service",
@@ -342,7 +330,6 @@ func TestWriteReportAllErrors_InfoLog(t *testing.T) {
 	core := zapcore.Core(&core{
 		Core:       debugcore,
 		permLabels: newLabels(),
-		tempLabels: newLabels(),
 		config: driverConfig{
 			ReportAllErrors: true,
 		},
@@ -369,15 +356,390 @@ func TestAllLabels(t *testing.T) {
 	core := &core{
 		Core:       zapcore.NewNopCore(),
 		permLabels: perm,
-		tempLabels: temp,
 	}
 
-	out := core.allLabels()
+	out := core.allLabels(temp)
 	require.Len(t, out.store, 3)
 
-	out.mutex.RLock()
 	assert.Equal(t, out.store["one"], "ONE")
 	assert.Equal(t, out.store["two"], "2")
 	assert.Equal(t, out.store["three"], "THREE")
-	out.mutex.RUnlock()
+}
+
+func TestWriteConcurrent_Labels(t *testing.T) {
+	goRoutines := 8
+	entries := 1000
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := zapcore.Core(&core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+	})
+	core = core.With([]zapcore.Field{Label("shared", "value")})
+
+	var wg sync.WaitGroup
+	wg.Add(goRoutines)
+	for i := 0; i < goRoutines; i++ {
+		go func(id string) {
+			defer wg.Done()
+			for j := 0; j < entries; j++ {
+				err := core.Write(zapcore.Entry{Message: id}, []zapcore.Field{Label("id", id)})
+				require.NoError(t, err)
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	require.Equal(t, goRoutines*entries, logs.Len())
+	for _, entry := range logs.All() {
+		labels := entry.ContextMap()[labelsKey].(map[string]interface{})
+
+		assert.Equal(t, map[string]interface{}{"shared": "value", "id": entry.Message}, labels)
+	}
+}
+
+func TestWith_DoesNotLeakLabelsIntoParent(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	parent := zapcore.Core(&core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+	})
+
+	parent.With([]zapcore.Field{Label("child", "value")})
+	err := parent.Write(zapcore.Entry{}, nil)
+	require.NoError(t, err)
+
+	assert.Empty(t, logs.All()[0].ContextMap()[labelsKey])
+}
+
+func TestAllLabels_InheritedLabelsWin(t *testing.T) {
+	perm := newLabels()
+	perm.store = map[string]string{"one": "1", "two": "2"}
+
+	temp := newLabels()
+	temp.store = map[string]string{"one": "ONE", "three": "THREE"}
+
+	core := &core{
+		Core:       zapcore.NewNopCore(),
+		permLabels: perm,
+		config: driverConfig{
+			LabelPrecedence: InheritedLabelsWin,
+		},
+	}
+
+	out := core.allLabels(temp)
+	require.Len(t, out.store, 3)
+
+	assert.Equal(t, out.store["one"], "1")
+	assert.Equal(t, out.store["two"], "2")
+	assert.Equal(t, out.store["three"], "THREE")
+}
+
+func TestWithAndWrite_LabelPrecedence(t *testing.T) {
+	var tests = map[LabelPrecedence]string{
+		EntryLabelsWin:     "entry",
+		InheritedLabelsWin: "inherited",
+	}
+
+	for precedence, want := range tests {
+		debugcore, logs := observer.New(zapcore.DebugLevel)
+		core := zapcore.Core(&core{
+			Core:       debugcore,
+			permLabels: newLabels(),
+			config: driverConfig{
+				LabelPrecedence: precedence,
+			},
+		})
+
+		core = core.With([]zapcore.Field{Label("env", "inherited")})
+		err := core.Write(zapcore.Entry{}, []zapcore.Field{Label("env", "entry")})
+		require.NoError(t, err)
+
+		labels := logs.All()[0].ContextMap()[labelsKey].(map[string]interface{})
+		assert.Equal(t, want, labels["env"])
+	}
+}
+
+func TestWrite_HTTPRequest(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	logger.Info("request", HTTP(&HTTPPayload{
+		RequestMethod: "POST",
+		RequestURL:    "https://example.com/orders",
+		Status:        201,
+		ResponseSize:  "42",
+		Latency:       "0.25s",
+	}))
+	logger.Info("no request")
+	require.NoError(t, logger.Sync())
+
+	assert.Contains(t, logs.All()[0].ContextMap(), httpRequestKey)
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+
+	r := entries[0].HttpRequest
+	require.NotNil(t, r)
+	assert.Equal(t, "POST", r.RequestMethod)
+	assert.Equal(t, "https://example.com/orders", r.RequestUrl)
+	assert.Equal(t, int32(201), r.Status)
+	assert.Equal(t, int64(42), r.ResponseSize)
+	assert.Equal(t, int32(250*time.Millisecond), r.Latency.Nanos)
+
+	assert.Nil(t, entries[1].HttpRequest)
+}
+
+type testMarshaler struct {
+	id    string
+	items int
+}
+
+func (m testMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", m.id)
+	enc.AddInt("items", m.items)
+	return enc.AddArray("tags", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		enc.AppendString("new")
+		return nil
+	}))
+}
+
+func TestToInterface_Marshalers(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, map[string]interface{}{
+		"id":    "order-1",
+		"items": 2,
+		"tags":  []interface{}{"new"},
+	}, ToInterface(zap.Object("order", testMarshaler{id: "order-1", items: 2})))
+
+	assert.Equal(t, []interface{}{"a", "b"}, ToInterface(zap.Strings("tags", []string{"a", "b"})))
+}
+
+type node struct {
+	Name string
+	Next *node
+}
+
+type hostname string
+
+func (h *hostname) String() string { return "host:" + string(*h) }
+
+func TestToInterface_Reflected(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	loop := &node{Name: "a"}
+	loop.Next = loop
+
+	assert.Equal(t, map[string]interface{}{
+		"at":      "2019-01-02T03:04:05Z",
+		"updates": "chan int",
+		"nested":  map[string]interface{}{"Name": "a", "Next": cycleValue},
+	}, ToInterface(zap.Reflect("value", map[string]interface{}{
+		"at":      at,
+		"updates": make(chan int),
+		"nested":  loop,
+	})))
+
+	h := hostname("db-1")
+	assert.Equal(t, "host:db-1", ToInterface(zap.Stringer("host", &h)))
+
+	// Like the local encoder, a panicking stringer is reported in a separate
+	// field.
+	payload := map[string]interface{}{}
+	addPayloadFields(payload, []zapcore.Field{zap.Stringer("host", (*hostname)(nil))})
+	assert.NotContains(t, payload, "host")
+	assert.Contains(t, payload["hostError"], "PANIC=")
+}
+
+func TestToInterface_FieldTypes(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "text", ToInterface(zap.ByteString("raw", []byte("text"))))
+	assert.Equal(t, "1+2i", ToInterface(zap.Complex128("c", 1+2i)))
+	assert.Equal(t, []interface{}{"1.5-1i"}, ToInterface(zap.Complex64s("c", []complex64{1.5 - 1i})))
+	assert.Equal(t, []interface{}{"a", "b"}, ToInterface(zap.ByteStrings("raw", [][]byte{[]byte("a"), []byte("b")})))
+	assert.Equal(t, time.Second, ToInterface(zap.Duration("d", time.Second)))
+	assert.Equal(t, map[string]string{"message": "boom", "type": "*errors.errorString"}, ToInterface(zap.Error(errors.New("boom"))))
+	assert.Nil(t, ToInterface(zap.Skip()))
+}
+
+func TestAddPayloadFields_Namespace(t *testing.T) {
+	t.Parallel()
+
+	payload := map[string]interface{}{}
+	addPayloadFields(payload,
+		[]zapcore.Field{zap.String("a", "1"), zap.Namespace("ns")},
+		[]zapcore.Field{zap.Int("b", 2), zap.Object("obj", testMarshaler{id: "x"})},
+	)
+
+	assert.Equal(t, map[string]interface{}{
+		"a": "1",
+		"ns": map[string]interface{}{
+			"b":   int64(2),
+			"obj": map[string]interface{}{"id": "x", "items": 0, "tags": []interface{}{"new"}},
+		},
+	}, payload)
+}
+
+func TestWrite_MarshalerPayload(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	logger.Info("placed", zap.Object("order", testMarshaler{id: "order-1", items: 2}), zap.Ints("sizes", []int{1, 2}))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+
+	payload := entries[0].GetJsonPayload().Fields
+	order := payload["order"].GetStructValue().Fields
+	assert.Equal(t, "order-1", order["id"].GetStringValue())
+	assert.Equal(t, float64(2), order["items"].GetNumberValue())
+	assert.Len(t, payload["sizes"].GetListValue().Values, 2)
+}
+
+func TestWrite_NamespacePayload(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core).With(zap.String("service", "orders"), zap.Namespace("db"), zap.String("table", "orders"))
+	logger.Info("queried", zap.Int("rows", 3), zap.Namespace("timing"), zap.Duration("elapsed", time.Second))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+
+	payload := entries[0].GetJsonPayload().Fields
+	assert.Equal(t, "orders", payload["service"].GetStringValue())
+	assert.Equal(t, "queried", payload["message"].GetStringValue())
+
+	db := payload["db"].GetStructValue().Fields
+	assert.Equal(t, "orders", db["table"].GetStringValue())
+	assert.Equal(t, float64(3), db["rows"].GetNumberValue())
+	assert.Contains(t, db["timing"].GetStructValue().Fields, "elapsed")
+}
+
+func TestAllLabels_NoEntryLabels(t *testing.T) {
+	perm := newLabels()
+	perm.store = map[string]string{"one": "1"}
+
+	core := &core{
+		Core:       zapcore.NewNopCore(),
+		permLabels: perm,
+	}
+
+	assert.True(t, core.allLabels(newLabels()) == perm)
+}
+
+func TestWrite_NoLabelsAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+
+	config := encoderConfig
+	config.TimeKey = ""
+
+	c := &core{
+		Core:       zapcore.NewCore(zapcore.NewJSONEncoder(config), zapcore.AddSync(discard{}), zapcore.DebugLevel),
+		permLabels: newLabels(),
+	}
+	fields := []zap.Field{zap.String("hello", "world"), zap.Int("count", 3)}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = c.Write(zapcore.Entry{Message: "hello"}, fields)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestWithFields(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, WrapCore(
+		WithFields(zap.String("service", "orders"), Label("team", "payments")),
+		UTC(),
+	))
+
+	assert.Equal(t, map[string]string{"team": "payments"}, InheritedLabels(logger))
+	assert.Equal(t, []zap.Field{zap.String("service", "orders")}, InheritedFields(logger))
+
+	logger.With(Label("tier", "backend")).Info("hello")
+
+	require.Equal(t, 1, logs.Len())
+	context := logs.All()[0].ContextMap()
+	assert.Equal(t, "orders", context["service"])
+	assert.Equal(t, map[string]interface{}{"team": "payments", "tier": "backend"}, context[labelsKey])
+}
+
+// recordingLogger is an EntryLogger keeping the entries it's given.
+type recordingLogger struct {
+	mutex   sync.Mutex
+	entries []logging.Entry
+	flushes int
+}
+
+func (r *recordingLogger) Log(e logging.Entry) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.entries = append(r.entries, e)
+}
+
+func (r *recordingLogger) Flush() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.flushes++
+	return nil
+}
+
+func TestWithLogger_EntryLogger(t *testing.T) {
+	t.Parallel()
+
+	rec := &recordingLogger{}
+	observed, _ := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, WrapCore(WithLogger(rec)))
+
+	logger.Info("hello", Label("one", "value"), zap.String("user", "alice"))
+	logger.Warn("careful")
+	require.NoError(t, logger.Sync())
+
+	require.Len(t, rec.entries, 2)
+	assert.Equal(t, logging.Info, rec.entries[0].Severity)
+	assert.Equal(t, map[string]string{"one": "value"}, rec.entries[0].Labels)
+	assert.Equal(t, "alice", rec.entries[0].Payload.(map[string]interface{})["user"])
+	assert.Equal(t, logging.Warning, rec.entries[1].Severity)
+	assert.Equal(t, 1, rec.flushes)
+}
+
+func TestWithLogger_EntryLoggerSynchronous(t *testing.T) {
+	t.Parallel()
+
+	rec := &recordingLogger{}
+	observed, _ := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, WrapCore(WithLogger(rec), SynchronousWrites()))
+
+	logger.Info("hello")
+
+	require.Len(t, rec.entries, 1)
+	assert.Equal(t, 1, rec.flushes)
+}
+
+func TestWithLogger_Nil(t *testing.T) {
+	t.Parallel()
+
+	var lg *logging.Logger
+	c := &core{}
+	WithLogger(lg)(c)
+
+	assert.Nil(t, c.lg)
+	assert.False(t, c.hasCloudDestination())
 }
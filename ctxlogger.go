@@ -0,0 +1,142 @@
+package zapdriver
+
+import (
+	"context"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// Logger wraps a `zap.Logger`, and adds the `Ctx` method, returning a logger
+// that adds the trace context, request ID and fields carried by a
+// `context.Context` to every entry:
+//
+//	logger := zapdriver.NewLogger(zapLogger, "my-project")
+//	logger.Ctx(ctx).Info("Order placed.", zap.String("order_id", id))
+type Logger struct {
+	*zap.Logger
+
+	projectName string
+	pprofLabels bool
+}
+
+// NewLogger wraps logger. The project name is used to format the trace ID, see
+// `TraceContext`.
+func NewLogger(logger *zap.Logger, projectName string) *Logger {
+	return &Logger{Logger: logger, projectName: projectName}
+}
+
+// With adds a variadic number of fields to the logging context.
+func (l *Logger) With(fields ...zap.Field) *Logger {
+	return &Logger{Logger: l.Logger.With(fields...), projectName: l.projectName, pprofLabels: l.pprofLabels}
+}
+
+// WithPprofLabels returns a logger whose `Ctx` method adds the pprof labels of
+// the context as labels as well, see `PprofLabels`.
+func (l *Logger) WithPprofLabels() *Logger {
+	return &Logger{Logger: l.Logger, projectName: l.projectName, pprofLabels: true}
+}
+
+// Ctx returns a logger adding the fields carried by ctx: the trace context of
+// the active span (see `TraceFromContext`), the request ID added using
+// `ContextWithRequestID` as the "request_id" label, the fields added using
+// `ContextWithFields`, and the labels added using `ContextWithLabels`. Labels of
+// the context take precedence over pprof labels with the same key (see
+// `WithPprofLabels`).
+func (l *Logger) Ctx(ctx context.Context) *zap.Logger {
+	fields := contextFields(ctx, l.projectName)
+	if l.pprofLabels {
+		if f, ok := pprofLabelsField(ctx); ok {
+			// Later labels take precedence.
+			fields = append([]zap.Field{f}, fields...)
+		}
+	}
+	if len(fields) == 0 {
+		return l.Logger
+	}
+
+	return l.Logger.With(fields...)
+}
+
+// contextFields returns all fields carried by ctx, see `Logger.Ctx`.
+func contextFields(ctx context.Context, projectName string) []zap.Field {
+	fields := append(TraceFromContext(ctx, projectName), FieldsFromContext(ctx)...)
+	if id, ok := RequestIDFromContext(ctx); ok {
+		fields = append(fields, Label(requestIDKey, id))
+	}
+
+	lbls := LabelsFromContext(ctx)
+	if len(lbls) == 0 {
+		return fields
+	}
+
+	keys := make([]string, 0, len(lbls))
+	for k := range lbls {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fields = append(fields, Label(k, lbls[k]))
+	}
+
+	return fields
+}
+
+type (
+	contextFieldsKey struct{}
+	contextLabelsKey struct{}
+)
+
+// ContextWithFields returns a copy of ctx carrying the given fields, in
+// addition to the fields ctx already carries. Loggers returned by `Ctx` add
+// them to every entry:
+//
+//	ctx = zapdriver.ContextWithFields(ctx, zapdriver.Label("tenant", tenant))
+func ContextWithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	prev := FieldsFromContext(ctx)
+
+	// Copy the fields, so contexts derived from the same parent don't share
+	// their backing array.
+	all := make([]zap.Field, 0, len(prev)+len(fields))
+	all = append(all, prev...)
+	all = append(all, fields...)
+
+	return context.WithValue(ctx, contextFieldsKey{}, all)
+}
+
+// FieldsFromContext returns the fields added to ctx using `ContextWithFields`.
+func FieldsFromContext(ctx context.Context) []zap.Field {
+	fields, _ := ctx.Value(contextFieldsKey{}).([]zap.Field)
+
+	return fields
+}
+
+// ContextWithLabels returns a copy of ctx carrying the given labels, in addition
+// to the labels ctx already carries. Labels with the same key replace the ones
+// of the parent context. Loggers returned by `Ctx` add them to every entry:
+//
+//	ctx = zapdriver.ContextWithLabels(ctx, map[string]string{"tenant": tenant})
+func ContextWithLabels(ctx context.Context, labels map[string]string) context.Context {
+	prev := LabelsFromContext(ctx)
+
+	// Copy the labels, so contexts derived from the same parent don't share
+	// them.
+	all := make(map[string]string, len(prev)+len(labels))
+	for k, v := range prev {
+		all[k] = v
+	}
+	for k, v := range labels {
+		all[k] = v
+	}
+
+	return context.WithValue(ctx, contextLabelsKey{}, all)
+}
+
+// LabelsFromContext returns the labels added to ctx using `ContextWithLabels`.
+// The returned map must not be modified.
+func LabelsFromContext(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(contextLabelsKey{}).(map[string]string)
+
+	return labels
+}
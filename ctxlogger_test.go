@@ -0,0 +1,70 @@
+package zapdriver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggerCtx(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(&core{Core: debugcore, permLabels: newLabels()}), "my-project")
+
+	ctx := ContextWithSpanContext(context.Background(), SpanContext{TraceID: "abc", SpanID: "def", Sampled: true})
+	ctx = ContextWithRequestID(ctx, "req-1")
+	ctx = ContextWithFields(ctx, Label("tenant", "acme"))
+
+	logger.With(zap.String("component", "orders")).Ctx(ctx).Info("placed", zap.Int("items", 2))
+	logger.Ctx(context.Background()).Info("plain")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "projects/my-project/traces/abc", fields[traceKey])
+	assert.Equal(t, "def", fields[spanKey])
+	assert.Equal(t, true, fields[traceSampledKey])
+	assert.Equal(t, "orders", fields["component"])
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "request_id": "req-1"}, fields[labelsKey])
+
+	assert.NotContains(t, entries[1].ContextMap(), traceKey)
+}
+
+func TestContextWithFields_DoesNotShareParent(t *testing.T) {
+	t.Parallel()
+
+	parent := ContextWithFields(context.Background(), zap.String("a", "1"))
+	first := ContextWithFields(parent, zap.String("b", "2"))
+	second := ContextWithFields(parent, zap.String("c", "3"))
+
+	assert.Equal(t, []zap.Field{zap.String("a", "1")}, FieldsFromContext(parent))
+	assert.Equal(t, []zap.Field{zap.String("a", "1"), zap.String("b", "2")}, FieldsFromContext(first))
+	assert.Equal(t, []zap.Field{zap.String("a", "1"), zap.String("c", "3")}, FieldsFromContext(second))
+}
+
+func TestContextWithLabels(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(&core{Core: debugcore, permLabels: newLabels()}), "my-project")
+
+	parent := ContextWithLabels(context.Background(), map[string]string{"tenant": "acme", "plan": "free"})
+	child := ContextWithLabels(parent, map[string]string{"plan": "pro", "request": "req-1"})
+
+	logger.Ctx(child).Info("placed", Label("order", "42"))
+
+	assert.Equal(t, map[string]string{"tenant": "acme", "plan": "free"}, LabelsFromContext(parent))
+	assert.Equal(t, map[string]interface{}{
+		"tenant":  "acme",
+		"plan":    "pro",
+		"request": "req-1",
+		"order":   "42",
+	}, logs.All()[0].ContextMap()[labelsKey])
+}
@@ -0,0 +1,182 @@
+package zapdriver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/api/option"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WithDeadLetterFile writes the entries Cloud Logging permanently failed to
+// accept to a local file, one JSON encoded `LogEntry` per line, so important
+// entries can be replayed manually instead of vanishing. Once the file grows
+// beyond `maxBytes`, it is rotated, keeping `backups` old files (`path.1` being
+// the most recent one).
+//
+// Entries are written to the file when a write fails with an error that isn't
+// retried, or when the client reports the failure of a retried write through
+// `OnError`, after all retries were exhausted. The option wraps the `OnError`
+// callback of the client created by `NewClient`, it should not be replaced
+// afterwards.
+func WithDeadLetterFile(path string, maxBytes int64, backups int) ClientOption {
+	return func(c *clientConfig) {
+		d := &deadLetter{
+			path:     path,
+			maxBytes: maxBytes,
+			backups:  backups,
+			pending:  map[*logpb.WriteLogEntriesRequest]context.Context{},
+		}
+
+		c.deadLetter = d
+		c.options = append(c.options, option.WithGRPCDialOption(grpc.WithUnaryInterceptor(d.intercept)))
+	}
+}
+
+// deadLetter writes failed entries to a rotated file.
+type deadLetter struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	mutex sync.Mutex
+
+	// pending holds the requests that failed with an error that is retried,
+	// and didn't succeed since, with the context of their last attempt.
+	pending map[*logpb.WriteLogEntriesRequest]context.Context
+}
+
+// intercept watches the outcome of every attempt to write entries.
+func (d *deadLetter) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	wreq, ok := req.(*logpb.WriteLogEntriesRequest)
+	if !ok {
+		return err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	switch {
+	case err == nil:
+		delete(d.pending, wreq)
+	case isRetriedCode(status.Code(err)) && ctx.Err() == nil:
+		d.pending[wreq] = ctx
+	default:
+		delete(d.pending, wreq)
+		_ = d.write(wreq)
+	}
+
+	return err
+}
+
+// onError writes the failing requests the client gave up on, as their deadline
+// passed while waiting for the next attempt. Requests that are still retried
+// are kept, they might succeed yet.
+func (d *deadLetter) onError(error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for req, ctx := range d.pending {
+		if ctx.Err() == nil {
+			continue
+		}
+
+		delete(d.pending, req)
+		_ = d.write(req)
+	}
+}
+
+// isRetriedCode reports whether the client retries writes failing with the
+// code.
+func isRetriedCode(code codes.Code) bool {
+	return code == codes.DeadlineExceeded || code == codes.Internal || code == codes.Unavailable
+}
+
+// write appends the entries of the request to the file. The mutex must be
+// held.
+func (d *deadLetter) write(req *logpb.WriteLogEntriesRequest) error {
+	buf := &bytes.Buffer{}
+	m := jsonpb.Marshaler{}
+
+	for _, e := range req.Entries {
+		// Make every entry self-contained, by adding the defaults of the
+		// request.
+		e = proto.Clone(e).(*logpb.LogEntry)
+		if e.LogName == "" {
+			e.LogName = req.LogName
+		}
+		if e.Resource == nil {
+			e.Resource = req.Resource
+		}
+		if len(req.Labels) > 0 {
+			labels := make(map[string]string, len(req.Labels)+len(e.Labels))
+			for k, v := range req.Labels {
+				labels[k] = v
+			}
+			for k, v := range e.Labels {
+				labels[k] = v
+			}
+			e.Labels = labels
+		}
+
+		if err := m.Marshal(buf, e); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	}
+
+	if err := d.rotate(int64(buf.Len())); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// rotate moves the file aside if writing `n` more bytes would grow it beyond
+// the maximum size.
+func (d *deadLetter) rotate(n int64) error {
+	info, err := os.Stat(d.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Size() == 0 || info.Size()+n <= d.maxBytes {
+		return nil
+	}
+
+	if d.backups < 1 {
+		return os.Remove(d.path)
+	}
+
+	for i := d.backups - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", d.path, i), fmt.Sprintf("%s.%d", d.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return os.Rename(d.path, d.path+".1")
+}
@@ -0,0 +1,134 @@
+package zapdriver
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func readDeadLetters(t *testing.T, path string) []*logpb.LogEntry {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var entries []*logpb.LogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		e := &logpb.LogEntry{}
+		require.NoError(t, jsonpb.UnmarshalString(scanner.Text(), e))
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func TestWithDeadLetterFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zapdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "dead.jsonl")
+	addr, server := newFakeServer(t)
+	server.setError(status.Error(codes.PermissionDenied, "denied"))
+
+	client, err := NewClient(context.Background(), "test-project",
+		WithEndpoint(addr),
+		WithClientOptions(
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithInsecure()),
+		),
+		WithDeadLetterFile(path, 1<<20, 1),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+	client.OnError = func(error) {}
+
+	lg := client.Logger("app", logging.CommonLabels(map[string]string{"team": "core"}))
+	lg.Log(logging.Entry{Payload: "lost"})
+	require.Error(t, lg.Flush())
+
+	entries := readDeadLetters(t, path)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "lost", entries[0].GetTextPayload())
+	assert.Equal(t, "projects/test-project/logs/app", entries[0].LogName)
+	assert.Equal(t, map[string]string{"team": "core"}, entries[0].Labels)
+}
+
+func TestDeadLetter_Retried(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zapdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d := &deadLetter{
+		path:     filepath.Join(dir, "dead.jsonl"),
+		maxBytes: 1 << 20,
+		pending:  map[*logpb.WriteLogEntriesRequest]context.Context{},
+	}
+
+	req := &logpb.WriteLogEntriesRequest{
+		LogName: "projects/p/logs/app",
+		Entries: []*logpb.LogEntry{{Payload: &logpb.LogEntry_TextPayload{TextPayload: "retried"}}},
+	}
+	unavailable := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	ok := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return nil
+	}
+
+	_ = d.intercept(context.Background(), "", req, nil, nil, unavailable)
+	_ = d.intercept(context.Background(), "", req, nil, nil, ok)
+	d.onError(nil)
+	_, err = os.Stat(d.path)
+	assert.True(t, os.IsNotExist(err), "a retried write that succeeded is dead-lettered")
+
+	// A failure of another request leaves the requests still being retried
+	// alone.
+	ctx, cancel := context.WithCancel(context.Background())
+	_ = d.intercept(ctx, "", req, nil, nil, unavailable)
+	d.onError(nil)
+	_, err = os.Stat(d.path)
+	assert.True(t, os.IsNotExist(err), "a write still being retried is dead-lettered")
+
+	// The client gave up on the request once its deadline passed.
+	cancel()
+	d.onError(nil)
+	assert.Len(t, readDeadLetters(t, d.path), 1)
+}
+
+func TestDeadLetter_Rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zapdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d := &deadLetter{path: filepath.Join(dir, "dead.jsonl"), maxBytes: 1, backups: 2}
+
+	for _, msg := range []string{"one", "two", "three"} {
+		req := &logpb.WriteLogEntriesRequest{
+			Entries: []*logpb.LogEntry{{Payload: &logpb.LogEntry_TextPayload{TextPayload: msg}}},
+		}
+		require.NoError(t, d.write(req))
+	}
+
+	for path, want := range map[string]string{d.path: "three", d.path + ".1": "two", d.path + ".2": "one"} {
+		b, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.True(t, strings.Contains(string(b), want), "%s doesn't contain %s", path, want)
+	}
+}
@@ -0,0 +1,120 @@
+package zapdriver
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const countKey = "count"
+
+// WithErrorDeduplication collapses identical entries of error level and above,
+// with the same message and source location, written within `window` of the
+// first one. The first entry is written right away, the identical entries
+// following it are counted instead. Once the window is over, the last of them
+// is written with a "count" field holding the number of collapsed entries.
+//
+// Windows are closed when the next entry is written after they ended, and when
+// the logger is synced.
+func WithErrorDeduplication(window time.Duration) func(*core) {
+	return func(c *core) {
+		c.config.Dedup = &deduplicator{window: window, seen: map[dedupKey]*dedupEntry{}}
+	}
+}
+
+// deduplicator counts identical error entries per window.
+type deduplicator struct {
+	window time.Duration
+
+	mutex sync.Mutex
+	seen  map[dedupKey]*dedupEntry
+}
+
+type dedupKey struct {
+	message string
+	caller  string
+}
+
+// dedupEntry is the last of the identical entries of a window.
+type dedupEntry struct {
+	start  time.Time
+	count  int
+	core   *core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// record reports whether the entry is written, or was collapsed into an
+// earlier identical entry. Either way, it returns the collapsed entries of the
+// windows that are over.
+func (d *deduplicator) record(c *core, ent zapcore.Entry, fields []zapcore.Field) (bool, []*dedupEntry) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	expired := d.expire(func(e *dedupEntry) bool { return ent.Time.Sub(e.start) >= d.window })
+
+	if ent.Level < zapcore.ErrorLevel {
+		return true, expired
+	}
+
+	key := dedupKey{message: ent.Message}
+	if ent.Caller.Defined {
+		key.caller = ent.Caller.String()
+	}
+
+	e, ok := d.seen[key]
+	if !ok {
+		d.seen[key] = &dedupEntry{start: ent.Time}
+		return true, expired
+	}
+
+	e.count++
+	e.core = c
+	e.ent = ent
+	e.fields = append(e.fields[:0], fields...)
+
+	return false, expired
+}
+
+// expire removes the windows matching `done`, and returns those that have
+// collapsed entries, ordered by the time of their last entry. The mutex must
+// be held.
+func (d *deduplicator) expire(done func(*dedupEntry) bool) []*dedupEntry {
+	var expired []*dedupEntry
+	for key, e := range d.seen {
+		if !done(e) {
+			continue
+		}
+
+		delete(d.seen, key)
+		if e.count > 0 {
+			expired = append(expired, e)
+		}
+	}
+
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ent.Time.Before(expired[j].ent.Time) })
+
+	return expired
+}
+
+// flush closes all windows, and returns their collapsed entries.
+func (d *deduplicator) flush() []*dedupEntry {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.expire(func(*dedupEntry) bool { return true })
+}
+
+// writeCollapsed writes the last entry of every window, with the number of
+// collapsed entries.
+func writeCollapsed(entries []*dedupEntry) {
+	for _, e := range entries {
+		c := *e.core
+		c.config.Dedup = nil
+
+		_ = c.write(e.ent, append(e.fields, zap.Int(countKey, e.count)), true)
+	}
+}
@@ -0,0 +1,63 @@
+package zapdriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWriteErrorDeduplication(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithErrorDeduplication(time.Minute)(core)
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		ent := zapcore.Entry{Level: zapcore.ErrorLevel, Time: now.Add(time.Duration(i) * time.Second), Message: "retry failed"}
+		require.NoError(t, core.Write(ent, []zapcore.Field{zap.Int("attempt", i)}))
+		require.NoError(t, core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: ent.Time, Message: "retrying"}, nil))
+	}
+
+	assert.Equal(t, 1, logs.FilterMessage("retry failed").Len())
+	assert.Equal(t, 5, logs.FilterMessage("retrying").Len())
+
+	// The next entry after the window closes it.
+	require.NoError(t, core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: now.Add(time.Minute), Message: "done"}, nil))
+
+	failed := logs.FilterMessage("retry failed").All()
+	require.Len(t, failed, 2)
+	assert.Equal(t, int64(4), failed[1].ContextMap()["attempt"])
+	assert.Equal(t, int64(4), failed[1].ContextMap()[countKey])
+	assert.Equal(t, "done", logs.All()[logs.Len()-1].Message)
+
+	require.NoError(t, core.Sync())
+	assert.Len(t, server.Entries(), logs.Len())
+}
+
+func TestWriteErrorDeduplication_Sync(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	WithErrorDeduplication(time.Hour)(core)
+
+	logger := zap.New(core)
+	logger.Error("failed")
+	logger.Error("failed")
+	logger.Error("other")
+	require.Equal(t, 2, logs.Len())
+
+	require.NoError(t, logger.Sync())
+	require.Equal(t, 3, logs.Len())
+	assert.Equal(t, "failed", logs.All()[2].Message)
+	assert.Equal(t, int64(1), logs.All()[2].ContextMap()[countKey])
+}
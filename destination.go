@@ -0,0 +1,56 @@
+package zapdriver
+
+import (
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const destinationKey = "logging.googleapis.com/destination"
+
+// Destination adds the field rerouting a single entry to the logger registered
+// for the project and log using `WithDestination`:
+//
+//	logger.Info("Invoice sent.", zapdriver.Destination(tenant.ProjectID, "billing"))
+//
+// Entries for a destination that isn't registered are written to the default
+// logger.
+func Destination(projectID, logID string) zap.Field {
+	return zap.String(destinationKey, destinationName(projectID, logID))
+}
+
+// WithDestination registers the logger entries with a `Destination(projectID,
+// logID)` field are written to. The logger is flushed by `Sync`.
+//
+// Destinations take precedence over failover and all other routing.
+func WithDestination(projectID, logID string, logger *logging.Logger) func(*core) {
+	return func(c *core) {
+		if c.config.Destinations == nil {
+			c.config.Destinations = map[string]*logging.Logger{}
+		}
+		c.config.Destinations[destinationName(projectID, logID)] = logger
+	}
+}
+
+func destinationName(projectID, logID string) string {
+	return "projects/" + projectID + "/logs/" + logID
+}
+
+// destination returns the logger registered for the `Destination()` field of an
+// entry, or nil if it has none. Later fields take precedence.
+func (c *core) destination(fields ...[]zapcore.Field) *logging.Logger {
+	var name string
+	for _, fs := range fields {
+		for i := range fs {
+			if fs[i].Key == destinationKey && fs[i].Type == zapcore.StringType {
+				name = fs[i].String
+			}
+		}
+	}
+
+	if name == "" {
+		return nil
+	}
+
+	return c.config.Destinations[name]
+}
@@ -0,0 +1,45 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDestination(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, zap.String(destinationKey, "projects/tenant-project/logs/billing"), Destination("tenant-project", "billing"))
+}
+
+func TestWriteDestination(t *testing.T) {
+	client, server := newFakeClient(t)
+	tenantClient, tenantServer := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	WithDestination("tenant-project", "billing", tenantClient.Logger("billing"))(core)
+	WithLogNameRouting(client, 10)(core)
+
+	logger := zap.New(core)
+	logger.Info("rerouted", Destination("tenant-project", "billing"), LogName("audit"))
+	logger.With(Destination("tenant-project", "billing")).Info("inherited")
+	logger.Info("unknown", Destination("other-project", "billing"))
+	logger.Info("default")
+	require.NoError(t, logger.Sync())
+
+	messages := func(server *fakeServer) []string {
+		var out []string
+		for _, e := range server.Entries() {
+			out = append(out, e.GetJsonPayload().Fields["message"].GetStringValue())
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"rerouted", "inherited"}, messages(tenantServer))
+	assert.Equal(t, []string{"unknown", "default"}, messages(server))
+}
@@ -0,0 +1,93 @@
+package zapdriver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/multierr"
+)
+
+// Limits enforced by Cloud Logging on every entry.
+const (
+	maxEntrySize      = 256 * 1024
+	maxEntryLabels    = 64
+	maxLabelKeySize   = 512
+	maxLabelValueSize = 64 * 1024
+)
+
+// DryRun converts and validates entries as if they were sent to Cloud
+// Logging, but never sends them. Entries that would be rejected, because of
+// their size, labels, resource or payload, are reported to `onError`. This
+// makes it possible to smoke test the logging configuration of a service in CI,
+// without credentials.
+//
+// A nil `onError` reports the violations to the error handler instead (see
+// `WithErrorHandler`), so a dry run can replace delivery in staging without
+// changing how failures are surfaced.
+//
+// Entries are still written to the wrapped core.
+func DryRun(onError func(error)) func(*core) {
+	return func(c *core) {
+		h := onError
+		if h == nil {
+			h = c.handleViolation
+		}
+		c.config.DryRun = h
+	}
+}
+
+// dryRun validates the entry, and reports violations to the dry run callback.
+func (c *core) dryRun(ent *logging.Entry) {
+	if err := validateEntry(ent); err != nil {
+		c.config.DryRun(err)
+	}
+}
+
+// validateEntry checks an entry against the limits of Cloud Logging.
+func validateEntry(ent *logging.Entry) error {
+	var err error
+
+	size := 0
+	if ent.Payload != nil {
+		b, jsonErr := json.Marshal(ent.Payload)
+		if jsonErr != nil {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: payload can't be encoded: %v", jsonErr))
+		}
+		size += len(b)
+	}
+
+	if len(ent.Labels) > maxEntryLabels {
+		err = multierr.Append(err, fmt.Errorf("zapdriver: entry has %d labels, more than %d", len(ent.Labels), maxEntryLabels))
+	}
+
+	for k, v := range ent.Labels {
+		size += len(k) + len(v)
+
+		if k == "" {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: label with value %q has an empty key", v))
+		}
+		if len(k) > maxLabelKeySize {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: label key %q is longer than %d bytes", k, maxLabelKeySize))
+		}
+		if len(v) > maxLabelValueSize {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: value of label %q is longer than %d bytes", k, maxLabelValueSize))
+		}
+	}
+
+	if r := ent.Resource; r != nil {
+		if r.Type == "" {
+			err = multierr.Append(err, errors.New("zapdriver: monitored resource has no type"))
+		}
+		for k, v := range r.Labels {
+			size += len(k) + len(v)
+		}
+	}
+
+	if size > maxEntrySize {
+		err = multierr.Append(err, fmt.Errorf("zapdriver: entry of %d bytes exceeds the limit of %d bytes", size, maxEntrySize))
+	}
+
+	return err
+}
@@ -0,0 +1,112 @@
+package zapdriver
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+func TestValidateEntry(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, validateEntry(&logging.Entry{
+		Payload: map[string]interface{}{"message": "hello"},
+		Labels:  map[string]string{"one": "value"},
+	}))
+
+	err := validateEntry(&logging.Entry{
+		Payload:  map[string]interface{}{"ratio": math.NaN(), "big": strings.Repeat("a", maxEntrySize)},
+		Labels:   map[string]string{"": "value", strings.Repeat("k", maxLabelKeySize+1): "value"},
+		Resource: &mrpb.MonitoredResource{},
+	})
+	require.Error(t, err)
+
+	assert.Len(t, multierr.Errors(err), 4)
+	assert.Contains(t, err.Error(), "payload can't be encoded")
+	assert.Contains(t, err.Error(), "empty key")
+	assert.Contains(t, err.Error(), "longer than 512 bytes")
+	assert.Contains(t, err.Error(), "monitored resource has no type")
+}
+
+func TestValidateEntry_Size(t *testing.T) {
+	t.Parallel()
+
+	err := validateEntry(&logging.Entry{
+		Payload: map[string]interface{}{"big": strings.Repeat("a", maxEntrySize)},
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the limit of 262144 bytes")
+}
+
+func TestWriteDryRun(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	var errs []error
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	DryRun(func(err error) { errs = append(errs, err) })(core)
+
+	logger := zap.New(core)
+	logger.Info("fine")
+	logger.Info("broken", zap.Float64("ratio", math.Inf(1)))
+	require.NoError(t, logger.Sync())
+
+	assert.Equal(t, 2, logs.Len())
+	assert.Empty(t, server.Entries())
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "payload can't be encoded")
+}
+
+func TestWriteDryRun_ErrorHandler(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	var errs []error
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	DryRun(nil)(core)
+	WithErrorHandler(func(err error) { errs = append(errs, err) })(core)
+
+	logger := zap.New(core)
+	logger.Info("fine")
+	logger.Info("broken", Label("", "value"))
+	require.NoError(t, logger.Sync())
+
+	assert.Empty(t, server.Entries())
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "empty key")
+}
+
+func TestDryRun_SharedOption(t *testing.T) {
+	t.Parallel()
+
+	var errs1, errs2 []error
+	option := DryRun(nil)
+
+	observed, _ := observer.New(zapcore.DebugLevel)
+	logger1 := zap.New(observed, WrapCore(option, WithErrorHandler(func(err error) { errs1 = append(errs1, err) })))
+	logger2 := zap.New(observed, WrapCore(option, WithErrorHandler(func(err error) { errs2 = append(errs2, err) })))
+
+	logger1.Info("broken", Label("", "value"))
+	logger2.Info("broken", Label("", "value"), Label(" ", "value"))
+
+	assert.Len(t, errs1, 1)
+	assert.Len(t, errs2, 1)
+}
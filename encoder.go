@@ -28,6 +28,8 @@ var logLevelSeverity = map[zapcore.Level]string{
 	zapcore.DPanicLevel: "CRITICAL",
 	zapcore.PanicLevel:  "ALERT",
 	zapcore.FatalLevel:  "EMERGENCY",
+	noticeLevel:         "NOTICE",
+	defaultLevel:        "DEFAULT",
 }
 
 // encoderConfig is the default encoder configuration, slightly tweaked to use
@@ -57,3 +59,16 @@ func EncodeLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
 func RFC3339NanoTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(t.Format(time.RFC3339Nano))
 }
+
+// SourceLocationCallerEncoder serializes a caller as the source location object
+// of Stackdriver, with its file, line and function. Encoders that can't append
+// objects get the trimmed path of the caller, like zapcore.ShortCallerEncoder.
+func SourceLocationCallerEncoder(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	arr, ok := enc.(zapcore.ArrayEncoder)
+	if !ok || !caller.Defined {
+		zapcore.ShortCallerEncoder(caller, enc)
+		return
+	}
+
+	_ = arr.AppendObject(newSource(caller.PC, caller.File, caller.Line, caller.Defined))
+}
@@ -1,6 +1,9 @@
 package zapdriver_test
 
 import (
+	"encoding/json"
+	"runtime"
+	"strconv"
 	"testing"
 	"time"
 
@@ -48,3 +51,53 @@ func TestRFC3339NanoTimeEncoder(t *testing.T) {
 	require.Len(t, enc.elems, 1)
 	assert.Equal(t, ts.Format(time.RFC3339Nano), enc.elems[0].(string))
 }
+
+func TestSourceLocationCallerEncoder(t *testing.T) {
+	t.Parallel()
+
+	pc, file, line, ok := runtime.Caller(0)
+	caller := zapcore.NewEntryCaller(pc, file, line, ok)
+
+	enc := &sliceArrayEncoder{}
+	zapdriver.SourceLocationCallerEncoder(caller, enc)
+
+	require.Len(t, enc.elems, 1)
+	assert.Equal(t, map[string]interface{}{
+		"file":     file,
+		"line":     strconv.Itoa(line),
+		"function": "github.com/blendle/zapdriver_test.TestSourceLocationCallerEncoder",
+	}, enc.elems[0])
+
+	enc = &sliceArrayEncoder{}
+	zapdriver.SourceLocationCallerEncoder(zapcore.EntryCaller{}, enc)
+
+	require.Len(t, enc.elems, 1)
+	assert.Equal(t, "undefined", enc.elems[0])
+}
+
+func TestNewAgentEncoderConfig(t *testing.T) {
+	t.Parallel()
+
+	pc, file, line, ok := runtime.Caller(0)
+	ent := zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    time.Date(2018, 4, 9, 12, 43, 12, 678359, time.UTC),
+		Message: "hello",
+		Caller:  zapcore.NewEntryCaller(pc, file, line, ok),
+	}
+
+	buf, err := zapcore.NewJSONEncoder(zapdriver.NewAgentEncoderConfig()).EncodeEntry(ent, nil)
+	require.NoError(t, err)
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+
+	assert.Equal(t, "WARNING", out["severity"])
+	assert.Equal(t, "2018-04-09T12:43:12.000678359Z", out["time"])
+	assert.Equal(t, "hello", out["message"])
+	assert.Equal(t, map[string]interface{}{
+		"file":     file,
+		"line":     strconv.Itoa(line),
+		"function": "github.com/blendle/zapdriver_test.TestNewAgentEncoderConfig",
+	}, out["logging.googleapis.com/sourceLocation"])
+}
@@ -0,0 +1,328 @@
+package zapdriver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Config describes a logger, so it can be configured without code, for
+// example from the environment using `ConfigFromEnv`.
+type Config struct {
+	// ProjectID is the project entries are sent to. Without a project, entries
+	// are only written locally.
+	ProjectID string
+
+	// LogID is the log entries are written to.
+	LogID string
+
+	// Endpoint is the Cloud Logging endpoint entries are sent to, see
+	// `WithEndpoint`.
+	Endpoint string
+
+	// EmulatorHost is the address of a local Cloud Logging emulator entries are
+	// sent to instead, without credentials, see `WithEmulator`.
+	EmulatorHost string
+
+	// ServiceName is added as `ServiceContext()` to all entries when set.
+	ServiceName string
+
+	// ServiceVersion is added to the service context of all entries when set.
+	ServiceVersion string
+
+	// ReportAllErrors reports all entries with level error or above to Error
+	// Reporting.
+	ReportAllErrors bool
+
+	// Development builds a development logger instead of a production one.
+	Development bool
+
+	// Level is the initial level of the logger, like "debug", which can be
+	// changed at runtime, see `WithLevel`. It defaults to "info", or "debug"
+	// for development loggers.
+	Level string
+
+	// Labels are added to all entries.
+	Labels map[string]string
+
+	// AsyncWorkers and AsyncBatchSize enable asynchronous writes, see `Async`.
+	AsyncWorkers   int
+	AsyncBatchSize int
+
+	// FlushInterval flushes buffered entries periodically, see
+	// `WithFlushEvery`.
+	FlushInterval time.Duration
+
+	// DetectResource attaches the detected monitored resource to all entries,
+	// see `AutoDetectResource`.
+	DetectResource bool
+
+	// DryRun validates entries without sending them, reporting violations to
+	// the error handler, see `DryRun`.
+	DryRun bool
+}
+
+// ConfigFromEnv returns the configuration described by the environment:
+//
+//	GOOGLE_CLOUD_PROJECT         ProjectID (or GCP_PROJECT, GCLOUD_PROJECT)
+//	ZAPDRIVER_LOG_ID             LogID (defaults to the service name, or "app")
+//	ZAPDRIVER_ENDPOINT           Endpoint
+//	ZAPDRIVER_EMULATOR_HOST      EmulatorHost (or LOGGING_EMULATOR_HOST)
+//	ZAPDRIVER_SERVICE_NAME       ServiceName (or K_SERVICE, GAE_SERVICE)
+//	ZAPDRIVER_SERVICE_VERSION    ServiceVersion (or K_REVISION, GAE_VERSION)
+//	ZAPDRIVER_REPORT_ALL_ERRORS  ReportAllErrors
+//	ZAPDRIVER_DEVELOPMENT        Development
+//	ZAPDRIVER_LEVEL              Level
+//	ZAPDRIVER_LABELS             Labels, as "key=value,key=value"
+//	ZAPDRIVER_ASYNC_WORKERS      AsyncWorkers
+//	ZAPDRIVER_ASYNC_BATCH_SIZE   AsyncBatchSize (defaults to 100)
+//	ZAPDRIVER_FLUSH_INTERVAL     FlushInterval, as a duration like "5s"
+//	ZAPDRIVER_DETECT_RESOURCE    DetectResource
+//	ZAPDRIVER_DRY_RUN            DryRun
+//
+// All invalid values are reported in the returned error.
+func ConfigFromEnv() (Config, error) {
+	return configFromEnv(os.Getenv)
+}
+
+func configFromEnv(getenv func(string) string) (Config, error) {
+	var err error
+	env := envParser{getenv: getenv, err: &err}
+
+	c := Config{
+		ProjectID:       env.first("GOOGLE_CLOUD_PROJECT", "GCP_PROJECT", "GCLOUD_PROJECT"),
+		ServiceName:     env.first("ZAPDRIVER_SERVICE_NAME", "K_SERVICE", "GAE_SERVICE"),
+		ServiceVersion:  env.first("ZAPDRIVER_SERVICE_VERSION", "K_REVISION", "GAE_VERSION"),
+		LogID:           getenv("ZAPDRIVER_LOG_ID"),
+		Endpoint:        getenv("ZAPDRIVER_ENDPOINT"),
+		EmulatorHost:    env.first("ZAPDRIVER_EMULATOR_HOST", "LOGGING_EMULATOR_HOST"),
+		ReportAllErrors: env.bool("ZAPDRIVER_REPORT_ALL_ERRORS"),
+		Development:     env.bool("ZAPDRIVER_DEVELOPMENT"),
+		Level:           env.level("ZAPDRIVER_LEVEL"),
+		Labels:          env.labels("ZAPDRIVER_LABELS"),
+		AsyncWorkers:    env.int("ZAPDRIVER_ASYNC_WORKERS"),
+		AsyncBatchSize:  env.int("ZAPDRIVER_ASYNC_BATCH_SIZE"),
+		FlushInterval:   env.duration("ZAPDRIVER_FLUSH_INTERVAL"),
+		DetectResource:  env.bool("ZAPDRIVER_DETECT_RESOURCE"),
+		DryRun:          env.bool("ZAPDRIVER_DRY_RUN"),
+	}
+
+	if c.LogID == "" {
+		c.LogID = c.ServiceName
+	}
+	if c.LogID == "" {
+		c.LogID = "app"
+	}
+	if c.AsyncWorkers > 0 && c.AsyncBatchSize == 0 {
+		c.AsyncBatchSize = 100
+	}
+
+	return c, err
+}
+
+// Options returns the core options described by the configuration.
+func (c Config) Options() []func(*core) {
+	var options []func(*core)
+
+	if level, err := c.level(); err == nil {
+		options = append(options, WithLevel(zap.NewAtomicLevelAt(level)))
+	}
+
+	if c.Endpoint != "" {
+		options = append(options, ClientOptions(WithEndpoint(c.Endpoint)))
+	}
+	if c.EmulatorHost != "" {
+		options = append(options, ClientOptions(WithEmulator(c.EmulatorHost)))
+	}
+	if c.ServiceName != "" {
+		options = append(options, ServiceName(c.ServiceName))
+	}
+	if c.ServiceVersion != "" {
+		options = append(options, ServiceVersion(c.ServiceVersion))
+	}
+	if c.ReportAllErrors {
+		options = append(options, ReportAllErrors(true))
+	}
+	if c.AsyncWorkers > 0 {
+		options = append(options, Async(c.AsyncWorkers, c.AsyncBatchSize))
+	}
+	if c.FlushInterval > 0 {
+		options = append(options, WithFlushEvery(c.FlushInterval))
+	}
+	if c.DetectResource {
+		options = append(options, AutoDetectResource())
+	}
+	if c.DryRun {
+		options = append(options, DryRun(nil))
+	}
+
+	return options
+}
+
+// Build builds the logger described by the configuration, applying the given
+// options after the ones of the configuration. The returned function flushes
+// all buffered entries, and closes the client. It should be called before the
+// application exits.
+func (c Config) Build(ctx context.Context, options ...func(*core)) (*zap.Logger, func(), error) {
+	if _, err := c.level(); err != nil {
+		return nil, nil, err
+	}
+
+	options = append(c.Options(), options...)
+	if err := Validate(options...); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		logger  *zap.Logger
+		cleanup func()
+		err     error
+	)
+
+	switch {
+	case c.ProjectID != "" && c.Development:
+		logger, cleanup, err = NewCloudDevelopment(ctx, c.ProjectID, c.LogID, options...)
+	case c.ProjectID != "":
+		logger, cleanup, err = NewCloudProduction(ctx, c.ProjectID, c.LogID, options...)
+	default:
+		config := NewProductionConfig()
+		if c.Development {
+			config = NewDevelopmentConfig()
+		}
+		c := newCore(options)
+		if level := c.config.Level; level != nil {
+			config.Level = *level
+		}
+
+		logger, err = validateLogger(config.Build(zap.WrapCore(c.wrap)))
+		cleanup = func() { _ = Close(logger) }
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(c.Labels) > 0 {
+		fields := make([]zap.Field, 0, len(c.Labels))
+		for k, v := range c.Labels {
+			fields = append(fields, Label(k, v))
+		}
+		logger = logger.With(Labels(fields...))
+	}
+
+	return logger, cleanup, nil
+}
+
+// level returns the initial level of the logger.
+func (c Config) level() (zapcore.Level, error) {
+	if c.Level == "" && c.Development {
+		return zapcore.DebugLevel, nil
+	}
+
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return level, fmt.Errorf("zapdriver: invalid level %q", c.Level)
+	}
+
+	return level, nil
+}
+
+// envParser parses environment variables, collecting all errors.
+type envParser struct {
+	getenv func(string) string
+	err    *error
+}
+
+// first returns the value of the first variable that is set.
+func (e envParser) first(keys ...string) string {
+	for _, key := range keys {
+		if v := e.getenv(key); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+func (e envParser) bool(key string) bool {
+	v := e.getenv(key)
+	if v == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.fail(key, v)
+	}
+
+	return b
+}
+
+func (e envParser) int(key string) int {
+	v := e.getenv(key)
+	if v == "" {
+		return 0
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		e.fail(key, v)
+	}
+
+	return i
+}
+
+func (e envParser) duration(key string) time.Duration {
+	v := e.getenv(key)
+	if v == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.fail(key, v)
+	}
+
+	return d
+}
+
+func (e envParser) level(key string) string {
+	v := e.getenv(key)
+
+	var level zapcore.Level
+	if v != "" && level.UnmarshalText([]byte(v)) != nil {
+		e.fail(key, v)
+	}
+
+	return v
+}
+
+func (e envParser) labels(key string) map[string]string {
+	v := e.getenv(key)
+	if v == "" {
+		return nil
+	}
+
+	labels := map[string]string{}
+	for _, pair := range strings.Split(v, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			e.fail(key, v)
+			return nil
+		}
+
+		labels[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return labels
+}
+
+func (e envParser) fail(key, value string) {
+	*e.err = multierr.Append(*e.err, fmt.Errorf("zapdriver: invalid value %q for %s", value, key))
+}
@@ -0,0 +1,127 @@
+package zapdriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"GCP_PROJECT":                 "my-project",
+		"K_SERVICE":                   "orders",
+		"K_REVISION":                  "orders-00042",
+		"ZAPDRIVER_REPORT_ALL_ERRORS": "true",
+		"ZAPDRIVER_LABELS":            "team=payments, tier = backend",
+		"ZAPDRIVER_ASYNC_WORKERS":     "2",
+		"ZAPDRIVER_FLUSH_INTERVAL":    "5s",
+		"ZAPDRIVER_DRY_RUN":           "1",
+		"ZAPDRIVER_LEVEL":             "warn",
+		"LOGGING_EMULATOR_HOST":       "localhost:8085",
+	}
+
+	c, err := configFromEnv(func(key string) string { return env[key] })
+	require.NoError(t, err)
+
+	assert.Equal(t, Config{
+		ProjectID:       "my-project",
+		LogID:           "orders",
+		EmulatorHost:    "localhost:8085",
+		ServiceName:     "orders",
+		ServiceVersion:  "orders-00042",
+		ReportAllErrors: true,
+		Level:           "warn",
+		Labels:          map[string]string{"team": "payments", "tier": "backend"},
+		AsyncWorkers:    2,
+		AsyncBatchSize:  100,
+		FlushInterval:   5 * time.Second,
+		DryRun:          true,
+	}, c)
+}
+
+func TestConfigFromEnv_Defaults(t *testing.T) {
+	t.Parallel()
+
+	c, err := configFromEnv(func(string) string { return "" })
+	require.NoError(t, err)
+
+	assert.Equal(t, Config{LogID: "app"}, c)
+}
+
+func TestConfigFromEnv_Invalid(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"ZAPDRIVER_DEVELOPMENT":    "maybe",
+		"ZAPDRIVER_LABELS":         "team",
+		"ZAPDRIVER_FLUSH_INTERVAL": "5",
+		"ZAPDRIVER_LEVEL":          "verbose",
+	}
+
+	_, err := configFromEnv(func(key string) string { return env[key] })
+	require.Error(t, err)
+
+	assert.Len(t, multierr.Errors(err), 4)
+	assert.Contains(t, err.Error(), `invalid value "maybe" for ZAPDRIVER_DEVELOPMENT`)
+}
+
+func TestConfigBuild_LocalOnly(t *testing.T) {
+	t.Parallel()
+
+	c := Config{ServiceName: "orders", Labels: map[string]string{"team": "payments"}}
+
+	logger, cleanup, err := c.Build(context.Background())
+	require.NoError(t, err)
+	defer cleanup()
+
+	assert.Equal(t, map[string]string{"team": "payments"}, InheritedLabels(logger))
+	assert.Equal(t, "orders", logger.Core().(*core).config.ServiceName)
+}
+
+func TestConfigBuild_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := Config{AsyncWorkers: 1}.Build(context.Background())
+	assert.Error(t, err)
+}
+
+func TestConfigBuild_Level(t *testing.T) {
+	t.Parallel()
+
+	logger, cleanup, err := Config{Level: "warn"}.Build(context.Background())
+	require.NoError(t, err)
+	defer cleanup()
+
+	level, ok := LevelOf(logger)
+	require.True(t, ok)
+	assert.Equal(t, zapcore.WarnLevel, level.Level())
+
+	level.SetLevel(zapcore.DebugLevel)
+	assert.True(t, logger.Core().Enabled(zapcore.DebugLevel))
+
+	_, _, err = Config{Level: "verbose"}.Build(context.Background())
+	assert.Error(t, err)
+}
+
+func TestConfigBuild_Emulator(t *testing.T) {
+	addr, server := newFakeServer(t)
+
+	c := Config{ProjectID: "test-project", LogID: "app", EmulatorHost: addr}
+
+	logger, cleanup, err := c.Build(context.Background())
+	require.NoError(t, err)
+
+	logger.Info("hello")
+	cleanup()
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "projects/test-project/logs/app", entries[0].LogName)
+}
@@ -0,0 +1,118 @@
+package zapdriver
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrorContextOption adds information about the circumstances of an error to
+// its report, see `ErrorContext`.
+type ErrorContextOption func(*reportContext)
+
+// ErrorContext adds the "context" field of a reported error, like
+// `ErrorReport`, with the request and user that triggered it, so Error
+// Reporting shows them next to the error:
+//
+//	logger.Error("Payment failed.", zapdriver.ErrorContext(
+//		zapdriver.ErrorRequest(r, http.StatusInternalServerError),
+//		zapdriver.ErrorUser(userID),
+//	))
+//
+// The zapdriver core adds the location of the log call as the report location.
+//
+// see: https://cloud.google.com/error-reporting/reference/rest/v1beta1/ErrorContext
+func ErrorContext(opts ...ErrorContextOption) zap.Field {
+	context := &reportContext{}
+	for _, opt := range opts {
+		opt(context)
+	}
+
+	return zap.Object(contextKey, context)
+}
+
+// ErrorRequest adds the method, URL, user agent, referrer and remote IP of the
+// request, and the status of the response, to the context of an error.
+func ErrorRequest(r *http.Request, status int) ErrorContextOption {
+	return func(c *reportContext) {
+		c.HTTPRequest = &errorHTTPRequest{
+			Method:             r.Method,
+			URL:                requestURL(r),
+			UserAgent:          r.UserAgent(),
+			Referrer:           r.Referer(),
+			ResponseStatusCode: status,
+			RemoteIP:           remoteIP(r.RemoteAddr),
+		}
+	}
+}
+
+// ErrorUser adds the ID of the user who triggered the error to its context,
+// so Error Reporting counts the affected users.
+func ErrorUser(id string) ErrorContextOption {
+	return func(c *reportContext) {
+		c.User = id
+	}
+}
+
+// errorHTTPRequest is the HTTP request that triggered a reported error.
+type errorHTTPRequest struct {
+	Method             string `json:"method,omitempty"`
+	URL                string `json:"url,omitempty"`
+	UserAgent          string `json:"userAgent,omitempty"`
+	Referrer           string `json:"referrer,omitempty"`
+	ResponseStatusCode int    `json:"responseStatusCode,omitempty"`
+	RemoteIP           string `json:"remoteIp,omitempty"`
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaller interface.
+func (req errorHTTPRequest) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range []struct{ key, value string }{
+		{"method", req.Method},
+		{"url", req.URL},
+		{"userAgent", req.UserAgent},
+		{"referrer", req.Referrer},
+		{"remoteIp", req.RemoteIP},
+	} {
+		if f.value != "" {
+			enc.AddString(f.key, f.value)
+		}
+	}
+	if req.ResponseStatusCode != 0 {
+		enc.AddInt("responseStatusCode", req.ResponseStatusCode)
+	}
+
+	return nil
+}
+
+// withReportLocation adds the location of the log call to an `ErrorContext()`
+// field without a report location. The fields are copied before the field is
+// replaced, so the fields of the caller are never modified.
+func withReportLocation(ent zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+	if !ent.Caller.Defined {
+		return fields
+	}
+
+	for i := range fields {
+		if fields[i].Key != contextKey || fields[i].Type != zapcore.ObjectMarshalerType {
+			continue
+		}
+
+		context, ok := fields[i].Interface.(*reportContext)
+		if !ok || context == nil || context.ReportLocation != (reportLocation{}) {
+			continue
+		}
+
+		completed := *newReportContext(ent.Caller.PC, ent.Caller.File, ent.Caller.Line, true)
+		completed.HTTPRequest = context.HTTPRequest
+		completed.User = context.User
+
+		out := make([]zapcore.Field, len(fields), cap(fields))
+		copy(out, fields)
+		out[i] = zap.Object(contextKey, &completed)
+
+		return out
+	}
+
+	return fields
+}
@@ -0,0 +1,69 @@
+package zapdriver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestErrorContext(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest("POST", "/orders?id=1", nil)
+	r.Header.Set("User-Agent", "test-agent")
+	r.RemoteAddr = "10.0.0.1:1234"
+
+	got := ErrorContext(ErrorRequest(r, http.StatusBadGateway), ErrorUser("user-1")).Interface.(*reportContext)
+
+	assert.Equal(t, &reportContext{
+		HTTPRequest: &errorHTTPRequest{
+			Method:             "POST",
+			URL:                "http://example.com/orders?id=1",
+			UserAgent:          "test-agent",
+			ResponseStatusCode: http.StatusBadGateway,
+			RemoteIP:           "10.0.0.1",
+		},
+		User: "user-1",
+	}, got)
+}
+
+func TestWriteErrorContext(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}, zap.AddCaller())
+
+	r := httptest.NewRequest("GET", "/orders", nil)
+	field := ErrorContext(ErrorRequest(r, http.StatusInternalServerError), ErrorUser("user-1"))
+	fields := []zap.Field{field}
+	logger.Error("failed", fields...)
+	require.NoError(t, logger.Sync())
+
+	// The field of the caller is left as-is.
+	assert.Equal(t, field, fields[0])
+
+	local := logs.All()[0].ContextMap()[contextKey].(map[string]interface{})
+	assert.Contains(t, local, "reportLocation")
+	assert.Equal(t, "user-1", local["user"])
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+
+	payload := entries[0].GetJsonPayload().Fields
+	assert.Equal(t, errorEventType, payload[errorEventTypeKey].GetStringValue())
+
+	context := payload[contextKey].GetStructValue().Fields
+	assert.Contains(t, context, "reportLocation")
+	assert.Equal(t, "user-1", context["user"].GetStringValue())
+
+	httpRequest := context["httpRequest"].GetStructValue().Fields
+	assert.Equal(t, "GET", httpRequest["method"].GetStringValue())
+	assert.Equal(t, "http://example.com/orders", httpRequest["url"].GetStringValue())
+	assert.Equal(t, float64(http.StatusInternalServerError), httpRequest["responseStatusCode"].GetNumberValue())
+}
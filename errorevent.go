@@ -0,0 +1,101 @@
+package zapdriver
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	errorEventTypeKey = "@type"
+	errorEventType    = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+	stackTraceKey     = "stack_trace"
+)
+
+// reportsError reports whether the entry is reported to Error Reporting, either
+// because all errors are, or because it has an `ErrorReport()` field.
+func (c *core) reportsError(ent zapcore.Entry, fields []zapcore.Field) bool {
+	if c.config.ReportAllErrors && zapcore.ErrorLevel.Enabled(ent.Level) {
+		return true
+	}
+
+	for _, set := range [][]zapcore.Field{c.fields, fields} {
+		for i := range set {
+			if set[i].Key == contextKey && set[i].Type == zapcore.ObjectMarshalerType {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// addErrorEvent turns the payload sent to Cloud Logging into a
+// `ReportedErrorEvent`, with the service context, report location and stack
+// trace Error Reporting uses to group errors.
+//
+// see: https://cloud.google.com/error-reporting/docs/formatting-error-messages
+func (c *core) addErrorEvent(payload map[string]interface{}, ent zapcore.Entry, fields []zapcore.Field) {
+	payload[errorEventTypeKey] = errorEventType
+
+	if _, ok := payload[serviceContextKey]; !ok {
+		name := c.config.ServiceName
+		if name == "" {
+			name = "unknown"
+		}
+		payload[serviceContextKey] = &serviceContext{Name: name, Version: c.config.ServiceVersion}
+	}
+
+	if _, ok := payload[contextKey]; !ok && ent.Caller.Defined {
+		payload[contextKey] = newReportContext(ent.Caller.PC, ent.Caller.File, ent.Caller.Line, true)
+	}
+
+	if stack := errorStack(ent, c.fields, fields); stack != "" {
+		payload[stackTraceKey] = stack
+	}
+}
+
+// stackTracer is implemented by errors carrying the stack trace of where they
+// were created, like the ones of github.com/pkg/errors.
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// errorStack returns the stack trace of an entry, in the format of
+// `runtime.Stack`, which Error Reporting parses. The stack trace of the entry
+// itself takes precedence over the one of an error field.
+func errorStack(ent zapcore.Entry, fields ...[]zapcore.Field) string {
+	message, stack := errorStackTrace(ent, fields...)
+	if stack == "" {
+		return ""
+	}
+
+	return message + "\n\n" + stack
+}
+
+// errorStackTrace returns the message and stack trace reported by
+// `errorStack`, separately. The stack trace is empty if the entry has none.
+func errorStackTrace(ent zapcore.Entry, fields ...[]zapcore.Field) (message, stack string) {
+	if ent.Stack != "" {
+		return ent.Message, "goroutine 1 [running]:\n" + ent.Stack
+	}
+
+	for i := len(fields) - 1; i >= 0; i-- {
+		for j := len(fields[i]) - 1; j >= 0; j-- {
+			f := fields[i][j]
+			if f.Type != zapcore.ErrorType {
+				continue
+			}
+
+			st, ok := f.Interface.(stackTracer)
+			if !ok {
+				continue
+			}
+
+			return fmt.Sprint(f.Interface), "goroutine 1 [running]:\n" + formatFrames(st.StackTrace())
+		}
+	}
+
+	return ent.Message, ""
+}
@@ -0,0 +1,72 @@
+package zapdriver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWriteErrorEvent(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	ReportAllErrors(true)(core)
+	ServiceName("orders")(core)
+
+	logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	logger.Error("stacked")
+	logger.Warn("not reported", zap.Error(errors.New("wrapped")))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+
+	payload := entries[0].GetJsonPayload().Fields
+	assert.Equal(t, errorEventType, payload[errorEventTypeKey].GetStringValue())
+	assert.Equal(t, "orders", payload[serviceContextKey].GetStructValue().Fields["service"].GetStringValue())
+	assert.Contains(t, payload[contextKey].GetStructValue().Fields, "reportLocation")
+	assert.True(t, strings.HasPrefix(payload[stackTraceKey].GetStringValue(), "stacked\n\ngoroutine 1 [running]:\n"))
+	assert.Contains(t, payload[stackTraceKey].GetStringValue(), "TestWriteErrorEvent")
+
+	assert.NotContains(t, entries[1].GetJsonPayload().Fields, errorEventTypeKey)
+}
+
+func TestWriteErrorEvent_ManualReport(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	logger.Warn("reported", ErrorReport(0, "main.go", 42, true), zap.Error(errors.New("boom")))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+
+	payload := entries[0].GetJsonPayload().Fields
+	assert.Equal(t, errorEventType, payload[errorEventTypeKey].GetStringValue())
+	assert.Equal(t, "unknown", payload[serviceContextKey].GetStructValue().Fields["service"].GetStringValue())
+	assert.True(t, strings.HasPrefix(payload[stackTraceKey].GetStringValue(), "boom\n\ngoroutine 1 [running]:\n"))
+}
+
+func TestErrorStack_FromErrorField(t *testing.T) {
+	t.Parallel()
+
+	err := errors.New("boom")
+	stack := errorStack(zapcore.Entry{Message: "failed"}, []zapcore.Field{zap.Error(err)})
+
+	assert.True(t, strings.HasPrefix(stack, "boom\n\ngoroutine 1 [running]:\n"))
+	assert.Contains(t, stack, "TestErrorStack_FromErrorField")
+	assert.Contains(t, stack, "errorevent_test.go:")
+
+	assert.Empty(t, errorStack(zapcore.Entry{}, []zapcore.Field{zap.Error(fmt.Errorf("plain"))}))
+}
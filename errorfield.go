@@ -0,0 +1,93 @@
+package zapdriver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// StructuredErrors writes error fields (see `zap.Error`) to the local output
+// as objects with the message, type and stack trace of the error, the same way
+// they are sent to the Cloud Logging API:
+//
+//	{"error": {"message": "no such order", "type": "*errors.fundamental", "stack": "..."}}
+//
+// The stack trace is taken from errors created by `github.com/pkg/errors`, or
+// from the verbose format ("%+v") of errors implementing `fmt.Formatter`.
+// Without this option, the local output has zap's default "error" and
+// "errorVerbose" keys.
+func StructuredErrors() func(*core) {
+	return func(c *core) {
+		c.config.StructuredErrors = true
+	}
+}
+
+// errorObject is the structured form of an error.
+type errorObject struct {
+	err error
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaller interface.
+func (e errorObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for k, v := range errorPayload(e.err) {
+		enc.AddString(k, v)
+	}
+
+	return nil
+}
+
+// errorPayload returns the message, type and, if available, stack trace of an
+// error.
+func errorPayload(err error) map[string]string {
+	payload := map[string]string{
+		"message": err.Error(),
+		"type":    fmt.Sprintf("%T", err),
+	}
+
+	if st, ok := err.(stackTracer); ok {
+		payload["stack"] = formatFrames(st.StackTrace())
+	} else if _, ok := err.(fmt.Formatter); ok {
+		if verbose := fmt.Sprintf("%+v", err); verbose != payload["message"] {
+			payload["stack"] = verbose
+		}
+	}
+
+	return payload
+}
+
+// structuredErrorFields replaces the error fields with their structured form.
+func structuredErrorFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		err, ok := f.Interface.(error)
+		if f.Type != zapcore.ErrorType || !ok {
+			continue
+		}
+
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i] = zap.Object(f.Key, errorObject{err})
+	}
+
+	if out == nil {
+		return fields
+	}
+
+	return out
+}
+
+// formatFrames formats a stack trace in the format of `runtime.Stack`, without
+// its header.
+func formatFrames(st errors.StackTrace) string {
+	var b strings.Builder
+	for _, frame := range st {
+		fmt.Fprintf(&b, "%+s:%d\n", frame, frame)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package zapdriver
+
+import (
+	"errors"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestErrorPayload(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, map[string]string{
+		"message": "plain",
+		"type":    "*errors.errorString",
+	}, errorPayload(errors.New("plain")))
+
+	stacked := errorPayload(pkgerrors.New("stacked"))
+	assert.Equal(t, "stacked", stacked["message"])
+	assert.Contains(t, stacked["stack"], "TestErrorPayload")
+	assert.Contains(t, stacked["stack"], "errorfield_test.go:")
+}
+
+func TestWriteErrorFields(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	logger.Info("failed", zap.Error(errors.New("boom")))
+	require.NoError(t, logger.Sync())
+
+	assert.Equal(t, "boom", logs.All()[0].ContextMap()["error"])
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+
+	errObj := entries[0].GetJsonPayload().Fields["error"].GetStructValue().Fields
+	assert.Equal(t, "boom", errObj["message"].GetStringValue())
+	assert.Equal(t, "*errors.errorString", errObj["type"].GetStringValue())
+}
+
+func TestWriteStructuredErrors(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	StructuredErrors()(core)
+
+	logger := zap.New(core)
+	logger.With(zap.NamedError("cause", errors.New("inherited"))).Info("failed", zap.Error(pkgerrors.New("boom")))
+
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, map[string]interface{}{"message": "inherited", "type": "*errors.errorString"}, fields["cause"])
+
+	errObj := fields["error"].(map[string]interface{})
+	assert.Equal(t, "boom", errObj["message"])
+	assert.Contains(t, errObj["stack"], "TestWriteStructuredErrors")
+}
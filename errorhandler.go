@@ -0,0 +1,43 @@
+package zapdriver
+
+// WithErrorHandler calls `handler` with every failure to deliver entries to
+// Cloud Logging, so applications can count or alert on them: the errors the
+// client reports through its `OnError` callback, as well as the errors of
+// entries written using `SynchronousWrites`.
+//
+// The `OnError` callback is only wired for clients created by the constructors
+// of this package (see `ClientOptions`). For other clients, call the handler
+// from `OnError` yourself. The handler may be called concurrently, and must not
+// log using the same logger.
+func WithErrorHandler(handler func(error)) func(*core) {
+	return func(c *core) {
+		c.config.ErrorHandler = handler
+		c.config.ClientOptions = append(c.config.ClientOptions, WithOnError(handler))
+	}
+}
+
+// handleError passes a delivery failure to the error handler, if any, and counts
+// it in the metrics and stats.
+func (c *core) handleError(err error) {
+	if err == nil {
+		return
+	}
+
+	c.config.Metrics.clientError(err)
+	c.config.Stats.error(err)
+	if c.config.Fallback != nil {
+		c.config.Fallback.OnError(err)
+	}
+	if c.config.ErrorHandler != nil {
+		c.config.ErrorHandler(err)
+	}
+}
+
+// handleViolation passes an entry that violates the limits of Cloud Logging to
+// the error handler, if any. Unlike delivery failures, violations aren't
+// counted in the metrics and stats.
+func (c *core) handleViolation(err error) {
+	if err != nil && c.config.ErrorHandler != nil {
+		c.config.ErrorHandler(err)
+	}
+}
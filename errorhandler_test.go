@@ -0,0 +1,76 @@
+package zapdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWithErrorHandler_SynchronousWrites(t *testing.T) {
+	client, server := newFakeClient(t)
+	server.setError(errors.New("unavailable"))
+
+	var errs []error
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	SynchronousWrites()(core)
+	WithErrorHandler(func(err error) { errs = append(errs, err) })(core)
+
+	zap.New(core).Info("hello")
+
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "unavailable")
+}
+
+func TestWithErrorHandler_OnError(t *testing.T) {
+	addr, server := newFakeServer(t)
+	server.setError(status.Error(codes.PermissionDenied, "denied"))
+
+	errs := make(chan error, 1)
+	scratch := &core{}
+	WithErrorHandler(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})(scratch)
+
+	opts := append(scratch.config.ClientOptions,
+		WithEndpoint(addr),
+		WithClientOptions(
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithInsecure()),
+		),
+	)
+	client, err := NewClient(context.Background(), "test-project", opts...)
+	require.NoError(t, err)
+	defer client.Close()
+
+	lg := client.Logger("app")
+	lg.Log(logging.Entry{Payload: "hello"})
+	_ = lg.Flush()
+
+	// The client calls OnError asynchronously.
+	select {
+	case err := <-errs:
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("error handler not called")
+	}
+}
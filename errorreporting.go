@@ -0,0 +1,96 @@
+package zapdriver
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+
+	"cloud.google.com/go/errorreporting"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithErrorReporting also sends every entry with level error or above directly
+// to the Error Reporting API, using the client, instead of relying on Error
+// Reporting to extract errors from the logs. It recognizes entries whose
+// payload it doesn't understand this way, as long as they have a stack trace
+// or caller.
+//
+// The reports are buffered by the client, and flushed by `Sync`. The request
+// and user of an `ErrorContext()` field are added to the report.
+func WithErrorReporting(client *errorreporting.Client) func(*core) {
+	return func(c *core) {
+		c.config.ErrorReporting = client
+	}
+}
+
+// reportError sends the entry to Error Reporting.
+func (c *core) reportError(ent zapcore.Entry, fields []zapcore.Field) {
+	message, stack := errorStackTrace(ent, c.fields, fields)
+	if stack == "" && ent.Caller.Defined {
+		stack = callerStack(ent.Caller)
+	}
+
+	e := errorreporting.Entry{Error: errors.New(message)}
+	if stack != "" {
+		e.Stack = []byte(stack)
+	}
+	if context := reportContextOf(c.fields, fields); context != nil {
+		e.User = context.User
+		e.Req = context.HTTPRequest.request()
+	}
+
+	c.config.ErrorReporting.Report(e)
+}
+
+// callerStack returns a stack trace holding only the caller of an entry, in the
+// format of `runtime.Stack`.
+func callerStack(caller zapcore.EntryCaller) string {
+	function := "unknown"
+	if fn := runtime.FuncForPC(caller.PC); fn != nil {
+		function = fn.Name()
+	}
+
+	return "goroutine 1 [running]:\n" + function + "()\n\t" + caller.File + ":" + strconv.Itoa(caller.Line) + "\n"
+}
+
+// reportContextOf returns the `ErrorContext()` or `ErrorReport()` of an entry,
+// if any. The fields of the entry take precedence over the fields of the core.
+func reportContextOf(fields ...[]zapcore.Field) *reportContext {
+	for i := len(fields) - 1; i >= 0; i-- {
+		for j := len(fields[i]) - 1; j >= 0; j-- {
+			if fields[i][j].Key != contextKey {
+				continue
+			}
+			if context, ok := fields[i][j].Interface.(*reportContext); ok && context != nil {
+				return context
+			}
+		}
+	}
+
+	return nil
+}
+
+// request returns an HTTP request, as described by the context of an error, for
+// the Error Reporting client.
+func (r *errorHTTPRequest) request() *http.Request {
+	if r == nil {
+		return nil
+	}
+
+	req := &http.Request{Method: r.Method, Header: http.Header{}, RemoteAddr: r.RemoteIP}
+	if u, err := url.Parse(r.URL); err == nil {
+		req.URL = u
+		req.Host = u.Host
+		req.RequestURI = u.RequestURI()
+	}
+	if r.UserAgent != "" {
+		req.Header.Set("User-Agent", r.UserAgent)
+	}
+	if r.Referrer != "" {
+		req.Header.Set("Referer", r.Referrer)
+	}
+
+	return req
+}
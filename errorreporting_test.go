@@ -0,0 +1,94 @@
+package zapdriver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/errorreporting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/api/option"
+	erpb "google.golang.org/genproto/googleapis/devtools/clouderrorreporting/v1beta1"
+	"google.golang.org/grpc"
+)
+
+// fakeErrorReporting is an in-memory implementation of the Error Reporting
+// API, recording every reported event.
+type fakeErrorReporting struct {
+	mutex  sync.Mutex
+	events []*erpb.ReportedErrorEvent
+}
+
+func (s *fakeErrorReporting) ReportErrorEvent(ctx context.Context, req *erpb.ReportErrorEventRequest) (*erpb.ReportErrorEventResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.events = append(s.events, req.Event)
+	return &erpb.ReportErrorEventResponse{}, nil
+}
+
+func (s *fakeErrorReporting) Events() []*erpb.ReportedErrorEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]*erpb.ReportedErrorEvent{}, s.events...)
+}
+
+func newFakeErrorReportingClient(t *testing.T) (*errorreporting.Client, *fakeErrorReporting) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	fake := &fakeErrorReporting{}
+	srv := grpc.NewServer()
+	erpb.RegisterReportErrorsServiceServer(srv, fake)
+	go srv.Serve(lis) // nolint: errcheck
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+
+	client, err := errorreporting.NewClient(context.Background(), "test-project",
+		errorreporting.Config{ServiceName: "orders", ServiceVersion: "1.2.3"},
+		option.WithGRPCConn(conn),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, fake
+}
+
+func TestWithErrorReporting(t *testing.T) {
+	client, server := newFakeErrorReportingClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	c := &core{Core: debugcore, permLabels: newLabels()}
+	WithErrorReporting(client)(c)
+	logger := zap.New(c, zap.AddCaller())
+
+	r := httptest.NewRequest("GET", "/orders?id=1", nil)
+	logger.Warn("not reported")
+	logger.Error("payment failed", ErrorContext(ErrorRequest(r, http.StatusInternalServerError), ErrorUser("user-1")))
+	require.NoError(t, logger.Sync())
+
+	assert.Len(t, logs.All(), 2)
+
+	events := server.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "orders", events[0].ServiceContext.Service)
+	assert.Equal(t, "1.2.3", events[0].ServiceContext.Version)
+	assert.True(t, strings.HasPrefix(events[0].Message, "payment failed\ngoroutine 1 [running]:\n"))
+	assert.Contains(t, events[0].Message, "TestWithErrorReporting")
+	assert.Equal(t, "user-1", events[0].Context.User)
+	assert.Equal(t, "GET", events[0].Context.HttpRequest.Method)
+	assert.Equal(t, "example.com/orders?id=1", events[0].Context.HttpRequest.Url)
+}
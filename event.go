@@ -0,0 +1,282 @@
+package zapdriver
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// eventKindKey is the label holding the kind of a typed event.
+const eventKindKey = "event_kind"
+
+// Kinds of typed events. Every typed event is labelled with its kind, and
+// written using a child logger named after it.
+const (
+	AccessEventKind = "access"
+	AuditEventKind  = "audit"
+	JobEventKind    = "job"
+)
+
+// emitEvent writes a typed event with consistent labels and logger name. The
+// caller of the entry is the caller of `Emit`.
+func emitEvent(logger *zap.Logger, kind string, level zapcore.Level, msg string, fields []zap.Field) {
+	fields = append(fields, Label(eventKindKey, kind))
+
+	// Skip emitEvent and Emit.
+	logger = logger.WithOptions(zap.AddCallerSkip(2))
+	if ce := logger.Named(kind).Check(level, msg); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
+// AccessEvent builds an entry describing a request handled by the service. The
+// request is added as the "httpRequest" field (see `HTTP`).
+type AccessEvent struct {
+	payload HTTPPayload
+	fields  []zap.Field
+}
+
+// NewAccessEvent returns an access event with the required method, URL and
+// status code.
+func NewAccessEvent(method, url string, status int) *AccessEvent {
+	return &AccessEvent{payload: HTTPPayload{
+		RequestMethod: method,
+		RequestURL:    url,
+		Status:        status,
+	}}
+}
+
+// Latency sets the time it took to handle the request.
+func (e *AccessEvent) Latency(d time.Duration) *AccessEvent {
+	e.payload.Latency = strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+	return e
+}
+
+// UserAgent sets the user agent of the client.
+func (e *AccessEvent) UserAgent(ua string) *AccessEvent {
+	e.payload.UserAgent = ua
+	return e
+}
+
+// RemoteIP sets the IP address of the client.
+func (e *AccessEvent) RemoteIP(ip string) *AccessEvent {
+	e.payload.RemoteIP = ip
+	return e
+}
+
+// With adds fields to the event.
+func (e *AccessEvent) With(fields ...zap.Field) *AccessEvent {
+	e.fields = append(e.fields, fields...)
+	return e
+}
+
+// Emit writes the event. Server errors are written at error level, client
+// errors at warn level, and other requests at info level. An error is returned
+// if a required field is missing.
+func (e *AccessEvent) Emit(logger *zap.Logger) error {
+	switch {
+	case e.payload.RequestMethod == "":
+		return errors.New("zapdriver: access event has no method")
+	case e.payload.RequestURL == "":
+		return errors.New("zapdriver: access event has no URL")
+	case e.payload.Status == 0:
+		return errors.New("zapdriver: access event has no status")
+	}
+
+	level := zapcore.InfoLevel
+	switch {
+	case e.payload.Status >= 500:
+		level = zapcore.ErrorLevel
+	case e.payload.Status >= 400:
+		level = zapcore.WarnLevel
+	}
+
+	payload := e.payload
+	fields := append([]zap.Field{HTTP(&payload)}, e.fields...)
+	emitEvent(logger, AccessEventKind, level, e.payload.RequestMethod+" "+e.payload.RequestURL, fields)
+
+	return nil
+}
+
+// AuditEvent builds an entry recording who did what to which resource. The
+// event is added as the "audit" field.
+type AuditEvent struct {
+	payload audit
+	fields  []zap.Field
+}
+
+// NewAuditEvent returns an audit event with the required actor, action and
+// resource. The outcome defaults to "success".
+func NewAuditEvent(actor, action, resource string) *AuditEvent {
+	return &AuditEvent{payload: audit{
+		Actor:    actor,
+		Action:   action,
+		Resource: resource,
+		Outcome:  "success",
+	}}
+}
+
+// Denied marks the action as denied, for the given reason.
+func (e *AuditEvent) Denied(reason string) *AuditEvent {
+	e.payload.Outcome = "denied"
+	e.payload.Reason = reason
+	return e
+}
+
+// With adds fields to the event.
+func (e *AuditEvent) With(fields ...zap.Field) *AuditEvent {
+	e.fields = append(e.fields, fields...)
+	return e
+}
+
+// Emit writes the event. Denied actions are written at warn level, other
+// actions at info level. An error is returned if a required field is missing.
+func (e *AuditEvent) Emit(logger *zap.Logger) error {
+	switch {
+	case e.payload.Actor == "":
+		return errors.New("zapdriver: audit event has no actor")
+	case e.payload.Action == "":
+		return errors.New("zapdriver: audit event has no action")
+	case e.payload.Resource == "":
+		return errors.New("zapdriver: audit event has no resource")
+	}
+
+	level := zapcore.InfoLevel
+	if e.payload.Outcome == "denied" {
+		level = zapcore.WarnLevel
+	}
+
+	payload := e.payload
+	fields := append([]zap.Field{zap.Object("audit", &payload)}, e.fields...)
+	emitEvent(logger, AuditEventKind, level, e.payload.Actor+" "+e.payload.Action+" "+e.payload.Resource, fields)
+
+	return nil
+}
+
+// audit is the payload of an audit event.
+type audit struct {
+	Actor    string `json:"actor"`
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+	Outcome  string `json:"outcome"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaller interface.
+func (a audit) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("actor", a.Actor)
+	enc.AddString("action", a.Action)
+	enc.AddString("resource", a.Resource)
+	enc.AddString("outcome", a.Outcome)
+	if a.Reason != "" {
+		enc.AddString("reason", a.Reason)
+	}
+
+	return nil
+}
+
+// JobState is the state of a job run.
+type JobState string
+
+// States of a job run.
+const (
+	JobStarted   JobState = "started"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+)
+
+// JobEvent builds an entry describing the progress of a (batch) job run. The
+// event is added as the "job" field, and all events of a run are grouped using
+// the operation field (see `Operation`).
+type JobEvent struct {
+	payload job
+	err     error
+	fields  []zap.Field
+}
+
+// NewJobEvent returns a job event with the required job name, run ID and
+// state.
+func NewJobEvent(name, runID string, state JobState) *JobEvent {
+	return &JobEvent{payload: job{
+		Name:  name,
+		RunID: runID,
+		State: state,
+	}}
+}
+
+// Duration sets the time the run took so far.
+func (e *JobEvent) Duration(d time.Duration) *JobEvent {
+	e.payload.Duration = d.String()
+	return e
+}
+
+// Error sets the error that failed the run.
+func (e *JobEvent) Error(err error) *JobEvent {
+	e.err = err
+	return e
+}
+
+// With adds fields to the event.
+func (e *JobEvent) With(fields ...zap.Field) *JobEvent {
+	e.fields = append(e.fields, fields...)
+	return e
+}
+
+// Emit writes the event. Failed runs are written at error level, other states
+// at info level. An error is returned if a required field is missing.
+func (e *JobEvent) Emit(logger *zap.Logger) error {
+	switch {
+	case e.payload.Name == "":
+		return errors.New("zapdriver: job event has no name")
+	case e.payload.RunID == "":
+		return errors.New("zapdriver: job event has no run ID")
+	case e.payload.State == "":
+		return errors.New("zapdriver: job event has no state")
+	}
+
+	level := zapcore.InfoLevel
+	if e.payload.State == JobFailed {
+		level = zapcore.ErrorLevel
+	}
+
+	op := OperationCont(e.payload.RunID, e.payload.Name)
+	switch e.payload.State {
+	case JobStarted:
+		op = OperationStart(e.payload.RunID, e.payload.Name)
+	case JobSucceeded, JobFailed:
+		op = OperationEnd(e.payload.RunID, e.payload.Name)
+	}
+
+	payload := e.payload
+	fields := append([]zap.Field{zap.Object("job", &payload), op}, e.fields...)
+	if e.err != nil {
+		fields = append(fields, zap.Error(e.err))
+	}
+	emitEvent(logger, JobEventKind, level, e.payload.Name+" "+string(e.payload.State), fields)
+
+	return nil
+}
+
+// job is the payload of a job event.
+type job struct {
+	Name     string   `json:"name"`
+	RunID    string   `json:"runId"`
+	State    JobState `json:"state"`
+	Duration string   `json:"duration,omitempty"`
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaller interface.
+func (j job) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", j.Name)
+	enc.AddString("runId", j.RunID)
+	enc.AddString("state", string(j.State))
+	if j.Duration != "" {
+		enc.AddString("duration", j.Duration)
+	}
+
+	return nil
+}
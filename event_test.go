@@ -0,0 +1,108 @@
+package zapdriver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newEventTestLogger() (*zap.Logger, *observer.ObservedLogs) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+
+	return zap.New(&core{Core: debugcore, permLabels: newLabels()}), logs
+}
+
+func TestAccessEvent(t *testing.T) {
+	t.Parallel()
+
+	logger, logs := newEventTestLogger()
+
+	err := NewAccessEvent("GET", "/hello", 503).Latency(1500 * time.Millisecond).Emit(logger)
+	require.NoError(t, err)
+
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+	assert.Equal(t, "access", entry.LoggerName)
+	assert.Equal(t, "GET /hello", entry.Message)
+
+	fields := entry.ContextMap()
+	assert.Equal(t, map[string]interface{}{eventKindKey: "access"}, fields[labelsKey])
+	assert.Equal(t, "1.5s", fields["httpRequest"].(map[string]interface{})["latency"])
+}
+
+func TestAccessEvent_Required(t *testing.T) {
+	t.Parallel()
+
+	logger, logs := newEventTestLogger()
+
+	assert.EqualError(t, NewAccessEvent("GET", "/hello", 0).Emit(logger), "zapdriver: access event has no status")
+	assert.Zero(t, logs.Len())
+}
+
+func TestAuditEvent(t *testing.T) {
+	t.Parallel()
+
+	logger, logs := newEventTestLogger()
+
+	err := NewAuditEvent("alice", "delete", "projects/x").Denied("not an owner").Emit(logger)
+	require.NoError(t, err)
+
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.WarnLevel, entry.Level)
+	assert.Equal(t, "audit", entry.LoggerName)
+
+	want := map[string]interface{}{
+		"actor":    "alice",
+		"action":   "delete",
+		"resource": "projects/x",
+		"outcome":  "denied",
+		"reason":   "not an owner",
+	}
+	assert.Equal(t, want, entry.ContextMap()["audit"])
+
+	assert.Error(t, NewAuditEvent("", "delete", "projects/x").Emit(logger))
+}
+
+func TestJobEvent(t *testing.T) {
+	t.Parallel()
+
+	logger, logs := newEventTestLogger()
+
+	require.NoError(t, NewJobEvent("reindex", "run-1", JobStarted).Emit(logger))
+	require.NoError(t, NewJobEvent("reindex", "run-1", JobFailed).Error(errors.New("boom")).Emit(logger))
+
+	require.Equal(t, 2, logs.Len())
+
+	started := logs.All()[0].ContextMap()
+	assert.Equal(t, true, started[operationKey].(map[string]interface{})["first"])
+
+	failed := logs.All()[1]
+	assert.Equal(t, zapcore.ErrorLevel, failed.Level)
+	assert.Equal(t, "reindex failed", failed.Message)
+	assert.Equal(t, true, failed.ContextMap()[operationKey].(map[string]interface{})["last"])
+	assert.Equal(t, "boom", failed.ContextMap()["error"])
+
+	assert.Error(t, NewJobEvent("reindex", "", JobRunning).Emit(logger))
+}
+
+func TestEvent_Caller(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()}, zap.AddCaller())
+
+	require.NoError(t, NewAccessEvent("GET", "/hello", 200).Emit(logger))
+	require.NoError(t, NewAuditEvent("alice", "delete", "doc").Emit(logger))
+	require.NoError(t, NewJobEvent("import", "run-1", JobFailed).Emit(logger))
+
+	require.Equal(t, 3, logs.Len())
+	for _, entry := range logs.All() {
+		assert.Contains(t, entry.Caller.File, "event_test.go", entry.Message)
+	}
+}
@@ -0,0 +1,115 @@
+package zapdriver
+
+import (
+	"sync"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Failover redirects entries to a secondary Cloud Logging logger once the
+// primary one keeps failing, for example because its (regional) endpoint or
+// project is unavailable.
+//
+// The Cloud Logging client buffers entries and reports delivery failures
+// asynchronously, so a Failover learns about them through `OnError`, which
+// should be set as the `OnError` callback of the primary `logging.Client`:
+//
+//	failover := zapdriver.NewFailover(secondary, 5, time.Minute)
+//	primaryClient.OnError = failover.OnError
+//
+// Once `threshold` errors are seen within `retryAfter` of each other, all
+// entries are written to the secondary logger for `retryAfter`, after which the
+// primary logger is tried again.
+type Failover struct {
+	secondary  *logging.Logger
+	threshold  int
+	retryAfter time.Duration
+
+	// match decides which errors count towards the threshold. All errors count
+	// when it is nil.
+	match func(error) bool
+
+	// labels are added to every entry written to the secondary logger.
+	labels map[string]string
+
+	mutex     sync.Mutex
+	failures  int
+	lastError time.Time
+	until     time.Time
+}
+
+// NewFailover returns a Failover writing to `secondary` for a period of
+// `retryAfter`, once the primary logger reported `threshold` errors, each
+// within `retryAfter` of the previous one. The client doesn't report successful
+// writes, so errors count towards the threshold even when writes succeeded in
+// between.
+func NewFailover(secondary *logging.Logger, threshold int, retryAfter time.Duration) *Failover {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &Failover{
+		secondary:  secondary,
+		threshold:  threshold,
+		retryAfter: retryAfter,
+	}
+}
+
+// NewQuotaOverflow returns a Failover that spills entries into `secondary` as
+// soon as the primary project runs out of write quota, for a period of
+// `retryAfter`. Spilled entries are labelled with `overflow=true`, so they can
+// be reconciled later on.
+func NewQuotaOverflow(secondary *logging.Logger, retryAfter time.Duration) *Failover {
+	f := NewFailover(secondary, 1, retryAfter)
+	f.match = isQuotaError
+	f.labels = map[string]string{"overflow": "true"}
+
+	return f
+}
+
+func isQuotaError(err error) bool {
+	return status.Code(err) == codes.ResourceExhausted
+}
+
+// OnError records a delivery failure of the primary logger.
+func (f *Failover) OnError(err error) {
+	if err == nil || (f.match != nil && !f.match(err)) {
+		return
+	}
+
+	now := time.Now()
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if now.Sub(f.lastError) > f.retryAfter {
+		f.failures = 0
+	}
+
+	f.failures++
+	f.lastError = now
+
+	if f.failures >= f.threshold {
+		f.failures = 0
+		f.until = now.Add(f.retryAfter)
+	}
+}
+
+// Active reports whether entries are currently written to the secondary logger.
+func (f *Failover) Active() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return time.Now().Before(f.until)
+}
+
+// WithFailover adds a failover destination to the zapdriver core. Multiple
+// failovers can be configured, the first active one receives the entries.
+func WithFailover(failover *Failover) func(*core) {
+	return func(c *core) {
+		c.config.Failovers = append(c.config.Failovers, failover)
+	}
+}
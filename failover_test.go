@@ -0,0 +1,110 @@
+package zapdriver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFailover(t *testing.T) {
+	t.Parallel()
+
+	f := NewFailover(nil, 2, time.Minute)
+	assert.False(t, f.Active())
+
+	f.OnError(errors.New("unavailable"))
+	assert.False(t, f.Active())
+
+	f.OnError(errors.New("unavailable"))
+	assert.True(t, f.Active())
+}
+
+func TestFailover_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	f := NewFailover(nil, 1, 10*time.Millisecond)
+
+	f.OnError(errors.New("unavailable"))
+	assert.True(t, f.Active())
+
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, f.Active())
+}
+
+func TestQuotaOverflow(t *testing.T) {
+	t.Parallel()
+
+	f := NewQuotaOverflow(nil, time.Minute)
+
+	f.OnError(status.Error(codes.Unavailable, "unavailable"))
+	assert.False(t, f.Active())
+
+	f.OnError(status.Error(codes.ResourceExhausted, "quota exceeded"))
+	assert.True(t, f.Active())
+}
+
+func TestWriteQuotaOverflow(t *testing.T) {
+	primary, _ := newFakeClient(t)
+	secondary, secondaryServer := newFakeClient(t)
+
+	overflow := NewQuotaOverflow(secondary.Logger("overflow"), time.Minute)
+	overflow.OnError(status.Error(codes.ResourceExhausted, "quota exceeded"))
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         primary.Logger("primary"),
+		permLabels: newLabels(),
+	}
+	WithFailover(overflow)(core)
+
+	logger := zap.New(core)
+	logger.Info("spilled", Label("hello", "world"))
+	require.NoError(t, logger.Sync())
+
+	entries := secondaryServer.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]string{"hello": "world", "overflow": "true"}, entries[0].Labels)
+}
+
+func TestWriteFailover(t *testing.T) {
+	primary, primaryServer := newFakeClient(t)
+	secondary, secondaryServer := newFakeClient(t)
+
+	failover := NewFailover(secondary.Logger("failover"), 1, time.Minute)
+	primary.OnError = failover.OnError
+	primaryServer.setError(status.Error(codes.PermissionDenied, "regional outage"))
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         primary.Logger("primary"),
+		permLabels: newLabels(),
+	}
+	WithFailover(failover)(core)
+
+	logger := zap.New(core)
+	logger.Info("lost")
+	require.NoError(t, logger.Sync())
+
+	for deadline := time.Now().Add(time.Second); !failover.Active(); {
+		require.True(t, time.Now().Before(deadline), "failover never activated")
+		time.Sleep(time.Millisecond)
+	}
+
+	logger.Info("rescued")
+	require.NoError(t, logger.Sync())
+
+	entries := secondaryServer.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "rescued", entries[0].GetJsonPayload().Fields["message"].GetStringValue())
+	assert.Empty(t, primaryServer.Entries())
+}
@@ -0,0 +1,143 @@
+package zapdriver
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/api/option"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeServer is an in-memory implementation of the Cloud Logging API. It
+// records every entry written to it, and can be told to fail all writes.
+type fakeServer struct {
+	logpb.LoggingServiceV2Server
+
+	mutex   sync.Mutex
+	entries []*logpb.LogEntry
+	err     error
+
+	// lostResponses is the number of upcoming writes that are processed, but
+	// still fail, as if the response never reached the client.
+	lostResponses int
+}
+
+func (s *fakeServer) WriteLogEntries(ctx context.Context, req *logpb.WriteLogEntriesRequest) (*logpb.WriteLogEntriesResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	for _, e := range req.Entries {
+		e = proto.Clone(e).(*logpb.LogEntry)
+		if e.LogName == "" {
+			e.LogName = req.LogName
+		}
+		for k, v := range req.Labels {
+			if _, ok := e.Labels[k]; !ok {
+				if e.Labels == nil {
+					e.Labels = map[string]string{}
+				}
+				e.Labels[k] = v
+			}
+		}
+
+		if !s.isDuplicate(e) {
+			s.entries = append(s.entries, e)
+		}
+	}
+
+	if s.lostResponses > 0 {
+		s.lostResponses--
+		return nil, status.Error(codes.Unavailable, "response lost")
+	}
+
+	return &logpb.WriteLogEntriesResponse{}, nil
+}
+
+// isDuplicate reports whether an entry with the same log name, timestamp and
+// insert ID was already stored, which Cloud Logging deduplicates.
+func (s *fakeServer) isDuplicate(e *logpb.LogEntry) bool {
+	if e.InsertId == "" {
+		return false
+	}
+
+	for _, stored := range s.entries {
+		if stored.LogName == e.LogName && stored.InsertId == e.InsertId && proto.Equal(stored.Timestamp, e.Timestamp) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *fakeServer) loseResponses(n int) {
+	s.mutex.Lock()
+	s.lostResponses = n
+	s.mutex.Unlock()
+}
+
+func (s *fakeServer) setError(err error) {
+	s.mutex.Lock()
+	s.err = err
+	s.mutex.Unlock()
+}
+
+func (s *fakeServer) Entries() []*logpb.LogEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]*logpb.LogEntry{}, s.entries...)
+}
+
+// newFakeServer starts a fake server, which is stopped when the test finishes.
+// It returns the address the server listens on.
+func newFakeServer(t testing.TB) (string, *fakeServer) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeServer{}
+	srv := grpc.NewServer()
+	logpb.RegisterLoggingServiceV2Server(srv, fake)
+	go srv.Serve(lis) // nolint: errcheck
+
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String(), fake
+}
+
+// newFakeClient returns a Cloud Logging client connected to a fresh fake
+// server. Both are torn down when the test finishes.
+func newFakeClient(t testing.TB) (*logging.Client, *fakeServer) {
+	t.Helper()
+
+	addr, fake := newFakeServer(t)
+
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := logging.NewClient(context.Background(), "projects/test-project", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.OnError = func(error) {}
+
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, fake
+}
@@ -0,0 +1,215 @@
+package zapdriver
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/logging"
+)
+
+// WithStderrFallback writes entries to standard error instead of Cloud Logging
+// once sending them failed `threshold` times, each within `probeAfter` of the
+// previous failure, so a Cloud Logging outage doesn't lose the logs of the
+// outage. See `NewFallback`.
+func WithStderrFallback(threshold int, probeAfter time.Duration) func(*core) {
+	return WithFallback(NewFallback(os.Stderr, threshold, probeAfter))
+}
+
+// WithFallback adds a fallback writer to the zapdriver core. Failures are
+// reported by the `OnError` callback of the clients created by the
+// constructors of this package (see `ClientOptions`), and by entries written
+// using `SynchronousWrites`. For other clients, call `OnError` of the fallback
+// from the `OnError` callback of the client:
+//
+//	fallback := zapdriver.NewFallback(os.Stderr, 5, time.Minute)
+//	client.OnError = fallback.OnError
+func WithFallback(fallback *Fallback) func(*core) {
+	return func(c *core) {
+		c.config.Fallback = fallback
+		c.config.ClientOptions = append(c.config.ClientOptions, WithOnError(fallback.OnError))
+	}
+}
+
+// Fallback is a circuit breaker, redirecting entries to a local writer while
+// Cloud Logging keeps failing. See `WithFallback`.
+type Fallback struct {
+	threshold  int
+	probeAfter time.Duration
+
+	writeMutex sync.Mutex
+	w          io.Writer
+
+	mutex     sync.Mutex
+	failures  int
+	lastError time.Time
+	until     time.Time
+}
+
+// NewFallback returns a Fallback writing entries to `w` instead of Cloud
+// Logging, once sending them failed `threshold` times, each within
+// `probeAfter` of the previous failure. Entries are written one per line, in
+// the JSON format the Cloud Logging agent (or the runtime of Cloud Run, Cloud
+// Functions or Kubernetes Engine) collects from standard output and standard
+// error.
+//
+// After `probeAfter`, entries are sent to Cloud Logging again. If that fails
+// once more, the fallback is used for another `probeAfter`.
+func NewFallback(w io.Writer, threshold int, probeAfter time.Duration) *Fallback {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &Fallback{w: w, threshold: threshold, probeAfter: probeAfter}
+}
+
+// OnError records a failure to send entries to Cloud Logging.
+func (f *Fallback) OnError(err error) {
+	if err == nil {
+		return
+	}
+
+	now := time.Now()
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	// A failure while probing, shortly after the fallback was last active,
+	// activates it again at once.
+	probing := !f.until.IsZero() && now.Sub(f.until) <= f.probeAfter
+	if now.Sub(f.lastError) > f.probeAfter {
+		f.failures = 0
+	}
+
+	f.failures++
+	f.lastError = now
+
+	if f.failures >= f.threshold || probing {
+		f.failures = 0
+		f.until = now.Add(f.probeAfter)
+	}
+}
+
+// Active reports whether entries are currently written to the fallback writer.
+func (f *Fallback) Active() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return time.Now().Before(f.until)
+}
+
+// succeeded records that an entry was sent to Cloud Logging, which ends
+// probing.
+func (f *Fallback) succeeded() {
+	f.mutex.Lock()
+	f.failures = 0
+	f.until = time.Time{}
+	f.mutex.Unlock()
+}
+
+// write writes the entry to the fallback writer.
+func (f *Fallback) write(ent *logging.Entry) error {
+	b, err := json.Marshal(agentEntry(ent))
+	if err != nil {
+		return err
+	}
+
+	f.writeMutex.Lock()
+	defer f.writeMutex.Unlock()
+
+	_, err = f.w.Write(append(b, '\n'))
+	return err
+}
+
+// agentEntry converts a Cloud Logging entry to the structured format of the
+// Cloud Logging agent.
+func agentEntry(ent *logging.Entry) map[string]interface{} {
+	out := map[string]interface{}{}
+
+	switch payload := ent.Payload.(type) {
+	case map[string]interface{}:
+		for k, v := range payload {
+			out[k] = v
+		}
+	case json.RawMessage:
+		if err := json.Unmarshal(payload, &out); err != nil {
+			out = map[string]interface{}{"message": string(payload)}
+		}
+	case nil:
+	default:
+		out["message"] = payload
+	}
+
+	out[encoderConfig.LevelKey] = strings.ToUpper(ent.Severity.String())
+	if !ent.Timestamp.IsZero() {
+		out["time"] = ent.Timestamp.Format(time.RFC3339Nano)
+	}
+	if len(ent.Labels) > 0 {
+		out[labelsKey] = ent.Labels
+	}
+	if ent.InsertID != "" {
+		out[insertIDKey] = ent.InsertID
+	}
+	if ent.Trace != "" {
+		out[traceKey] = ent.Trace
+		out[traceSampledKey] = ent.TraceSampled
+	}
+	if ent.SpanID != "" {
+		out[spanKey] = ent.SpanID
+	}
+	if op := ent.Operation; op != nil {
+		out[operationKey] = map[string]interface{}{
+			"id":       op.Id,
+			"producer": op.Producer,
+			"first":    op.First,
+			"last":     op.Last,
+		}
+	}
+	if loc := ent.SourceLocation; loc != nil {
+		out[sourceKey] = map[string]interface{}{
+			"file":     loc.File,
+			"line":     strconv.FormatInt(loc.Line, 10),
+			"function": loc.Function,
+		}
+	}
+	if req := ent.HTTPRequest; req != nil {
+		out[httpRequestKey] = httpPayloadOf(req)
+	}
+
+	return out
+}
+
+// httpPayloadOf converts the HTTP request of a Cloud Logging entry.
+func httpPayloadOf(req *logging.HTTPRequest) *HTTPPayload {
+	p := &HTTPPayload{
+		Status:                         req.Status,
+		RemoteIP:                       req.RemoteIP,
+		ServerIP:                       req.LocalIP,
+		CacheHit:                       req.CacheHit,
+		CacheValidatedWithOriginServer: req.CacheValidatedWithOriginServer,
+	}
+	if req.RequestSize > 0 {
+		p.RequestSize = strconv.FormatInt(req.RequestSize, 10)
+	}
+	if req.ResponseSize > 0 {
+		p.ResponseSize = strconv.FormatInt(req.ResponseSize, 10)
+	}
+	if req.Latency > 0 {
+		p.Latency = formatLatency(req.Latency)
+	}
+	if r := req.Request; r != nil {
+		p.RequestMethod = r.Method
+		p.UserAgent = r.UserAgent()
+		p.Referer = r.Referer()
+		p.Protocol = r.Proto
+		if r.URL != nil {
+			p.RequestURL = r.URL.String()
+		}
+	}
+
+	return p
+}
@@ -0,0 +1,125 @@
+package zapdriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+)
+
+func TestWriteFallback(t *testing.T) {
+	client, server := newFakeClient(t)
+	buf := &bytes.Buffer{}
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	SynchronousWrites()(core)
+	WithFallback(NewFallback(buf, 2, 20*time.Millisecond))(core)
+
+	logger := zap.New(core)
+	server.setError(errors.New("unavailable"))
+	logger.Info("one")
+	logger.Info("two")
+	assert.Zero(t, buf.Len())
+	require.True(t, core.config.Fallback.Active())
+
+	logger.Warn("three", Label("env", "test"), zap.Int("count", 3))
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "WARNING", line["severity"])
+	assert.Equal(t, "three", line["message"])
+	assert.Equal(t, float64(3), line["count"])
+	assert.Equal(t, map[string]interface{}{"env": "test"}, line[labelsKey])
+	assert.NotEmpty(t, line["time"])
+
+	// Once the fallback expires, a single failure activates it again.
+	time.Sleep(30 * time.Millisecond)
+	require.False(t, core.config.Fallback.Active())
+	logger.Info("four")
+	assert.True(t, core.config.Fallback.Active())
+
+	// A successful probe ends probing.
+	time.Sleep(30 * time.Millisecond)
+	server.setError(nil)
+	logger.Info("five")
+	require.Len(t, server.Entries(), 1)
+
+	server.setError(errors.New("unavailable"))
+	logger.Info("six")
+	assert.False(t, core.config.Fallback.Active())
+}
+
+func TestFallback_OnError(t *testing.T) {
+	f := NewFallback(&bytes.Buffer{}, 2, 20*time.Millisecond)
+
+	f.OnError(nil)
+	f.OnError(errors.New("one"))
+	assert.False(t, f.Active())
+
+	// Failures more than probeAfter apart don't add up.
+	time.Sleep(30 * time.Millisecond)
+	f.OnError(errors.New("two"))
+	assert.False(t, f.Active())
+
+	f.OnError(errors.New("three"))
+	assert.True(t, f.Active())
+}
+
+func TestAgentEntry(t *testing.T) {
+	u, _ := url.Parse("https://example.com/path")
+	ent := &logging.Entry{
+		Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Severity:  logging.Error,
+		Payload:   json.RawMessage(`{"message":"raw"}`),
+		Trace:     "projects/p/traces/abc",
+		SpanID:    "def",
+		Operation: &logpb.LogEntryOperation{Id: "op", Producer: "app", First: true},
+		SourceLocation: &logpb.LogEntrySourceLocation{
+			File:     "main.go",
+			Line:     12,
+			Function: "main.main",
+		},
+		HTTPRequest: &logging.HTTPRequest{
+			Request: &http.Request{Method: "GET", URL: u, Header: http.Header{}},
+			Status:  500,
+			Latency: 1500 * time.Millisecond,
+		},
+	}
+
+	b, err := json.Marshal(agentEntry(ent))
+	require.NoError(t, err)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &line))
+
+	assert.Equal(t, "raw", line["message"])
+	assert.Equal(t, "ERROR", line["severity"])
+	assert.Equal(t, "2020-01-02T03:04:05Z", line["time"])
+	assert.Equal(t, "projects/p/traces/abc", line[traceKey])
+	assert.Equal(t, false, line[traceSampledKey])
+	assert.Equal(t, "def", line[spanKey])
+	assert.Equal(t, map[string]interface{}{"id": "op", "producer": "app", "first": true, "last": false}, line[operationKey])
+	assert.Equal(t, map[string]interface{}{"file": "main.go", "line": "12", "function": "main.main"}, line[sourceKey])
+
+	req := line[httpRequestKey].(map[string]interface{})
+	assert.Equal(t, "GET", req["requestMethod"])
+	assert.Equal(t, "https://example.com/path", req["requestUrl"])
+	assert.Equal(t, float64(500), req["status"])
+	assert.Equal(t, "1.5s", req["latency"])
+}
@@ -0,0 +1,144 @@
+package zapdriver
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	fieldSizesKey = "field_sizes"
+
+	// defaultFieldSizeTop is the number of keys reported periodically.
+	defaultFieldSizeTop = 10
+)
+
+// WithFieldSizeAccounting is a diagnostic recording the encoded size of every
+// top-level key of the payloads sent to Cloud Logging, to find out which
+// fields drive the ingestion cost. The keys taking up the most bytes are
+// reported every `interval` in an info entry; with an interval of 0, they are
+// only reported on demand, using `ReportFieldSizes`.
+//
+// Encoding every field twice is expensive, this is not meant to be enabled
+// permanently.
+func WithFieldSizeAccounting(interval time.Duration) func(*core) {
+	return func(c *core) {
+		c.config.FieldSizes = &fieldSizes{
+			interval: interval,
+			last:     time.Now(),
+			sizes:    map[string]*fieldSize{},
+		}
+	}
+}
+
+// ReportFieldSizes logs the `top` keys taking up the most bytes, as recorded
+// by `WithFieldSizeAccounting`, at info level. It does nothing if the logger
+// doesn't record field sizes.
+func ReportFieldSizes(logger *zap.Logger, top int) {
+	c, ok := logger.Core().(*core)
+	if !ok || c.config.FieldSizes == nil {
+		return
+	}
+
+	logger.Info("zapdriver: payload keys by size", zap.Array(fieldSizesKey, c.config.FieldSizes.top(top)))
+}
+
+// fieldSizes records the encoded size of payload keys.
+type fieldSizes struct {
+	interval time.Duration
+
+	mutex sync.Mutex
+	last  time.Time
+	sizes map[string]*fieldSize
+}
+
+// fieldSize is the total encoded size of a payload key.
+type fieldSize struct {
+	Key   string
+	Bytes int64
+	Count int64
+}
+
+type fieldSizeList []fieldSize
+
+// MarshalLogArray implements zapcore.ArrayMarshaler interface.
+func (l fieldSizeList) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, s := range l {
+		s := s
+		_ = enc.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("key", s.Key)
+			enc.AddInt64("bytes", s.Bytes)
+			enc.AddInt64("count", s.Count)
+			return nil
+		}))
+	}
+
+	return nil
+}
+
+// record adds the encoded sizes of the keys of the payload. It reports whether
+// the periodic report is due.
+func (s *fieldSizes) record(payload map[string]interface{}) bool {
+	sizes := make(map[string]int, len(payload))
+	for k, v := range payload {
+		b, _ := json.Marshal(v)
+		sizes[k] = len(k) + len(b)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for k, n := range sizes {
+		size, ok := s.sizes[k]
+		if !ok {
+			size = &fieldSize{Key: k}
+			s.sizes[k] = size
+		}
+		size.Bytes += int64(n)
+		size.Count++
+	}
+
+	if s.interval <= 0 || time.Since(s.last) < s.interval {
+		return false
+	}
+
+	s.last = time.Now()
+	return true
+}
+
+// top returns the `n` keys taking up the most bytes.
+func (s *fieldSizes) top(n int) fieldSizeList {
+	s.mutex.Lock()
+	list := make(fieldSizeList, 0, len(s.sizes))
+	for _, size := range s.sizes {
+		list = append(list, *size)
+	}
+	s.mutex.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Bytes != list[j].Bytes {
+			return list[i].Bytes > list[j].Bytes
+		}
+		return list[i].Key < list[j].Key
+	})
+
+	if n >= 0 && len(list) > n {
+		list = list[:n]
+	}
+
+	return list
+}
+
+// reportFieldSizes writes the periodic report to the wrapped core.
+func (c *core) reportFieldSizes(ent zapcore.Entry) {
+	_ = c.Core.Write(zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       ent.Time,
+		LoggerName: ent.LoggerName,
+		Message:    "zapdriver: payload keys by size",
+	}, []zapcore.Field{zap.Array(fieldSizesKey, c.config.FieldSizes.top(defaultFieldSizeTop))})
+}
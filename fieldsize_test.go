@@ -0,0 +1,61 @@
+package zapdriver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFieldSizes(t *testing.T) {
+	t.Parallel()
+
+	c := &core{}
+	WithFieldSizeAccounting(0)(c)
+	s := c.config.FieldSizes
+
+	assert.False(t, s.record(map[string]interface{}{"message": "hi", "body": strings.Repeat("a", 10)}))
+	assert.False(t, s.record(map[string]interface{}{"message": "hi", "body": strings.Repeat("a", 10)}))
+
+	want := fieldSizeList{
+		{Key: "body", Bytes: 32, Count: 2},
+		{Key: "message", Bytes: 22, Count: 2},
+	}
+	assert.Equal(t, want, s.top(10))
+	assert.Equal(t, want[:1], s.top(1))
+}
+
+func TestReportFieldSizes(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	WithFieldSizeAccounting(0)(core)
+
+	logger := zap.New(core)
+	logger.Info("hello", zap.String("body", strings.Repeat("a", 100)))
+	ReportFieldSizes(logger, 1)
+
+	require.Equal(t, 2, logs.Len())
+
+	sizes := logs.All()[1].ContextMap()[fieldSizesKey].([]interface{})
+	require.Len(t, sizes, 1)
+	assert.Equal(t, "body", sizes[0].(map[string]interface{})["key"])
+}
+
+func TestWriteFieldSizeAccounting_Periodic(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	WithFieldSizeAccounting(time.Nanosecond)(core)
+
+	zap.New(core).Info("hello")
+
+	assert.Equal(t, 1, logs.FilterMessage("zapdriver: payload keys by size").Len())
+}
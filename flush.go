@@ -0,0 +1,176 @@
+package zapdriver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// WithFlushEvery flushes the entries buffered by the Cloud Logging client
+// every `interval`, so low-traffic services don't hold on to entries for a
+// long time. The background goroutine is started when the first entry is
+// written, and stopped by `Close`. A non-positive interval is ignored, and
+// reported by `Validate`.
+func WithFlushEvery(interval time.Duration) func(*core) {
+	return func(c *core) {
+		c.config.Flusher = &flusher{interval: interval, stop: make(chan struct{})}
+	}
+}
+
+// Close stops the background work of the zapdriver core of the logger, and
+// flushes all buffered entries. The logger should not be used afterwards.
+func Close(logger *zap.Logger) error {
+	if c, ok := logger.Core().(*core); ok {
+		if c.config.Async != nil {
+			c.config.Async.close()
+		}
+		if c.config.Flusher != nil {
+			c.config.Flusher.close()
+		}
+	}
+
+	return logger.Sync()
+}
+
+// FlushWithContext writes all queued entries, and flushes the entries buffered
+// by the Cloud Logging client, like `Sync`, but returns the error of the flush,
+// and gives up once ctx is done:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	err := zapdriver.FlushWithContext(ctx, logger)
+//
+// When ctx is done first, the error of ctx is returned, and the flush goes on
+// in the background.
+func FlushWithContext(ctx context.Context, logger *zap.Logger) error {
+	c, ok := logger.Core().(*core)
+	if !ok {
+		return logger.Sync()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- multierr.Append(c.flush(), c.Core.Sync())
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown is the same as Close, but gives up once ctx is done, and also
+// closes the Cloud Logging client created by the constructors of this package,
+// like `NewCloudProduction`:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	dropped, err := zapdriver.Shutdown(ctx, logger)
+//
+// When ctx is done first, the entries still queued by `Async` are dropped, and
+// the error of ctx is returned right away. The entries being written finish,
+// and the client is closed, in the background. It returns the number of
+// entries the queue of `Async` dropped while shutting down.
+func Shutdown(ctx context.Context, logger *zap.Logger) (uint64, error) {
+	c, ok := logger.Core().(*core)
+	if !ok {
+		return 0, logger.Sync()
+	}
+
+	before := DroppedEntries(logger)
+	done := make(chan error, 1)
+	go func() {
+		if c.config.Async != nil {
+			c.config.Async.close()
+		}
+
+		if c.config.Flusher != nil {
+			c.config.Flusher.close()
+		}
+
+		err := logger.Sync()
+		if c.config.Client != nil {
+			err = multierr.Append(err, c.config.Client.close())
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return DroppedEntries(logger) - before, err
+	case <-ctx.Done():
+		if c.config.Async != nil {
+			c.config.Async.abandon()
+		}
+
+		return DroppedEntries(logger) - before, ctx.Err()
+	}
+}
+
+// ownedClient is a Cloud Logging client created by this package, which is
+// closed at most once.
+type ownedClient struct {
+	client *logging.Client
+
+	once sync.Once
+	err  error
+}
+
+// close closes the client, the first time it's called.
+func (o *ownedClient) close() error {
+	o.once.Do(func() { o.err = o.client.Close() })
+
+	return o.err
+}
+
+// flusher periodically flushes a core in the background.
+type flusher struct {
+	interval time.Duration
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      sync.WaitGroup
+}
+
+func (f *flusher) start(c *core) {
+	if f.interval <= 0 {
+		return
+	}
+
+	f.startOnce.Do(func() {
+		f.done.Add(1)
+		go f.run(c)
+	})
+}
+
+func (f *flusher) run(c *core) {
+	defer f.done.Done()
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = c.flushCloud()
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+// close stops the background goroutine, and waits for it to exit.
+func (f *flusher) close() {
+	// Make sure a core that never wrote an entry can't start the goroutine
+	// afterwards.
+	f.startOnce.Do(func() {})
+	f.stopOnce.Do(func() { close(f.stop) })
+	f.done.Wait()
+}
@@ -0,0 +1,211 @@
+package zapdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithFlushEvery(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithFlushEvery(10 * time.Millisecond)(core)
+
+	logger := zap.New(core)
+	logger.Info("hello")
+
+	for deadline := time.Now().Add(time.Second); len(server.Entries()) == 0; {
+		require.True(t, time.Now().Before(deadline), "entry never flushed")
+		time.Sleep(time.Millisecond)
+	}
+
+	require.NoError(t, Close(logger))
+}
+
+func TestWithFlushEvery_NotPositive(t *testing.T) {
+	core := &core{
+		Core:       zapcore.NewNopCore(),
+		permLabels: newLabels(),
+	}
+	WithFlushEvery(0)(core)
+
+	logger := zap.New(core)
+	assert.NotPanics(t, func() { logger.Info("hello") })
+	assert.NoError(t, Close(logger))
+}
+
+func TestClose_WithoutWrites(t *testing.T) {
+	core := &core{
+		Core:       zapcore.NewNopCore(),
+		permLabels: newLabels(),
+	}
+	WithFlushEvery(time.Millisecond)(core)
+
+	logger := zap.New(core)
+	require.NoError(t, Close(logger))
+
+	// Writing after closing doesn't restart the background goroutine.
+	require.NoError(t, core.Write(zapcore.Entry{}, nil))
+	assert.NoError(t, Close(logger))
+}
+
+func TestShutdown(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	Async(1, 1)(core)
+
+	logger := zap.New(core)
+	logger.Info("one")
+	logger.Info("two")
+
+	dropped, err := Shutdown(context.Background(), logger)
+	require.NoError(t, err)
+
+	assert.Zero(t, dropped)
+	assert.Equal(t, 2, logs.Len())
+	assert.Len(t, server.Entries(), 2)
+}
+
+func TestShutdown_Deadline(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	blocking := &blockingCore{Core: debugcore, release: make(chan struct{}), started: make(chan struct{}, 1)}
+	core := &core{Core: blocking, permLabels: newLabels()}
+	Async(1, 1)(core)
+
+	logger := zap.New(core)
+	for i := 0; i < 5; i++ {
+		logger.Info("hello")
+	}
+	<-blocking.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Shutdown returns while the write is still blocked.
+	dropped, err := Shutdown(ctx, logger)
+	require.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, uint64(4), dropped)
+	assert.Equal(t, 0, logs.Len())
+
+	// The entry being written when the deadline passed is still written.
+	close(blocking.release)
+	for deadline := time.Now().Add(5 * time.Second); logs.Len() < 1; {
+		require.True(t, time.Now().Before(deadline), "entry never written")
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, uint64(4), DroppedEntries(logger))
+}
+
+func TestShutdown_DeadlineBlockedWriter(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	blocking := &blockingCore{Core: debugcore, release: make(chan struct{}), started: make(chan struct{}, 1)}
+	core := &core{Core: blocking, permLabels: newLabels()}
+	Async(1, 1)(core)
+	AsyncQueue(1, QueueBlock)(core)
+
+	logger := zap.New(core)
+	logger.Info("written")
+	<-blocking.started
+	logger.Info("queued")
+
+	// The next writer blocks on the full queue.
+	go logger.Info("blocked")
+	a := core.config.Async
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		a.pendingMutex.Lock()
+		pending := a.pending
+		a.pendingMutex.Unlock()
+		if pending == 3 {
+			break
+		}
+		require.True(t, time.Now().Before(deadline), "writer never blocked")
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Shutdown(ctx, logger)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.Equal(t, context.DeadlineExceeded, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown blocked behind the writer")
+	}
+
+	close(blocking.release)
+	for deadline := time.Now().Add(5 * time.Second); logs.Len() < 1 || DroppedEntries(logger) < 2; {
+		require.True(t, time.Now().Before(deadline), "entries never written or dropped")
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, uint64(2), DroppedEntries(logger))
+}
+
+func TestFlushWithContext(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+
+	logger := zap.New(core)
+	logger.Info("hello")
+
+	require.NoError(t, FlushWithContext(context.Background(), logger))
+	assert.Len(t, server.Entries(), 1)
+
+	// Unlike Sync, the error of the flush is returned.
+	server.setError(errors.New("unavailable"))
+	logger.Info("hello")
+	assert.Error(t, FlushWithContext(context.Background(), logger))
+
+	logger.Info("hello")
+	assert.NoError(t, logger.Sync())
+}
+
+func TestFlushWithContext_Deadline(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	blocking := &blockingCore{Core: debugcore, release: make(chan struct{})}
+	core := &core{Core: blocking, permLabels: newLabels()}
+	Async(1, 1)(core)
+
+	logger := zap.New(core)
+	logger.Info("hello")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, FlushWithContext(ctx, logger))
+
+	close(blocking.release)
+	require.NoError(t, FlushWithContext(context.Background(), logger))
+	assert.Equal(t, 1, logs.Len())
+	require.NoError(t, Close(logger))
+}
@@ -3,12 +3,20 @@ module github.com/blendle/zapdriver
 go 1.12
 
 require (
+	cloud.google.com/go v0.43.0
 	cloud.google.com/go/logging v1.0.0
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/pkg/errors v0.8.1 // indirect
+	github.com/go-logr/logr v1.2.4
+	github.com/golang/protobuf v1.3.2
+	github.com/pkg/errors v0.8.1
+	github.com/prometheus/client_golang v0.9.4
+	github.com/prometheus/client_model v0.0.0-20190129233127-fd36f4220a90
 	github.com/stretchr/testify v1.3.0
 	go.uber.org/atomic v1.4.0 // indirect
-	go.uber.org/multierr v1.1.0 // indirect
+	go.uber.org/multierr v1.1.0
 	go.uber.org/zap v1.10.0
+	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
+	google.golang.org/api v0.7.0
 	google.golang.org/genproto v0.0.0-20190716160619-c506a9f90610
+	google.golang.org/grpc v1.21.1
 )
@@ -0,0 +1,203 @@
+package zapdriver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// InterceptorOption configures the gRPC interceptors.
+type InterceptorOption func(*interceptorConfig)
+
+type interceptorConfig struct {
+	logPayloads bool
+}
+
+// WithPayloadLogging makes the interceptors log every request and response
+// message at debug level.
+func WithPayloadLogging() InterceptorOption {
+	return func(c *interceptorConfig) {
+		c.logPayloads = true
+	}
+}
+
+// UnaryServerInterceptor returns a gRPC server interceptor logging one entry per
+// RPC, with its method, status code, duration and peer address:
+//
+//	grpc.NewServer(grpc.UnaryInterceptor(zapdriver.UnaryServerInterceptor(logger, "my-project")))
+//
+// The trace context of the "x-cloud-trace-context" metadata is added to the
+// entry, and to the context of the RPC (see `ContextWithSpanContext`).
+//
+// RPCs are logged at info, warn or error level, depending on their status code.
+func UnaryServerInterceptor(logger *zap.Logger, projectName string, opts ...InterceptorOption) grpc.UnaryServerInterceptor {
+	config := newInterceptorConfig(opts)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx, fields := rpcContext(ctx, info.FullMethod, projectName)
+
+		if config.logPayloads {
+			logger.Debug("gRPC request received.", concatFields(fields, protoField("grpc.request", req))...)
+		}
+
+		resp, err := handler(ctx, req)
+
+		if config.logPayloads && err == nil {
+			logger.Debug("gRPC response sent.", concatFields(fields, protoField("grpc.response", resp))...)
+		}
+
+		logRPC(logger, info.FullMethod, start, err, fields)
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a gRPC server interceptor logging one entry
+// per stream, like `UnaryServerInterceptor`. With payload logging, every
+// message sent and received is logged.
+func StreamServerInterceptor(logger *zap.Logger, projectName string, opts ...InterceptorOption) grpc.StreamServerInterceptor {
+	config := newInterceptorConfig(opts)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, fields := rpcContext(ss.Context(), info.FullMethod, projectName)
+
+		stream := &loggedServerStream{ServerStream: ss, ctx: ctx}
+		if config.logPayloads {
+			stream.logger = logger.With(fields...)
+		}
+
+		err := handler(srv, stream)
+		logRPC(logger, info.FullMethod, start, err, fields)
+
+		return err
+	}
+}
+
+func newInterceptorConfig(opts []InterceptorOption) *interceptorConfig {
+	config := &interceptorConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return config
+}
+
+// rpcContext returns the context of an RPC, carrying the trace context of its
+// metadata, and the fields identifying the RPC.
+func rpcContext(ctx context.Context, method, projectName string) (context.Context, []zap.Field) {
+	fields := []zap.Field{zap.String("grpc.method", method)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, zap.String("peer.address", p.Addr.String()))
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := md.Get(strings.ToLower(TraceHeader))
+	if len(header) == 0 {
+		return ctx, fields
+	}
+
+	trace, spanId, sampled, ok := parseTraceHeader(header[0])
+	if !ok {
+		return ctx, fields
+	}
+
+	if _, ok := SpanContextFromContext(ctx); !ok {
+		ctx = ContextWithSpanContext(ctx, SpanContext{TraceID: trace, SpanID: spanId, Sampled: sampled})
+	}
+
+	return ctx, append(fields, TraceContext(trace, spanId, sampled, projectName)...)
+}
+
+// logRPC logs the outcome of an RPC.
+func logRPC(logger *zap.Logger, method string, start time.Time, err error, fields []zap.Field) {
+	code := status.Code(err)
+
+	ce := logger.Check(codeLevel(code), "gRPC "+method)
+	if ce == nil {
+		return
+	}
+
+	fields = concatFields(fields,
+		zap.String("grpc.code", code.String()),
+		zap.Duration("grpc.duration", time.Since(start)),
+	)
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+
+	ce.Write(fields...)
+}
+
+// codeLevel returns the level RPCs with the given status code are logged at.
+func codeLevel(code codes.Code) zapcore.Level {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound,
+		codes.AlreadyExists, codes.Unauthenticated:
+		return zapcore.InfoLevel
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange:
+		return zapcore.WarnLevel
+	}
+
+	return zapcore.ErrorLevel
+}
+
+// concatFields returns a new slice holding the fields of a, followed by b, so
+// entries written asynchronously never share their fields.
+func concatFields(a []zap.Field, b ...zap.Field) []zap.Field {
+	fields := make([]zap.Field, 0, len(a)+len(b))
+	fields = append(fields, a...)
+
+	return append(fields, b...)
+}
+
+// protoField returns a field holding a message, encoded as JSON.
+func protoField(key string, msg interface{}) zap.Field {
+	if pb, ok := msg.(proto.Message); ok {
+		return Proto(key, pb)
+	}
+
+	return Any(key, msg)
+}
+
+// loggedServerStream overrides the context of a server stream, and logs its
+// messages if logger is set.
+type loggedServerStream struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	logger *zap.Logger
+}
+
+func (s *loggedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *loggedServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if s.logger != nil && err == nil {
+		s.logger.Debug("gRPC message sent.", protoField("grpc.response", m))
+	}
+
+	return err
+}
+
+func (s *loggedServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if s.logger != nil && err == nil {
+		s.logger.Debug("gRPC message received.", protoField("grpc.request", m))
+	}
+
+	return err
+}
@@ -0,0 +1,95 @@
+package zapdriver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	interceptor := UnaryServerInterceptor(zap.New(debugcore), "my-project", WithPayloadLogging())
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-cloud-trace-context", "abc/1;o=1"))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}
+
+	var handlerCtx context.Context
+	_, err := interceptor(ctx, &wrappers.StringValue{Value: "order-1"}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerCtx = ctx
+		return nil, status.Error(codes.NotFound, "no such order")
+	})
+	require.Error(t, err)
+
+	sc, ok := SpanContextFromContext(handlerCtx)
+	require.True(t, ok)
+	assert.Equal(t, "abc", sc.TraceID)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+	assert.Equal(t, `"order-1"`, string(entries[0].ContextMap()["grpc.request"].(json.RawMessage)))
+
+	fields := entries[1].ContextMap()
+	assert.Equal(t, zapcore.InfoLevel, entries[1].Level)
+	assert.Equal(t, "gRPC /orders.Orders/Get", entries[1].Message)
+	assert.Equal(t, "NotFound", fields["grpc.code"])
+	assert.Equal(t, "10.0.0.1:1234", fields["peer.address"])
+	assert.Equal(t, "projects/my-project/traces/abc", fields[traceKey])
+	assert.Contains(t, fields, "grpc.duration")
+}
+
+func TestCodeLevel(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, zapcore.InfoLevel, codeLevel(codes.OK))
+	assert.Equal(t, zapcore.WarnLevel, codeLevel(codes.PermissionDenied))
+	assert.Equal(t, zapcore.ErrorLevel, codeLevel(codes.Internal))
+	assert.Equal(t, zapcore.ErrorLevel, codeLevel(codes.Unknown))
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context  { return s.ctx }
+func (s *testServerStream) SendMsg(interface{}) error { return nil }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	interceptor := StreamServerInterceptor(zap.New(debugcore), "my-project", WithPayloadLogging())
+
+	ss := &testServerStream{ctx: context.Background()}
+	info := &grpc.StreamServerInfo{FullMethod: "/orders.Orders/Watch"}
+
+	err := interceptor(nil, ss, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return stream.SendMsg(&wrappers.StringValue{Value: "order-1"})
+	})
+	require.NoError(t, err)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "gRPC message sent.", entries[0].Message)
+	assert.Equal(t, "/orders.Orders/Watch", entries[0].ContextMap()["grpc.method"])
+	assert.Equal(t, "OK", entries[1].ContextMap()["grpc.code"])
+}
@@ -0,0 +1,78 @@
+package zapdriver
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/grpclog"
+)
+
+// NewGRPCLogger returns a `grpclog.LoggerV2` writing the internal logs of gRPC
+// through the logger, with the `source=grpc` label:
+//
+//	grpclog.SetLoggerV2(zapdriver.NewGRPCLogger(logger, 0))
+//
+// Info, warning and error logs are written at the matching levels, fatal logs
+// are written at fatal level, which exits the program, as gRPC expects.
+// `verbosity` is the highest verbosity level gRPC logs at, see `V`.
+//
+// The entries are only written to the local output, not to the Cloud Logging
+// API: the Cloud Logging client itself uses gRPC, so sending them would feed
+// the logs of each write back into further writes, and errors of an
+// unreachable API would be logged through that same API. On Google Cloud, the
+// local output is still collected by the Cloud Logging agent.
+func NewGRPCLogger(logger *zap.Logger, verbosity int) grpclog.LoggerV2 {
+	// gRPC calls the logger through the functions of grpclog, so two frames
+	// are skipped to report the caller within gRPC.
+	logger = logger.With(Label("source", "grpc")).WithOptions(zap.AddCallerSkip(2), localOnly())
+
+	return &grpcLogger{logger: logger.Sugar(), verbosity: verbosity}
+}
+
+// localOnly returns a `zap.Option` keeping the entries of a child logger out
+// of Cloud Logging.
+func localOnly() zap.Option {
+	return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		zc, ok := c.(*core)
+		if !ok {
+			return c
+		}
+
+		clone := *zc
+		clone.config.LocalOnly = true
+
+		return &clone
+	})
+}
+
+// grpcLogger implements `grpclog.LoggerV2` using a sugared zap logger.
+type grpcLogger struct {
+	logger    *zap.SugaredLogger
+	verbosity int
+}
+
+func (l *grpcLogger) Info(args ...interface{})                    { l.logger.Info(args...) }
+func (l *grpcLogger) Infoln(args ...interface{})                  { l.logger.Info(sprintln(args)) }
+func (l *grpcLogger) Infof(format string, args ...interface{})    { l.logger.Infof(format, args...) }
+func (l *grpcLogger) Warning(args ...interface{})                 { l.logger.Warn(args...) }
+func (l *grpcLogger) Warningln(args ...interface{})               { l.logger.Warn(sprintln(args)) }
+func (l *grpcLogger) Warningf(format string, args ...interface{}) { l.logger.Warnf(format, args...) }
+func (l *grpcLogger) Error(args ...interface{})                   { l.logger.Error(args...) }
+func (l *grpcLogger) Errorln(args ...interface{})                 { l.logger.Error(sprintln(args)) }
+func (l *grpcLogger) Errorf(format string, args ...interface{})   { l.logger.Errorf(format, args...) }
+func (l *grpcLogger) Fatal(args ...interface{})                   { l.logger.Fatal(args...) }
+func (l *grpcLogger) Fatalln(args ...interface{})                 { l.logger.Fatal(sprintln(args)) }
+func (l *grpcLogger) Fatalf(format string, args ...interface{})   { l.logger.Fatalf(format, args...) }
+
+// V reports whether gRPC logs at the verbosity level.
+func (l *grpcLogger) V(level int) bool {
+	return level <= l.verbosity
+}
+
+// sprintln formats the arguments like fmt.Sprintln, without the trailing
+// newline.
+func sprintln(args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
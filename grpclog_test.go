@@ -0,0 +1,58 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// logThroughGRPCLog calls the logger like the functions of grpclog do, which
+// the logger skips.
+func logThroughGRPCLog(log func()) {
+	log()
+}
+
+func TestNewGRPCLogger(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := NewGRPCLogger(zap.New(&core{Core: debugcore, permLabels: newLabels()}, zap.AddCaller()), 1)
+
+	logThroughGRPCLog(func() { logger.Infoln("transport:", "closing") })
+	logThroughGRPCLog(func() { logger.Warningf("retrying in %s", "1s") })
+	logThroughGRPCLog(func() { logger.Error("failed") })
+
+	entries := logs.All()
+	require.Len(t, entries, 3)
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, "transport: closing", entries[0].Message)
+	assert.Contains(t, entries[0].Caller.File, "grpclog_test.go")
+	assert.Equal(t, map[string]interface{}{"source": "grpc"}, entries[0].ContextMap()[labelsKey])
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, "retrying in 1s", entries[1].Message)
+	assert.Equal(t, zapcore.ErrorLevel, entries[2].Level)
+
+	assert.True(t, logger.V(1))
+	assert.False(t, logger.V(2))
+}
+
+func TestNewGRPCLogger_LocalOnly(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	recorder := &recordingLogger{}
+	parent := zap.New(&core{Core: debugcore, lg: recorder, permLabels: newLabels()})
+	logger := NewGRPCLogger(parent, 0)
+
+	logThroughGRPCLog(func() { logger.Warning("transport: connection reset") })
+	assert.Equal(t, 1, logs.Len())
+	assert.Empty(t, recorder.entries)
+
+	parent.Info("hello")
+	assert.Equal(t, 2, logs.Len())
+	assert.Len(t, recorder.entries, 1)
+}
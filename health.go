@@ -0,0 +1,70 @@
+package zapdriver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap"
+)
+
+// WithHealthCheckClient sets the client pinged by `HealthCheck`, for cores
+// sending entries using `WithLogger`. Loggers created by `NewCloudProduction`
+// and `NewCloudDevelopment` ping their own client.
+func WithHealthCheckClient(client *logging.Client) func(*core) {
+	return func(c *core) {
+		c.config.HealthClient = client
+	}
+}
+
+// HealthCheck verifies that the logger can deliver entries to Cloud Logging,
+// by writing a deduplicated entry to the `ping` log of the project (see
+// `Preflight`). It fails if the credentials are missing or lack permissions,
+// or the API can't be reached. Use it in a readiness probe, so a broken logging
+// path keeps a deployment from rolling out (see `HealthHandler`).
+//
+// A passing check updates the `LastSent` time of the stats of the logger (see
+// `StatsOf`), a failing one is counted as an error.
+func HealthCheck(ctx context.Context, logger *zap.Logger) error {
+	c, ok := logger.Core().(*core)
+	if !ok {
+		return errors.New("zapdriver: health check failed: the logger doesn't use the zapdriver core")
+	}
+
+	client := c.config.HealthClient
+	if client == nil && c.config.Client != nil {
+		client = c.config.Client.client
+	}
+	if client == nil {
+		return errors.New("zapdriver: health check failed: no Cloud Logging client to check (see WithHealthCheckClient)")
+	}
+
+	if err := Preflight(ctx, client); err != nil {
+		c.config.Stats.error(err)
+		return err
+	}
+
+	c.config.Stats.sent()
+
+	return nil
+}
+
+// HealthHandler returns an HTTP handler running `HealthCheck` for every request,
+// for use as readiness probe. It responds with "200 OK" if the check passes,
+// and with "503 Service Unavailable" and the error otherwise:
+//
+//	http.Handle("/readyz", zapdriver.HealthHandler(logger))
+func HealthHandler(logger *zap.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		if err := HealthCheck(r.Context(), logger); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(err.Error() + "\n"))
+			return
+		}
+
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
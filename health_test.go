@@ -0,0 +1,86 @@
+package zapdriver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHealthCheck(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	observed, _ := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, WrapCore(WithLogger(client.Logger("app")), WithHealthCheckClient(client)))
+
+	require.NoError(t, HealthCheck(context.Background(), logger))
+	assert.Len(t, server.Entries(), 1)
+	assert.False(t, StatsOf(logger).LastSent.IsZero())
+
+	server.setError(status.Error(codes.PermissionDenied, "denied"))
+
+	err := HealthCheck(context.Background(), logger)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "logging.logEntries.write")
+	assert.Equal(t, uint64(1), StatsOf(logger).Errors)
+}
+
+func TestHealthCheck_NoClient(t *testing.T) {
+	t.Parallel()
+
+	observed, _ := observer.New(zapcore.DebugLevel)
+
+	err := HealthCheck(context.Background(), zap.New(observed))
+	assert.EqualError(t, err, "zapdriver: health check failed: the logger doesn't use the zapdriver core")
+
+	err = HealthCheck(context.Background(), zap.New(observed, WrapCore()))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Cloud Logging client")
+}
+
+func TestHealthHandler(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	observed, _ := observer.New(zapcore.DebugLevel)
+	handler := HealthHandler(zap.New(observed, WrapCore(WithHealthCheckClient(client))))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok\n", rec.Body.String())
+
+	server.setError(status.Error(codes.Unauthenticated, "no credentials"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no valid credentials found")
+}
+
+func TestStats_LastSent(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	observed, _ := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, WrapCore(WithLogger(client.Logger("app"))))
+
+	require.NoError(t, logger.Sync())
+	assert.True(t, StatsOf(logger).LastSent.IsZero(), "nothing was sent")
+
+	logger.Info("hello")
+	require.NoError(t, logger.Sync())
+	assert.Len(t, server.Entries(), 1)
+
+	sent := StatsOf(logger).LastSent
+	assert.False(t, sent.IsZero())
+
+	require.NoError(t, logger.Sync())
+	assert.Equal(t, sent, StatsOf(logger).LastSent)
+}
@@ -0,0 +1,44 @@
+package zapdriver
+
+import (
+	"cloud.google.com/go/logging"
+)
+
+// WithEntryHook hands every entry to `hook`, instead of sending it to Cloud
+// Logging. The entry is the one the logger would have received, with its
+// payload, labels, severity, trace and HTTP request, so the output of a service
+// can be tested without a client (see the `zapdrivertest` package).
+//
+// The hook owns the entry, and is called synchronously, by the goroutine
+// writing it.
+func WithEntryHook(hook func(logging.Entry)) func(*core) {
+	return func(c *core) {
+		c.config.EntryHook = hook
+	}
+}
+
+// runHook hands a copy of the entry to the entry hook.
+func (c *core) runHook(glog *logging.Entry) {
+	c.config.EntryHook(copyEntry(glog))
+}
+
+// copyEntry returns a copy of the entry that can be kept. The payload and
+// labels are copied, as the payload map is reused once the entry is written.
+func copyEntry(glog *logging.Entry) logging.Entry {
+	e := *glog
+	if payload, ok := glog.Payload.(map[string]interface{}); ok {
+		p := make(map[string]interface{}, len(payload))
+		for k, v := range payload {
+			p[k] = v
+		}
+		e.Payload = p
+	}
+	if glog.Labels != nil {
+		e.Labels = make(map[string]string, len(glog.Labels))
+		for k, v := range glog.Labels {
+			e.Labels[k] = v
+		}
+	}
+
+	return e
+}
@@ -0,0 +1,33 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithEntryHook(t *testing.T) {
+	t.Parallel()
+
+	var entries []logging.Entry
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore, WrapCore(WithEntryHook(func(e logging.Entry) {
+		entries = append(entries, e)
+	})))
+
+	logger.With(Label("one", "1")).Warn("hello", zap.String("key", "value"), Label("two", "2"))
+	logger.Info("world")
+
+	require.Len(t, entries, 2)
+	assert.Equal(t, 2, logs.Len())
+
+	assert.Equal(t, logging.Warning, entries[0].Severity)
+	assert.Equal(t, map[string]string{"one": "1", "two": "2"}, entries[0].Labels)
+	assert.Equal(t, map[string]interface{}{"message": "hello", "key": "value"}, entries[0].Payload)
+	assert.Equal(t, map[string]interface{}{"message": "world"}, entries[1].Payload)
+}
@@ -13,18 +13,25 @@ package zapdriver
 import (
 	"bytes"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-// HTTP adds the correct Stackdriver "HTTP" field.
+const httpRequestKey = "httpRequest"
+
+// HTTP adds the correct Stackdriver "HTTP" field. Entries sent to the Cloud
+// Logging API get their HTTP request from this field.
 //
 // see: https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest
 func HTTP(req *HTTPPayload) zap.Field {
-	return zap.Object("httpRequest", req)
+	return zap.Object(httpRequestKey, req)
 }
 
 // HTTPPayload is the complete payload that can be interpreted by
@@ -138,6 +145,152 @@ func NewHTTP(req *http.Request, res *http.Response) *HTTPPayload {
 	return sdreq
 }
 
+// ResponseInfo describes the response a server sent to a request, see
+// `NewHTTPFromResponse`.
+type ResponseInfo struct {
+	// The status code of the response.
+	Status int
+
+	// The number of body bytes sent.
+	Size int64
+
+	// The headers sent with the response, counted in the response size.
+	Header http.Header
+
+	// The time the request was received, the latency is measured from it.
+	Start time.Time
+
+	// The processing latency of the request, used instead of the time since
+	// Start if set.
+	Latency time.Duration
+
+	// The IP address of the server that handled the request.
+	ServerIP string
+
+	// The cache status of the response. CacheFillBytes is only used when
+	// greater than zero.
+	CacheLookup                    bool
+	CacheHit                       bool
+	CacheValidatedWithOriginServer bool
+	CacheFillBytes                 int64
+}
+
+// NewHTTPFromResponse returns the HTTPPayload of a request handled by a server,
+// computing the fields that are easily gotten wrong:
+//
+//	res := &zapdriver.ResponseInfo{Status: status, Size: written, Start: start}
+//	logger.Info("Request handled.", zapdriver.HTTP(zapdriver.NewHTTPFromResponse(r, res)))
+//
+// Request and response sizes include the headers, the latency is formatted as
+// Cloud Logging expects it, and the remote IP is that of the client in the
+// "X-Forwarded-For" header, if any, as set by load balancers. Credentials in the
+// URL and "Referer" header are removed. Either argument can be nil.
+func NewHTTPFromResponse(req *http.Request, res *ResponseInfo) *HTTPPayload {
+	if req == nil {
+		req = &http.Request{}
+	}
+
+	if res == nil {
+		res = &ResponseInfo{}
+	}
+
+	p := &HTTPPayload{
+		RequestMethod:                  req.Method,
+		Status:                         res.Status,
+		UserAgent:                      req.UserAgent(),
+		RemoteIP:                       clientIP(req),
+		ServerIP:                       res.ServerIP,
+		Referer:                        scrubURL(req.Referer()),
+		Protocol:                       requestProtocol(req),
+		CacheLookup:                    res.CacheLookup,
+		CacheHit:                       res.CacheHit,
+		CacheValidatedWithOriginServer: res.CacheValidatedWithOriginServer,
+	}
+
+	if req.URL != nil {
+		p.RequestURL = scrubURL(requestURL(req))
+	}
+
+	if req.Method != "" {
+		size := headerSize(req.Header) + int64(len(req.Method)+len(req.RequestURI)+len(req.Proto)+4)
+		if req.ContentLength > 0 {
+			size += req.ContentLength
+		}
+		p.RequestSize = strconv.FormatInt(size, 10)
+	}
+
+	if res.Status != 0 {
+		p.ResponseSize = strconv.FormatInt(res.Size+headerSize(res.Header), 10)
+	}
+
+	latency := res.Latency
+	if latency == 0 && !res.Start.IsZero() {
+		latency = time.Since(res.Start)
+	}
+	if latency > 0 {
+		p.Latency = formatLatency(latency)
+	}
+
+	if res.CacheFillBytes > 0 {
+		p.CacheFillBytes = strconv.FormatInt(res.CacheFillBytes, 10)
+	}
+
+	return p
+}
+
+// formatLatency formats a duration as a number of seconds, terminated by 's'.
+func formatLatency(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// headerSize returns the size of the headers, each "Key: value\r\n", and the
+// empty line ending them.
+func headerSize(h http.Header) int64 {
+	size := int64(2)
+	for k, vs := range h {
+		for _, v := range vs {
+			size += int64(len(k) + len(v) + 4)
+		}
+	}
+
+	return size
+}
+
+// clientIP returns the IP address of the client of a request: the first valid
+// address of the "X-Forwarded-For" header, or the remote address.
+func clientIP(req *http.Request) string {
+	for _, header := range req.Header["X-Forwarded-For"] {
+		for _, addr := range strings.Split(header, ",") {
+			if ip := net.ParseIP(strings.TrimSpace(addr)); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+
+	return remoteIP(req.RemoteAddr)
+}
+
+// requestProtocol returns the protocol of a request, "websocket" for upgraded
+// websocket connections.
+func requestProtocol(req *http.Request) string {
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return "websocket"
+	}
+
+	return req.Proto
+}
+
+// scrubURL removes the user information, like a password, from a URL.
+func scrubURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+
+	u.User = nil
+	return u.String()
+}
+
 // MarshalLogObject implements zapcore.ObjectMarshaller interface.
 func (req HTTPPayload) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	enc.AddString("requestMethod", req.RequestMethod)
@@ -0,0 +1,111 @@
+package zapdriver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"golang.org/x/oauth2"
+	iamcredentials "google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+// WithImpersonation makes the client write entries as the `target` service
+// account, using short-lived access tokens generated with the default
+// credentials of the process. This allows centralized-logging architectures in
+// which workloads write into the logs of another project, without distributing
+// service account keys.
+//
+// The default credentials need the `roles/iam.serviceAccountTokenCreator` role
+// on the target, or on the first of the `delegates`, a chain of service
+// accounts each able to impersonate the next one. Impersonation always starts
+// from the Application Default Credentials: credentials passed using
+// `WithClientOptions` are not used to generate the tokens.
+func WithImpersonation(target string, delegates ...string) ClientOption {
+	return func(c *clientConfig) {
+		c.impersonation = &impersonatedTokenSource{
+			target:    target,
+			delegates: delegates,
+		}
+	}
+}
+
+// WithScopes sets the OAuth2 scopes of the credentials of the client, also when
+// impersonating a service account. It defaults to the Cloud Logging write
+// scope when impersonating, and to the scopes of `logging.NewClient` otherwise.
+func WithScopes(scopes ...string) ClientOption {
+	return func(c *clientConfig) {
+		c.scopes = append(c.scopes, scopes...)
+	}
+}
+
+// WithAudiences sets the audiences of the self-signed JWTs the client
+// authenticates with, when using service account credentials.
+func WithAudiences(audiences ...string) ClientOption {
+	return WithClientOptions(option.WithAudiences(audiences...))
+}
+
+// credentialOptions returns the client options for the configured scopes and
+// impersonation.
+func (c *clientConfig) credentialOptions() []option.ClientOption {
+	if ts := c.impersonation; ts != nil {
+		ts.scopes = c.scopes
+		if len(ts.scopes) == 0 {
+			ts.scopes = []string{logging.WriteScope}
+		}
+
+		return []option.ClientOption{option.WithTokenSource(oauth2.ReuseTokenSource(nil, ts))}
+	}
+
+	if len(c.scopes) > 0 {
+		return []option.ClientOption{option.WithScopes(c.scopes...)}
+	}
+
+	return nil
+}
+
+// impersonatedTokenSource generates access tokens of a service account using
+// the IAM Credentials API.
+type impersonatedTokenSource struct {
+	target    string
+	delegates []string
+	scopes    []string
+
+	once    sync.Once
+	service *iamcredentials.Service
+	err     error
+}
+
+// Token implements the oauth2.TokenSource interface.
+func (ts *impersonatedTokenSource) Token() (*oauth2.Token, error) {
+	ts.once.Do(func() {
+		ts.service, ts.err = iamcredentials.NewService(context.Background())
+	})
+	if ts.err != nil {
+		return nil, ts.err
+	}
+
+	delegates := make([]string, len(ts.delegates))
+	for i, d := range ts.delegates {
+		delegates[i] = serviceAccountName(d)
+	}
+
+	req := &iamcredentials.GenerateAccessTokenRequest{Delegates: delegates, Scope: ts.scopes}
+	res, err := ts.service.Projects.ServiceAccounts.GenerateAccessToken(serviceAccountName(ts.target), req).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	expiry, err := time.Parse(time.RFC3339, res.ExpireTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Token{AccessToken: res.AccessToken, TokenType: "Bearer", Expiry: expiry}, nil
+}
+
+// serviceAccountName returns the resource name of a service account.
+func serviceAccountName(email string) string {
+	return "projects/-/serviceAccounts/" + email
+}
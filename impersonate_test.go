@@ -0,0 +1,62 @@
+package zapdriver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	iamcredentials "google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+func TestImpersonatedTokenSource(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]interface{}
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(`{"accessToken":"token","expireTime":"2030-01-02T15:04:05Z"}`))
+	}))
+	defer srv.Close()
+
+	config := &clientConfig{}
+	WithImpersonation("writer@central.iam.gserviceaccount.com", "hop@app.iam.gserviceaccount.com")(config)
+	require.Len(t, config.credentialOptions(), 1)
+
+	// The service is created up front, to use the test server.
+	ts := config.impersonation
+	ts.once.Do(func() {
+		ts.service, ts.err = iamcredentials.NewService(context.Background(),
+			option.WithEndpoint(srv.URL+"/"), option.WithHTTPClient(srv.Client()))
+	})
+
+	token, err := ts.Token()
+	require.NoError(t, err)
+
+	assert.Equal(t, "token", token.AccessToken)
+	assert.Equal(t, 2030, token.Expiry.Year())
+	assert.Equal(t, "/v1/projects/-/serviceAccounts/writer@central.iam.gserviceaccount.com:generateAccessToken", path)
+	assert.Equal(t, []interface{}{logging.WriteScope}, body["scope"])
+	assert.Equal(t, []interface{}{"projects/-/serviceAccounts/hop@app.iam.gserviceaccount.com"}, body["delegates"])
+}
+
+func TestCredentialOptions(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, (&clientConfig{}).credentialOptions())
+
+	config := &clientConfig{}
+	WithScopes("https://www.googleapis.com/auth/cloud-platform")(config)
+	assert.Len(t, config.credentialOptions(), 1)
+
+	WithImpersonation("writer@central.iam.gserviceaccount.com")(config)
+	config.credentialOptions()
+	assert.Equal(t, []string{"https://www.googleapis.com/auth/cloud-platform"}, config.impersonation.scopes)
+}
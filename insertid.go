@@ -0,0 +1,112 @@
+package zapdriver
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap"
+)
+
+const insertIDKey = "logging.googleapis.com/insertId"
+
+// InsertID adds the correct Stackdriver "insertId" field.
+//
+// Entries sent to the Cloud Logging API get their insert ID from this field.
+// Cloud Logging deduplicates entries with the same timestamp and insert ID, so
+// an application that retries deliveries itself can use a stable ID, derived
+// from the entry, to make them idempotent. An explicit insert ID takes
+// precedence over the one generated by `AutoInsertID`.
+func InsertID(id string) zap.Field {
+	return zap.String(insertIDKey, id)
+}
+
+// AutoInsertID gives every entry sent to the Cloud Logging API a unique insert
+// ID.
+//
+// Cloud Logging deduplicates entries with the same timestamp and insert ID.
+// The ID is generated once per entry, before it is handed to the client, so
+// when the client retries a failed write (it does so for "unavailable",
+// "internal" and "deadline exceeded" errors), the retried entries are stored
+// only once, even if the failed attempt was partially processed.
+func AutoInsertID() func(*core) {
+	return func(c *core) {
+		c.config.InsertIDs = newInsertIDGenerator()
+		c.config.InsertIDHasher = nil
+	}
+}
+
+// HashedInsertID derives the insert ID of every entry sent to the Cloud Logging
+// API from a hash of its timestamp, truncated to `bucket`, its message, source
+// location, and the values of the payload fields with the given keys:
+//
+//	zapdriver.HashedInsertID(time.Second, "order_id")
+//
+// Cloud Logging deduplicates entries with the same timestamp and insert ID, so
+// the timestamp of the entry is truncated to the bucket as well. Identical
+// entries emitted within the same bucket, by retries of the client or by
+// multiple replicas processing the same event, are stored only once. Entries
+// that are only distinguished by other fields are collapsed too, so select the
+// keys identifying an entry. A bucket of zero keeps the timestamp as-is, and
+// only collapses retries.
+//
+// It replaces `AutoInsertID`, and an explicit `InsertID` takes precedence.
+func HashedInsertID(bucket time.Duration, keys ...string) func(*core) {
+	return func(c *core) {
+		c.config.InsertIDHasher = &insertIDHasher{bucket: bucket, keys: keys}
+		c.config.InsertIDs = nil
+	}
+}
+
+// insertIDGenerator generates insert IDs that are unique across processes, by
+// combining a random prefix with a sequence number.
+type insertIDGenerator struct {
+	prefix string
+	seq    uint64
+}
+
+func newInsertIDGenerator() *insertIDGenerator {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return &insertIDGenerator{prefix: hex.EncodeToString(b)}
+}
+
+func (g *insertIDGenerator) next() string {
+	return g.prefix + "-" + strconv.FormatUint(atomic.AddUint64(&g.seq, 1), 10)
+}
+
+// insertIDHasher derives insert IDs from the contents of entries, see
+// `HashedInsertID`.
+type insertIDHasher struct {
+	bucket time.Duration
+	keys   []string
+}
+
+// hash truncates the timestamp of the entry to the bucket, and sets its insert
+// ID to the hash of its contents.
+func (h *insertIDHasher) hash(ent *logging.Entry, message string) {
+	if h.bucket > 0 {
+		ent.Timestamp = ent.Timestamp.Truncate(h.bucket)
+	}
+
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%d\x00%s\x00", ent.Timestamp.UnixNano(), message)
+	if loc := ent.SourceLocation; loc != nil {
+		fmt.Fprintf(sum, "%s:%d\x00", loc.File, loc.Line)
+	}
+
+	payload, _ := ent.Payload.(map[string]interface{})
+	for _, key := range h.keys {
+		if value, ok := payload[key]; ok {
+			fmt.Fprintf(sum, "%s=%v\x00", key, value)
+		}
+	}
+
+	ent.InsertID = hex.EncodeToString(sum.Sum(nil)[:16])
+}
@@ -0,0 +1,150 @@
+package zapdriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestInsertIDGenerator(t *testing.T) {
+	t.Parallel()
+
+	g1, g2 := newInsertIDGenerator(), newInsertIDGenerator()
+
+	assert.NotEqual(t, g1.next(), g1.next())
+	assert.NotEqual(t, g1.next(), g2.next())
+}
+
+func TestWriteAutoInsertID_IdempotentRetry(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	core := &core{
+		Core:       zapcore.NewNopCore(),
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	AutoInsertID()(core)
+
+	// The first write is stored, but the client never hears back, and retries.
+	server.loseResponses(1)
+
+	require.NoError(t, core.Write(zapcore.Entry{Message: "once"}, nil))
+	require.NoError(t, core.Write(zapcore.Entry{Message: "twice"}, nil))
+	require.NoError(t, core.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+	assert.NotEmpty(t, entries[0].InsertId)
+	assert.NotEqual(t, entries[0].InsertId, entries[1].InsertId)
+}
+
+func TestWrite_DuplicatesWithoutInsertID(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	core := &core{
+		Core:       zapcore.NewNopCore(),
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+
+	server.loseResponses(1)
+
+	require.NoError(t, core.Write(zapcore.Entry{Message: "once"}, nil))
+	require.NoError(t, core.Sync())
+
+	assert.Len(t, server.Entries(), 2)
+}
+
+func TestWriteInsertID(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	AutoInsertID()(core)
+
+	logger := zap.New(core)
+	logger.Info("explicit", InsertID("payment-42"))
+	logger.Info("generated")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "payment-42", entries[0].InsertId)
+	assert.NotEmpty(t, entries[1].InsertId)
+	assert.NotEqual(t, "payment-42", entries[1].InsertId)
+}
+
+func TestWriteInsertID_WithoutAutoInsertID(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	logger.Info("explicit", InsertID("payment-42"))
+	logger.Info("none")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "payment-42", entries[0].InsertId)
+	assert.NotNil(t, entries[0].Timestamp)
+}
+
+func TestWriteHashedInsertID(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	HashedInsertID(time.Second, "order_id")(core)
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	write := func(offset time.Duration, msg string, fields ...zapcore.Field) {
+		require.NoError(t, core.Write(zapcore.Entry{Time: start.Add(offset), Message: msg}, fields))
+	}
+
+	write(100*time.Millisecond, "captured", zap.String("order_id", "1"), zap.String("replica", "a"))
+	write(800*time.Millisecond, "captured", zap.String("order_id", "1"), zap.String("replica", "b"))
+	write(200*time.Millisecond, "captured", zap.String("order_id", "2"))
+	write(1200*time.Millisecond, "captured", zap.String("order_id", "1"))
+	write(300*time.Millisecond, "refunded", zap.String("order_id", "1"))
+	write(400*time.Millisecond, "refunded", InsertID("explicit"))
+	require.NoError(t, core.Sync())
+
+	// The entries of both replicas are collapsed by the server.
+	entries := server.Entries()
+	require.Len(t, entries, 5)
+
+	assert.Len(t, entries[0].InsertId, 32)
+	assert.Equal(t, "a", entries[0].GetJsonPayload().Fields["replica"].GetStringValue())
+	assert.Equal(t, start.Unix(), entries[0].Timestamp.Seconds)
+	assert.Equal(t, int32(0), entries[0].Timestamp.Nanos)
+
+	ids := map[string]bool{}
+	for _, entry := range entries {
+		ids[entry.InsertId] = true
+	}
+	assert.Len(t, ids, 5)
+	assert.Equal(t, "explicit", entries[4].InsertId)
+}
+
+func TestHashedInsertID_ReplacesAutoInsertID(t *testing.T) {
+	t.Parallel()
+
+	core := &core{}
+	AutoInsertID()(core)
+	HashedInsertID(0)(core)
+
+	assert.Nil(t, core.config.InsertIDs)
+	require.NotNil(t, core.config.InsertIDHasher)
+
+	AutoInsertID()(core)
+	assert.Nil(t, core.config.InsertIDHasher)
+}
@@ -0,0 +1,43 @@
+package zapdriver
+
+import (
+	"go.uber.org/zap"
+)
+
+// InheritedLabels returns a copy of the labels the logger adds to every entry,
+// as added using `logger.With()`. It returns nil if the logger doesn't use the
+// zapdriver core.
+func InheritedLabels(logger *zap.Logger) map[string]string {
+	c, ok := logger.Core().(*core)
+	if !ok {
+		return nil
+	}
+
+	return c.permLabels.snapshot()
+}
+
+// InheritedFields returns a copy of the fields (other than labels) the logger
+// adds to every entry, as added using `logger.With()`. It returns nil if the
+// logger doesn't use the zapdriver core.
+func InheritedFields(logger *zap.Logger) []zap.Field {
+	c, ok := logger.Core().(*core)
+	if !ok {
+		return nil
+	}
+
+	return append([]zap.Field{}, c.fields...)
+}
+
+// HasField reports whether the logger already adds a field with the given key
+// to every entry, so middlewares can avoid duplicating context.
+func HasField(logger *zap.Logger, key string) bool {
+	for _, f := range InheritedFields(logger) {
+		if f.Key == key {
+			return true
+		}
+	}
+
+	_, ok := InheritedLabels(logger)[key]
+
+	return ok
+}
@@ -0,0 +1,45 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestInheritedLabelsAndFields(t *testing.T) {
+	t.Parallel()
+
+	logger := zap.New(&core{Core: zapcore.NewNopCore(), permLabels: newLabels()}).
+		With(zap.String("hello", "world"), Label("tenant", "acme"))
+
+	assert.Equal(t, map[string]string{"tenant": "acme"}, InheritedLabels(logger))
+	assert.Equal(t, []zap.Field{zap.String("hello", "world")}, InheritedFields(logger))
+
+	assert.True(t, HasField(logger, "hello"))
+	assert.True(t, HasField(logger, "tenant"))
+	assert.False(t, HasField(logger, "trace"))
+}
+
+func TestInheritedLabelsAndFields_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	logger := zap.New(&core{Core: zapcore.NewNopCore(), permLabels: newLabels()}).
+		With(zap.String("hello", "world"), Label("tenant", "acme"))
+
+	InheritedLabels(logger)["tenant"] = "changed"
+	InheritedFields(logger)[0] = zap.String("hello", "changed")
+
+	assert.Equal(t, map[string]string{"tenant": "acme"}, InheritedLabels(logger))
+	assert.Equal(t, []zap.Field{zap.String("hello", "world")}, InheritedFields(logger))
+}
+
+func TestInheritedLabelsAndFields_OtherCore(t *testing.T) {
+	t.Parallel()
+
+	logger := zap.NewNop()
+
+	assert.Nil(t, InheritedLabels(logger))
+	assert.Nil(t, InheritedFields(logger))
+}
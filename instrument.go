@@ -0,0 +1,158 @@
+package zapdriver
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/option"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+	"google.golang.org/grpc"
+)
+
+// Reasons entries are dropped, as counted by `Metrics`.
+const (
+	dropSampler     = "sampler"
+	dropQueue       = "queue"
+	dropTenantQuota = "tenant_quota"
+)
+
+// Metrics instruments the delivery of entries to Cloud Logging, so the health of
+// the logging pipeline itself can be monitored. It's a `prometheus.Collector`:
+//
+//	metrics := zapdriver.NewMetrics()
+//	prometheus.MustRegister(metrics)
+//
+//	zapdriver.WrapCore(zapdriver.WithLogger(lg), zapdriver.WithMetrics(metrics))
+//
+// To instrument several loggers, register the metrics of each of them using
+// `prometheus.WrapRegistererWith`, with a label telling them apart.
+type Metrics struct {
+	entries  *prometheus.CounterVec
+	bytes    prometheus.Counter
+	errors   prometheus.Counter
+	dropped  *prometheus.CounterVec
+	duration prometheus.Histogram
+}
+
+// NewMetrics returns the metrics of a logger:
+//
+//	zapdriver_entries_total          entries sent, by severity
+//	zapdriver_sent_bytes_total       size of the requests delivered to Cloud Logging
+//	zapdriver_client_errors_total    failures to deliver entries
+//	zapdriver_dropped_entries_total  entries dropped, by reason ("sampler", "queue" or "tenant_quota")
+//	zapdriver_write_duration_seconds latency of the requests writing entries
+//
+// The bytes sent and the write duration are measured on the requests of the
+// client, every attempt of a retried request being timed separately.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "zapdriver",
+			Name:      "entries_total",
+			Help:      "Number of entries sent to Cloud Logging, by severity.",
+		}, []string{"severity"}),
+		bytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "zapdriver",
+			Name:      "sent_bytes_total",
+			Help:      "Size of the requests delivered to Cloud Logging.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "zapdriver",
+			Name:      "client_errors_total",
+			Help:      "Number of failures to deliver entries to Cloud Logging.",
+		}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "zapdriver",
+			Name:      "dropped_entries_total",
+			Help:      "Number of entries dropped before being sent, by reason.",
+		}, []string{"reason"}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "zapdriver",
+			Name:      "write_duration_seconds",
+			Help:      "Latency of the requests writing entries to Cloud Logging.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		}),
+	}
+}
+
+// WithMetrics records the metrics of the logger. The requests and delivery
+// failures of the client are only measured for clients created by the
+// constructors of this package (see `ClientOptions`).
+func WithMetrics(m *Metrics) func(*core) {
+	return func(c *core) {
+		c.config.Metrics = m
+		c.config.ClientOptions = append(c.config.ClientOptions,
+			WithOnError(m.clientError),
+			WithClientOptions(option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(m.intercept))),
+		)
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.entries.Describe(ch)
+	m.bytes.Describe(ch)
+	m.errors.Describe(ch)
+	m.dropped.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.entries.Collect(ch)
+	m.bytes.Collect(ch)
+	m.errors.Collect(ch)
+	m.dropped.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// The methods recording metrics are no-ops on nil metrics, so the core doesn't
+// have to check whether it's instrumented.
+
+// sent records an entry handed to the client.
+func (m *Metrics) sent(ent *logging.Entry) {
+	if m == nil {
+		return
+	}
+
+	m.entries.WithLabelValues(ent.Severity.String()).Inc()
+}
+
+// intercept times the requests writing entries, and records the size of the
+// delivered ones.
+func (m *Metrics) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	wreq, ok := req.(*logpb.WriteLogEntriesRequest)
+	if !ok {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	m.duration.Observe(time.Since(start).Seconds())
+	if err == nil {
+		m.bytes.Add(float64(proto.Size(wreq)))
+	}
+
+	return err
+}
+
+// clientError records a failure to deliver entries.
+func (m *Metrics) clientError(error) {
+	if m == nil {
+		return
+	}
+
+	m.errors.Inc()
+}
+
+// drop records an entry dropped for the given reason.
+func (m *Metrics) drop(reason string) {
+	if m == nil {
+		return
+	}
+
+	m.dropped.WithLabelValues(reason).Inc()
+}
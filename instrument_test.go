@@ -0,0 +1,75 @@
+package zapdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+func TestWithMetrics(t *testing.T) {
+	addr, server := newFakeServer(t)
+
+	metrics := NewMetrics()
+	logger, cleanup, err := NewCloudProduction(context.Background(), "test-project", "app",
+		ClientOptions(
+			WithEndpoint(addr),
+			WithClientOptions(option.WithoutAuthentication(), option.WithGRPCDialOption(grpc.WithInsecure())),
+		),
+		SynchronousWrites(),
+		WithLogNameSampling(map[string]float64{"noisy": 0}),
+		WithMetrics(metrics),
+	)
+	require.NoError(t, err)
+	defer cleanup()
+
+	logger.Info("hello")
+	logger.Info("world")
+	logger.Error("failed")
+	logger.Named("noisy").Info("dropped")
+
+	server.setError(errors.New("unavailable"))
+	logger.Info("lost")
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.entries.WithLabelValues("Info")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.entries.WithLabelValues("Error")))
+	assert.True(t, testutil.ToFloat64(metrics.bytes) > 0)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.errors))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.dropped.WithLabelValues(dropSampler)))
+
+	// Every request is timed, including the failed one.
+	var m dto.Metric
+	require.NoError(t, metrics.duration.Write(&m))
+	assert.Equal(t, uint64(4), m.GetHistogram().GetSampleCount())
+}
+
+func TestMetrics_Register(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(NewMetrics()))
+
+	// The metrics of several loggers are told apart using a label.
+	registry = prometheus.NewRegistry()
+	for _, name := range []string{"app", "audit"} {
+		wrapped := prometheus.WrapRegistererWith(prometheus.Labels{"logger": name}, registry)
+		assert.NoError(t, wrapped.Register(NewMetrics()))
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	t.Parallel()
+
+	var metrics *Metrics
+	assert.NotPanics(t, func() {
+		metrics.clientError(errors.New("failed"))
+		metrics.drop(dropQueue)
+	})
+}
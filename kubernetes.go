@@ -0,0 +1,115 @@
+package zapdriver
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/compute/metadata"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+// KubernetesMetadata adds the pod, namespace, node and container the process
+// runs in as permanent labels to every entry, and attaches the matching
+// `k8s_container` resource to entries sent to the Cloud Logging API.
+//
+// The metadata is read from environment variables, set using the downward
+// API:
+//
+//	env:
+//	- name: POD_NAME
+//	  valueFrom: {fieldRef: {fieldPath: metadata.name}}
+//	- name: POD_NAMESPACE
+//	  valueFrom: {fieldRef: {fieldPath: metadata.namespace}}
+//	- name: NODE_NAME
+//	  valueFrom: {fieldRef: {fieldPath: spec.nodeName}}
+//	- name: CONTAINER_NAME
+//	  value: my-container
+//
+// The cluster name and location are read from the metadata server. Detection
+// happens once per process, and is cached.
+func KubernetesMetadata() func(*core) {
+	return func(c *core) {
+		k8s := detectKubernetes()
+
+		c.addPermLabels(k8s.labels)
+		c.config.Resource = k8s.resource
+	}
+}
+
+// kubernetesMetadata is the metadata of the pod the process runs in.
+type kubernetesMetadata struct {
+	labels   map[string]string
+	resource *mrpb.MonitoredResource
+}
+
+var detectedKubernetes struct {
+	once     sync.Once
+	metadata kubernetesMetadata
+}
+
+// detectKubernetes returns the metadata of the current pod, detecting it on
+// first use.
+func detectKubernetes() kubernetesMetadata {
+	detectedKubernetes.once.Do(func() {
+		d := &resourceDetector{
+			getenv:   os.Getenv,
+			onGCE:    metadata.OnGCE,
+			metadata: metadata.Get,
+			readFile: ioutil.ReadFile,
+		}
+		detectedKubernetes.metadata = d.kubernetes()
+	})
+
+	return detectedKubernetes.metadata
+}
+
+// kubernetes returns the labels and resource describing the current pod.
+func (d *resourceDetector) kubernetes() kubernetesMetadata {
+	resource := d.k8sContainer()
+
+	labels := map[string]string{}
+	for key, value := range map[string]string{
+		"pod_name":       resource.Labels["pod_name"],
+		"namespace_name": resource.Labels["namespace_name"],
+		"container_name": resource.Labels["container_name"],
+		"node_name":      d.getenv("NODE_NAME"),
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+
+	return kubernetesMetadata{labels: labels, resource: resource}
+}
+
+// k8sContainer returns the `k8s_container` resource of the current container.
+// The downward API variables take precedence over the hostname and the
+// namespace of the service account.
+func (d *resourceDetector) k8sContainer() *mrpb.MonitoredResource {
+	namespace := firstNonEmpty(d.getenv("POD_NAMESPACE"), d.getenv("NAMESPACE"))
+	if namespace == "" {
+		b, _ := d.readFile(namespaceFile)
+		namespace = strings.TrimSpace(string(b))
+	}
+
+	return d.resource("k8s_container", map[string]string{
+		"location":       d.get("instance/attributes/cluster-location"),
+		"cluster_name":   d.get("instance/attributes/cluster-name"),
+		"namespace_name": namespace,
+		"pod_name":       firstNonEmpty(d.getenv("POD_NAME"), d.getenv("HOSTNAME")),
+		"container_name": d.getenv("CONTAINER_NAME"),
+	})
+}
+
+// firstNonEmpty returns the first of the values that isn't empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,51 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+func TestResourceDetector_Kubernetes(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+		"HOSTNAME":                "my-host",
+		"POD_NAME":                "my-pod",
+		"POD_NAMESPACE":           "payments",
+		"NODE_NAME":               "node-1",
+		"CONTAINER_NAME":          "app",
+	}
+
+	got := newTestResourceDetector(env, true).kubernetes()
+
+	assert.Equal(t, map[string]string{
+		"pod_name":       "my-pod",
+		"namespace_name": "payments",
+		"node_name":      "node-1",
+		"container_name": "app",
+	}, got.labels)
+	assert.Equal(t, &mrpb.MonitoredResource{Type: "k8s_container", Labels: map[string]string{
+		"project_id":     "my-project",
+		"location":       "europe-west1",
+		"cluster_name":   "my-cluster",
+		"namespace_name": "payments",
+		"pod_name":       "my-pod",
+		"container_name": "app",
+	}}, got.resource)
+}
+
+func TestKubernetesMetadata(t *testing.T) {
+	detectedKubernetes.once.Do(func() {})
+	detectedKubernetes.metadata = newTestResourceDetector(map[string]string{"POD_NAME": "my-pod", "NODE_NAME": "node-1"}, true).kubernetes()
+
+	perm := newLabels()
+	c := &core{permLabels: perm}
+	KubernetesMetadata()(c)
+
+	assert.Equal(t, map[string]string{"pod_name": "my-pod", "namespace_name": "default", "node_name": "node-1"}, c.permLabels.store)
+	assert.Empty(t, perm.store)
+	assert.Equal(t, "k8s_container", c.config.Resource.Type)
+}
@@ -1,8 +1,14 @@
 package zapdriver
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -20,58 +26,351 @@ func Label(key, value string) zap.Field {
 	return zap.String("labels."+key, value)
 }
 
+// LabelInt adds a label with an integer value, formatted in base 10.
+func LabelInt(key string, value int) zap.Field {
+	return Label(key, strconv.Itoa(value))
+}
+
+// LabelInt64 adds a label with an integer value, formatted in base 10.
+func LabelInt64(key string, value int64) zap.Field {
+	return Label(key, strconv.FormatInt(value, 10))
+}
+
+// LabelBool adds a label with the value "true" or "false".
+func LabelBool(key string, value bool) zap.Field {
+	return Label(key, strconv.FormatBool(value))
+}
+
+// LabelFloat64 adds a label with a floating point value, in the shortest
+// representation, for example "1.5".
+func LabelFloat64(key string, value float64) zap.Field {
+	return Label(key, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// LabelDuration adds a label with a duration value, formatted like "1.5s".
+func LabelDuration(key string, value time.Duration) zap.Field {
+	return Label(key, value.String())
+}
+
+// Labelf adds a label with a value formatted using `fmt.Sprintf`.
+func Labelf(key, format string, args ...interface{}) zap.Field {
+	return Label(key, fmt.Sprintf(format, args...))
+}
+
 // Labels takes Zap fields, filters the ones that have their key start with the
-// string `labels.` and their value type set to StringType. It then wraps those
-// key/value pairs in a top-level `labels` namespace.
+// string `labels.` (see `isLabelField`). It then wraps those key/value pairs in
+// a top-level `labels` namespace.
 func Labels(fields ...zap.Field) zap.Field {
 	lbls := newLabels()
+	for i := range fields {
+		addLabelField(lbls.store, fields[i], rawLabelKey)
+	}
+
+	return labelsField(lbls)
+}
+
+// LabelMap adds every key/value pair of the map as a label, for example to
+// attach request metadata in bulk:
+//
+//	logger.Info("Request handled.", zapdriver.LabelMap(metadata))
+//
+// The map is copied, so it can be modified afterwards.
+func LabelMap(m map[string]string) zap.Field {
+	lbls := newLabels()
+	for k, v := range m {
+		lbls.store[k] = v
+	}
+
+	return labelsField(lbls)
+}
+
+// LabelsObject adds the labels contributed by the `MarshalLogObject`
+// implementation of `obj`, so domain objects can define their own canonical
+// label set. Values other than strings are formatted using `fmt.Sprint`.
+//
+// Without the zapdriver core, the labels are added as a "labels" object.
+func LabelsObject(obj zapcore.ObjectMarshaler) zap.Field {
+	return zap.Object("labels", labelsObject{obj})
+}
+
+// labelsObject marks an object contributing labels.
+type labelsObject struct {
+	zapcore.ObjectMarshaler
+}
+
+// LabelPrecedence decides which value is used when a label added to a single
+// entry has the same key as a label inherited through `With()`.
+type LabelPrecedence int
+
+const (
+	// EntryLabelsWin lets per-entry labels override inherited labels. This is
+	// the default.
+	EntryLabelsWin LabelPrecedence = iota
+
+	// InheritedLabelsWin makes inherited labels immutable, per-entry labels
+	// with the same key are ignored.
+	InheritedLabelsWin
+)
+
+// WithLabelPrecedence configures the precedence between inherited and
+// per-entry labels.
+func WithLabelPrecedence(p LabelPrecedence) func(*core) {
+	return func(c *core) {
+		c.config.LabelPrecedence = p
+	}
+}
+
+// WithCommonLabels adds a static set of labels, such as the cluster, region or
+// team, to every entry of the logger. Inherited and per-entry labels with the
+// same key override them, and they're not returned by `InheritedLabels`.
+func WithCommonLabels(common map[string]string) func(*core) {
+	return func(c *core) {
+		lbls := newLabels()
+		for k, v := range common {
+			lbls.store[k] = v
+		}
+		c.config.CommonLabels = lbls
+	}
+}
+
+// withCommonLabels returns the labels of an entry, completed with the common
+// labels. The common labels are returned as-is if the entry has no other
+// labels, so they must not be modified.
+func (c *core) withCommonLabels(lbls *labels) *labels {
+	common := c.config.CommonLabels
+	if common == nil || len(common.store) == 0 {
+		return lbls
+	}
+	if len(lbls.store) == 0 {
+		return common
+	}
+
+	c.config.Stats.labelMerge()
+
+	// Merged sets are owned by the entry, the permanent labels are shared.
+	if lbls == c.permLabels {
+		lbls = lbls.clone()
+	}
+	for k, v := range common.store {
+		if _, ok := lbls.store[k]; !ok {
+			lbls.store[k] = v
+		}
+	}
+
+	return lbls
+}
+
+// addPermLabels adds labels to the permanent labels of a core that's being
+// configured. The labels are copied first, as they can be shared.
+func (c *core) addPermLabels(add map[string]string) {
+	if len(add) == 0 {
+		return
+	}
+
+	lbls := c.permLabels.clone()
+	for k, v := range add {
+		lbls.store[k] = v
+	}
+	c.permLabels = lbls
+}
+
+// addLabelField adds the labels of the field to the store, and reports whether
+// the field holds labels.
+func addLabelField(store map[string]string, field zap.Field, key func(string) string) bool {
+	if isLabelField(field) {
+		store[key(strings.Replace(field.Key, "labels.", "", 1))] = labelValue(field)
+		return true
+	}
+
+	obj, ok := labelsObjectOf(field)
+	if !ok {
+		return false
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	_ = obj.MarshalLogObject(enc)
+	for k, v := range enc.Fields {
+		if s, ok := v.(string); ok {
+			store[key(k)] = s
+			continue
+		}
+
+		store[key(k)] = fmt.Sprint(v)
+	}
+
+	return true
+}
+
+// labelsObjectOf returns the object holding the labels of the field, if any.
+func labelsObjectOf(field zap.Field) (zapcore.ObjectMarshaler, bool) {
+	if field.Type != zapcore.ObjectMarshalerType {
+		return nil, false
+	}
+
+	// Fields built by `Labels`, also those of the original blendle/zapdriver
+	// package, hold the labels in an object.
+	switch v := field.Interface.(type) {
+	case labelsObject:
+		return v, true
+	case zapcore.ObjectMarshaler:
+		return v, field.Key == labelsKey
+	}
+
+	return nil, false
+}
 
-	lbls.mutex.Lock()
+// hasLabelFields reports whether any of the fields holds labels.
+func hasLabelFields(fields []zap.Field) bool {
 	for i := range fields {
 		if isLabelField(fields[i]) {
-			lbls.store[strings.Replace(fields[i].Key, "labels.", "", 1)] = fields[i].String
+			return true
+		}
+		if _, ok := labelsObjectOf(fields[i]); ok {
+			return true
 		}
 	}
-	lbls.mutex.Unlock()
 
-	return labelsField(lbls)
+	return false
 }
 
+// isLabelField reports whether the field is a label: its key starts with
+// "labels.". Values other than strings are formatted as one (see `labelValue`),
+// so fields like `zap.Int("labels.count", 3)` aren't silently ignored.
 func isLabelField(field zap.Field) bool {
-	return strings.HasPrefix(field.Key, "labels.") && field.Type == zapcore.StringType
+	return isLabelKey(field.Key) && field.Type != zapcore.NamespaceType && field.Type != zapcore.SkipType
+}
+
+// isScalarField reports whether the value of the field is a string or a scalar.
+func isScalarField(field zap.Field) bool {
+	switch field.Type {
+	case zapcore.StringType, zapcore.BoolType, zapcore.DurationType,
+		zapcore.Float64Type, zapcore.Float32Type,
+		zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return true
+	}
+
+	return false
+}
+
+// labelValue returns the value of a label field, formatted as a string.
+// Values other than scalars are converted by `ToInterface`, and formatted as
+// JSON unless they're times or implement `fmt.Stringer`.
+func labelValue(field zap.Field) string {
+	switch field.Type {
+	case zapcore.StringType:
+		return field.String
+	case zapcore.BoolType:
+		return strconv.FormatBool(field.Integer == 1)
+	case zapcore.DurationType:
+		return time.Duration(field.Integer).String()
+	case zapcore.Float64Type:
+		return strconv.FormatFloat(math.Float64frombits(uint64(field.Integer)), 'g', -1, 64)
+	case zapcore.Float32Type:
+		return strconv.FormatFloat(float64(math.Float32frombits(uint32(field.Integer))), 'g', -1, 32)
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return strconv.FormatInt(field.Integer, 10)
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return strconv.FormatUint(uint64(field.Integer), 10)
+	case zapcore.ByteStringType:
+		return string(field.Interface.([]byte))
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok {
+			return err.Error()
+		}
+	}
+
+	v := ToInterface(field)
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+
+	return fmt.Sprint(v)
+}
+
+func isLabelKey(key string) bool {
+	return strings.HasPrefix(key, "labels.")
 }
 
 func labelsField(l *labels) zap.Field {
 	return zap.Object(labelsKey, l)
 }
 
+// labels is a set of labels. Sets are copy-on-write: a set is only modified
+// by the goroutine that created it, before it's shared with other cores or
+// handed to an encoder, so it's read without locking.
 type labels struct {
 	store map[string]string
-	mutex *sync.RWMutex
 }
 
 func newLabels() *labels {
-	return &labels{store: map[string]string{}, mutex: &sync.RWMutex{}}
+	return &labels{store: map[string]string{}}
+}
+
+// labelsPool holds the scratch sets the labels of single entries are collected
+// in, before they're merged with the permanent labels of the core.
+var labelsPool = sync.Pool{
+	New: func() interface{} { return newLabels() },
 }
 
-func (l *labels) Add(key, value string) {
-	l.mutex.Lock()
-	l.store[key] = value
-	l.mutex.Unlock()
+func getLabels() *labels {
+	return labelsPool.Get().(*labels)
 }
 
-func (l *labels) reset() {
-	l.mutex.Lock()
-	l.store = map[string]string{}
-	l.mutex.Unlock()
+// releaseLabels returns a scratch set to the pool. The set must not be used
+// afterwards.
+func releaseLabels(l *labels) {
+	if l == nil {
+		return
+	}
+
+	for k := range l.store {
+		delete(l.store, k)
+	}
+	labelsPool.Put(l)
 }
 
-func (l labels) MarshalLogObject(enc zapcore.ObjectEncoder) error {
-	l.mutex.RLock()
+// clone returns a copy of the labels, which can be modified.
+func (l *labels) clone() *labels {
+	return &labels{store: l.snapshot()}
+}
+
+// snapshot returns a copy of the labels.
+func (l *labels) snapshot() map[string]string {
+	out := make(map[string]string, len(l.store))
 	for k, v := range l.store {
-		enc.AddString(k, v)
+		out[k] = v
+	}
+
+	return out
+}
+
+func (l *labels) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if len(l.store) == 0 {
+		return nil
+	}
+
+	// The labels are encoded in key order, so the output is stable.
+	keys := make([]string, 0, len(l.store))
+	for k := range l.store {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		enc.AddString(k, l.store[k])
 	}
-	l.mutex.RUnlock()
 
 	return nil
 }
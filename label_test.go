@@ -1,10 +1,14 @@
 package zapdriver
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestLabel(t *testing.T) {
@@ -15,6 +19,57 @@ func TestLabel(t *testing.T) {
 	assert.Equal(t, zap.String("labels.key", "value"), field)
 }
 
+func TestTypedLabels(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Label("count", "3"), LabelInt("count", 3))
+	assert.Equal(t, Label("count", "-3"), LabelInt64("count", -3))
+	assert.Equal(t, Label("ok", "true"), LabelBool("ok", true))
+	assert.Equal(t, Label("ratio", "1.5"), LabelFloat64("ratio", 1.5))
+	assert.Equal(t, Label("timeout", "1.5s"), LabelDuration("timeout", 1500*time.Millisecond))
+	assert.Equal(t, Label("shard", "eu-3"), Labelf("shard", "%s-%d", "eu", 3))
+}
+
+func TestWriteLabelFields(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()})
+
+	logger.Info("hello",
+		zap.Int("labels.count", 3),
+		zap.Uint8("labels.shard", 7),
+		zap.Bool("labels.ok", false),
+		zap.Float32("labels.ratio", 0.25),
+		zap.Duration("labels.timeout", time.Second),
+		zap.Any("labels.object", map[string]string{"region": "eu"}),
+		zap.Stringer("labels.addr", addr("10.0.0.1")),
+		zap.Error(errors.New("boom")),
+		zap.NamedError("labels.error", errors.New("boom")),
+		zap.Time("labels.since", time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)),
+		zap.Ints("labels.ports", []int{80, 443}),
+	)
+
+	context := logs.All()[0].ContextMap()
+	assert.Equal(t, map[string]interface{}{
+		"count":   "3",
+		"shard":   "7",
+		"ok":      "false",
+		"ratio":   "0.25",
+		"timeout": "1s",
+		"object":  `{"region":"eu"}`,
+		"addr":    "10.0.0.1",
+		"error":   "boom",
+		"since":   "2019-01-02T03:04:05Z",
+		"ports":   "[80,443]",
+	}, context[labelsKey])
+	assert.NotContains(t, context, "labels.object")
+}
+
+type addr string
+
+func (a addr) String() string { return string(a) }
+
 func TestLabels(t *testing.T) {
 	t.Parallel()
 
@@ -28,3 +83,76 @@ func TestLabels(t *testing.T) {
 
 	assert.Equal(t, zap.Object(labelsKey, labels), field)
 }
+
+type tenant struct {
+	ID   string
+	Tier int
+}
+
+func (t tenant) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("tenant", t.ID)
+	enc.AddInt("tier", t.Tier)
+	return nil
+}
+
+func TestLabelsObject(t *testing.T) {
+	t.Parallel()
+
+	field := Labels(LabelsObject(tenant{ID: "acme", Tier: 2}), Label("hello", "world"))
+
+	labels := newLabels()
+	labels.store = map[string]string{"tenant": "acme", "tier": "2", "hello": "world"}
+
+	assert.Equal(t, zap.Object(labelsKey, labels), field)
+}
+
+func TestWriteLabelsObject(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()})
+
+	logger.With(LabelsObject(tenant{ID: "acme", Tier: 2})).Info("hello", Label("hi", "there"))
+
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "tier": "2", "hi": "there"}, fields[labelsKey])
+	assert.NotContains(t, fields, "labels")
+}
+
+func TestWriteLabelMap(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()})
+
+	metadata := map[string]string{"method": "GET", "Route": "/orders"}
+	field := LabelMap(metadata)
+	metadata["method"] = "POST"
+
+	logger.With(Label("tenant", "acme")).Info("hello", field, Label("hi", "there"))
+
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "method": "GET", "route": "/orders", "hi": "there"}, fields[labelsKey])
+}
+
+func TestWithCommonLabels(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	c := &core{Core: debugcore, permLabels: newLabels()}
+	WithCommonLabels(map[string]string{"region": "eu", "team": "core"})(c)
+	logger := zap.New(c)
+
+	logger.Info("common")
+	child := logger.With(Label("team", "payments"))
+	child.Info("inherited")
+	child.Info("entry", Label("region", "us"))
+
+	entries := logs.All()
+	assert.Equal(t, map[string]interface{}{"region": "eu", "team": "core"}, entries[0].ContextMap()[labelsKey])
+	assert.Equal(t, map[string]interface{}{"region": "eu", "team": "payments"}, entries[1].ContextMap()[labelsKey])
+	assert.Equal(t, map[string]interface{}{"region": "us", "team": "payments"}, entries[2].ContextMap()[labelsKey])
+
+	assert.Equal(t, map[string]string{"team": "payments"}, InheritedLabels(child))
+	assert.Equal(t, map[string]string{"region": "eu", "team": "core"}, c.config.CommonLabels.store)
+}
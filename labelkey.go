@@ -0,0 +1,85 @@
+package zapdriver
+
+import (
+	"strings"
+)
+
+// SanitizeLabelKey returns a label key that never breaks ingestion: it's
+// lowercased, characters other than letters, digits, "_", "-", "." and "/" are
+// replaced by "_", runs of dots are collapsed, and leading or trailing dots are
+// removed. For example "User ID..Hash" becomes "user_id.hash".
+//
+// The core applies it to the keys of labels added as fields (see `Label`), as
+// they're often generated dynamically.
+func SanitizeLabelKey(key string) string {
+	if validLabelKey(key) {
+		return key
+	}
+
+	var b strings.Builder
+	b.Grow(len(key))
+
+	dot := false
+	for _, r := range strings.ToLower(key) {
+		switch {
+		case r == '.':
+			if !dot && b.Len() > 0 {
+				dot = true
+			}
+			continue
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-', r == '/':
+		default:
+			r = '_'
+		}
+
+		if dot {
+			b.WriteByte('.')
+			dot = false
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// validLabelKey reports whether the key is left as-is by SanitizeLabelKey.
+func validLabelKey(key string) bool {
+	if strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		switch b := key[i]; {
+		case b >= 'a' && b <= 'z', b >= '0' && b <= '9', b == '_', b == '-', b == '.', b == '/':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// WithLabelKeySanitizer replaces the function sanitizing the keys of labels
+// added as fields, which is `SanitizeLabelKey` by default. A nil function
+// disables sanitizing, keys are then used as-is.
+func WithLabelKeySanitizer(sanitize func(string) string) func(*core) {
+	return func(c *core) {
+		if sanitize == nil {
+			sanitize = rawLabelKey
+		}
+		c.config.LabelKeySanitizer = sanitize
+	}
+}
+
+func rawLabelKey(key string) string {
+	return key
+}
+
+// labelKeySanitizer returns the function sanitizing the label keys of the core.
+func (c *core) labelKeySanitizer() func(string) string {
+	if c.config.LabelKeySanitizer == nil {
+		return SanitizeLabelKey
+	}
+
+	return c.config.LabelKeySanitizer
+}
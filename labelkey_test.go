@@ -0,0 +1,54 @@
+package zapdriver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSanitizeLabelKey(t *testing.T) {
+	t.Parallel()
+
+	for key, want := range map[string]string{
+		"user_id":                "user_id",
+		"k8s-pod/name.v1":        "k8s-pod/name.v1",
+		"User ID..Hash":          "user_id.hash",
+		".leading.and.trailing.": "leading.and.trailing",
+		"émoji🙂":                 "_moji_",
+		"":                       "",
+	} {
+		assert.Equal(t, want, SanitizeLabelKey(key), key)
+	}
+}
+
+func TestWriteSanitizedLabelKeys(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()})
+
+	logger.With(Label("Tenant ID", "acme")).Info("hello", Label("Region..Name", "eu"))
+
+	assert.Equal(t, map[string]interface{}{"tenant_id": "acme", "region.name": "eu"}, logs.All()[0].ContextMap()[labelsKey])
+}
+
+func TestWithLabelKeySanitizer(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	upper := &core{Core: debugcore, permLabels: newLabels()}
+	WithLabelKeySanitizer(strings.ToUpper)(upper)
+
+	raw := &core{Core: debugcore, permLabels: newLabels()}
+	WithLabelKeySanitizer(nil)(raw)
+
+	zap.New(upper).Info("hello", Label("tenant", "acme"))
+	zap.New(raw).Info("hello", Label("Tenant ID", "acme"))
+
+	assert.Equal(t, map[string]interface{}{"TENANT": "acme"}, logs.All()[0].ContextMap()[labelsKey])
+	assert.Equal(t, map[string]interface{}{"Tenant ID": "acme"}, logs.All()[1].ContextMap()[labelsKey])
+}
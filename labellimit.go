@@ -0,0 +1,100 @@
+package zapdriver
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/multierr"
+)
+
+// LabelLimitPolicy decides what happens to labels exceeding the limits of Cloud
+// Logging, which rejects entries with more than 64 labels, keys longer than 512
+// bytes, or values longer than 64 KiB.
+type LabelLimitPolicy int
+
+const (
+	// LabelLimitTruncate truncates values exceeding the limit.
+	LabelLimitTruncate LabelLimitPolicy = iota
+
+	// LabelLimitDrop drops labels with values exceeding the limit.
+	LabelLimitDrop
+)
+
+// WithLabelLimits enforces the label limits of Cloud Logging on every entry,
+// so entries with too many or too large labels aren't rejected as a whole.
+// Values exceeding the limit are truncated or dropped, depending on `policy`.
+// Labels with keys exceeding the limit are always dropped, and so are labels
+// beyond the 64th, in the order of their keys, so the same labels are kept for
+// every entry.
+//
+// Every entry that had to be changed is reported to the error handler (see
+// `WithErrorHandler`).
+func WithLabelLimits(policy LabelLimitPolicy) func(*core) {
+	return func(c *core) {
+		c.config.LabelLimits = &policy
+	}
+}
+
+// enforceLabelLimits applies the label limits to the labels of an entry, and
+// returns the labels to use, which are copied if they have to be changed.
+func (c *core) enforceLabelLimits(lbls *labels) *labels {
+	if !exceedsLabelLimits(lbls) {
+		return lbls
+	}
+
+	if lbls == c.permLabels || lbls == c.config.CommonLabels {
+		lbls = lbls.clone()
+	}
+
+	var err error
+	for k, v := range lbls.store {
+		if len(k) > maxLabelKeySize {
+			delete(lbls.store, k)
+			err = multierr.Append(err, fmt.Errorf("zapdriver: dropped label %q, its key is longer than %d bytes", truncateString(k, 64), maxLabelKeySize))
+			continue
+		}
+		if len(v) <= maxLabelValueSize {
+			continue
+		}
+
+		if *c.config.LabelLimits == LabelLimitDrop {
+			delete(lbls.store, k)
+			err = multierr.Append(err, fmt.Errorf("zapdriver: dropped label %q, its value is longer than %d bytes", k, maxLabelValueSize))
+			continue
+		}
+		lbls.store[k] = truncateString(v, maxLabelValueSize)
+		err = multierr.Append(err, fmt.Errorf("zapdriver: truncated label %q, its value is longer than %d bytes", k, maxLabelValueSize))
+	}
+
+	if len(lbls.store) > maxEntryLabels {
+		keys := make([]string, 0, len(lbls.store))
+		for k := range lbls.store {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys[maxEntryLabels:] {
+			delete(lbls.store, k)
+		}
+		err = multierr.Append(err, fmt.Errorf("zapdriver: dropped %d labels, entries can't have more than %d", len(keys)-maxEntryLabels, maxEntryLabels))
+	}
+
+	c.handleViolation(err)
+
+	return lbls
+}
+
+// exceedsLabelLimits reports whether any of the label limits is exceeded.
+func exceedsLabelLimits(lbls *labels) bool {
+	if len(lbls.store) > maxEntryLabels {
+		return true
+	}
+
+	for k, v := range lbls.store {
+		if len(k) > maxLabelKeySize || len(v) > maxLabelValueSize {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,84 @@
+package zapdriver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithLabelLimits(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		policy LabelLimitPolicy
+		want   map[string]interface{}
+		errors []string
+	}{
+		{
+			policy: LabelLimitTruncate,
+			want:   map[string]interface{}{"big": strings.Repeat("v", maxLabelValueSize), "small": "value"},
+			errors: []string{"dropped label", "truncated label"},
+		},
+		{
+			policy: LabelLimitDrop,
+			want:   map[string]interface{}{"small": "value"},
+			errors: []string{"dropped label", "dropped label"},
+		},
+	} {
+		var errs []error
+		debugcore, logs := observer.New(zapcore.DebugLevel)
+		core := &core{Core: debugcore, permLabels: newLabels()}
+		WithLabelLimits(tt.policy)(core)
+		WithErrorHandler(func(err error) { errs = append(errs, err) })(core)
+
+		logger := zap.New(core)
+		logger.Info("hello",
+			Label("small", "value"),
+			Label("big", strings.Repeat("v", maxLabelValueSize+10)),
+			Label(strings.Repeat("k", maxLabelKeySize+1), "value"),
+		)
+		logger.Info("fine", Label("small", "value"))
+
+		assert.Equal(t, tt.want, logs.All()[0].ContextMap()[labelsKey], "policy %d", tt.policy)
+
+		require.Len(t, errs, 1)
+		violations := multierr.Errors(errs[0])
+		require.Len(t, violations, 2)
+		for _, msg := range tt.errors {
+			assert.Contains(t, errs[0].Error(), msg)
+		}
+	}
+}
+
+func TestWithLabelLimits_TooMany(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	WithLabelLimits(LabelLimitTruncate)(core)
+
+	fields := make([]zap.Field, 0, 70)
+	for i := 0; i < 70; i++ {
+		fields = append(fields, Label(fmt.Sprintf("key_%02d", i), "value"))
+	}
+
+	// The permanent labels are shared, and must not be modified.
+	logger := zap.New(core).With(fields...)
+	logger.Info("hello")
+	logger.Info("world")
+
+	for _, entry := range logs.All() {
+		lbls := entry.ContextMap()[labelsKey].(map[string]interface{})
+		assert.Len(t, lbls, maxEntryLabels)
+		assert.Contains(t, lbls, "key_63")
+		assert.NotContains(t, lbls, "key_64")
+	}
+	assert.Len(t, InheritedLabels(logger), 70)
+}
@@ -0,0 +1,90 @@
+package zapdriver
+
+import (
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// WithLevel only logs entries enabled by `level`, both locally and to the Cloud
+// Logging API. The level can be changed while the application runs, for
+// example to raise the verbosity in production without restarting it, and the
+// change applies to all loggers derived from the logger at once.
+//
+// The level of the wrapped core still applies to the local output.
+// `NewCloudProduction`, `NewCloudDevelopment` and `Config.Build` build their
+// local output at `level`; with other constructors, use the same level as
+// `zap.Config.Level`.
+//
+// `zap.AtomicLevel` is an `http.Handler`, reporting the level on GET requests,
+// and changing it on PUT requests, like `{"level": "debug"}`:
+//
+//	level, _ := zapdriver.LevelOf(logger)
+//	http.Handle("/debug/level", level)
+func WithLevel(level zap.AtomicLevel) func(*core) {
+	return func(c *core) {
+		c.config.Level = &level
+	}
+}
+
+// LevelOf returns the level of a logger created using `WithLevel`.
+func LevelOf(logger *zap.Logger) (zap.AtomicLevel, bool) {
+	c, ok := logger.Core().(*core)
+	if !ok || c.config.Level == nil {
+		return zap.AtomicLevel{}, false
+	}
+
+	return *c.config.Level, true
+}
+
+// ReloadLevelOnSignal sets the level of a logger created using `WithLevel` to
+// the level in the file `path`, like "debug", every time the process receives
+// SIGHUP. This fits levels stored in a mounted Kubernetes ConfigMap. Every
+// change, or failure to read the level, is logged. The returned function stops
+// watching for the signal.
+//
+// It returns a no-op function if the logger has no level.
+func ReloadLevelOnSignal(logger *zap.Logger, path string) (stop func()) {
+	level, ok := LevelOf(logger)
+	if !ok {
+		return func() {}
+	}
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				reloadLevel(logger, level, path)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// reloadLevel sets the level to the level in the file.
+func reloadLevel(logger *zap.Logger, level zap.AtomicLevel, path string) {
+	b, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = level.UnmarshalText([]byte(strings.TrimSpace(string(b))))
+	}
+	if err != nil {
+		logger.Warn("zapdriver: unable to reload the log level", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	logger.Info("zapdriver: log level changed", zap.Stringer("level", level.Level()))
+}
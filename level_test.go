@@ -0,0 +1,44 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithLevel(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithLevel(level)(core)
+
+	logger := zap.New(core)
+	child := logger.With(zap.String("child", "true"))
+
+	child.Debug("hidden")
+	level.SetLevel(zapcore.DebugLevel)
+	child.Debug("shown")
+	require.NoError(t, logger.Sync())
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "shown", logs.All()[0].Message)
+	require.Len(t, server.Entries(), 1)
+	assert.Equal(t, "shown", server.Entries()[0].GetJsonPayload().Fields["message"].GetStringValue())
+
+	got, ok := LevelOf(child)
+	require.True(t, ok)
+	assert.Equal(t, zapcore.DebugLevel, got.Level())
+
+	_, ok = LevelOf(zap.NewNop())
+	assert.False(t, ok)
+}
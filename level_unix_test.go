@@ -0,0 +1,47 @@
+//go:build !windows
+// +build !windows
+
+package zapdriver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestReloadLevelOnSignal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zapdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "level")
+	require.NoError(t, ioutil.WriteFile(path, []byte("debug\n"), 0600))
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	WithLevel(level)(core)
+
+	logger := zap.New(core)
+	stop := ReloadLevelOnSignal(logger, path)
+	defer stop()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	for deadline := time.Now().Add(5 * time.Second); level.Level() != zapcore.DebugLevel; {
+		require.True(t, time.Now().Before(deadline), "level never reloaded")
+		time.Sleep(time.Millisecond)
+	}
+
+	reloadLevel(logger, level, filepath.Join(dir, "missing"))
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+	assert.Equal(t, 1, logs.FilterMessage("zapdriver: unable to reload the log level").Len())
+}
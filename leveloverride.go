@@ -0,0 +1,117 @@
+package zapdriver
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithLevelOverrides enables lower levels for entries carrying a matching label
+// or field, for targeted debug logging of a single component:
+//
+//	zapdriver.WithLevelOverrides(map[string]zapcore.Level{
+//	  "component=scheduler": zapcore.DebugLevel,
+//	})
+//
+// Every key is of the form "key=value", and matches entries with a label (see
+// `Label`) or field `key` whose value is `value`. Matching entries are written
+// both locally and to the Cloud Logging API if their level is enabled by the
+// override, regardless of the level of the logger.
+//
+// Overrides matching the labels and fields added using `With` are resolved
+// once, when the child logger is created. Otherwise, entries of an overridden
+// level are built to match their fields, and dropped if none matches.
+func WithLevelOverrides(overrides map[string]zapcore.Level) func(*core) {
+	return func(c *core) {
+		for k, level := range overrides {
+			o := levelOverride{level: level}
+			if kv := strings.SplitN(k, "=", 2); len(kv) == 2 {
+				o.key, o.value = kv[0], kv[1]
+			} else {
+				o.key = k
+				o.invalid = true
+			}
+
+			c.config.LevelOverrides = append(c.config.LevelOverrides, o)
+		}
+	}
+}
+
+type levelOverride struct {
+	key, value string
+	level      zapcore.Level
+	invalid    bool
+}
+
+type levelOverrides []levelOverride
+
+// mayEnable reports whether an override could enable the level, depending on
+// the fields of the entry.
+func (o levelOverrides) mayEnable(level zapcore.Level) bool {
+	for i := range o {
+		if !o[i].invalid && o[i].level.Enabled(level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match returns the lowest level of the overrides matching the labels or
+// fields.
+func (o levelOverrides) match(lbls *labels, fields []zapcore.Field) (zapcore.Level, bool) {
+	var (
+		lowest  zapcore.Level
+		matched bool
+	)
+
+	for i := range o {
+		if o[i].invalid || (matched && o[i].level >= lowest) || !o[i].matches(lbls, fields) {
+			continue
+		}
+
+		lowest, matched = o[i].level, true
+	}
+
+	return lowest, matched
+}
+
+func (o levelOverride) matches(lbls *labels, fields []zapcore.Field) bool {
+	if lbls != nil {
+		if v, ok := lbls.store[o.key]; ok && v == o.value {
+			return true
+		}
+	}
+
+	for i := range fields {
+		if fields[i].Key == o.key && fieldString(fields[i]) == o.value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contextOverride returns the override enabled by the labels and fields of the
+// core, if any.
+func (c *core) contextOverride() zapcore.LevelEnabler {
+	if level, ok := c.config.LevelOverrides.match(c.permLabels, c.fields); ok {
+		return level
+	}
+
+	return nil
+}
+
+// levelOverridden reports whether the level of the entry is enabled by an
+// override matching the core or the entry.
+func (c *core) levelOverridden(level zapcore.Level, lbls *labels, fields []zapcore.Field) bool {
+	if c.config.LevelOverrides == nil {
+		return false
+	}
+	if c.override != nil && c.override.Enabled(level) {
+		return true
+	}
+
+	override, ok := c.config.LevelOverrides.match(lbls, fields)
+	return ok && override.Enabled(level)
+}
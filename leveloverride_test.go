@@ -0,0 +1,53 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithLevelOverrides(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	infocore, logs := observer.New(zapcore.InfoLevel)
+	core := &core{
+		Core:       infocore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithLevelOverrides(map[string]zapcore.Level{"component=scheduler": zapcore.DebugLevel})(core)
+
+	logger := zap.New(core)
+	scheduler := logger.With(Label("component", "scheduler"))
+	api := logger.With(Label("component", "api"))
+
+	assert.False(t, logger.Core().Enabled(zapcore.DebugLevel))
+	assert.True(t, scheduler.Core().Enabled(zapcore.DebugLevel))
+	assert.False(t, api.Core().Enabled(zapcore.DebugLevel))
+
+	logger.Debug("hidden")
+	api.Debug("hidden")
+	logger.Debug("field", zap.String("component", "scheduler"))
+	logger.Debug("label", Label("component", "scheduler"))
+	scheduler.Debug("child")
+	scheduler.Named("jobs").Debug("grandchild")
+	api.Info("info")
+	require.NoError(t, logger.Sync())
+
+	var messages []string
+	for _, e := range logs.All() {
+		messages = append(messages, e.Message)
+	}
+	assert.Equal(t, []string{"field", "label", "child", "grandchild", "info"}, messages)
+	assert.Len(t, server.Entries(), 5)
+}
+
+func TestWithLevelOverrides_Validate(t *testing.T) {
+	err := Validate(WithLevelOverrides(map[string]zapcore.Level{"component": zapcore.DebugLevel}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `level override "component" is not of the form key=value`)
+}
@@ -1,39 +1,88 @@
 package zapdriver
 
 import (
+	"fmt"
+
 	"go.uber.org/zap"
 )
 
 // NewProduction builds a sensible production Logger that writes InfoLevel and
 // above logs to standard error as JSON.
 //
-// It's a shortcut for NewProductionConfig().Build(...Option).
+// It's a shortcut for NewProductionConfig().Build(...Option). To also send
+// entries to the Cloud Logging API, use `NewCloudProduction`, which creates the
+// client and logger in one call.
 func NewProduction(options ...zap.Option) (*zap.Logger, error) {
 	options = append(options, WrapCore())
 
-	return NewProductionConfig().Build(options...)
+	return validateLogger(NewProductionConfig().Build(options...))
+}
+
+// NewAgent builds a production Logger that writes InfoLevel and above logs to
+// standard output as JSON lines understood by the Cloud Logging agent, without
+// a Cloud Logging client. Stack traces of errors are written the way Error
+// Reporting expects them, see `AgentStackTraces`.
+//
+// It's a shortcut for NewAgentConfig().Build(...Option).
+func NewAgent(options ...zap.Option) (*zap.Logger, error) {
+	options = append(options, WrapCore(AgentStackTraces()))
+
+	return validateLogger(NewAgentConfig().Build(options...))
 }
 
 // NewProductionWithCore is same as NewProduction but accepts a custom configured core
 func NewProductionWithCore(core zap.Option, options ...zap.Option) (*zap.Logger, error) {
 	options = append(options, core)
 
-	return NewProductionConfig().Build(options...)
+	return validateLogger(NewProductionConfig().Build(options...))
 }
 
 // NewDevelopment builds a development Logger that writes DebugLevel and above
 // logs to standard error in a human-friendly format.
 //
-// It's a shortcut for NewDevelopmentConfig().Build(...Option).
+// It's a shortcut for NewDevelopmentConfig().Build(...Option). To also send
+// entries to the Cloud Logging API, use `NewCloudDevelopment`.
 func NewDevelopment(options ...zap.Option) (*zap.Logger, error) {
 	options = append(options, WrapCore())
 
-	return NewDevelopmentConfig().Build(options...)
+	return validateLogger(NewDevelopmentConfig().Build(options...))
 }
 
 // NewDevelopmentWithCore is same as NewDevelopment but accepts a custom configured core
 func NewDevelopmentWithCore(core zap.Option, options ...zap.Option) (*zap.Logger, error) {
 	options = append(options, core)
 
-	return NewDevelopmentConfig().Build(options...)
+	return validateLogger(NewDevelopmentConfig().Build(options...))
+}
+
+// MustNewProduction is like NewProduction, but panics if the logger can't be
+// built.
+func MustNewProduction(options ...zap.Option) *zap.Logger {
+	return must(NewProduction(options...))
+}
+
+// MustNewProductionWithCore is like NewProductionWithCore, but panics if the
+// logger can't be built.
+func MustNewProductionWithCore(core zap.Option, options ...zap.Option) *zap.Logger {
+	return must(NewProductionWithCore(core, options...))
+}
+
+// MustNewDevelopment is like NewDevelopment, but panics if the logger can't be
+// built.
+func MustNewDevelopment(options ...zap.Option) *zap.Logger {
+	return must(NewDevelopment(options...))
+}
+
+// MustNewDevelopmentWithCore is like NewDevelopmentWithCore, but panics if the
+// logger can't be built.
+func MustNewDevelopmentWithCore(core zap.Option, options ...zap.Option) *zap.Logger {
+	return must(NewDevelopmentWithCore(core, options...))
+}
+
+func must(logger *zap.Logger, err error) *zap.Logger {
+	if err != nil {
+		panic(fmt.Sprintf("zapdriver: unable to build logger: %v", err))
+	}
+
+	return logger
 }
@@ -42,3 +42,48 @@ func TestNewDevelopmentWithCore(t *testing.T) {
 	require.NoError(t, err)
 	assert.IsType(t, &zap.Logger{}, logger)
 }
+
+func TestNewProductionWithCore_Invalid(t *testing.T) {
+	logger, err := NewProductionWithCore(
+		WrapCore(WithLogNameSampling(map[string]float64{"access": -1})),
+	)
+
+	require.Error(t, err)
+	assert.Nil(t, logger)
+}
+
+func TestMustNewProduction(t *testing.T) {
+	assert.IsType(t, &zap.Logger{}, MustNewProduction())
+	assert.IsType(t, &zap.Logger{}, MustNewProductionWithCore(WrapCore()))
+}
+
+func TestMustNewDevelopment(t *testing.T) {
+	assert.IsType(t, &zap.Logger{}, MustNewDevelopment())
+	assert.IsType(t, &zap.Logger{}, MustNewDevelopmentWithCore(WrapCore()))
+}
+
+func TestMustNewProductionWithCore_Panics(t *testing.T) {
+	assert.PanicsWithValue(t, `zapdriver: unable to build logger: zapdriver: sampling rate -1 of logger "access" is not between 0 and 1`, func() {
+		MustNewProductionWithCore(WrapCore(WithLogNameSampling(map[string]float64{"access": -1})))
+	})
+}
+
+func TestNewAgent(t *testing.T) {
+	logger, err := NewAgent()
+	require.NoError(t, err)
+
+	c, ok := logger.Core().(*core)
+	require.True(t, ok)
+	assert.Nil(t, c.lg)
+
+	assert.NotPanics(t, func() { logger.Debug("not enabled") })
+}
+
+func TestNewAgentConfig(t *testing.T) {
+	t.Parallel()
+
+	config := NewAgentConfig()
+	assert.Equal(t, []string{"stdout"}, config.OutputPaths)
+	assert.Equal(t, "time", config.EncoderConfig.TimeKey)
+	assert.Equal(t, "timestamp", NewProductionConfig().EncoderConfig.TimeKey)
+}
@@ -0,0 +1,58 @@
+package zapdriver
+
+import (
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap/zapcore"
+)
+
+const loggerNameLabel = "logger"
+
+// LoggerNameLabel adds the name of the zap logger of every entry, as set using
+// `logger.Named`, as the "logger" label. Labels set on the entry with the same
+// key take precedence.
+func LoggerNameLabel() func(*core) {
+	return func(c *core) {
+		c.config.LoggerNameLabel = true
+	}
+}
+
+// WithLoggerNameRouting writes the entries of named zap loggers to a log named
+// after the logger, for example to give the scheduler of an application its
+// own log:
+//
+//	zapdriver.WithLoggerNameRouting(client, "app.{value}", 20)
+//
+// An entry of `logger.Named("scheduler")` is written to the "app.scheduler"
+// log. Loggers are created lazily on `client`, using `opts`. Once `maxLogs`
+// distinct logs exist, entries of new logger names are written to the default
+// logger instead, as are the entries of unnamed loggers.
+//
+// `WithLogNameRouting` takes precedence over it, and it takes precedence over
+// `WithFieldRouting`.
+func WithLoggerNameRouting(client *logging.Client, template string, maxLogs int, opts ...logging.LoggerOption) func(*core) {
+	return func(c *core) {
+		c.config.LoggerNames = &fieldRouter{
+			client:   client,
+			template: template,
+			maxLogs:  maxLogs,
+			opts:     opts,
+			loggers:  map[string]*logging.Logger{},
+		}
+	}
+}
+
+// stampsLoggerName reports whether `stampLoggerName` adds a label to the entry.
+func (c *core) stampsLoggerName(ent zapcore.Entry) bool {
+	return c.config.LoggerNameLabel && ent.LoggerName != ""
+}
+
+// stampLoggerName adds the logger name label to the labels of an entry.
+func (c *core) stampLoggerName(lbls *labels, ent zapcore.Entry) {
+	if !c.stampsLoggerName(ent) {
+		return
+	}
+
+	if _, ok := lbls.store[loggerNameLabel]; !ok {
+		lbls.store[loggerNameLabel] = ent.LoggerName
+	}
+}
@@ -0,0 +1,62 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggerNameLabel(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	LoggerNameLabel()(core)
+
+	logger := zap.New(core)
+	logger.Named("scheduler").Named("cron").Info("named")
+	logger.Named("scheduler").Info("explicit", Label(loggerNameLabel, "other"))
+	logger.Info("unnamed")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, map[string]string{loggerNameLabel: "scheduler.cron"}, entries[0].Labels)
+	assert.Equal(t, map[string]string{loggerNameLabel: "other"}, entries[1].Labels)
+	assert.Empty(t, entries[2].Labels)
+
+	assert.Equal(t, map[string]interface{}{loggerNameLabel: "scheduler.cron"}, logs.All()[0].ContextMap()[labelsKey])
+	assert.Empty(t, core.permLabels.store)
+}
+
+func TestWriteLoggerNameRouting(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	WithLogNameRouting(client, 10)(core)
+	WithLoggerNameRouting(client, "app.{value}", 1)(core)
+
+	logger := zap.New(core)
+	logger.Named("scheduler").Info("named")
+	logger.Named("scheduler").Info("audit", LogName("audit"))
+	logger.Named("worker").Info("capped")
+	logger.Info("unnamed")
+	require.NoError(t, logger.Sync())
+
+	logNames := map[string]string{}
+	for _, e := range server.Entries() {
+		logNames[e.GetJsonPayload().Fields["message"].GetStringValue()] = e.LogName
+	}
+
+	assert.Equal(t, map[string]string{
+		"named":   "projects/test-project/logs/app.scheduler",
+		"audit":   "projects/test-project/logs/audit",
+		"capped":  "projects/test-project/logs/app",
+		"unnamed": "projects/test-project/logs/app",
+	}, logNames)
+}
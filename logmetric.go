@@ -0,0 +1,145 @@
+package zapdriver
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// DefaultMaxMetricLabelValues is the number of distinct values per label of a
+// `LogMetric` by default.
+const DefaultMaxMetricLabelValues = 100
+
+// otherLabelValue replaces label values of a `LogMetric` beyond its limit.
+const otherLabelValue = "other"
+
+// metricNamePattern is the format of the names of metrics and their labels.
+var metricNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// LogMetric is a declared measurement, written in the shape of `RecordMetric`,
+// with a fixed set of labels. It's the contract between the code and the
+// log-based metrics extracting counters or distributions from its entries:
+//
+//	failures, err := zapdriver.NewCounter("order_failures", "route", "code")
+//	...
+//	_ = failures.Inc(logger, "/orders", "500")
+//
+// Label values beyond the limit of distinct values per label (see
+// `LimitLabelValues`) are replaced with "other", so the cardinality of the
+// log-based metric stays bounded.
+type LogMetric struct {
+	name      string
+	unit      string
+	labelKeys []string
+	maxValues int
+
+	mutex  sync.Mutex
+	values []map[string]struct{}
+}
+
+// NewCounter declares a counter metric, with the given label keys. Its entries
+// have unit "1".
+func NewCounter(name string, labelKeys ...string) (*LogMetric, error) {
+	return newLogMetric(name, "1", labelKeys)
+}
+
+// NewDistribution declares a distribution metric of values with the given
+// unit, and with the given label keys.
+func NewDistribution(name, unit string, labelKeys ...string) (*LogMetric, error) {
+	return newLogMetric(name, unit, labelKeys)
+}
+
+func newLogMetric(name, unit string, labelKeys []string) (*LogMetric, error) {
+	if !metricNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("zapdriver: invalid metric name %q", name)
+	}
+	if unit == "" {
+		return nil, fmt.Errorf("zapdriver: metric %q has no unit", name)
+	}
+
+	seen := map[string]bool{metricNameKey: true}
+	for _, key := range labelKeys {
+		if !metricNamePattern.MatchString(key) {
+			return nil, fmt.Errorf("zapdriver: invalid label key %q of metric %q", key, name)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("zapdriver: duplicate label key %q of metric %q", key, name)
+		}
+		seen[key] = true
+	}
+
+	values := make([]map[string]struct{}, len(labelKeys))
+	for i := range values {
+		values[i] = map[string]struct{}{}
+	}
+
+	return &LogMetric{
+		name:      name,
+		unit:      unit,
+		labelKeys: labelKeys,
+		maxValues: DefaultMaxMetricLabelValues,
+		values:    values,
+	}, nil
+}
+
+// LimitLabelValues changes the number of distinct values per label, and returns
+// the metric.
+func (m *LogMetric) LimitLabelValues(max int) *LogMetric {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.maxValues = max
+	return m
+}
+
+// Inc records a single occurrence, with the values of the labels of the
+// metric, in order.
+func (m *LogMetric) Inc(logger *zap.Logger, labelValues ...string) error {
+	return m.Record(logger, 1, labelValues...)
+}
+
+// Record writes an entry for a measurement, with the values of the labels of
+// the metric, in order. Measurements that aren't finite numbers, or have the
+// wrong number of label values, are not written, and reported as error
+// instead.
+func (m *LogMetric) Record(logger *zap.Logger, value float64, labelValues ...string) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("zapdriver: value %v of metric %q is not a finite number", value, m.name)
+	}
+	if len(labelValues) != len(m.labelKeys) {
+		return fmt.Errorf("zapdriver: metric %q has %d labels, got %d values", m.name, len(m.labelKeys), len(labelValues))
+	}
+
+	fields := make([]zap.Field, len(labelValues))
+	for i, value := range m.boundLabelValues(labelValues) {
+		fields[i] = Label(m.labelKeys[i], value)
+	}
+
+	RecordMetric(logger, m.name, value, m.unit, fields...)
+	return nil
+}
+
+// boundLabelValues records the label values, and returns them with the values
+// beyond the limit replaced.
+func (m *LogMetric) boundLabelValues(labelValues []string) []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	bound := make([]string, len(labelValues))
+	for i, value := range labelValues {
+		if _, ok := m.values[i][value]; !ok {
+			if len(m.values[i]) >= m.maxValues {
+				value = otherLabelValue
+			} else {
+				m.values[i][value] = struct{}{}
+			}
+		}
+
+		bound[i] = value
+	}
+
+	return bound
+}
@@ -0,0 +1,93 @@
+package zapdriver
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewCounter(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()})
+
+	m, err := NewCounter("order_failures", "route", "code")
+	require.NoError(t, err)
+	require.NoError(t, m.Inc(logger, "/orders", "500"))
+
+	entry := logs.All()[0]
+	assert.Equal(t, "order_failures", entry.Message)
+
+	fields := entry.ContextMap()
+	assert.Equal(t, map[string]interface{}{"name": "order_failures", "value": float64(1), "unit": "1"}, fields[metricKey])
+	assert.Equal(t, map[string]interface{}{metricNameKey: "order_failures", "route": "/orders", "code": "500"}, fields[labelsKey])
+}
+
+func TestNewDistribution(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()})
+
+	m, err := NewDistribution("request_latency", "ms", "route")
+	require.NoError(t, err)
+	require.NoError(t, m.Record(logger, 12.5, "/orders"))
+
+	assert.Error(t, m.Record(logger, math.NaN(), "/orders"))
+	assert.Error(t, m.Record(logger, math.Inf(1), "/orders"))
+	assert.Error(t, m.Record(logger, 1))
+	assert.Error(t, m.Record(logger, 1, "/orders", "extra"))
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, map[string]interface{}{"name": "request_latency", "value": 12.5, "unit": "ms"}, fields[metricKey])
+}
+
+func TestNewLogMetric_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, unit string
+		labelKeys  []string
+	}{
+		{"Order-Failures", "1", nil},
+		{"", "1", nil},
+		{"latency", "", nil},
+		{"latency", "ms", []string{"Route"}},
+		{"latency", "ms", []string{"route", "route"}},
+		{"latency", "ms", []string{metricNameKey}},
+	}
+
+	for _, tt := range tests {
+		_, err := NewDistribution(tt.name, tt.unit, tt.labelKeys...)
+		assert.Error(t, err, tt)
+	}
+}
+
+func TestLogMetric_LimitLabelValues(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()})
+
+	m, err := NewCounter("requests", "user")
+	require.NoError(t, err)
+	m.LimitLabelValues(2)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, m.Inc(logger, "user-"+strconv.Itoa(i%3)))
+	}
+
+	var users []interface{}
+	for _, entry := range logs.All() {
+		users = append(users, entry.ContextMap()[labelsKey].(map[string]interface{})["user"])
+	}
+	assert.Equal(t, []interface{}{"user-0", "user-1", "other", "user-0"}, users)
+}
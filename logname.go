@@ -0,0 +1,35 @@
+package zapdriver
+
+import (
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap"
+)
+
+const logNameKey = "logging.googleapis.com/logName"
+
+// LogName adds the field selecting the log an entry is written to, when log
+// name routing is enabled using `WithLogNameRouting`:
+//
+//	logger.Info("Role granted.", zapdriver.LogName("audit"))
+func LogName(name string) zap.Field {
+	return zap.String(logNameKey, name)
+}
+
+// WithLogNameRouting writes entries with a `LogName` field to the named log,
+// instead of the log of the logger. Loggers are created lazily on `client`,
+// using `opts`. Once `maxLogs` distinct logs exist, entries for new log names
+// are written to the default logger instead.
+//
+// Log name routing takes precedence over `WithFieldRouting`.
+func WithLogNameRouting(client *logging.Client, maxLogs int, opts ...logging.LoggerOption) func(*core) {
+	return func(c *core) {
+		c.config.LogNames = &fieldRouter{
+			client:   client,
+			key:      logNameKey,
+			template: "{value}",
+			maxLogs:  maxLogs,
+			opts:     opts,
+			loggers:  map[string]*logging.Logger{},
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, zap.String(logNameKey, "audit"), LogName("audit"))
+}
+
+func TestWriteLogNameRouting(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithLogNameRouting(client, 1)(core)
+	WithFieldRouting(client, "tenant_id", "app-{value}", 10)(core)
+
+	logger := zap.New(core)
+	logger.Info("audit", LogName("audit"))
+	logger.With(LogName("audit")).Info("inherited", zap.String("tenant_id", "acme"))
+	logger.Info("capped", LogName("security"))
+	logger.Info("tenant", zap.String("tenant_id", "acme"))
+	logger.Info("default")
+	require.NoError(t, logger.Sync())
+
+	logNames := map[string]string{}
+	for _, e := range server.Entries() {
+		logNames[e.GetJsonPayload().Fields["message"].GetStringValue()] = e.LogName
+	}
+
+	assert.Equal(t, map[string]string{
+		"audit":     "projects/test-project/logs/audit",
+		"inherited": "projects/test-project/logs/audit",
+		"capped":    "projects/test-project/logs/app",
+		"tenant":    "projects/test-project/logs/app-acme",
+		"default":   "projects/test-project/logs/app",
+	}, logNames)
+}
@@ -0,0 +1,98 @@
+package zapdriver
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewLogr returns a `logr.Logger` writing through the logger, for libraries
+// that only accept a logr.Logger, such as Kubernetes controllers:
+//
+//	ctrl.SetLogger(zapdriver.NewLogr(logger))
+//
+// Entries of V-level 0 are logged at info level, all others at debug level.
+// Key/value pairs become fields, or labels for keys starting with "labels.",
+// and zap fields, such as the ones returned by `Label`, can be passed inline.
+func NewLogr(logger *zap.Logger) logr.Logger {
+	return logr.New(&logrSink{logger: logger})
+}
+
+// logrSink implements `logr.LogSink` using a zap logger.
+type logrSink struct {
+	logger *zap.Logger
+}
+
+func (s *logrSink) Init(info logr.RuntimeInfo) {
+	s.logger = s.logger.WithOptions(zap.AddCallerSkip(info.CallDepth + 1))
+}
+
+func (s *logrSink) Enabled(level int) bool {
+	return s.logger.Core().Enabled(logrLevel(level))
+}
+
+func (s *logrSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if ce := s.logger.Check(logrLevel(level), msg); ce != nil {
+		ce.Write(logrFields(keysAndValues)...)
+	}
+}
+
+func (s *logrSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if ce := s.logger.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(append(logrFields(keysAndValues), zap.Error(err))...)
+	}
+}
+
+func (s *logrSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &logrSink{logger: s.logger.With(logrFields(keysAndValues)...)}
+}
+
+func (s *logrSink) WithName(name string) logr.LogSink {
+	return &logrSink{logger: s.logger.Named(name)}
+}
+
+// WithCallDepth implements `logr.CallDepthLogSink`.
+func (s *logrSink) WithCallDepth(depth int) logr.LogSink {
+	return &logrSink{logger: s.logger.WithOptions(zap.AddCallerSkip(depth))}
+}
+
+// logrLevel returns the zap level of a V-level.
+func logrLevel(level int) zapcore.Level {
+	if level > 0 {
+		return zapcore.DebugLevel
+	}
+
+	return zapcore.InfoLevel
+}
+
+// logrFields returns the fields of the key/value pairs. Keys that aren't
+// strings are formatted using `fmt.Sprint`, and a trailing key without a value
+// is logged as the "ignored" field.
+func logrFields(keysAndValues []interface{}) []zap.Field {
+	keysAndValues = flattenFields(keysAndValues)
+
+	fields := make([]zap.Field, 0, len(keysAndValues)/2+1)
+	for i := 0; i < len(keysAndValues); {
+		if f, ok := keysAndValues[i].(zap.Field); ok {
+			fields = append(fields, f)
+			i++
+			continue
+		}
+
+		if i+1 == len(keysAndValues) {
+			fields = append(fields, zap.Any("ignored", keysAndValues[i]))
+			break
+		}
+
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields = append(fields, zap.Any(key, keysAndValues[i+1]))
+		i += 2
+	}
+
+	return fields
+}
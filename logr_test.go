@@ -0,0 +1,50 @@
+package zapdriver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewLogr(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogr(zap.New(&core{Core: debugcore, permLabels: newLabels()}, zap.AddCaller()))
+
+	logger = logger.WithName("controller").WithValues("labels.team", "platform")
+	logger.Info("reconciling", "namespace", "default", Label("kind", "Pod"))
+	logger.V(1).Info("details", 42, "answer", "trailing")
+	logger.Error(errors.New("boom"), "failed")
+
+	entries := logs.All()
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, "controller", entries[0].LoggerName)
+	assert.Contains(t, entries[0].Caller.File, "logr_test.go")
+	assert.Equal(t, "default", entries[0].ContextMap()["namespace"])
+	assert.Equal(t, map[string]interface{}{"team": "platform", "kind": "Pod"}, entries[0].ContextMap()[labelsKey])
+
+	assert.Equal(t, zapcore.DebugLevel, entries[1].Level)
+	assert.Equal(t, "answer", entries[1].ContextMap()["42"])
+	assert.Equal(t, "trailing", entries[1].ContextMap()["ignored"])
+
+	assert.Equal(t, zapcore.ErrorLevel, entries[2].Level)
+	assert.Equal(t, "boom", entries[2].ContextMap()["error"])
+}
+
+func TestNewLogr_Enabled(t *testing.T) {
+	t.Parallel()
+
+	infocore, _ := observer.New(zapcore.InfoLevel)
+	logger := NewLogr(zap.New(&core{Core: infocore, permLabels: newLabels()}))
+
+	assert.True(t, logger.Enabled())
+	assert.False(t, logger.V(1).Enabled())
+}
@@ -0,0 +1,253 @@
+package zapdriver
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Patterns of sensitive values, for use with `RedactValues`.
+var (
+	// CreditCardNumbers matches credit card numbers of 13 to 19 digits,
+	// optionally grouped using spaces or dashes.
+	CreditCardNumbers = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+	// BearerTokens matches bearer tokens, as used in the Authorization header.
+	BearerTokens = regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`)
+
+	// EmailAddresses matches email addresses.
+	EmailAddresses = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+)
+
+// RedactKeys masks the values of fields, labels and URL query parameters with
+// the given keys, compared case-insensitively, so sensitive data never reaches
+// the payload or labels:
+//
+//	zapdriver.RedactKeys("password", "token", "authorization")
+//
+// Keys of nested objects are masked as well. The values are replaced with
+// "[REDACTED]". Masking applies to the fields added using `logger.With()`, to
+// the fields of single entries, and to the URLs of the `HTTP` field.
+func RedactKeys(keys ...string) func(*core) {
+	return func(c *core) {
+		m := c.config.Masker.copy()
+		for _, k := range keys {
+			m.keys[strings.ToLower(k)] = true
+		}
+		c.config.Masker = m
+	}
+}
+
+// RedactValues masks the parts of string values matching any of the patterns,
+// such as `CreditCardNumbers`, `BearerTokens` and `EmailAddresses`. It applies
+// to the message, to the same fields as `RedactKeys`, and to the values of
+// labels.
+//
+// Error values aren't masked. Masking objects and arrays means encoding them
+// for every entry, which is costly for large ones.
+func RedactValues(patterns ...*regexp.Regexp) func(*core) {
+	return func(c *core) {
+		m := c.config.Masker.copy()
+		m.patterns = append(m.patterns, patterns...)
+		c.config.Masker = m
+	}
+}
+
+// masker masks sensitive values of fields and labels.
+type masker struct {
+	keys     map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// copy returns a copy of the masker, which may be nil, so options never change
+// the masker of another core.
+func (m *masker) copy() *masker {
+	out := &masker{keys: map[string]bool{}}
+	if m != nil {
+		for k := range m.keys {
+			out.keys[k] = true
+		}
+		out.patterns = append(out.patterns, m.patterns...)
+	}
+
+	return out
+}
+
+// denied reports whether the values of the key are masked.
+func (m *masker) denied(key string) bool {
+	return len(m.keys) > 0 && m.keys[strings.ToLower(key)]
+}
+
+// maskString masks the parts of s matching the patterns.
+func (m *masker) maskString(s string) string {
+	for _, p := range m.patterns {
+		s = p.ReplaceAllString(s, redactedValue)
+	}
+
+	return s
+}
+
+// labels masks the labels in place.
+func (m *masker) labels(lbls *labels) {
+	if lbls == nil {
+		return
+	}
+
+	for k, v := range lbls.store {
+		if m.denied(k) {
+			lbls.store[k] = redactedValue
+			continue
+		}
+
+		lbls.store[k] = m.maskString(v)
+	}
+}
+
+// fields returns the fields with their sensitive values masked. The original
+// slice is returned if nothing was masked.
+func (m *masker) fields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i := range fields {
+		f, ok := m.field(fields[i])
+		if !ok {
+			continue
+		}
+
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i] = f
+	}
+
+	if out == nil {
+		return fields
+	}
+
+	return out
+}
+
+// field returns the masked field, and whether anything was masked.
+func (m *masker) field(f zapcore.Field) (zapcore.Field, bool) {
+	if p, ok := f.Interface.(*HTTPPayload); ok && f.Key == httpRequestKey {
+		masked := m.httpPayload(p)
+		return zap.Object(f.Key, masked), *masked != *p
+	}
+
+	// The special fields of this package are never masked.
+	if isReservedKey(f.Key) {
+		return f, false
+	}
+
+	if m.denied(f.Key) {
+		return zap.String(f.Key, redactedValue), true
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		s := m.maskString(f.String)
+		return zap.String(f.Key, s), s != f.String
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType:
+		v, changed := m.value(ToInterface(f))
+		if changed {
+			return zap.Reflect(f.Key, v), true
+		}
+	}
+
+	return f, false
+}
+
+// value masks the JSON-compatible value, as returned by `ToInterface`. It
+// returns a masked copy, and whether anything was masked.
+func (m *masker) value(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		s := m.maskString(v)
+		return s, s != v
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for k, e := range v {
+			masked, changed := interface{}(redactedValue), true
+			if !m.denied(k) {
+				masked, changed = m.value(e)
+			}
+			if !changed {
+				continue
+			}
+
+			if out == nil {
+				out = make(map[string]interface{}, len(v))
+				for k, e := range v {
+					out[k] = e
+				}
+			}
+			out[k] = masked
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	case []interface{}:
+		var out []interface{}
+		for i, e := range v {
+			masked, changed := m.value(e)
+			if !changed {
+				continue
+			}
+
+			if out == nil {
+				out = append([]interface{}{}, v...)
+			}
+			out[i] = masked
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	}
+
+	return v, false
+}
+
+// httpPayload returns a copy of the payload, with the sensitive values of its
+// URLs and user agent masked.
+func (m *masker) httpPayload(p *HTTPPayload) *HTTPPayload {
+	out := *p
+	out.RequestURL = m.maskURL(p.RequestURL)
+	out.Referer = m.maskURL(p.Referer)
+	out.UserAgent = m.maskString(p.UserAgent)
+
+	return &out
+}
+
+// maskURL masks the query parameters of the URL with denied keys, and the
+// parts of the URL matching the patterns.
+func (m *masker) maskURL(raw string) string {
+	if len(m.keys) > 0 && strings.Contains(raw, "?") {
+		if u, err := url.Parse(raw); err == nil {
+			query, masked := u.Query(), false
+			for k := range query {
+				if m.denied(k) {
+					query[k] = []string{redactedValue}
+					masked = true
+				}
+			}
+
+			if masked {
+				u.RawQuery = query.Encode()
+				raw = u.String()
+			}
+		}
+	}
+
+	return m.maskString(raw)
+}
+
+// isReservedKey reports whether the key is one of the special fields of this
+// package.
+func isReservedKey(key string) bool {
+	return strings.HasPrefix(key, "logging.googleapis.com/") || key == serviceContextKey || key == contextKey
+}
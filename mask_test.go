@@ -0,0 +1,69 @@
+package zapdriver
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMaskString(t *testing.T) {
+	t.Parallel()
+
+	m := &masker{patterns: []*regexp.Regexp{CreditCardNumbers, BearerTokens, EmailAddresses}}
+
+	tests := map[string]string{
+		"card 4111 1111 1111 1111 declined":   "card [REDACTED] declined",
+		"card 4111-1111-1111-1111":            "card [REDACTED]",
+		"Authorization: Bearer abc.def-ghi==": "Authorization: [REDACTED]",
+		"mail jane.doe+test@example.com now":  "mail [REDACTED] now",
+		"order 12345 shipped":                 "order 12345 shipped",
+	}
+
+	for in, want := range tests {
+		assert.Equal(t, want, m.maskString(in), in)
+	}
+}
+
+func TestWriteMasked(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	RedactKeys("Password", "token")(core)
+	RedactValues(EmailAddresses)(core)
+	WithLabelKeySanitizer(nil)(core)
+
+	logger := zap.New(core).With(zap.String("password", "hunter2"), Label("owner", "jane@example.com"))
+	logger.Info("signup by jane@example.com",
+		zap.String("user", "jane"),
+		zap.Any("request", map[string]interface{}{"token": "abc", "plan": "pro"}),
+		Label("Token", "abc"),
+		HTTP(&HTTPPayload{RequestURL: "https://example.com/signup?token=abc&plan=pro"}),
+	)
+	require.NoError(t, logger.Sync())
+
+	local := logs.All()[0]
+	assert.Equal(t, "signup by [REDACTED]", local.Message)
+
+	fields := local.ContextMap()
+	assert.Equal(t, redactedValue, fields["password"])
+	assert.Equal(t, "jane", fields["user"])
+	assert.Equal(t, map[string]interface{}{"token": redactedValue, "plan": "pro"}, fields["request"])
+	assert.Equal(t, map[string]interface{}{"owner": redactedValue, "Token": redactedValue}, fields[labelsKey])
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]string{"owner": redactedValue, "Token": redactedValue}, entries[0].Labels)
+	assert.Equal(t, "signup by [REDACTED]", entries[0].GetJsonPayload().Fields["message"].GetStringValue())
+	assert.Equal(t, redactedValue, entries[0].GetJsonPayload().Fields["password"].GetStringValue())
+	assert.Equal(t, "https://example.com/signup?plan=pro&token=%5BREDACTED%5D", entries[0].HttpRequest.RequestUrl)
+}
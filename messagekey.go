@@ -0,0 +1,27 @@
+package zapdriver
+
+// MessageKey changes the key of the message in the payload of entries sent to
+// the Cloud Logging API from "message" to `key`, for example to match
+// log-based metrics or queries written for another format:
+//
+//	config := zapdriver.NewAgentConfig()
+//	config.EncoderConfig.MessageKey = "msg"
+//	logger, err := config.Build(zapdriver.WrapCore(zapdriver.MessageKey("msg")))
+//
+// The key of the local output is set by the `MessageKey` of the encoder
+// config, set both to the same key, so the lines collected by the Cloud Logging
+// agent and the entries sent to the API match.
+func MessageKey(key string) func(*core) {
+	return func(c *core) {
+		c.config.MessageKey = key
+	}
+}
+
+// messageKey returns the key of the message in the payload.
+func (c *core) messageKey() string {
+	if c.config.MessageKey == "" {
+		return "message"
+	}
+
+	return c.config.MessageKey
+}
@@ -0,0 +1,63 @@
+package zapdriver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMessageKey(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+
+	config := NewAgentConfig().EncoderConfig
+	config.MessageKey = "msg"
+	out := &bytes.Buffer{}
+
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(config), zapcore.AddSync(out), zapcore.DebugLevel),
+		WrapCore(WithLogger(client.Logger("app")), MessageKey("msg")))
+	logger.Info("hello")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+
+	payload := entries[0].GetJsonPayload().Fields
+	assert.Equal(t, "hello", payload["msg"].GetStringValue())
+	assert.NotContains(t, payload, "message")
+	assert.Contains(t, out.String(), `"msg":"hello"`)
+}
+
+func TestMessageKey_Oversize(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+
+	core := &core{Core: zapcore.NewNopCore(), lg: client.Logger("app"), permLabels: newLabels()}
+	MessageKey("msg")(core)
+	WithOversizedEntries(OversizeSplit)(core)
+
+	big := strings.Repeat("x", 200*1024)
+	require.NoError(t, core.Write(zapcore.Entry{Message: "hello"}, []zapcore.Field{
+		zap.String("a", big), zap.String("b", big),
+	}))
+	require.NoError(t, core.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "hello", entry.GetJsonPayload().Fields["msg"].GetStringValue())
+	}
+}
+
+func TestMessageKey_Default(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "message", (&core{}).messageKey())
+}
@@ -0,0 +1,65 @@
+package zapdriver
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	metricKey = "metric"
+
+	// metricNameKey is the label holding the name of the metric, so log-based
+	// metrics can filter on it without parsing the payload.
+	metricNameKey = "metric_name"
+)
+
+// Metric adds a "metric" field with the name, value and unit of a measurement,
+// in a stable shape for log-based metrics. Counter metrics can filter on
+// `jsonPayload.metric.name`, distribution metrics extract their values from
+// `jsonPayload.metric.value`.
+//
+// Units follow the Cloud Monitoring conventions, for example "1", "ms" or "By".
+func Metric(name string, value float64, unit string) zap.Field {
+	return zap.Object(metricKey, &metric{
+		Name:  name,
+		Value: value,
+		Unit:  unit,
+	})
+}
+
+// RecordMetric writes an info entry for a measurement, with the metric field
+// (see `Metric`) and a `metric_name` label. The message of the entry is the
+// name of the metric.
+func RecordMetric(logger *zap.Logger, name string, value float64, unit string, fields ...zap.Field) {
+	recordMetric(logger, name, value, unit, fields)
+}
+
+// CountMetric records a single occurrence of an event, for counter log-based
+// metrics. See `RecordMetric`.
+func CountMetric(logger *zap.Logger, name string, fields ...zap.Field) {
+	recordMetric(logger, name, 1, "1", fields)
+}
+
+// recordMetric writes the entry of a measurement, reporting the caller of
+// `RecordMetric` or `CountMetric` as its caller.
+func recordMetric(logger *zap.Logger, name string, value float64, unit string, fields []zap.Field) {
+	fields = append([]zap.Field{Metric(name, value, unit), Label(metricNameKey, name)}, fields...)
+
+	logger.WithOptions(zap.AddCallerSkip(2)).Info(name, fields...)
+}
+
+// metric is the payload of a measurement.
+type metric struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaller interface.
+func (m metric) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", m.Name)
+	enc.AddFloat64("value", m.Value)
+	enc.AddString("unit", m.Unit)
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package zapdriver
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecordMetric(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()})
+
+	RecordMetric(logger, "cache_size", 42, "By", Label("cache", "users"))
+
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.InfoLevel, entry.Level)
+	assert.Equal(t, "cache_size", entry.Message)
+
+	fields := entry.ContextMap()
+	assert.Equal(t, map[string]interface{}{"name": "cache_size", "value": float64(42), "unit": "By"}, fields[metricKey])
+	assert.Equal(t, map[string]interface{}{metricNameKey: "cache_size", "cache": "users"}, fields[labelsKey])
+}
+
+func TestRecordMetric_Caller(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()}, zap.AddCaller())
+
+	RecordMetric(logger, "cache_size", 42, "By")
+	CountMetric(logger, "cache_miss")
+
+	require.Equal(t, 2, logs.Len())
+	for _, entry := range logs.All() {
+		assert.Contains(t, entry.Caller.File, "metric_test.go", entry.Message)
+	}
+}
+
+// The shape of metric entries is relied upon by log-based metrics, changing it
+// breaks them.
+func ExampleRecordMetric() {
+	config := NewProductionEncoderConfig()
+	config.TimeKey = ""
+
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(config), os.Stdout, zapcore.InfoLevel), WrapCore())
+
+	RecordMetric(logger, "request_latency", 12.5, "ms", Label("route", "/hello"))
+	CountMetric(logger, "cache_miss")
+
+	// Output:
+	// {"severity":"INFO","message":"request_latency","metric":{"name":"request_latency","value":12.5,"unit":"ms"},"logging.googleapis.com/labels":{"metric_name":"request_latency","route":"/hello"}}
+	// {"severity":"INFO","message":"cache_miss","metric":{"name":"cache_miss","value":1,"unit":"1"},"logging.googleapis.com/labels":{"metric_name":"cache_miss"}}
+}
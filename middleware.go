@@ -0,0 +1,150 @@
+package zapdriver
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Middleware returns HTTP middleware logging one entry per request, with an
+// `HTTP` field holding the method, URL, status, latency, request and response
+// sizes, remote IP and user agent, so Cloud Logging shows it as a request log.
+// The field is built by `NewHTTPFromResponse`:
+//
+//	http.ListenAndServe(":8080", zapdriver.Middleware(logger, "my-project")(mux))
+//
+// The trace context of the "X-Cloud-Trace-Context" header is added to the
+// entry, and to the request context (see `ContextWithSpanContext`), so entries
+// logged by the handler using `Logger.Ctx` are correlated with the request. The
+// request ID added by `RequestIDMiddleware` is added as a label.
+//
+// Requests are logged at info level, or at warn and error level for 4xx and 5xx
+// responses.
+func Middleware(logger *zap.Logger, projectName string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			var fields []zap.Field
+			if trace, spanId, sampled, ok := parseTraceHeader(r.Header.Get(TraceHeader)); ok {
+				fields = TraceContext(trace, spanId, sampled, projectName)
+
+				if _, ok := SpanContextFromContext(r.Context()); !ok {
+					sc := SpanContext{TraceID: trace, SpanID: spanId, Sampled: sampled}
+					r = r.WithContext(ContextWithSpanContext(r.Context(), sc))
+				}
+			}
+
+			if id, ok := RequestIDFromContext(r.Context()); ok {
+				fields = append(fields, Label(requestIDKey, id))
+			}
+
+			rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			payload := NewHTTPFromResponse(r, &ResponseInfo{
+				Status: rw.status,
+				Size:   rw.size,
+				Header: rw.Header(),
+				Start:  start,
+			})
+
+			level := zapcore.InfoLevel
+			switch {
+			case rw.status >= 500:
+				level = zapcore.ErrorLevel
+			case rw.status >= 400:
+				level = zapcore.WarnLevel
+			}
+
+			if ce := logger.Check(level, r.Method+" "+r.URL.Path); ce != nil {
+				ce.Write(append(fields, HTTP(payload))...)
+			}
+		})
+	}
+}
+
+// responseRecorder records the status and size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+
+	n, err := r.ResponseWriter.Write(b)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// Flush implements http.Flusher, if the wrapped writer does.
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, if the wrapped writer does, so the
+// middleware doesn't break WebSockets.
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("zapdriver: response writer does not implement http.Hijacker")
+	}
+
+	return h.Hijack()
+}
+
+// Push implements http.Pusher, if the wrapped writer does.
+func (r *responseRecorder) Push(target string, opts *http.PushOptions) error {
+	p, ok := r.ResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+
+	return p.Push(target, opts)
+}
+
+// requestURL returns the absolute URL of a server request.
+func requestURL(r *http.Request) string {
+	u := *r.URL
+	if u.Host == "" {
+		u.Host = r.Host
+	}
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+
+	return u.String()
+}
+
+// remoteIP strips the port from a remote address.
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+
+	return addr
+}
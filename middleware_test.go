@@ -0,0 +1,122 @@
+package zapdriver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMiddleware(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	logger := zap.New(core)
+
+	handler := Middleware(logger, "my-project")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		NewLogger(logger, "my-project").Ctx(r.Context()).Info("handling")
+
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	}))
+
+	req := httptest.NewRequest("POST", "/orders?id=1", strings.NewReader("hello"))
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "curl")
+	req.Header.Set(TraceHeader, "abc/1;o=1")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.NoError(t, logger.Sync())
+
+	local := logs.AllUntimed()
+	require.Len(t, local, 2)
+	assert.Equal(t, zapcore.WarnLevel, local[1].Level)
+	assert.Equal(t, "POST /orders", local[1].Message)
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "projects/my-project/traces/abc", entries[0].Trace)
+	assert.Equal(t, "projects/my-project/traces/abc", entries[1].Trace)
+	assert.Equal(t, "0000000000000001", entries[1].SpanId)
+	assert.True(t, entries[1].TraceSampled)
+
+	r := entries[1].HttpRequest
+	require.NotNil(t, r)
+	assert.Equal(t, "POST", r.RequestMethod)
+	assert.Equal(t, "http://example.com/orders?id=1", r.RequestUrl)
+	assert.Equal(t, int32(404), r.Status)
+	// The sizes include the request line and headers (see NewHTTPFromResponse).
+	requestLine := int64(len("POST") + len("/orders?id=1") + len("HTTP/1.1") + 4)
+	assert.Equal(t, requestLine+headerSize(req.Header)+5, r.RequestSize)
+	assert.Equal(t, headerSize(rec.Header())+9, r.ResponseSize)
+	assert.Equal(t, "curl", r.UserAgent)
+	assert.Equal(t, "203.0.113.7", r.RemoteIp)
+	assert.NotNil(t, r.Latency)
+}
+
+func TestMiddleware_Levels(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore)
+
+	for _, status := range []int{200, 500} {
+		status := status
+		handler := Middleware(logger, "my-project")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+	assert.NotContains(t, entries[0].ContextMap(), traceKey)
+}
+
+func TestMiddleware_Hijack(t *testing.T) {
+	logger := zap.NewNop()
+
+	handler := Middleware(logger, "my-project")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 6\r\nConnection: close\r\n\r\nraw ok")
+		_ = rw.Flush()
+	}))
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "raw ok", string(body))
+}
+
+func TestMiddleware_Push(t *testing.T) {
+	logger := zap.NewNop()
+
+	var err error
+	handler := Middleware(logger, "my-project")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = w.(http.Pusher).Push("/style.css", nil)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.ErrNotSupported, err)
+}
@@ -0,0 +1,86 @@
+package zapdriver
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithPayloadNamespace nests all fields of entries under a single object with
+// the given key, both in the payload sent to the Cloud Logging API and in the
+// local output, so they can't collide with the keys interpreted by Cloud
+// Logging, Error Reporting and the Cloud Logging agent:
+//
+//	{"severity": "INFO", "message": "hello", "data": {"user": "alice"}}
+//
+// The special fields of this package, like `SourceLocation`, `ErrorReport`,
+// `ServiceContext` and `HTTP`, stay at the top level.
+func WithPayloadNamespace(key string) func(*core) {
+	return func(c *core) {
+		c.config.PayloadNamespace = key
+	}
+}
+
+// isTopLevelKey reports whether a field is interpreted by Cloud Logging, Error
+// Reporting or the Cloud Logging agent, and is kept at the top level by
+// `WithPayloadNamespace`.
+func isTopLevelKey(key string) bool {
+	switch key {
+	case httpRequestKey, errorEventTypeKey, stackTraceKey:
+		return true
+	}
+
+	return isReservedKey(key)
+}
+
+// splitTopLevel returns the fields kept at the top level, and the other fields,
+// of the sets, keeping their order.
+func splitTopLevel(sets ...[]zapcore.Field) (topLevel, nested []zapcore.Field) {
+	for _, fields := range sets {
+		for i := range fields {
+			if isTopLevelKey(fields[i].Key) {
+				topLevel = append(topLevel, fields[i])
+			} else {
+				nested = append(nested, fields[i])
+			}
+		}
+	}
+
+	return topLevel, nested
+}
+
+// addNamespacedPayloadFields adds the top level fields to the payload, and the
+// other fields to an object under the namespace key.
+func addNamespacedPayloadFields(payload map[string]interface{}, namespace string, sets ...[]zapcore.Field) {
+	topLevel, nested := splitTopLevel(sets...)
+	addPayloadFields(payload, topLevel)
+
+	if len(nested) > 0 {
+		object := map[string]interface{}{}
+		addPayloadFields(object, nested)
+		payload[namespace] = object
+	}
+}
+
+// namespacedLocalFields returns the fields of an entry written to the wrapped
+// core, with the fields of the core and the entry, except top level ones, nested
+// under the namespace. The top level fields of the core were added to the
+// wrapped core by `With`.
+func (c *core) namespacedLocalFields(fields []zapcore.Field) []zapcore.Field {
+	inherited := c.fields
+	if c.config.StructuredErrors {
+		inherited = structuredErrorFields(inherited)
+	}
+
+	topLevel, nested := splitTopLevel(fields)
+	_, nestedInherited := splitTopLevel(inherited)
+	if len(nested)+len(nestedInherited) == 0 {
+		return topLevel
+	}
+
+	out := make([]zapcore.Field, 0, len(topLevel)+1+len(nestedInherited)+len(nested))
+	out = append(out, topLevel...)
+	out = append(out, zap.Namespace(c.config.PayloadNamespace))
+	out = append(out, nestedInherited...)
+
+	return append(out, nested...)
+}
@@ -0,0 +1,77 @@
+package zapdriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithPayloadNamespace(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+	out := &bytes.Buffer{}
+
+	local := zapcore.NewCore(zapcore.NewJSONEncoder(NewAgentConfig().EncoderConfig), zapcore.AddSync(out), zapcore.DebugLevel)
+	logger := zap.New(local, zap.AddCaller(), WrapCore(
+		WithLogger(client.Logger("app")),
+		WithPayloadNamespace("data"),
+		ServiceName("service"),
+	)).With(zap.String("user", "alice"), HTTP(&HTTPPayload{RequestMethod: "GET"}))
+
+	logger.Info("hello", zap.Int("attempt", 2), zap.String("message", "collides"))
+	logger.Info("plain")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+
+	payload := entries[0].GetJsonPayload().Fields
+	assert.Equal(t, "hello", payload["message"].GetStringValue())
+	assert.NotContains(t, payload, httpRequestKey)
+	assert.Equal(t, "GET", entries[0].HttpRequest.RequestMethod)
+	data := payload["data"].GetStructValue().Fields
+	assert.Equal(t, "alice", data["user"].GetStringValue())
+	assert.Equal(t, float64(2), data["attempt"].GetNumberValue())
+	assert.Equal(t, "collides", data["message"].GetStringValue())
+	assert.NotContains(t, payload, "user")
+
+	var lines []map[string]interface{}
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var line map[string]interface{}
+		require.NoError(t, dec.Decode(&line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+
+	assert.Equal(t, "hello", lines[0]["message"])
+	assert.Contains(t, lines[0], httpRequestKey)
+	assert.Contains(t, lines[0], sourceKey)
+	assert.Contains(t, lines[0], serviceContextKey)
+	assert.Equal(t, map[string]interface{}{"user": "alice", "attempt": float64(2), "message": "collides"}, lines[0]["data"])
+	assert.Equal(t, map[string]interface{}{"user": "alice"}, lines[1]["data"])
+}
+
+func TestWithPayloadNamespace_NoFields(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+	out := &bytes.Buffer{}
+
+	local := zapcore.NewCore(zapcore.NewJSONEncoder(NewAgentConfig().EncoderConfig), zapcore.AddSync(out), zapcore.DebugLevel)
+	logger := zap.New(local, WrapCore(WithLogger(client.Logger("app")), WithPayloadNamespace("data")))
+
+	logger.Info("hello")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.NotContains(t, entries[0].GetJsonPayload().Fields, "data")
+	assert.NotContains(t, out.String(), `"data"`)
+}
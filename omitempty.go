@@ -0,0 +1,52 @@
+package zapdriver
+
+import (
+	"reflect"
+)
+
+// OmitEmpty prunes empty strings, zero numbers, false booleans, nil values and
+// empty collections from the payload sent to the Cloud Logging API, to reduce
+// ingestion size. Keys listed in `except` are always kept.
+func OmitEmpty(except ...string) func(*core) {
+	return func(c *core) {
+		c.config.OmitEmpty = true
+		c.config.OmitEmptyExcept = map[string]bool{}
+		for _, k := range except {
+			c.config.OmitEmptyExcept[k] = true
+		}
+	}
+}
+
+// pruneEmpty removes all empty values from the payload, recursing into nested
+// maps.
+func pruneEmpty(payload map[string]interface{}, except map[string]bool) {
+	for k, v := range payload {
+		if except[k] {
+			continue
+		}
+
+		if m, ok := v.(map[string]interface{}); ok {
+			pruneEmpty(m, nil)
+		}
+
+		if isEmpty(v) {
+			delete(payload, k)
+		}
+	}
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+
+	return rv.IsZero()
+}
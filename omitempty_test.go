@@ -0,0 +1,59 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestPruneEmpty(t *testing.T) {
+	t.Parallel()
+
+	payload := map[string]interface{}{
+		"message": "",
+		"count":   0,
+		"ok":      false,
+		"err":     nil,
+		"tags":    []string{},
+		"nested":  map[string]interface{}{"empty": ""},
+		"user":    map[string]interface{}{"name": "alice", "email": ""},
+		"retries": 3,
+	}
+
+	pruneEmpty(payload, map[string]bool{"message": true})
+
+	want := map[string]interface{}{
+		"message": "",
+		"user":    map[string]interface{}{"name": "alice"},
+		"retries": 3,
+	}
+
+	assert.Equal(t, want, payload)
+}
+
+func TestWriteOmitEmpty(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	core := &core{
+		Core:       zapcore.NewNopCore(),
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	OmitEmpty("kept")(core)
+
+	err := core.Write(zapcore.Entry{Message: "hello"}, []zapcore.Field{
+		zap.String("empty", ""),
+		zap.String("kept", ""),
+		zap.Int("count", 1),
+	})
+	require.NoError(t, err)
+	require.NoError(t, core.Sync())
+
+	fields := server.Entries()[0].GetJsonPayload().Fields
+	assert.NotContains(t, fields, "empty")
+	assert.Contains(t, fields, "kept")
+	assert.Contains(t, fields, "count")
+}
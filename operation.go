@@ -1,6 +1,12 @@
 package zapdriver
 
 import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -10,7 +16,8 @@ const operationKey = "logging.googleapis.com/operation"
 // Operation adds the correct Stackdriver "operation" field.
 //
 // Additional information about a potentially long-running operation with which
-// a log entry is associated.
+// a log entry is associated. Entries sent to the Cloud Logging API get their
+// operation from this field.
 //
 // see: https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#LogEntryOperation
 func Operation(id, producer string, first, last bool) zap.Field {
@@ -70,3 +77,167 @@ func (op operation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 
 	return nil
 }
+
+// OperationLogger is a Logger whose entries are grouped into a single
+// operation, see `StartOperation`.
+type OperationLogger struct {
+	*zap.Logger
+
+	op *operationState
+}
+
+// StartOperation returns a logger whose entries all share the operation field
+// with a generated operation ID, and the given producer. The first entry is
+// marked as the first of the operation, and `Finish` logs the last one:
+//
+//	op := zapdriver.StartOperation(logger, "my-app/import")
+//	op.Info("Importing.", zap.Int("rows", len(rows)))
+//	...
+//	op.Finish("Imported.")
+//
+// Loggers derived from it, using `With` or `Sugar`, share the operation.
+// Entries already holding an operation field are left untouched. An empty
+// producer is replaced by `DefaultProducer`.
+func StartOperation(logger *zap.Logger, producer string) *OperationLogger {
+	return startOperation(logger, randomID(), producer)
+}
+
+// StartProcessOperation is like `StartOperation`, for cron jobs and batch
+// workers: the operation is shared by the whole process, with an ID generated
+// once (see `ProcessOperationID`) and the `DefaultProducer`, so all entries of
+// a run are grouped without any configuration:
+//
+//	op := zapdriver.StartProcessOperation(logger)
+//	defer op.Finish("Job done.")
+//
+// Only the first entry of the process is marked as the first of the operation.
+func StartProcessOperation(logger *zap.Logger) *OperationLogger {
+	return newOperationLogger(logger, processOperationState())
+}
+
+var processOperation struct {
+	once  sync.Once
+	state *operationState
+}
+
+func processOperationState() *operationState {
+	processOperation.once.Do(func() {
+		processOperation.state = &operationState{id: randomID(), producer: DefaultProducer()}
+	})
+
+	return processOperation.state
+}
+
+// ProcessOperationID returns the operation ID of the running process, see
+// `StartProcessOperation`.
+func ProcessOperationID() string {
+	return processOperationState().id
+}
+
+// DefaultProducer returns the producer of the operations of the running
+// binary: the import path of its main package, read from the build info, or
+// the name of the binary.
+func DefaultProducer() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Path != "" {
+			return info.Path
+		}
+		if info.Main.Path != "" {
+			return info.Main.Path
+		}
+	}
+
+	return filepath.Base(os.Args[0])
+}
+
+func startOperation(logger *zap.Logger, id, producer string) *OperationLogger {
+	if producer == "" {
+		producer = DefaultProducer()
+	}
+
+	return newOperationLogger(logger, &operationState{id: id, producer: producer})
+}
+
+func newOperationLogger(logger *zap.Logger, op *operationState) *OperationLogger {
+	return &OperationLogger{
+		Logger: logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			// The zapdriver core is cloned rather than wrapped, so the helpers
+			// asserting it, like `Close` or `StatsOf`, keep working.
+			if zc, ok := c.(*core); ok {
+				clone := *zc
+				clone.operation = op
+				return &clone
+			}
+
+			return &operationCore{Core: c, op: op}
+		})),
+		op: op,
+	}
+}
+
+// ID returns the generated ID of the operation.
+func (l *OperationLogger) ID() string {
+	return l.op.id
+}
+
+// Finish logs the last entry of the operation at info level. If nothing was
+// logged before, the entry is marked as both the first and last one.
+func (l *OperationLogger) Finish(msg string, fields ...zap.Field) {
+	// Report the caller of Finish as the source of the entry.
+	logger := l.Logger.WithOptions(zap.AddCallerSkip(1))
+	if ce := logger.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(concatFields(fields, l.op.field(true))...)
+	}
+}
+
+// operationState is the operation shared by an OperationLogger and the loggers
+// derived from it.
+type operationState struct {
+	id       string
+	producer string
+
+	// started is set atomically once the first entry is written.
+	started uint32
+}
+
+// field returns the operation field of the next entry of the operation.
+func (op *operationState) field(last bool) zap.Field {
+	first := atomic.CompareAndSwapUint32(&op.started, 0, 1)
+	return Operation(op.id, op.producer, first, last)
+}
+
+// add returns the fields with the operation field of the next entry, unless
+// they already hold an operation field.
+func (op *operationState) add(fields []zapcore.Field) []zapcore.Field {
+	for i := range fields {
+		if fields[i].Key == operationKey {
+			return fields
+		}
+	}
+
+	return concatFields(fields, op.field(false))
+}
+
+// operationCore adds the operation field to every entry written to it, for
+// loggers that don't use the zapdriver core.
+type operationCore struct {
+	zapcore.Core
+
+	op *operationState
+}
+
+func (c *operationCore) With(fields []zapcore.Field) zapcore.Core {
+	return &operationCore{Core: c.Core.With(fields), op: c.op}
+}
+
+func (c *operationCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Core.Check(ent, nil) == nil {
+		return ce
+	}
+
+	return ce.AddCore(ent, c)
+}
+
+func (c *operationCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.op.add(fields))
+}
@@ -4,7 +4,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestOperation(t *testing.T) {
@@ -42,3 +45,137 @@ func TestOperationEnd(t *testing.T) {
 
 	assert.Equal(t, zap.Object(operationKey, op), field)
 }
+
+func TestWriteOperation(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	logger.Info("started", OperationStart("op-1", "my-app"))
+	logger.Info("done", OperationEnd("op-1", "my-app"))
+	logger.Info("unrelated")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 3)
+
+	require.NotNil(t, entries[0].Operation)
+	assert.Equal(t, "op-1", entries[0].Operation.Id)
+	assert.Equal(t, "my-app", entries[0].Operation.Producer)
+	assert.True(t, entries[0].Operation.First)
+	assert.False(t, entries[0].Operation.Last)
+
+	require.NotNil(t, entries[1].Operation)
+	assert.True(t, entries[1].Operation.Last)
+
+	assert.Nil(t, entries[2].Operation)
+}
+
+func TestStartOperation(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	op := StartOperation(logger, "my-app/import")
+	op.Info("importing")
+	op.With(zap.Int("row", 1)).Debug("imported row")
+	logger.Info("unrelated")
+	op.Finish("imported", zap.Int("rows", 1))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 4)
+
+	for _, i := range []int{0, 1, 3} {
+		require.NotNil(t, entries[i].Operation, entries[i])
+		assert.Equal(t, op.ID(), entries[i].Operation.Id)
+		assert.Equal(t, "my-app/import", entries[i].Operation.Producer)
+	}
+	assert.Len(t, op.ID(), 16)
+
+	assert.True(t, entries[0].Operation.First)
+	assert.False(t, entries[0].Operation.Last)
+	assert.False(t, entries[1].Operation.First)
+	assert.False(t, entries[1].Operation.Last)
+	assert.Nil(t, entries[2].Operation)
+	assert.False(t, entries[3].Operation.First)
+	assert.True(t, entries[3].Operation.Last)
+}
+
+func TestStartOperation_FinishOnly(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	op := StartOperation(zap.New(core, zap.AddCaller()), "my-app")
+	op.Finish("done")
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, zap.Object(operationKey, &operation{ID: op.ID(), Producer: "my-app", First: true, Last: true}), entry.Context[0])
+	assert.Contains(t, entry.Caller.File, "operation_test.go")
+}
+
+func TestStartOperation_ExplicitOperation(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	op := StartOperation(zap.New(core), "my-app")
+	op.Info("other", OperationCont("other-id", "other"))
+
+	require.Equal(t, 1, logs.Len())
+	assert.Len(t, logs.All()[0].Context, 1)
+	assert.Equal(t, OperationCont("other-id", "other"), logs.All()[0].Context[0])
+}
+
+func TestStartProcessOperation(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	first := StartProcessOperation(zap.New(core))
+	second := StartProcessOperation(zap.New(core))
+	first.Info("first")
+	second.Finish("second")
+
+	assert.Equal(t, ProcessOperationID(), first.ID())
+	assert.Equal(t, first.ID(), second.ID())
+	assert.NotEmpty(t, DefaultProducer())
+
+	require.Equal(t, 2, logs.Len())
+	op := logs.All()[1].Context[0].Interface.(*operation)
+	assert.Equal(t, &operation{ID: first.ID(), Producer: DefaultProducer(), Last: true}, op)
+}
+
+func TestStartOperation_DefaultProducer(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	StartOperation(zap.New(core), "").Info("hello")
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, DefaultProducer(), logs.All()[0].Context[0].Interface.(*operation).Producer)
+}
+
+func TestStartOperation_ZapdriverCore(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore, WrapCore())
+
+	op := StartOperation(logger, "my-app")
+	op.With(zap.Int("row", 1)).Info("imported row")
+
+	// The helpers asserting the zapdriver core work on operation loggers.
+	_, ok := op.Core().(*core)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), StatsOf(op.Logger).Entries)
+	assert.Equal(t, StatsOf(logger), StatsOf(op.Logger))
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, OperationStart(op.ID(), "my-app"), logs.All()[0].Context[1])
+}
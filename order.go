@@ -0,0 +1,46 @@
+package zapdriver
+
+import (
+	"sync"
+	"time"
+)
+
+// PreserveOrder guarantees that entries show up in Cloud Logging in the order
+// they were produced, which matters for audit streams.
+//
+// Cloud Logging orders entries by their timestamp, and entries produced in
+// quick succession (or on hosts with a coarse clock) often share the same
+// timestamp. With this option, every entry gets a timestamp strictly after the
+// one of the previous entry, by bumping it by a nanosecond where needed. The
+// timestamp is fixed when the entry is written, before it's queued by `Async`
+// or held by `BufferRequest`. With `Async`, a single worker writes the entries,
+// whatever the number of workers it's given, so they're also written in order.
+func PreserveOrder() func(*core) {
+	return func(c *core) {
+		c.config.Order = &sequencer{}
+	}
+}
+
+// sequencer hands out strictly increasing timestamps.
+type sequencer struct {
+	mutex sync.Mutex
+	last  time.Time
+}
+
+// next returns t, or the timestamp right after the previous one if t isn't
+// after it.
+func (s *sequencer) next(t time.Time) time.Time {
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !t.After(s.last) {
+		t = s.last.Add(time.Nanosecond)
+	}
+	s.last = t
+
+	return t
+}
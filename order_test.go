@@ -0,0 +1,94 @@
+package zapdriver
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSequencer(t *testing.T) {
+	t.Parallel()
+
+	s := &sequencer{}
+	now := time.Now()
+
+	assert.Equal(t, now, s.next(now))
+	assert.Equal(t, now.Add(time.Nanosecond), s.next(now))
+	assert.Equal(t, now.Add(2*time.Nanosecond), s.next(now.Add(-time.Second)))
+	assert.Equal(t, now.Add(time.Second), s.next(now.Add(time.Second)))
+}
+
+func TestWritePreserveOrder(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	core := &core{
+		Core:       zapcore.NewNopCore(),
+		lg:         client.Logger("audit"),
+		permLabels: newLabels(),
+	}
+	PreserveOrder()(core)
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, core.Write(zapcore.Entry{Time: now}, nil))
+	}
+	require.NoError(t, core.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 3)
+
+	var last time.Time
+	for _, e := range entries {
+		ts, err := ptypes.Timestamp(e.Timestamp)
+		require.NoError(t, err)
+
+		assert.True(t, ts.After(last))
+		last = ts
+	}
+}
+
+func TestWritePreserveOrder_Async(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	c := &core{
+		Core:       zapcore.NewNopCore(),
+		lg:         client.Logger("audit"),
+		permLabels: newLabels(),
+	}
+	PreserveOrder()(c)
+	Async(8, 1)(c)
+	defer c.config.Async.close()
+
+	now := time.Now()
+	for i := 0; i < 2000; i++ {
+		require.NoError(t, c.Write(zapcore.Entry{Time: now, Message: strconv.Itoa(i)}, nil))
+	}
+	require.NoError(t, c.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 2000)
+
+	times := make([]time.Time, len(entries))
+	for _, e := range entries {
+		i, err := strconv.Atoi(e.GetJsonPayload().Fields["message"].GetStringValue())
+		require.NoError(t, err)
+
+		times[i], err = ptypes.Timestamp(e.Timestamp)
+		require.NoError(t, err)
+	}
+
+	for i := 1; i < len(times); i++ {
+		assert.True(t, times[i].After(times[i-1]), "entry %d is out of order", i)
+	}
+
+	// A single worker writes the entries, in the order they were produced.
+	assert.Equal(t, 1, c.config.Async.workers)
+	for i, e := range entries {
+		assert.Equal(t, strconv.Itoa(i), e.GetJsonPayload().Fields["message"].GetStringValue())
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/blendle/zapdriver/otel
+
+go 1.16
+
+require (
+	github.com/blendle/zapdriver v0.0.0
+	github.com/stretchr/testify v1.7.1
+	go.opentelemetry.io/otel/trace v1.7.0
+)
+
+replace github.com/blendle/zapdriver => ../
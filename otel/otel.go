@@ -0,0 +1,44 @@
+// Package otel integrates OpenTelemetry with zapdriver: once `Register` is
+// called, `zapdriver.TraceFromContext` reads the active OpenTelemetry span of a
+// context:
+//
+//	otel.Register()
+//	...
+//	logger.Info("Order placed.", zapdriver.TraceFromContext(ctx, "my-project")...)
+//
+// It's a separate module, so zapdriver itself doesn't depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/blendle/zapdriver"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var registerOnce sync.Once
+
+// Register registers `SpanContext` as a span context extractor of zapdriver
+// (see `zapdriver.RegisterSpanContextExtractor`). Calling it more than once
+// registers it once.
+func Register() {
+	registerOnce.Do(func() {
+		zapdriver.RegisterSpanContextExtractor(SpanContext)
+	})
+}
+
+// SpanContext returns the span context of the active OpenTelemetry span in
+// ctx, and whether there is a valid one.
+func SpanContext(ctx context.Context) (zapdriver.SpanContext, bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return zapdriver.SpanContext{}, false
+	}
+
+	return zapdriver.SpanContext{
+		TraceID: sc.TraceID().String(),
+		SpanID:  sc.SpanID().String(),
+		Sampled: sc.IsSampled(),
+	}, true
+}
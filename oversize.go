@@ -0,0 +1,270 @@
+package zapdriver
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"cloud.google.com/go/logging"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+)
+
+const (
+	droppedFieldsKey = "dropped_fields"
+	splitKey         = "split"
+
+	// splitProducer is the producer of the operations linking split entries.
+	splitProducer = "github.com/blendle/zapdriver"
+
+	// oversizeMargin is kept free below the entry size limit, for the parts of
+	// an entry that aren't accounted for, like its timestamp, log name and
+	// source location.
+	oversizeMargin = 4 * 1024
+)
+
+// OversizePolicy decides what happens to entries exceeding the size limit of
+// Cloud Logging, which rejects them.
+type OversizePolicy int
+
+const (
+	// OversizeIgnore sends oversized entries as-is. This is the default.
+	OversizeIgnore OversizePolicy = iota
+
+	// OversizeTruncate truncates the largest string values of the payload,
+	// including the message, until the entry fits. The entry gets a "truncated"
+	// field set to true, and a "truncated_sizes" field holding the original size
+	// of every truncated key. If that isn't enough, because of large values
+	// other than strings, those are dropped as with OversizeDropFields.
+	OversizeTruncate
+
+	// OversizeDropFields drops the largest fields of the payload, other than the
+	// message, until the entry fits. The keys of the dropped fields are added as
+	// the "dropped_fields" field.
+	OversizeDropFields
+
+	// OversizeSplit splits the payload over multiple entries, each holding the
+	// message and a part of the fields, chained using the operation field (see
+	// `Operation`), and a "split" field holding the index and count of the
+	// part. Fields too large for an entry of their own are truncated.
+	OversizeSplit
+)
+
+// WithOversizedEntries configures what happens to entries exceeding the size
+// limit of Cloud Logging (256KB), instead of losing them with an opaque client
+// error. Only the entries sent to Cloud Logging are changed, the wrapped core
+// still receives the original entry.
+func WithOversizedEntries(policy OversizePolicy) func(*core) {
+	return func(c *core) {
+		c.config.OversizePolicy = policy
+	}
+}
+
+// fitEntry applies the oversize policy to the entry. Truncated entries and
+// entries with dropped fields are changed in place. It returns the parts of a
+// split entry, or nil if the entry is sent as-is.
+func (c *core) fitEntry(ent *logging.Entry) []logging.Entry {
+	payload, ok := ent.Payload.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	budget := maxEntrySize - oversizeMargin - metadataSize(ent)
+	if payloadSize(payload) <= budget {
+		return nil
+	}
+
+	switch c.config.OversizePolicy {
+	case OversizeTruncate:
+		truncatePayload(payload, budget, c.messageKey())
+	case OversizeDropFields:
+		dropPayloadFields(payload, budget, c.messageKey())
+	case OversizeSplit:
+		return splitEntry(ent, payload, budget, c.messageKey())
+	}
+
+	return nil
+}
+
+// truncatePayload truncates the largest string values of the payload until it
+// fits the budget, and drops the largest other values if that isn't enough.
+func truncatePayload(payload map[string]interface{}, budget int, messageKey string) {
+	t := truncation{}
+
+	size := payloadSize(payload)
+	for _, k := range keysBySize(payload, "") {
+		if size <= budget {
+			break
+		}
+
+		s, ok := payload[k].(string)
+		if !ok {
+			continue
+		}
+
+		// Leave room for the fields describing the truncation.
+		max := len(s) - (size - budget) - len(k) - 64
+		if max < 0 {
+			max = 0
+		}
+
+		t.add(k, len(s))
+		payload[k] = truncateString(s, max)
+		size = payloadSize(payload)
+	}
+
+	if len(t) > 0 {
+		addTruncation(payload, t)
+	}
+
+	dropPayloadFields(payload, budget, messageKey)
+}
+
+// addTruncation adds the fields describing the truncation to the payload,
+// merging them with those added by `WithFieldSizeLimit`.
+func addTruncation(payload map[string]interface{}, t truncation) {
+	sizes, ok := payload[truncatedSizesKey].(map[string]interface{})
+	if !ok {
+		sizes = make(map[string]interface{}, len(t))
+	}
+	for k, v := range t {
+		sizes[k] = v
+	}
+
+	payload[truncatedKey] = true
+	payload[truncatedSizesKey] = sizes
+}
+
+// dropPayloadFields drops the largest fields of the payload, other than the
+// message, until it fits the budget.
+func dropPayloadFields(payload map[string]interface{}, budget int, messageKey string) {
+	var dropped []string
+
+	size := payloadSize(payload)
+	for _, k := range keysBySize(payload, messageKey) {
+		if size <= budget {
+			break
+		}
+
+		delete(payload, k)
+		dropped = append(dropped, k)
+		payload[droppedFieldsKey] = dropped
+		size = payloadSize(payload)
+	}
+}
+
+// splitEntry splits the payload over multiple entries fitting the budget.
+func splitEntry(ent *logging.Entry, payload map[string]interface{}, budget int, messageKey string) []logging.Entry {
+	message, hasMessage := payload[messageKey]
+
+	newPart := func() map[string]interface{} {
+		part := map[string]interface{}{}
+		if hasMessage {
+			part[messageKey] = message
+		}
+		return part
+	}
+
+	keys := make([]string, 0, len(payload))
+	for k := range payload {
+		if k != messageKey {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	// Leave room for the split field.
+	budget -= 64
+
+	part := newPart()
+	baseLen, baseSize := len(part), payloadSize(part)
+	size := baseSize
+	parts := []map[string]interface{}{part}
+	for _, k := range keys {
+		n := payloadSize(payload[k]) + len(k) + 4
+		if size+n > budget && len(part) > baseLen {
+			part = newPart()
+			size = baseSize
+			parts = append(parts, part)
+		}
+
+		part[k] = payload[k]
+		size += n
+	}
+
+	id := randomID()
+	entries := make([]logging.Entry, len(parts))
+	for i, p := range parts {
+		p[splitKey] = map[string]interface{}{"index": i, "count": len(parts)}
+		if payloadSize(p) > budget {
+			truncatePayload(p, budget, messageKey)
+		}
+
+		e := *ent
+		e.Payload = p
+		if ent.Operation == nil {
+			e.Operation = &logpb.LogEntryOperation{
+				Id:       id,
+				Producer: splitProducer,
+				First:    i == 0,
+				Last:     i == len(parts)-1,
+			}
+		}
+		if ent.InsertID != "" {
+			e.InsertID = ent.InsertID + "-" + strconv.Itoa(i)
+		}
+
+		entries[i] = e
+	}
+
+	return entries
+}
+
+// keysBySize returns the keys of the payload, other than `except`, ordered by
+// the size of their encoded value, largest first.
+func keysBySize(payload map[string]interface{}, except string) []string {
+	keys := make([]string, 0, len(payload))
+	sizes := make(map[string]int, len(payload))
+	for k, v := range payload {
+		if k == except || k == droppedFieldsKey || k == truncatedKey || k == truncatedSizesKey {
+			continue
+		}
+
+		keys = append(keys, k)
+		sizes[k] = payloadSize(v)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if sizes[keys[i]] != sizes[keys[j]] {
+			return sizes[keys[i]] > sizes[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+// payloadSize returns the size of the value, encoded as JSON.
+func payloadSize(v interface{}) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+
+	return len(b)
+}
+
+// metadataSize returns the size of the labels of the entry, and of its
+// monitored resource, which count towards the size limit.
+func metadataSize(ent *logging.Entry) int {
+	size := 0
+	for k, v := range ent.Labels {
+		size += len(k) + len(v)
+	}
+	if r := ent.Resource; r != nil {
+		for k, v := range r.Labels {
+			size += len(k) + len(v)
+		}
+	}
+
+	return size
+}
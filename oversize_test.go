@@ -0,0 +1,107 @@
+package zapdriver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+)
+
+func writeOversized(t *testing.T, policy OversizePolicy) ([]*logpb.LogEntry, *observer.ObservedLogs) {
+	t.Helper()
+
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithOversizedEntries(policy)(core)
+
+	logger := zap.New(core)
+	logger.Info("hello",
+		zap.String("big", strings.Repeat("a", 200*1024)),
+		zap.String("bigger", strings.Repeat("b", 220*1024)),
+		zap.String("small", "value"),
+	)
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	for _, e := range entries {
+		assert.True(t, proto.Size(e) < maxEntrySize)
+		assert.Equal(t, "hello", e.GetJsonPayload().Fields["message"].GetStringValue())
+	}
+
+	return entries, logs
+}
+
+func TestWriteOversized_Truncate(t *testing.T) {
+	entries, logs := writeOversized(t, OversizeTruncate)
+	require.Len(t, entries, 1)
+
+	fields := entries[0].GetJsonPayload().Fields
+	assert.True(t, fields[truncatedKey].GetBoolValue())
+	sizes := fields[truncatedSizesKey].GetStructValue().Fields
+	assert.Equal(t, float64(220*1024), sizes["bigger"].GetNumberValue())
+	assert.Equal(t, "value", fields["small"].GetStringValue())
+
+	// The wrapped core receives the original entry.
+	assert.Len(t, logs.All()[0].ContextMap()["bigger"], 220*1024)
+}
+
+func TestWriteOversized_DropFields(t *testing.T) {
+	entries, _ := writeOversized(t, OversizeDropFields)
+	require.Len(t, entries, 1)
+
+	fields := entries[0].GetJsonPayload().Fields
+	assert.NotContains(t, fields, "bigger")
+	assert.Contains(t, fields, "big")
+	assert.Equal(t, "bigger", fields[droppedFieldsKey].GetListValue().Values[0].GetStringValue())
+}
+
+func TestWriteOversized_Split(t *testing.T) {
+	entries, _ := writeOversized(t, OversizeSplit)
+	require.Len(t, entries, 2)
+
+	first, last := entries[0], entries[1]
+	assert.Contains(t, first.GetJsonPayload().Fields, "big")
+	assert.Contains(t, last.GetJsonPayload().Fields, "bigger")
+	assert.Contains(t, last.GetJsonPayload().Fields, "small")
+
+	require.NotNil(t, first.Operation)
+	require.NotNil(t, last.Operation)
+	assert.Equal(t, first.Operation.Id, last.Operation.Id)
+	assert.True(t, first.Operation.First)
+	assert.True(t, last.Operation.Last)
+
+	split := last.GetJsonPayload().Fields[splitKey].GetStructValue().Fields
+	assert.Equal(t, float64(1), split["index"].GetNumberValue())
+	assert.Equal(t, float64(2), split["count"].GetNumberValue())
+}
+
+func TestWriteOversized_Fits(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	core := &core{
+		Core:       zapcore.NewNopCore(),
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithOversizedEntries(OversizeSplit)(core)
+
+	require.NoError(t, core.Write(zapcore.Entry{Message: "hello"}, []zap.Field{zap.String("small", "value")}))
+	require.NoError(t, core.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Nil(t, entries[0].Operation)
+	assert.NotContains(t, entries[0].GetJsonPayload().Fields, splitKey)
+}
@@ -0,0 +1,152 @@
+package zapdriver
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// payloadEncoder is an ObjectEncoder building the JSON-safe payload of Cloud
+// Logging entries. Fields are encoded using their own `AddTo` method, so every
+// field type is encoded like the local encoder does, with these exceptions:
+//
+//   - reflected values are converted like the values of `Any`,
+//   - objects with `log` struct tags are redacted (see `redact`),
+//   - errors are encoded as an object holding their message, type and stack
+//     trace (see `StructuredErrors`),
+//   - byte strings are encoded as strings, and complex numbers as strings
+//     like "1+2i", as JSON can't represent them.
+type payloadEncoder struct {
+	fields map[string]interface{}
+}
+
+// addField adds the field to the payload.
+func (e *payloadEncoder) addField(f zapcore.Field) {
+	if f.Type == zapcore.ErrorType {
+		if err, ok := f.Interface.(error); ok {
+			e.fields[f.Key] = errorPayload(err)
+		} else {
+			e.fields[f.Key] = f.Interface
+		}
+		return
+	}
+
+	f.AddTo(e)
+}
+
+func (e *payloadEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	arr := &payloadArrayEncoder{elems: make([]interface{}, 0)}
+	err := v.MarshalLogArray(arr)
+	e.fields[key] = arr.elems
+	return err
+}
+
+func (e *payloadEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	if needsRedaction(reflect.TypeOf(v)) {
+		e.fields[key] = redact(v)
+		return nil
+	}
+
+	obj := &payloadEncoder{fields: map[string]interface{}{}}
+	err := v.MarshalLogObject(obj)
+	e.fields[key] = obj.fields
+	return err
+}
+
+func (e *payloadEncoder) AddBinary(key string, v []byte)         { e.fields[key] = v }
+func (e *payloadEncoder) AddByteString(key string, v []byte)     { e.fields[key] = string(v) }
+func (e *payloadEncoder) AddBool(key string, v bool)             { e.fields[key] = v }
+func (e *payloadEncoder) AddComplex128(key string, v complex128) { e.fields[key] = formatComplex(v) }
+func (e *payloadEncoder) AddComplex64(key string, v complex64) {
+	e.fields[key] = formatComplex(complex128(v))
+}
+func (e *payloadEncoder) AddDuration(key string, v time.Duration) { e.fields[key] = v }
+func (e *payloadEncoder) AddFloat64(key string, v float64)        { e.fields[key] = v }
+func (e *payloadEncoder) AddFloat32(key string, v float32)        { e.fields[key] = v }
+func (e *payloadEncoder) AddInt(key string, v int)                { e.fields[key] = v }
+func (e *payloadEncoder) AddInt64(key string, v int64)            { e.fields[key] = v }
+func (e *payloadEncoder) AddInt32(key string, v int32)            { e.fields[key] = v }
+func (e *payloadEncoder) AddInt16(key string, v int16)            { e.fields[key] = v }
+func (e *payloadEncoder) AddInt8(key string, v int8)              { e.fields[key] = v }
+func (e *payloadEncoder) AddString(key string, v string)          { e.fields[key] = v }
+func (e *payloadEncoder) AddTime(key string, v time.Time)         { e.fields[key] = v }
+func (e *payloadEncoder) AddUint(key string, v uint)              { e.fields[key] = v }
+func (e *payloadEncoder) AddUint64(key string, v uint64)          { e.fields[key] = v }
+func (e *payloadEncoder) AddUint32(key string, v uint32)          { e.fields[key] = v }
+func (e *payloadEncoder) AddUint16(key string, v uint16)          { e.fields[key] = v }
+func (e *payloadEncoder) AddUint8(key string, v uint8)            { e.fields[key] = v }
+func (e *payloadEncoder) AddUintptr(key string, v uintptr)        { e.fields[key] = v }
+
+func (e *payloadEncoder) AddReflected(key string, v interface{}) error {
+	e.fields[key] = encodeAny(v, DefaultAnyDepth)
+	return nil
+}
+
+// OpenNamespace nests the fields added afterwards in an object, like the local
+// encoder does.
+func (e *payloadEncoder) OpenNamespace(key string) {
+	ns := map[string]interface{}{}
+	e.fields[key] = ns
+	e.fields = ns
+}
+
+// payloadArrayEncoder is the ArrayEncoder of the payloadEncoder.
+type payloadArrayEncoder struct {
+	elems []interface{}
+}
+
+func (e *payloadArrayEncoder) AppendArray(v zapcore.ArrayMarshaler) error {
+	arr := &payloadArrayEncoder{elems: make([]interface{}, 0)}
+	err := v.MarshalLogArray(arr)
+	e.elems = append(e.elems, arr.elems)
+	return err
+}
+
+func (e *payloadArrayEncoder) AppendObject(v zapcore.ObjectMarshaler) error {
+	if needsRedaction(reflect.TypeOf(v)) {
+		e.elems = append(e.elems, redact(v))
+		return nil
+	}
+
+	obj := &payloadEncoder{fields: map[string]interface{}{}}
+	err := v.MarshalLogObject(obj)
+	e.elems = append(e.elems, obj.fields)
+	return err
+}
+
+func (e *payloadArrayEncoder) AppendReflected(v interface{}) error {
+	e.elems = append(e.elems, encodeAny(v, DefaultAnyDepth))
+	return nil
+}
+
+func (e *payloadArrayEncoder) AppendBool(v bool)         { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendByteString(v []byte) { e.elems = append(e.elems, string(v)) }
+func (e *payloadArrayEncoder) AppendComplex128(v complex128) {
+	e.elems = append(e.elems, formatComplex(v))
+}
+func (e *payloadArrayEncoder) AppendComplex64(v complex64) {
+	e.elems = append(e.elems, formatComplex(complex128(v)))
+}
+func (e *payloadArrayEncoder) AppendDuration(v time.Duration) { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendFloat64(v float64)        { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendFloat32(v float32)        { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendInt(v int)                { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendInt64(v int64)            { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendInt32(v int32)            { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendInt16(v int16)            { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendInt8(v int8)              { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendString(v string)          { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendTime(v time.Time)         { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendUint(v uint)              { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendUint64(v uint64)          { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendUint32(v uint32)          { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendUint16(v uint16)          { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendUint8(v uint8)            { e.elems = append(e.elems, v) }
+func (e *payloadArrayEncoder) AppendUintptr(v uintptr)        { e.elems = append(e.elems, v) }
+
+// formatComplex formats a complex number like the JSON encoder of zap does.
+func formatComplex(c complex128) string {
+	return fmt.Sprintf("%g%+gi", real(c), imag(c))
+}
@@ -0,0 +1,46 @@
+package zapdriver
+
+import (
+	"context"
+	"runtime/pprof"
+
+	"go.uber.org/zap"
+)
+
+// PprofLabels adds the pprof labels of ctx, set using `pprof.Do` or
+// `pprof.WithLabels`, as labels, so logs and CPU profiles can be joined on the
+// same dimensions:
+//
+//	pprof.Do(ctx, pprof.Labels("tenant", tenant), func(ctx context.Context) {
+//	  logger.Info("Importing.", zapdriver.PprofLabels(ctx))
+//	})
+//
+// Go can only read the pprof labels of a context, not those of the current
+// goroutine. To add them to every entry logged using `Logger.Ctx`, use
+// `Logger.WithPprofLabels`.
+func PprofLabels(ctx context.Context) zap.Field {
+	if f, ok := pprofLabelsField(ctx); ok {
+		return f
+	}
+
+	return zap.Skip()
+}
+
+// pprofLabelsField returns the field holding the pprof labels of ctx, if it has
+// any.
+func pprofLabelsField(ctx context.Context) (zap.Field, bool) {
+	var lbls *labels
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		if lbls == nil {
+			lbls = newLabels()
+		}
+		lbls.store[key] = value
+		return true
+	})
+
+	if lbls == nil {
+		return zap.Field{}, false
+	}
+
+	return labelsField(lbls), true
+}
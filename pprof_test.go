@@ -0,0 +1,47 @@
+package zapdriver
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestPprofLabels(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, zap.Skip(), PprofLabels(context.Background()))
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()})
+
+	pprof.Do(context.Background(), pprof.Labels("tenant", "acme", "job", "import"), func(ctx context.Context) {
+		logger.Info("importing", PprofLabels(ctx))
+	})
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "job": "import"}, logs.All()[0].ContextMap()[labelsKey])
+}
+
+func TestLoggerWithPprofLabels(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(&core{Core: debugcore, permLabels: newLabels()}), "my-project")
+
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("tenant", "acme", "job", "import"))
+	ctx = ContextWithLabels(ctx, map[string]string{"job": "export"})
+
+	logger.Ctx(ctx).Info("without")
+	logger.WithPprofLabels().With(zap.String("component", "jobs")).Ctx(ctx).Info("with")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	assert.Equal(t, map[string]interface{}{"job": "export"}, entries[0].ContextMap()[labelsKey])
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "job": "export"}, entries[1].ContextMap()[labelsKey])
+}
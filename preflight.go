@@ -0,0 +1,41 @@
+package zapdriver
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Preflight verifies that entries can be written using the client, by writing
+// a single deduplicated entry to the `ping` log of the project. Call it when
+// the service boots, so a missing `logging.logEntries.write` permission fails
+// the deployment, instead of silently dropping entries in production.
+//
+// The returned error explains how to resolve the most common failures.
+func Preflight(ctx context.Context, client *logging.Client) error {
+	err := client.Ping(ctx)
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("zapdriver: preflight failed: %v%s", err, preflightHint(err))
+}
+
+// preflightHint returns an actionable hint for a failed preflight check.
+func preflightHint(err error) string {
+	switch status.Code(err) {
+	case codes.PermissionDenied:
+		return " (grant the `logging.logEntries.write` permission, e.g. using the `roles/logging.logWriter` role, to the service account)"
+	case codes.Unauthenticated:
+		return " (no valid credentials found, check GOOGLE_APPLICATION_CREDENTIALS or the metadata server)"
+	case codes.NotFound:
+		return " (the project does not exist, check the project ID)"
+	case codes.ResourceExhausted:
+		return " (the write quota of the project is exhausted)"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,29 @@
+package zapdriver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPreflight(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	require.NoError(t, Preflight(context.Background(), client))
+	assert.Len(t, server.Entries(), 1)
+}
+
+func TestPreflight_PermissionDenied(t *testing.T) {
+	client, server := newFakeClient(t)
+	server.setError(status.Error(codes.PermissionDenied, "denied"))
+
+	err := Preflight(context.Background(), client)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "zapdriver: preflight failed")
+	assert.Contains(t, err.Error(), "logging.logEntries.write")
+}
@@ -0,0 +1,59 @@
+package zapdriver
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// PromoteToLabels turns the fields matching `promote` into labels, so existing
+// fields such as "tenant_id" become labels without renaming them at every call
+// site (see `Label`). Only fields with a string or scalar value are promoted,
+// their keys are sanitized like those of other labels (see `SanitizeLabelKey`).
+//
+// Promoted fields are removed from the payload.
+func PromoteToLabels(promote func(zapcore.Field) bool) func(*core) {
+	return func(c *core) {
+		c.config.PromoteLabels = promote
+	}
+}
+
+// PromoteKeysToLabels turns the fields with the given keys into labels, see
+// `PromoteToLabels`.
+func PromoteKeysToLabels(keys ...string) func(*core) {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+
+	return PromoteToLabels(func(f zapcore.Field) bool { return set[f.Key] })
+}
+
+// promoted reports whether the field is promoted to a label.
+func (c *core) promoted(f zapcore.Field) bool {
+	return c.config.PromoteLabels != nil && isScalarField(f) && c.config.PromoteLabels(f)
+}
+
+// hasPromotedFields reports whether any of the fields is promoted to a label.
+func (c *core) hasPromotedFields(fields []zapcore.Field) bool {
+	if c.config.PromoteLabels == nil {
+		return false
+	}
+
+	for i := range fields {
+		if c.promoted(fields[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addLabel adds the labels of the field to the store, including promoted
+// fields, and reports whether it did.
+func (c *core) addLabel(store map[string]string, f zapcore.Field, key func(string) string) bool {
+	if c.promoted(f) {
+		store[key(f.Key)] = labelValue(f)
+		return true
+	}
+
+	return addLabelField(store, f, key)
+}
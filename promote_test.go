@@ -0,0 +1,52 @@
+package zapdriver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestPromoteKeysToLabels(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	PromoteKeysToLabels("tenant_id", "region", "shard", "request")(core)
+
+	logger := zap.New(core).With(zap.String("tenant_id", "acme"))
+	logger.Info("hello",
+		zap.String("region", "eu"),
+		zap.Int("shard", 3),
+		zap.Any("request", map[string]string{"not": "scalar"}),
+		zap.String("user", "jane"),
+	)
+	require.NoError(t, logger.Sync())
+
+	context := logs.All()[0].ContextMap()
+	assert.Equal(t, map[string]interface{}{"tenant_id": "acme", "region": "eu", "shard": "3"}, context[labelsKey])
+	assert.NotContains(t, context, "region")
+	assert.Contains(t, context, "request")
+	assert.Equal(t, "jane", context["user"])
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]string{"tenant_id": "acme", "region": "eu", "shard": "3"}, entries[0].Labels)
+	assert.NotContains(t, entries[0].GetJsonPayload().Fields, "tenant_id")
+}
+
+func TestPromoteToLabels(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	PromoteToLabels(func(f zapcore.Field) bool { return strings.HasSuffix(f.Key, "_id") })(core)
+
+	zap.New(core).Info("hello", zap.String("Tenant_ID", "acme"), zap.String("user_id", "42"), zap.String("user", "jane"))
+
+	assert.Equal(t, map[string]interface{}{"user_id": "42"}, logs.All()[0].ContextMap()[labelsKey])
+}
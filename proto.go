@@ -0,0 +1,50 @@
+package zapdriver
+
+import (
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+// protoMarshaler serializes messages to JSON, keeping the field names of their
+// definition, and the names of enum values.
+var protoMarshaler = &jsonpb.Marshaler{OrigName: true}
+
+// Proto adds a field holding a protocol buffer message as a nested object,
+// serialized like protojson does, instead of through reflection, which loses
+// the field names and enum value names of the message:
+//
+//	logger.Info("Access checked.", zapdriver.Proto("audit", auditLog))
+//
+// A message that can't be serialized is added as `Any` would.
+func Proto(key string, msg proto.Message) zap.Field {
+	s, err := protoMarshaler.MarshalToString(msg)
+	if err != nil {
+		return Any(key, msg)
+	}
+
+	return RawJSON(key, []byte(s))
+}
+
+// ProtoPayload replaces the entire payload of the entry sent to Cloud Logging
+// with a protocol buffer message, serialized like `Proto`, and with an "@type"
+// key holding the type URL of the message, the way Cloud Logging presents
+// messages. Like with `RawPayload`, the other fields and the message are not
+// part of that payload, and the message is added as the "payload" field
+// locally.
+//
+// The Cloud Logging client only sends JSON payloads, so the entry has a JSON
+// payload instead of a proto payload.
+func ProtoPayload(msg proto.Message) zap.Field {
+	s, err := protoMarshaler.MarshalToString(msg)
+	if err != nil {
+		return Any(rawPayloadKey, msg)
+	}
+
+	typ := `{"@type":"type.googleapis.com/` + proto.MessageName(msg) + `"`
+	if s == "{}" {
+		return RawPayload([]byte(typ + "}"))
+	}
+
+	return RawPayload([]byte(typ + "," + s[1:]))
+}
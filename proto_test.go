@@ -0,0 +1,64 @@
+package zapdriver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	ltype "google.golang.org/genproto/googleapis/logging/type"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+)
+
+func TestWriteProto(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	msg := &logpb.LogEntry{LogName: "audit", Severity: ltype.LogSeverity_NOTICE}
+
+	logger := zap.New(core)
+	logger.Info("checked", Proto("audit", msg))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+
+	audit := entries[0].GetJsonPayload().Fields["audit"].GetStructValue().Fields
+	assert.Equal(t, "audit", audit["log_name"].GetStringValue())
+	assert.Equal(t, "NOTICE", audit["severity"].GetStringValue())
+
+	assert.JSONEq(t, `{"log_name":"audit","severity":"NOTICE"}`, string(logs.All()[0].Context[0].Interface.(json.RawMessage)))
+}
+
+func TestWriteProtoPayload(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	logger.Info("checked", ProtoPayload(&logpb.LogEntry{LogName: "audit"}), zap.String("other", "field"))
+	logger.Info("empty", ProtoPayload(&logpb.LogEntry{}))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+
+	payload := entries[0].GetJsonPayload().Fields
+	assert.Len(t, payload, 2)
+	assert.Equal(t, "type.googleapis.com/google.logging.v2.LogEntry", payload["@type"].GetStringValue())
+	assert.Equal(t, "audit", payload["log_name"].GetStringValue())
+
+	payload = entries[1].GetJsonPayload().Fields
+	assert.Len(t, payload, 1)
+	assert.Equal(t, "type.googleapis.com/google.logging.v2.LogEntry", payload["@type"].GetStringValue())
+}
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package zapdriver
+
+// raceEnabled reports whether the tests run with the race detector, which
+// makes allocations.
+const raceEnabled = true
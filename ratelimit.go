@@ -0,0 +1,162 @@
+package zapdriver
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithRateLimit writes at most `limit` entries every `per` for every distinct
+// combination of values of the given labels, so a single noisy component can't
+// exhaust the Cloud Logging quota:
+//
+//	zapdriver.WithRateLimit(1000, time.Minute, "component")
+//
+// Entries without any of the labels aren't limited. Suppressed entries are
+// written neither to Cloud Logging nor to the wrapped core. Once a period is
+// over, the next entry written is preceded by a warning per label combination
+// that had entries suppressed, with their count. `SuppressedEntries` returns
+// the total number of suppressed entries.
+func WithRateLimit(limit int, per time.Duration, keys ...string) func(*core) {
+	return func(c *core) {
+		c.config.RateLimit = &rateLimiter{
+			limit:  uint64(limit),
+			per:    per,
+			keys:   keys,
+			counts: map[string]*rateCount{},
+		}
+	}
+}
+
+// SuppressedEntries returns the number of entries the rate limit of the logger
+// suppressed (see `WithRateLimit`). It returns 0 if the logger doesn't use the
+// zapdriver core, or isn't rate limited.
+func SuppressedEntries(logger *zap.Logger) uint64 {
+	c, ok := logger.Core().(*core)
+	if !ok || c.config.RateLimit == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&c.config.RateLimit.suppressed)
+}
+
+// rateLimiter counts the entries per combination of label values, in periods
+// of `per`.
+type rateLimiter struct {
+	// suppressed counts all suppressed entries. It's accessed atomically, and
+	// kept first for alignment.
+	suppressed uint64
+
+	limit uint64
+	per   time.Duration
+	keys  []string
+
+	mutex  sync.Mutex
+	period int64
+	counts map[string]*rateCount
+}
+
+// rateCount counts the entries of a combination of label values in the current
+// period.
+type rateCount struct {
+	labels     map[string]string
+	written    uint64
+	suppressed uint64
+}
+
+// allow reports whether the entry with the given labels is written. It returns
+// the counts of the previous period that had entries suppressed, once it's
+// over.
+func (r *rateLimiter) allow(ent zapcore.Entry, lbls *labels) (bool, []*rateCount) {
+	var key strings.Builder
+	values := map[string]string{}
+	for _, k := range r.keys {
+		if v, ok := lbls.store[k]; ok {
+			values[k] = v
+			key.WriteString(k + "=" + v)
+		}
+		key.WriteByte(0)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	period := int64(0)
+	if r.per > 0 {
+		period = ent.Time.UnixNano() / int64(r.per)
+	}
+
+	var summaries []*rateCount
+	if period != r.period {
+		keys := make([]string, 0, len(r.counts))
+		for k, count := range r.counts {
+			if count.suppressed > 0 {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			summaries = append(summaries, r.counts[k])
+		}
+		r.period = period
+		r.counts = map[string]*rateCount{}
+	}
+
+	if len(values) == 0 {
+		return true, summaries
+	}
+
+	count, ok := r.counts[key.String()]
+	if !ok {
+		count = &rateCount{labels: values}
+		r.counts[key.String()] = count
+	}
+
+	if count.written < r.limit {
+		count.written++
+		return true, summaries
+	}
+
+	count.suppressed++
+	atomic.AddUint64(&r.suppressed, 1)
+
+	return false, summaries
+}
+
+// reportSuppressed writes a warning for every count of the previous period
+// that had entries suppressed. The warnings aren't rate limited themselves.
+func (c *core) reportSuppressed(ent zapcore.Entry, counts []*rateCount) {
+	unlimited := *c
+	unlimited.config.RateLimit = nil
+
+	for _, count := range counts {
+		keys := make([]string, 0, len(count.labels))
+		for k := range count.labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fields := make([]zapcore.Field, 0, len(keys)+3)
+		for _, k := range keys {
+			fields = append(fields, Label(k, count.labels[k]))
+		}
+		fields = append(fields,
+			zap.Uint64("suppressed", count.suppressed),
+			zap.Uint64("limit", c.config.RateLimit.limit),
+			zap.Duration("period", c.config.RateLimit.per),
+		)
+
+		_ = unlimited.write(zapcore.Entry{
+			Level:      zapcore.WarnLevel,
+			Time:       ent.Time,
+			LoggerName: ent.LoggerName,
+			Message:    "zapdriver: entries suppressed by the rate limit",
+		}, fields, true)
+	}
+}
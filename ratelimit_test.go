@@ -0,0 +1,51 @@
+package zapdriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWriteRateLimit(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithRateLimit(2, time.Minute, "component")(core)
+
+	logger := zap.New(core)
+	noisy := logger.With(Label("component", "noisy"))
+
+	now := time.Now().Truncate(time.Minute)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, noisy.Core().Write(zapcore.Entry{Time: now, Message: "noisy"}, nil))
+		require.NoError(t, logger.Core().Write(zapcore.Entry{Time: now, Message: "quiet"}, []zapcore.Field{Label("component", "quiet")}))
+		require.NoError(t, logger.Core().Write(zapcore.Entry{Time: now, Message: "unlabeled"}, nil))
+	}
+
+	assert.Equal(t, 2, logs.FilterMessage("noisy").Len())
+	assert.Equal(t, 2, logs.FilterMessage("quiet").Len())
+	assert.Equal(t, 5, logs.FilterMessage("unlabeled").Len())
+	assert.Equal(t, uint64(6), SuppressedEntries(logger))
+
+	// The first entry of the next period is preceded by the summaries.
+	require.NoError(t, logger.Core().Write(zapcore.Entry{Time: now.Add(time.Minute), Message: "next"}, nil))
+
+	summaries := logs.FilterMessage("zapdriver: entries suppressed by the rate limit").All()
+	require.Len(t, summaries, 2)
+	assert.Equal(t, map[string]interface{}{"component": "noisy"}, summaries[0].ContextMap()[labelsKey])
+	assert.Equal(t, uint64(3), summaries[0].ContextMap()["suppressed"])
+	assert.Equal(t, map[string]interface{}{"component": "quiet"}, summaries[1].ContextMap()[labelsKey])
+
+	require.NoError(t, logger.Sync())
+	assert.Len(t, server.Entries(), logs.Len())
+}
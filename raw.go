@@ -0,0 +1,56 @@
+package zapdriver
+
+import (
+	"encoding/json"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const rawPayloadKey = "payload"
+
+// RawJSON adds a field with already-serialized JSON, which is passed through
+// untouched, instead of being decoded and encoded again. Invalid JSON is added
+// as a string instead.
+func RawJSON(key string, b []byte) zap.Field {
+	if !json.Valid(b) {
+		return zap.ByteString(key, b)
+	}
+
+	return zap.Reflect(key, json.RawMessage(b))
+}
+
+// RawPayload replaces the entire payload of the entry sent to Cloud Logging
+// with an already-serialized JSON object, passed through untouched. All other
+// fields, and the message, are not part of that payload. Locally, the object
+// is added as the "payload" field.
+//
+// An invalid JSON object is added as a regular field instead.
+func RawPayload(b []byte) zap.Field {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return zap.ByteString(rawPayloadKey, b)
+	}
+
+	return zap.Reflect(rawPayloadKey, rawPayload(b))
+}
+
+// rawPayload is an already-serialized JSON object replacing the payload of an
+// entry.
+type rawPayload []byte
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p rawPayload) MarshalJSON() ([]byte, error) {
+	return p, nil
+}
+
+// findRawPayload returns the raw payload in the fields, if any.
+func findRawPayload(fields []zapcore.Field) (json.RawMessage, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if p, ok := fields[i].Interface.(rawPayload); ok && fields[i].Type == zapcore.ReflectType {
+			return json.RawMessage(p), true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,67 @@
+package zapdriver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRawJSON(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "message"})
+	logger := zap.New(zapcore.NewCore(enc, zapcore.AddSync(buf), zapcore.DebugLevel))
+
+	logger.Info("hello", RawJSON("blob", []byte(`{"b":1,"a":[true]}`)), RawJSON("broken", []byte(`{`)))
+
+	assert.Equal(t, `{"message":"hello","blob":{"b":1,"a":[true]},"broken":"{"}`+"\n", buf.String())
+}
+
+func TestWriteRawJSON(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	logger := zap.New(core)
+
+	logger.Info("hello", RawJSON("blob", []byte(`{"a":[1,2]}`)))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+
+	blob := entries[0].GetJsonPayload().Fields["blob"].GetStructValue()
+	assert.Len(t, blob.Fields["a"].GetListValue().Values, 2)
+}
+
+func TestWriteRawPayload(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	logger := zap.New(core)
+
+	logger.Info("hello", zap.String("other", "field"), RawPayload([]byte(`{"event":"signup"}`)))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+
+	payload := entries[0].GetJsonPayload().Fields
+	assert.Len(t, payload, 1)
+	assert.Equal(t, "signup", payload["event"].GetStringValue())
+
+	assert.Contains(t, logs.All()[0].ContextMap(), "payload")
+}
+
+func TestRawPayload_Invalid(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, zap.ByteString("payload", []byte(`[1]`)), RawPayload([]byte(`[1]`)))
+}
@@ -0,0 +1,152 @@
+package zapdriver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoverAndLog recovers a panic, and logs it at critical severity, with the
+// stack trace of the panicking goroutine and an `ErrorReport()` for the
+// location of the panic, so it shows up in Error Reporting. The logger is
+// flushed afterwards. It must be deferred directly:
+//
+//	defer zapdriver.RecoverAndLog(logger)
+//
+// The entry is written without panicking, even by development loggers.
+func RecoverAndLog(logger *zap.Logger) {
+	if r := recover(); r != nil {
+		logPanic(logger, r)
+	}
+}
+
+// RecoverAndRepanic is the same as RecoverAndLog, but panics again with the
+// recovered value after logging it, so the program still crashes.
+func RecoverAndRepanic(logger *zap.Logger) {
+	if r := recover(); r != nil {
+		logPanic(logger, r)
+		panic(r)
+	}
+}
+
+// RecoverMiddleware returns HTTP middleware recovering panics of the handler,
+// logging them like RecoverAndLog, and responding with status 500.
+//
+// Panics with `http.ErrAbortHandler` are not logged, and passed on.
+func RecoverMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				logPanic(logger, rec, zap.String("method", r.Method), zap.String("url", r.URL.String()))
+				w.WriteHeader(http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnaryServerRecoveryInterceptor returns a gRPC server interceptor recovering
+// panics of the handler, logging them like RecoverAndLog, and returning an
+// error with code Internal instead.
+func UnaryServerRecoveryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(logger, r, zap.String("grpc.method", info.FullMethod))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerRecoveryInterceptor is the same as
+// UnaryServerRecoveryInterceptor, for streaming RPCs.
+func StreamServerRecoveryInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(logger, r, zap.String("grpc.method", info.FullMethod))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// logPanic logs a recovered panic at critical severity, and flushes the logger.
+// It must be called by the deferred function that recovered the panic.
+func logPanic(logger *zap.Logger, r interface{}, fields ...zap.Field) {
+	ent := zapcore.Entry{
+		Level:   zapcore.DPanicLevel,
+		Time:    time.Now(),
+		Message: fmt.Sprintf("panic: %v", r),
+		Stack:   panicStack(),
+	}
+
+	pc, file, line, ok := panicCaller()
+	if ok {
+		ent.Caller = zapcore.NewEntryCaller(pc, file, line, true)
+	}
+
+	// The entry is checked against the core instead of the logger, so
+	// development loggers don't panic on the DPanic level.
+	if ce := logger.Core().Check(ent, nil); ce != nil {
+		ce.Write(append(fields, zap.Any("panic", r), ErrorReport(pc, file, line, ok))...)
+	}
+
+	_ = logger.Sync()
+}
+
+// panicCaller returns the location of the panic being recovered, which is the
+// first frame outside of the runtime, after the deferred functions.
+func panicCaller() (pc uintptr, file string, line int, ok bool) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+
+	inRuntime := false
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "runtime.") {
+			inRuntime = true
+		} else if inRuntime {
+			return frame.PC, frame.File, frame.Line, true
+		}
+
+		if !more {
+			return 0, "", 0, false
+		}
+	}
+}
+
+// panicStack returns the stack trace of the current goroutine, without its
+// header, in the same format as the stack traces of zap.
+func panicStack() string {
+	stack := string(debug.Stack())
+	if i := strings.Index(stack, "\n"); i >= 0 {
+		stack = stack[i+1:]
+	}
+
+	return strings.TrimRight(stack, "\n")
+}
@@ -0,0 +1,96 @@
+package zapdriver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func panicking(logger *zap.Logger) {
+	defer RecoverAndLog(logger)
+
+	panic("boom")
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore, zap.Development())
+
+	assert.NotPanics(t, func() { panicking(logger) })
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	assert.Equal(t, zapcore.DPanicLevel, entries[0].Level)
+	assert.Equal(t, "panic: boom", entries[0].Message)
+	assert.Contains(t, entries[0].Stack, "zapdriver.panicking")
+	assert.Contains(t, entries[0].Caller.File, "recover_test.go")
+
+	report := entries[0].ContextMap()[contextKey].(map[string]interface{})
+	assert.Equal(t, "github.com/blendle/zapdriver.panicking", report["reportLocation"].(map[string]interface{})["functionName"])
+}
+
+func TestRecoverAndRepanic(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		defer RecoverAndRepanic(logger)
+		panic("boom")
+	})
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestRecoverAndLog_NoPanic(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	func() {
+		defer RecoverAndLog(zap.New(debugcore))
+	}()
+
+	assert.Equal(t, 0, logs.Len())
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	handler := RecoverMiddleware(zap.New(debugcore))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/orders", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "GET", logs.All()[0].ContextMap()["method"])
+}
+
+func TestUnaryServerRecoveryInterceptor(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	interceptor := UnaryServerRecoveryInterceptor(zap.New(debugcore))
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}, func(context.Context, interface{}) (interface{}, error) {
+		panic("boom")
+	})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, 1, logs.Len())
+}
@@ -0,0 +1,103 @@
+package zapdriver
+
+import (
+	"reflect"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactedValue replaces the value of struct members tagged `log:"redact"`.
+const redactedValue = "[REDACTED]"
+
+// redactFields returns the fields with all reflected values passed through
+// `redact`. The original slice is returned if no field needs redacting.
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i := range fields {
+		if fields[i].Type != zapcore.ReflectType || !needsRedaction(reflect.TypeOf(fields[i].Interface)) {
+			continue
+		}
+
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+
+		out[i] = zap.Reflect(fields[i].Key, redact(fields[i].Interface))
+	}
+
+	if out == nil {
+		return fields
+	}
+
+	return out
+}
+
+// redact returns a JSON-compatible copy of v in which struct members tagged
+// `log:"-"` are omitted, and members tagged `log:"redact"` are masked, so
+// sensitive data never reaches the log payload:
+//
+//	type User struct {
+//	  Name     string `json:"name"`
+//	  Password string `log:"-"`
+//	  Email    string `json:"email" log:"redact"`
+//	}
+//
+// Values of types without such tags are returned as is.
+func redact(v interface{}) interface{} {
+	if v == nil || !needsRedaction(reflect.TypeOf(v)) {
+		return v
+	}
+
+	return encodeAny(v, DefaultAnyDepth)
+}
+
+// redactionCache caches whether a type (transitively) has any `log` struct
+// tags.
+var redactionCache sync.Map
+
+// needsRedaction reports whether values of type t contain struct members with
+// a `log` tag.
+func needsRedaction(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+
+	if v, ok := redactionCache.Load(t); ok {
+		return v.(bool)
+	}
+
+	needs := hasLogTags(t, map[reflect.Type]bool{})
+	redactionCache.Store(t, needs)
+
+	return needs
+}
+
+func hasLogTags(t reflect.Type, seen map[reflect.Type]bool) bool {
+	// A type that is already being inspected is answered by that inspection,
+	// this breaks cycles in recursive types.
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasLogTags(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+
+			if _, tagged := sf.Tag.Lookup("log"); tagged || hasLogTags(sf.Type, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type redactUser struct {
+	Name     string `json:"name"`
+	Password string `log:"-"`
+	Email    string `json:"email" log:"redact"`
+	Friend   *redactUser
+	private  string
+}
+
+type plainUser struct {
+	Name string `json:"name"`
+}
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	user := &redactUser{
+		Name:     "alice",
+		Password: "secret",
+		Email:    "alice@example.com",
+		Friend:   &redactUser{Name: "bob", Password: "hunter2"},
+	}
+
+	want := map[string]interface{}{
+		"name":  "alice",
+		"email": redactedValue,
+		"Friend": map[string]interface{}{
+			"name":   "bob",
+			"email":  redactedValue,
+			"Friend": nil,
+		},
+	}
+
+	assert.Equal(t, want, redact(user))
+}
+
+func TestRedact_Untagged(t *testing.T) {
+	t.Parallel()
+
+	user := plainUser{Name: "alice"}
+
+	assert.Equal(t, user, redact(user))
+}
+
+func TestRedact_Collections(t *testing.T) {
+	t.Parallel()
+
+	users := map[string][]redactUser{"admins": {{Name: "alice", Password: "secret"}}}
+
+	want := map[string]interface{}{
+		"admins": []interface{}{
+			map[string]interface{}{"name": "alice", "email": redactedValue, "Friend": nil},
+		},
+	}
+
+	assert.Equal(t, want, redact(users))
+}
+
+func TestWriteRedactsReflectedFields(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := zapcore.Core(&core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+	})
+
+	core = core.With([]zapcore.Field{zap.Reflect("owner", redactUser{Password: "secret"})})
+	err := core.Write(zapcore.Entry{}, []zapcore.Field{zap.Reflect("user", redactUser{Password: "secret"})})
+	require.NoError(t, err)
+
+	assert.NotContains(t, logs.All()[0].ContextMap()["owner"], "Password")
+	assert.NotContains(t, logs.All()[0].ContextMap()["user"], "Password")
+}
+
+func TestRedact_Cycle(t *testing.T) {
+	t.Parallel()
+
+	user := &redactUser{Name: "alice"}
+	user.Friend = user
+
+	assert.Equal(t, cycleValue, redact(user).(map[string]interface{})["Friend"])
+}
@@ -38,12 +38,22 @@ func (location reportLocation) MarshalLogObject(enc zapcore.ObjectEncoder) error
 
 // reportContext is the context information attached to a log for reporting errors
 type reportContext struct {
-	ReportLocation reportLocation `json:"reportLocation"`
+	ReportLocation reportLocation    `json:"reportLocation"`
+	HTTPRequest    *errorHTTPRequest `json:"httpRequest,omitempty"`
+	User           string            `json:"user,omitempty"`
 }
 
 // MarshalLogObject implements zapcore.ObjectMarshaller interface.
 func (context reportContext) MarshalLogObject(enc zapcore.ObjectEncoder) error {
-	enc.AddObject("reportLocation", context.ReportLocation)
+	if context.ReportLocation != (reportLocation{}) {
+		enc.AddObject("reportLocation", context.ReportLocation)
+	}
+	if context.HTTPRequest != nil {
+		enc.AddObject("httpRequest", context.HTTPRequest)
+	}
+	if context.User != "" {
+		enc.AddString("user", context.User)
+	}
 
 	return nil
 }
@@ -0,0 +1,104 @@
+package zapdriver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// RequestIDHeader is the header carrying the request ID, see
+// `RequestIDMiddleware`.
+const RequestIDHeader = "X-Request-Id"
+
+const requestIDKey = "request_id"
+
+// maxRequestIDLength bounds the length of inbound request IDs.
+const maxRequestIDLength = 128
+
+type requestIDContextKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID, which
+// loggers returned by `Ctx` add as the "request_id" label. Work spawned for the
+// request, like background goroutines, is tied to it by passing the context
+// along.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID added to ctx using
+// `ContextWithRequestID`.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+
+	return id, ok && id != ""
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// RequestIDMiddleware returns HTTP middleware adding a request ID to the
+// request context (see `ContextWithRequestID`), so entries logged by the
+// handler using `Logger.Ctx`, and by `Middleware`, carry it as a label:
+//
+//	http.ListenAndServe(":8080", zapdriver.RequestIDMiddleware(zapdriver.Middleware(logger, "my-project")(mux)))
+//
+// The ID of the "X-Request-Id" header is used if the request has one, a new ID
+// is generated otherwise. The ID is set on the response as well.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = NewRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID reports whether an inbound request ID can be used: it's not
+// empty, not too long, and holds printable ASCII characters only.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RequestInsertIDs derives the insert ID of every entry sent to the Cloud
+// Logging API carrying the "request_id" label (see `ContextWithRequestID`) from
+// the request ID, followed by a sequence number, so the entries of a request
+// can be found by their insert ID. Like `AutoInsertID`, retried writes are
+// stored only once.
+//
+// Entries without a request ID get an insert ID from `AutoInsertID` or
+// `HashedInsertID`, if used, and an explicit `InsertID` takes precedence.
+func RequestInsertIDs() func(*core) {
+	return func(c *core) {
+		c.config.RequestInsertIDs = &requestInsertIDs{}
+	}
+}
+
+// requestInsertIDs generates insert IDs for the entries of requests.
+type requestInsertIDs struct {
+	seq uint64
+}
+
+func (g *requestInsertIDs) next(id string) string {
+	return id + "-" + strconv.FormatUint(atomic.AddUint64(&g.seq, 1), 10)
+}
@@ -0,0 +1,98 @@
+package zapdriver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestIDFromContext(t *testing.T) {
+	t.Parallel()
+
+	_, ok := RequestIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	id, ok := RequestIDFromContext(ContextWithRequestID(context.Background(), "req-1"))
+	assert.True(t, ok)
+	assert.Equal(t, "req-1", id)
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := RequestIDFromContext(r.Context())
+		got = append(got, id)
+	}))
+
+	for _, inbound := range []string{"req-1", "", "not valid", strings.Repeat("x", maxRequestIDLength+1)} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if inbound != "" {
+			req.Header.Set(RequestIDHeader, inbound)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, got[len(got)-1], rec.Header().Get(RequestIDHeader))
+	}
+
+	require.Len(t, got, 4)
+	assert.Equal(t, "req-1", got[0])
+	for _, id := range got[1:] {
+		assert.Len(t, id, 32)
+	}
+	assert.NotEqual(t, got[1], got[2])
+}
+
+func TestRequestIDMiddleware_Labels(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{Core: debugcore, permLabels: newLabels()})
+
+	handler := RequestIDMiddleware(Middleware(logger, "my-project")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		NewLogger(logger, "my-project").Ctx(r.Context()).Info("handling")
+	})))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		assert.Equal(t, map[string]interface{}{requestIDKey: "req-1"}, e.ContextMap()[labelsKey])
+	}
+}
+
+func TestRequestInsertIDs(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	RequestInsertIDs()(core)
+	logger := NewLogger(zap.New(core), "my-project")
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	logger.Ctx(ctx).Info("first")
+	logger.Ctx(ctx).Info("second")
+	logger.Ctx(ctx).Info("explicit", InsertID("mine"))
+	logger.Info("other")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 4)
+	assert.Equal(t, "req-1-1", entries[0].InsertId)
+	assert.Equal(t, "req-1-2", entries[1].InsertId)
+	assert.Equal(t, "mine", entries[2].InsertId)
+	assert.NotContains(t, entries[3].InsertId, "req-1")
+}
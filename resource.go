@@ -0,0 +1,179 @@
+package zapdriver
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/compute/metadata"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+// AutoDetectResource attaches the monitored resource the application runs on
+// to every entry sent to the Cloud Logging API, so entries show up under the
+// right resource in the Logs Explorer.
+//
+// The resource is detected from well-known environment variables and the
+// metadata server, in this order:
+//
+//	Cloud Functions    (FUNCTION_TARGET, FUNCTION_NAME)  cloud_function
+//	Cloud Run          (K_SERVICE)                       cloud_run_revision
+//	App Engine         (GAE_SERVICE)                     gae_app
+//	Kubernetes Engine  (KUBERNETES_SERVICE_HOST)         k8s_container
+//	Compute Engine     (metadata server)                 gce_instance
+//
+// On Cloud Run, the service, revision and configuration names are also added
+// as permanent labels to every entry, so entries can be filtered per revision.
+// On App Engine, the instance name (GAE_INSTANCE) is added as a label, and the
+// service and version are used for the service context, unless `ServiceName`
+// is used.
+//
+// Detection happens once per process, and is cached. When no resource is
+// detected, the client's default resource is used.
+func AutoDetectResource() func(*core) {
+	return func(c *core) {
+		c.useResource(detectResource(), os.Getenv)
+	}
+}
+
+// useResource attaches the detected resource to the entries of the core, and
+// adds the labels and service context derived from it.
+func (c *core) useResource(resource *mrpb.MonitoredResource, getenv func(string) string) {
+	c.config.Resource = resource
+	c.addPermLabels(resourceLabels(resource, getenv))
+
+	if resource != nil && resource.Type == "gae_app" && c.config.ServiceName == "" {
+		c.config.ServiceName = resource.Labels["module_id"]
+		if c.config.ServiceVersion == "" {
+			c.config.ServiceVersion = resource.Labels["version_id"]
+		}
+	}
+}
+
+// resourceLabels returns the labels added to every entry for the resource.
+func resourceLabels(resource *mrpb.MonitoredResource, getenv func(string) string) map[string]string {
+	if resource == nil {
+		return nil
+	}
+
+	labels := map[string]string{}
+	switch resource.Type {
+	case "cloud_run_revision":
+		for _, key := range []string{"service_name", "revision_name", "configuration_name"} {
+			if v := resource.Labels[key]; v != "" {
+				labels[key] = v
+			}
+		}
+	case "gae_app":
+		if v := getenv("GAE_INSTANCE"); v != "" {
+			labels["appengine.googleapis.com/instance_name"] = v
+		}
+	}
+
+	return labels
+}
+
+var detectedResource struct {
+	once     sync.Once
+	resource *mrpb.MonitoredResource
+}
+
+// detectResource returns the monitored resource of the current process,
+// detecting it on first use.
+func detectResource() *mrpb.MonitoredResource {
+	detectedResource.once.Do(func() {
+		d := &resourceDetector{
+			getenv:   os.Getenv,
+			onGCE:    metadata.OnGCE,
+			metadata: metadata.Get,
+			readFile: ioutil.ReadFile,
+		}
+		detectedResource.resource = d.detect()
+	})
+
+	return detectedResource.resource
+}
+
+// resourceDetector detects the monitored resource using its environment
+// variables, metadata server and file system.
+type resourceDetector struct {
+	getenv   func(key string) string
+	onGCE    func() bool
+	metadata func(suffix string) (string, error)
+	readFile func(name string) ([]byte, error)
+}
+
+const namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+func (d *resourceDetector) detect() *mrpb.MonitoredResource {
+	switch {
+	case d.getenv("FUNCTION_TARGET") != "" || d.getenv("FUNCTION_NAME") != "":
+		name := d.getenv("FUNCTION_NAME")
+		if name == "" {
+			name = d.getenv("K_SERVICE")
+		}
+
+		return d.resource("cloud_function", map[string]string{
+			"function_name": name,
+			"region":        d.region(),
+		})
+	case d.getenv("K_SERVICE") != "":
+		return d.resource("cloud_run_revision", map[string]string{
+			"service_name":       d.getenv("K_SERVICE"),
+			"revision_name":      d.getenv("K_REVISION"),
+			"configuration_name": d.getenv("K_CONFIGURATION"),
+			"location":           d.region(),
+		})
+	case d.getenv("GAE_SERVICE") != "":
+		return d.resource("gae_app", map[string]string{
+			"module_id":  d.getenv("GAE_SERVICE"),
+			"version_id": d.getenv("GAE_VERSION"),
+			"zone":       d.zone(),
+		})
+	case d.getenv("KUBERNETES_SERVICE_HOST") != "":
+		return d.k8sContainer()
+	case d.onGCE():
+		return d.resource("gce_instance", map[string]string{
+			"instance_id": d.get("instance/id"),
+			"zone":        d.zone(),
+		})
+	}
+
+	return nil
+}
+
+// resource returns a monitored resource of the given type, adding the project
+// ID to its labels.
+func (d *resourceDetector) resource(typ string, labels map[string]string) *mrpb.MonitoredResource {
+	labels["project_id"] = d.get("project/project-id")
+
+	return &mrpb.MonitoredResource{Type: typ, Labels: labels}
+}
+
+// get returns the metadata value for the given suffix, or an empty string if
+// it isn't available.
+func (d *resourceDetector) get(suffix string) string {
+	v, err := d.metadata(suffix)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(v)
+}
+
+// zone returns the zone of the instance, for example "us-central1-a".
+func (d *resourceDetector) zone() string {
+	return lastPathSegment(d.get("instance/zone"))
+}
+
+// region returns the region of the instance, for example "us-central1".
+func (d *resourceDetector) region() string {
+	return lastPathSegment(d.get("instance/region"))
+}
+
+// lastPathSegment returns the part of s after its last slash, turning values
+// like "projects/123/zones/us-central1-a" into "us-central1-a".
+func lastPathSegment(s string) string {
+	return s[strings.LastIndex(s, "/")+1:]
+}
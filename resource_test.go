@@ -0,0 +1,186 @@
+package zapdriver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+func newTestResourceDetector(env map[string]string, onGCE bool) *resourceDetector {
+	md := map[string]string{
+		"project/project-id":                   "my-project",
+		"instance/id":                          "1234",
+		"instance/zone":                        "projects/42/zones/europe-west1-b",
+		"instance/region":                      "projects/42/regions/europe-west1",
+		"instance/attributes/cluster-name":     "my-cluster",
+		"instance/attributes/cluster-location": "europe-west1",
+	}
+
+	return &resourceDetector{
+		getenv: func(key string) string { return env[key] },
+		onGCE:  func() bool { return onGCE },
+		metadata: func(suffix string) (string, error) {
+			v, ok := md[suffix]
+			if !ok {
+				return "", errors.New("not defined")
+			}
+			return v, nil
+		},
+		readFile: func(string) ([]byte, error) { return []byte("default\n"), nil },
+	}
+}
+
+func TestResourceDetector(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		env   map[string]string
+		onGCE bool
+		want  *mrpb.MonitoredResource
+	}{
+		"cloud functions": {
+			env: map[string]string{"FUNCTION_TARGET": "Handle", "K_SERVICE": "my-function"},
+			want: &mrpb.MonitoredResource{Type: "cloud_function", Labels: map[string]string{
+				"project_id":    "my-project",
+				"function_name": "my-function",
+				"region":        "europe-west1",
+			}},
+		},
+		"cloud run": {
+			env: map[string]string{"K_SERVICE": "my-service", "K_REVISION": "my-service-001", "K_CONFIGURATION": "my-service"},
+			want: &mrpb.MonitoredResource{Type: "cloud_run_revision", Labels: map[string]string{
+				"project_id":         "my-project",
+				"service_name":       "my-service",
+				"revision_name":      "my-service-001",
+				"configuration_name": "my-service",
+				"location":           "europe-west1",
+			}},
+		},
+		"app engine": {
+			env: map[string]string{"GAE_SERVICE": "default", "GAE_VERSION": "v1"},
+			want: &mrpb.MonitoredResource{Type: "gae_app", Labels: map[string]string{
+				"project_id": "my-project",
+				"module_id":  "default",
+				"version_id": "v1",
+				"zone":       "europe-west1-b",
+			}},
+		},
+		"kubernetes engine": {
+			env:   map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1", "HOSTNAME": "my-pod", "CONTAINER_NAME": "app"},
+			onGCE: true,
+			want: &mrpb.MonitoredResource{Type: "k8s_container", Labels: map[string]string{
+				"project_id":     "my-project",
+				"location":       "europe-west1",
+				"cluster_name":   "my-cluster",
+				"namespace_name": "default",
+				"pod_name":       "my-pod",
+				"container_name": "app",
+			}},
+		},
+		"compute engine": {
+			onGCE: true,
+			want: &mrpb.MonitoredResource{Type: "gce_instance", Labels: map[string]string{
+				"project_id":  "my-project",
+				"instance_id": "1234",
+				"zone":        "europe-west1-b",
+			}},
+		},
+		"unknown": {},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, newTestResourceDetector(tt.env, tt.onGCE).detect())
+		})
+	}
+}
+
+func TestWriteResource(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	core.config.Resource = newTestResourceDetector(nil, true).detect()
+
+	logger := zap.New(core)
+	logger.Info("hello")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	require.NotNil(t, entries[0].Resource)
+	assert.Equal(t, "gce_instance", entries[0].Resource.Type)
+	assert.Equal(t, "1234", entries[0].Resource.Labels["instance_id"])
+}
+
+func TestResourceLabels(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{"K_SERVICE": "my-service", "K_REVISION": "my-service-001", "GAE_INSTANCE": "aef-1"}
+	getenv := func(key string) string { return env[key] }
+
+	cloudRun := newTestResourceDetector(env, false).detect()
+	assert.Equal(t, map[string]string{"service_name": "my-service", "revision_name": "my-service-001"}, resourceLabels(cloudRun, getenv))
+
+	appEngine := newTestResourceDetector(map[string]string{"GAE_SERVICE": "default"}, false).detect()
+	assert.Equal(t, map[string]string{"appengine.googleapis.com/instance_name": "aef-1"}, resourceLabels(appEngine, getenv))
+
+	assert.Empty(t, resourceLabels(newTestResourceDetector(nil, true).detect(), getenv))
+	assert.Empty(t, resourceLabels(nil, getenv))
+}
+
+func TestUseResource_CloudRunLabels(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"K_SERVICE":       "my-service",
+		"K_REVISION":      "my-service-001",
+		"K_CONFIGURATION": "my-service",
+	}
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	c := &core{Core: debugcore, permLabels: newLabels()}
+	c.useResource(newTestResourceDetector(env, false).detect(), func(key string) string { return env[key] })
+
+	zap.New(c).Info("hello")
+
+	assert.Equal(t, "cloud_run_revision", c.config.Resource.Type)
+	assert.Equal(t, map[string]interface{}{
+		"service_name":       "my-service",
+		"revision_name":      "my-service-001",
+		"configuration_name": "my-service",
+	}, logs.All()[0].ContextMap()[labelsKey])
+}
+
+func TestUseResource_AppEngine(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{"GAE_SERVICE": "default", "GAE_VERSION": "v1", "GAE_INSTANCE": "aef-1"}
+	resource := newTestResourceDetector(env, false).detect()
+	getenv := func(key string) string { return env[key] }
+
+	c := &core{permLabels: newLabels()}
+	c.useResource(resource, getenv)
+
+	assert.Equal(t, "default", c.config.ServiceName)
+	assert.Equal(t, "v1", c.config.ServiceVersion)
+	assert.Equal(t, map[string]string{"appengine.googleapis.com/instance_name": "aef-1"}, c.permLabels.store)
+
+	// A configured service name is kept.
+	c = &core{permLabels: newLabels()}
+	ServiceName("orders")(c)
+	c.useResource(resource, getenv)
+
+	assert.Equal(t, "orders", c.config.ServiceName)
+	assert.Empty(t, c.config.ServiceVersion)
+}
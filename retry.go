@@ -0,0 +1,130 @@
+package zapdriver
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxRetryBackoff caps the delay between two attempts of `WithRetry`.
+const maxRetryBackoff = 10 * time.Second
+
+// WithRetry retries sending entries that failed with a transient error
+// (deadline exceeded or unavailable), up to `maxAttempts` attempts in total.
+// The first retry waits `backoff`, which doubles for every next retry, up to 10
+// seconds. No attempt is made once `budget` passed since the first one; a zero
+// budget doesn't limit the time spent.
+//
+// Retries apply to entries sent using `SynchronousWrites`, as entries buffered
+// by the Cloud Logging client are retried by the client itself. Combine it with
+// `Async` to keep the retries off the goroutine writing the entry:
+//
+//	zapdriver.WrapCore(
+//	  zapdriver.SynchronousWrites(),
+//	  zapdriver.Async(4, 1),
+//	  zapdriver.WithRetry(5, 100*time.Millisecond, 10*time.Second),
+//	)
+//
+// Entries that still fail are reported to the error handler (see
+// `WithErrorHandler`).
+func WithRetry(maxAttempts int, backoff, budget time.Duration) func(*core) {
+	return func(c *core) {
+		if maxAttempts < 1 {
+			maxAttempts = 1
+		}
+
+		c.config.Retry = &retryPolicy{maxAttempts: maxAttempts, backoff: backoff, budget: budget}
+	}
+}
+
+type retryPolicy struct {
+	maxAttempts int
+	backoff     time.Duration
+	budget      time.Duration
+}
+
+// do calls send until it succeeds, fails with a permanent error, or the
+// attempts or budget run out. It returns the error of the last attempt.
+//
+// With a budget, every attempt gets an equal share of the remaining budget, as
+// the client keeps retrying unavailable errors until the deadline of the call.
+func (p *retryPolicy) do(send func(ctx context.Context) error) error {
+	ctx := context.Background()
+	if p.budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.budget)
+		defer cancel()
+	}
+
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		err := p.attempt(ctx, attempt, send)
+		if err == nil || attempt >= p.maxAttempts || !isTransientError(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// attempt calls send, with its share of the remaining budget, if any.
+func (p *retryPolicy) attempt(ctx context.Context, attempt int, send func(ctx context.Context) error) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return send(ctx)
+	}
+
+	share := time.Until(deadline) / time.Duration(p.maxAttempts-attempt+1)
+	ctx, cancel := context.WithTimeout(ctx, share)
+	defer cancel()
+
+	return send(ctx)
+}
+
+// isTransientError reports whether a failed send is worth retrying.
+func isTransientError(err error) bool {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.Unavailable:
+		return true
+	}
+
+	return err == context.DeadlineExceeded
+}
+
+// logSync sends an entry to Cloud Logging synchronously, retrying it according
+// to the retry policy of the core, if any. Every call is bounded by the write
+// timeout. Loggers that can't send entries synchronously get the entry logged
+// and flushed, without retries.
+func (c *core) logSync(lg EntryLogger, e logging.Entry) error {
+	slg, ok := lg.(syncEntryLogger)
+	if !ok {
+		lg.Log(e)
+		return lg.Flush()
+	}
+
+	send := func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, c.writeTimeout())
+		defer cancel()
+
+		return slg.LogSync(ctx, e)
+	}
+
+	if c.config.Retry == nil {
+		return send(context.Background())
+	}
+
+	return c.config.Retry.do(send)
+}
@@ -0,0 +1,93 @@
+package zapdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	tests := []struct {
+		name     string
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{"success", []error{nil}, 1, nil},
+		{"transient", []error{unavailable, unavailable, nil}, 3, nil},
+		{"exhausted", []error{unavailable, unavailable, unavailable, nil}, 3, unavailable},
+		{"permanent", []error{status.Error(codes.InvalidArgument, "invalid"), nil}, 1, status.Error(codes.InvalidArgument, "invalid")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := &core{permLabels: newLabels()}
+			WithRetry(3, time.Millisecond, 0)(core)
+
+			attempts := 0
+			err := core.config.Retry.do(func(context.Context) error {
+				attempts++
+				return tt.errs[attempts-1]
+			})
+
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.attempts, attempts)
+		})
+	}
+}
+
+func TestRetryPolicy_Budget(t *testing.T) {
+	core := &core{permLabels: newLabels()}
+	WithRetry(100, time.Millisecond, 50*time.Millisecond)(core)
+
+	attempts := 0
+	start := time.Now()
+	err := core.config.Retry.do(func(ctx context.Context) error {
+		attempts++
+
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		require.True(t, time.Until(deadline) <= 50*time.Millisecond)
+
+		<-ctx.Done()
+		return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.True(t, attempts > 1)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestWriteRetry(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	var handled []error
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	SynchronousWrites()(core)
+	WithRetry(3, time.Millisecond, time.Second)(core)
+	WithErrorHandler(func(err error) { handled = append(handled, err) })(core)
+
+	logger := zap.New(core)
+	logger.Info("hello")
+	require.Len(t, server.Entries(), 1)
+
+	server.setError(errors.New("permanent"))
+	logger.Info("hello")
+	assert.Len(t, handled, 1)
+}
@@ -0,0 +1,141 @@
+package zapdriver
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithFieldRouting routes entries to a log name derived from the value of the
+// field `key`, for example to give every tenant its own log:
+//
+//	zapdriver.WithFieldRouting(client, "tenant_id", "app-{value}", 100)
+//
+// The template may use "{tenant}" instead of "{value}", which reads better for
+// tenant IDs: "app-{tenant}".
+//
+// Loggers are created lazily on `client`, using `opts`. Once `maxLogs`
+// distinct logs exist, entries for new values are written to the default
+// logger instead. Entries without the field are written to the default logger
+// as well.
+func WithFieldRouting(client *logging.Client, key, template string, maxLogs int, opts ...logging.LoggerOption) func(*core) {
+	return func(c *core) {
+		c.config.Router = &fieldRouter{
+			client:   client,
+			key:      key,
+			template: template,
+			maxLogs:  maxLogs,
+			opts:     opts,
+			loggers:  map[string]*logging.Logger{},
+		}
+	}
+}
+
+// fieldRouter lazily creates a logger per distinct value of a field.
+type fieldRouter struct {
+	client   *logging.Client
+	key      string
+	template string
+	maxLogs  int
+	opts     []logging.LoggerOption
+
+	mutex   sync.Mutex
+	loggers map[string]*logging.Logger
+}
+
+// route returns the logger for the given fields, or nil if the entry should be
+// written to the default logger. Later fields take precedence.
+func (r *fieldRouter) route(fields ...[]zapcore.Field) *logging.Logger {
+	var value string
+	for _, fs := range fields {
+		for i := range fs {
+			if fs[i].Key == r.key {
+				value = fieldString(fs[i])
+			}
+		}
+	}
+
+	if value == "" {
+		return nil
+	}
+
+	return r.logger(value)
+}
+
+// expandTemplate replaces the "{value}" and "{tenant}" placeholders of a
+// routing template with the value.
+func expandTemplate(template, value string) string {
+	return strings.NewReplacer("{value}", value, "{tenant}", value).Replace(template)
+}
+
+// hasPlaceholder reports whether a routing template contains a placeholder.
+func hasPlaceholder(template string) bool {
+	return strings.Contains(template, "{value}") || strings.Contains(template, "{tenant}")
+}
+
+// logger returns the logger for the value, or nil once `maxLogs` loggers
+// exist.
+func (r *fieldRouter) logger(value string) *logging.Logger {
+	logID := expandTemplate(r.template, sanitizeLogID(value))
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if lg, ok := r.loggers[logID]; ok {
+		return lg
+	}
+
+	if len(r.loggers) >= r.maxLogs {
+		return nil
+	}
+
+	lg := r.client.Logger(logID, r.opts...)
+	r.loggers[logID] = lg
+
+	return lg
+}
+
+func (r *fieldRouter) flush() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var err error
+	for _, lg := range r.loggers {
+		err = multierr.Append(err, lg.Flush())
+	}
+
+	return err
+}
+
+// fieldString formats the value of a field as a string.
+func fieldString(f zapcore.Field) string {
+	if f.Type == zapcore.StringType {
+		return f.String
+	}
+
+	v := ToInterface(f)
+	if v == nil {
+		return ""
+	}
+
+	return fmt.Sprint(v)
+}
+
+// sanitizeLogID replaces all characters not allowed in a log ID with an
+// underscore.
+func sanitizeLogID(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '/', r == '_', r == '-', r == '.':
+			return r
+		}
+
+		return '_'
+	}, id)
+}
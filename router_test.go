@@ -0,0 +1,59 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSanitizeLogID(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "app-acme_inc.eu_1", sanitizeLogID("app-acme inc.eu#1"))
+}
+
+func TestWriteFieldRouting(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithFieldRouting(client, "tenant_id", "app-{value}", 1)(core)
+
+	logger := zap.New(core)
+	logger.Info("routed", zap.String("tenant_id", "acme"))
+	logger.With(zap.String("tenant_id", "acme")).Info("inherited")
+	logger.Info("capped", zap.String("tenant_id", "globex"))
+	logger.Info("default")
+	require.NoError(t, logger.Sync())
+
+	logNames := map[string]string{}
+	for _, e := range server.Entries() {
+		logNames[e.GetJsonPayload().Fields["message"].GetStringValue()] = e.LogName
+	}
+
+	assert.Equal(t, map[string]string{
+		"routed":    "projects/test-project/logs/app-acme",
+		"inherited": "projects/test-project/logs/app-acme",
+		"capped":    "projects/test-project/logs/app",
+		"default":   "projects/test-project/logs/app",
+	}, logNames)
+}
+
+func TestExpandTemplate(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "app-acme", expandTemplate("app-{value}", "acme"))
+	assert.Equal(t, "app-acme", expandTemplate("app-{tenant}", "acme"))
+	assert.Equal(t, "acme-app-acme", expandTemplate("{tenant}-app-{value}", "acme"))
+
+	assert.True(t, hasPlaceholder("app-{tenant}"))
+	assert.False(t, hasPlaceholder("app"))
+}
@@ -0,0 +1,151 @@
+package zapdriver
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithLogNameSampling configures the fraction of entries that are kept per
+// logger name, as set using `logger.Named()`. This allows a single logger tree
+// to serve very different retention and cost requirements:
+//
+//	zapdriver.WithLogNameSampling(map[string]float64{
+//	  "audit":  1,
+//	  "access": 0.05,
+//	})
+//
+// Named child loggers inherit the rate of their parent (`access.http` is
+// sampled like `access`), unless they have a rate of their own. Entries of
+// loggers without a configured rate are always kept, unless a rate is
+// configured for the empty name.
+func WithLogNameSampling(rates map[string]float64) func(*core) {
+	return func(c *core) {
+		c.config.LogNameSampling = newNameSampler(rates)
+	}
+}
+
+// nameSampler deterministically keeps a configured fraction of entries for
+// every logger name.
+type nameSampler struct {
+	rates map[string]float64
+
+	mutex  sync.Mutex
+	counts map[string]uint64
+}
+
+func newNameSampler(rates map[string]float64) *nameSampler {
+	s := &nameSampler{rates: map[string]float64{}, counts: map[string]uint64{}}
+	for k, v := range rates {
+		s.rates[k] = v
+	}
+
+	return s
+}
+
+// sample reports whether the entry should be kept.
+func (s *nameSampler) sample(ent zapcore.Entry) bool {
+	name, rate := s.rate(ent.LoggerName)
+	if rate >= 1 {
+		return true
+	}
+
+	if rate <= 0 {
+		return false
+	}
+
+	s.mutex.Lock()
+	s.counts[name]++
+	n := s.counts[name]
+	s.mutex.Unlock()
+
+	// Keep an entry every time the running total of kept entries crosses a whole
+	// number, which spreads the kept entries evenly.
+	return uint64(float64(n)*rate) != uint64(float64(n-1)*rate)
+}
+
+// rate returns the most specific configured logger name matching `name`, and
+// its sampling rate.
+func (s *nameSampler) rate(name string) (string, float64) {
+	for {
+		if rate, ok := s.rates[name]; ok {
+			return name, rate
+		}
+
+		if name == "" {
+			return name, 1
+		}
+
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[:i]
+		} else {
+			name = ""
+		}
+	}
+}
+
+// WithSampling samples entries per level and message, like the sampler of zap:
+// of the entries with the same level and message, the first `first` of every
+// `tick` are kept, and every `thereafter`th entry after that. Entries of error
+// level and above are never sampled away.
+//
+// The decision is made once per entry, so an entry is either written to both
+// the Cloud Logging API and the wrapped core, or to neither. Disable the
+// sampling of zap (see `zap.Config.Sampling`) when using this option.
+func WithSampling(tick time.Duration, first, thereafter int) func(*core) {
+	return func(c *core) {
+		c.config.Sampler = &levelSampler{
+			tick:       tick,
+			first:      uint64(first),
+			thereafter: uint64(thereafter),
+			counts:     map[samplingKey]uint64{},
+		}
+	}
+}
+
+// levelSampler counts the entries per level and message in windows of `tick`.
+type levelSampler struct {
+	tick       time.Duration
+	first      uint64
+	thereafter uint64
+
+	mutex  sync.Mutex
+	window int64
+	counts map[samplingKey]uint64
+}
+
+type samplingKey struct {
+	level   zapcore.Level
+	message string
+}
+
+// sample reports whether the entry should be kept.
+func (s *levelSampler) sample(ent zapcore.Entry) bool {
+	if ent.Level >= zapcore.ErrorLevel {
+		return true
+	}
+
+	window := int64(0)
+	if s.tick > 0 {
+		window = ent.Time.UnixNano() / int64(s.tick)
+	}
+
+	s.mutex.Lock()
+	if window != s.window {
+		s.window = window
+		s.counts = map[samplingKey]uint64{}
+	}
+
+	key := samplingKey{level: ent.Level, message: ent.Message}
+	s.counts[key]++
+	n := s.counts[key]
+	s.mutex.Unlock()
+
+	if n <= s.first {
+		return true
+	}
+
+	return s.thereafter > 0 && (n-s.first)%s.thereafter == 0
+}
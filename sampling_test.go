@@ -0,0 +1,99 @@
+package zapdriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNameSamplerRate(t *testing.T) {
+	t.Parallel()
+
+	s := newNameSampler(map[string]float64{"access": 0.05, "access.admin": 1})
+
+	var tests = map[string]struct {
+		name string
+		rate float64
+	}{
+		"":                   {"", 1},
+		"audit":              {"", 1},
+		"access":             {"access", 0.05},
+		"access.http":        {"access", 0.05},
+		"access.admin":       {"access.admin", 1},
+		"access.admin.users": {"access.admin", 1},
+	}
+
+	for name, tt := range tests {
+		got, rate := s.rate(name)
+
+		assert.Equal(t, tt.name, got, name)
+		assert.Equal(t, tt.rate, rate, name)
+	}
+}
+
+func TestWithLogNameSampling(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+	}
+	WithLogNameSampling(map[string]float64{"audit": 1, "access": 0.05, "debug": 0})(core)
+
+	logger := zap.New(core)
+	for i := 0; i < 100; i++ {
+		logger.Named("audit").Info("audit")
+		logger.Named("access").Info("access")
+		logger.Named("debug").Info("debug")
+		logger.Info("default")
+	}
+
+	assert.Equal(t, 100, logs.FilterMessage("audit").Len())
+	assert.Equal(t, 5, logs.FilterMessage("access").Len())
+	assert.Equal(t, 0, logs.FilterMessage("debug").Len())
+	assert.Equal(t, 100, logs.FilterMessage("default").Len())
+}
+
+func TestWriteSampling(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	WithSampling(time.Hour, 2, 3)(core)
+
+	logger := zap.New(core)
+	for i := 0; i < 10; i++ {
+		logger.Info("hello")
+		logger.Debug("world")
+		logger.Error("failed")
+	}
+	require.NoError(t, logger.Sync())
+
+	// The first 2, then every 3rd entry: 1, 2, 5 and 8.
+	assert.Equal(t, 4, logs.FilterMessage("hello").Len())
+	assert.Equal(t, 4, logs.FilterMessage("world").Len())
+	assert.Equal(t, 10, logs.FilterMessage("failed").Len())
+
+	// The same entries are sent to the API.
+	assert.Len(t, server.Entries(), logs.Len())
+}
+
+func TestLevelSampler_Tick(t *testing.T) {
+	t.Parallel()
+
+	s := &levelSampler{tick: time.Second, first: 1, counts: map[samplingKey]uint64{}}
+	now := time.Now()
+
+	assert.True(t, s.sample(zapcore.Entry{Time: now, Message: "hello"}))
+	assert.False(t, s.sample(zapcore.Entry{Time: now, Message: "hello"}))
+	assert.True(t, s.sample(zapcore.Entry{Time: now, Message: "world"}))
+	assert.True(t, s.sample(zapcore.Entry{Time: now.Add(time.Second), Message: "hello"}))
+}
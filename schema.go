@@ -0,0 +1,22 @@
+package zapdriver
+
+// schemaVersionKey is the label holding the schema version of an entry.
+const schemaVersionKey = "log_schema_version"
+
+// SchemaVersion stamps every entry with a `log_schema_version` label, so
+// downstream parsers can tell entries apart when the field layout of a service
+// changes. The version takes precedence over labels with the same key.
+func SchemaVersion(version string) func(*core) {
+	return func(c *core) {
+		c.config.SchemaVersion = version
+	}
+}
+
+// stampSchemaVersion adds the schema version label to the labels of an entry.
+func (c *core) stampSchemaVersion(lbls *labels) {
+	if c.config.SchemaVersion == "" {
+		return
+	}
+
+	lbls.store[schemaVersionKey] = c.config.SchemaVersion
+}
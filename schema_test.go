@@ -0,0 +1,64 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWriteSchemaVersion(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	SchemaVersion("2")(core)
+
+	logger := zap.New(core)
+	logger.Info("hello", Label("one", "value"), Label(schemaVersionKey, "1"))
+	require.NoError(t, logger.Sync())
+
+	want := map[string]interface{}{"one": "value", schemaVersionKey: "2"}
+	assert.Equal(t, want, logs.All()[0].ContextMap()[labelsKey])
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]string{"one": "value", schemaVersionKey: "2"}, entries[0].Labels)
+}
+
+func TestWriteSchemaVersion_Unset(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+	}
+
+	zap.New(core).Info("hello")
+
+	assert.NotContains(t, logs.All()[0].ContextMap(), labelsKey)
+}
+
+func TestWriteSchemaVersion_InheritedLabels(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+	}
+	SchemaVersion("2")(core)
+
+	logger := zap.New(core).With(Label("one", "value"))
+	logger.Info("hello")
+
+	want := map[string]interface{}{"one": "value", schemaVersionKey: "2"}
+	assert.Equal(t, want, logs.All()[0].ContextMap()[labelsKey])
+
+	// The inherited labels are shared, and must not be modified by the entry.
+	assert.Equal(t, map[string]string{"one": "value"}, InheritedLabels(logger))
+}
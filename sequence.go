@@ -0,0 +1,67 @@
+package zapdriver
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// instanceIDLabel is the label holding the ID of the process instance.
+	instanceIDLabel = "instance_id"
+
+	// sequenceKey is the field holding the sequence number of an entry.
+	sequenceKey = "seq"
+)
+
+// SequenceNumbers stamps every entry with the `instance_id` label, identifying
+// the process, and a `seq` field holding a number that increases with every
+// entry written by the process. Entries of replicas with identical timestamps
+// can be totally ordered by sorting them per instance on their sequence number.
+//
+// Without an instance ID, a random one is generated once per process. The
+// sequence number is a field, as a label with a different value for every entry
+// would defeat label cardinality limits (see `WithLabelCardinalityLimit`). Explicit
+// `instance_id` labels take precedence over the stamped one.
+func SequenceNumbers(instanceID string) func(*core) {
+	if instanceID == "" {
+		instanceID = processInstanceID()
+	}
+
+	return func(c *core) {
+		c.config.InstanceID = instanceID
+	}
+}
+
+var processInstance struct {
+	once sync.Once
+	id   string
+}
+
+// processInstanceID returns the random instance ID of the process.
+func processInstanceID() string {
+	processInstance.once.Do(func() {
+		processInstance.id = randomID()
+	})
+
+	return processInstance.id
+}
+
+// processSequence is the last sequence number handed out by the process.
+var processSequence uint64
+
+// stampSequence adds the instance ID label to the labels of an entry, and
+// returns the fields with its sequence number.
+func (c *core) stampSequence(lbls *labels, fields []zapcore.Field) []zapcore.Field {
+	if c.config.InstanceID == "" {
+		return fields
+	}
+
+	if _, ok := lbls.store[instanceIDLabel]; !ok {
+		lbls.store[instanceIDLabel] = c.config.InstanceID
+	}
+
+	return append(fields, zap.Uint64(sequenceKey, atomic.AddUint64(&processSequence, 1)))
+}
@@ -0,0 +1,47 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSequenceNumbers(t *testing.T) {
+	rec := &recordingLogger{}
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, WrapCore(WithLogger(rec), SequenceNumbers("replica-1")))
+
+	logger.Info("first")
+	logger.With(Label("team", "core")).Info("second")
+	logger.Info("explicit", Label(instanceIDLabel, "custom"))
+
+	require.Len(t, rec.entries, 3)
+	first := rec.entries[0].Payload.(map[string]interface{})[sequenceKey].(uint64)
+	for i, e := range rec.entries {
+		assert.Equal(t, first+uint64(i), e.Payload.(map[string]interface{})[sequenceKey])
+	}
+
+	assert.Equal(t, map[string]string{instanceIDLabel: "replica-1"}, rec.entries[0].Labels)
+	assert.Equal(t, map[string]string{instanceIDLabel: "replica-1", "team": "core"}, rec.entries[1].Labels)
+	assert.Equal(t, map[string]string{instanceIDLabel: "custom"}, rec.entries[2].Labels)
+
+	require.Len(t, logs.All(), 3)
+	assert.Equal(t, first, logs.All()[0].ContextMap()[sequenceKey])
+
+	// The permanent labels of the parent are left alone.
+	parent := logger.Core().(*core)
+	assert.NotContains(t, parent.permLabels.store, instanceIDLabel)
+}
+
+func TestSequenceNumbers_Process(t *testing.T) {
+	a, b := &core{}, &core{}
+	SequenceNumbers("")(a)
+	SequenceNumbers("")(b)
+
+	assert.Len(t, a.config.InstanceID, 16)
+	assert.Equal(t, a.config.InstanceID, b.config.InstanceID)
+}
@@ -1,12 +1,18 @@
 package zapdriver
 
 import (
+	"runtime/debug"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 const serviceContextKey = "serviceContext"
 
+// versionLabel is the label holding the service version read from the build
+// info, see `useBuildVersion`.
+const versionLabel = "version"
+
 // ServiceContext adds the correct service information adding the log line
 // It is a required field if an error needs to be reported.
 //
@@ -16,15 +22,26 @@ func ServiceContext(name string) zap.Field {
 	return zap.Object(serviceContextKey, newServiceContext(name))
 }
 
+// ServiceContextVersion is the same as ServiceContext, but also adds the
+// version of the service, which Error Reporting uses to group errors per
+// release.
+func ServiceContextVersion(name, version string) zap.Field {
+	return zap.Object(serviceContextKey, &serviceContext{Name: name, Version: version})
+}
+
 // serviceContext describes a running service that sends errors.
-// Currently it only describes a service name.
+// It describes a service name, and optionally its version.
 type serviceContext struct {
-	Name string `json:"service"`
+	Name    string `json:"service"`
+	Version string `json:"version,omitempty"`
 }
 
 // MarshalLogObject implements zapcore.ObjectMarshaller interface.
 func (service_context serviceContext) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	enc.AddString("service", service_context.Name)
+	if service_context.Version != "" {
+		enc.AddString("version", service_context.Version)
+	}
 
 	return nil
 }
@@ -34,3 +51,28 @@ func newServiceContext(name string) *serviceContext {
 		Name: name,
 	}
 }
+
+// useBuildVersion sets the service version to the version of the binary, when
+// a service name is set without a version, so Error Reporting can detect
+// regressions per release. The version is added as the "version" label too.
+func (c *core) useBuildVersion(version func() string) {
+	if c.config.ServiceName == "" || c.config.ServiceVersion != "" {
+		return
+	}
+
+	if v := version(); v != "" {
+		c.config.ServiceVersion = v
+		c.addPermLabels(map[string]string{versionLabel: v})
+	}
+}
+
+// buildVersion returns the version of the main module of the binary, see
+// `buildInfoVersion`.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	return buildInfoVersion(info)
+}
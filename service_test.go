@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestServiceContext(t *testing.T) {
@@ -14,6 +17,14 @@ func TestServiceContext(t *testing.T) {
 	assert.Equal(t, "test service name", got.Name)
 }
 
+func TestServiceContextVersion(t *testing.T) {
+	t.Parallel()
+
+	got := ServiceContextVersion("test service name", "1.2.3").Interface.(*serviceContext)
+
+	assert.Equal(t, &serviceContext{Name: "test service name", Version: "1.2.3"}, got)
+}
+
 func TestNewServiceContext(t *testing.T) {
 	t.Parallel()
 
@@ -21,3 +32,43 @@ func TestNewServiceContext(t *testing.T) {
 
 	assert.Equal(t, "test service name", got.Name)
 }
+
+func TestWriteServiceVersion(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	c := &core{Core: debugcore, permLabels: newLabels()}
+	ServiceName("orders")(c)
+	ServiceVersion("1.2.3")(c)
+
+	zap.New(c).Info("hello")
+	zap.New(&core{Core: debugcore, permLabels: newLabels(), config: driverConfig{ServiceName: "orders"}}).Info("hello")
+
+	entries := logs.All()
+	assert.Equal(t, map[string]interface{}{"service": "orders", "version": "1.2.3"}, entries[0].ContextMap()[serviceContextKey])
+	assert.Equal(t, map[string]interface{}{"service": "orders"}, entries[1].ContextMap()[serviceContextKey])
+}
+
+func TestUseBuildVersion(t *testing.T) {
+	t.Parallel()
+
+	version := func() string { return "v1.2.3" }
+
+	c := &core{permLabels: newLabels()}
+	c.config.ServiceName = "orders"
+	c.useBuildVersion(version)
+	assert.Equal(t, "v1.2.3", c.config.ServiceVersion)
+	assert.Equal(t, map[string]string{versionLabel: "v1.2.3"}, c.permLabels.store)
+
+	c = &core{permLabels: newLabels()}
+	c.config.ServiceName = "orders"
+	c.config.ServiceVersion = "v2"
+	c.useBuildVersion(version)
+	assert.Equal(t, "v2", c.config.ServiceVersion)
+	assert.Empty(t, c.permLabels.store)
+
+	c = &core{permLabels: newLabels()}
+	c.useBuildVersion(version)
+	assert.Empty(t, c.config.ServiceVersion)
+	assert.Empty(t, c.permLabels.store)
+}
@@ -0,0 +1,181 @@
+package zapdriver
+
+import (
+	"strings"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const severityKey = "logging.googleapis.com/severity"
+
+// Levels encoding the severities without zap level in the local output of
+// entries with a `Severity()` field, see `EncodeLevel`.
+const (
+	noticeLevel zapcore.Level = iota + 10
+	defaultLevel
+)
+
+// severityLevels are the levels encoding the severities in the local output.
+// Critical, alert and emergency are encoded by the levels that don't panic or
+// exit in the wrapped core.
+var severityLevels = map[logging.Severity]zapcore.Level{
+	logging.Default:   defaultLevel,
+	logging.Debug:     zapcore.DebugLevel,
+	logging.Info:      zapcore.InfoLevel,
+	logging.Notice:    noticeLevel,
+	logging.Warning:   zapcore.WarnLevel,
+	logging.Error:     zapcore.ErrorLevel,
+	logging.Critical:  zapcore.DPanicLevel,
+	logging.Alert:     zapcore.PanicLevel,
+	logging.Emergency: zapcore.FatalLevel,
+}
+
+// Severity sets the severity of the entry, overriding the severity of its
+// level, to log at the severities of Cloud Logging zap has no level for, like
+// notice:
+//
+//	logger.Info("Deployed.", zapdriver.Severity(logging.Notice))
+//
+// The entry is still checked, sampled and handled by zap at its own level. The
+// severity is used for the entry sent to the Cloud Logging API, and for the
+// local output when it's encoded using `EncodeLevel`. Entries with a critical,
+// alert or emergency severity don't panic or exit.
+func Severity(severity logging.Severity) zap.Field {
+	return zap.Stringer(severityKey, severity)
+}
+
+// severityOverride returns the severity of the last `Severity()` field of the
+// entry, if any.
+func severityOverride(fields ...[]zapcore.Field) (logging.Severity, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		for j := len(fields[i]) - 1; j >= 0; j-- {
+			if fields[i][j].Key != severityKey {
+				continue
+			}
+			if severity, ok := fields[i][j].Interface.(logging.Severity); ok {
+				return severity, true
+			}
+		}
+	}
+
+	return logging.Default, false
+}
+
+// enablingLevel returns the zap level deciding whether an entry is enabled,
+// for entries whose level encodes a severity without zap level.
+func enablingLevel(level zapcore.Level) zapcore.Level {
+	if level == noticeLevel || level == defaultLevel {
+		return zapcore.InfoLevel
+	}
+
+	return level
+}
+
+// WithSeverityRule changes the level of entries written by the logger named
+// `loggerName` (as set using `logger.Named()`) from `from` to `to`, for example
+// to stop a noisy third-party component from triggering error-based alerts,
+// without silencing it entirely:
+//
+//	zapdriver.WithSeverityRule("grpc.health", zap.ErrorLevel, zap.WarnLevel)
+//
+// Named child loggers inherit the rules of their parent, unless they have a
+// rule for the same level of their own. The rule only changes the reported
+// severity, it doesn't stop zap from panicking or exiting on such entries.
+func WithSeverityRule(loggerName string, from, to zapcore.Level) func(*core) {
+	return func(c *core) {
+		if c.config.SeverityRules == nil {
+			c.config.SeverityRules = map[string]map[zapcore.Level]zapcore.Level{}
+		}
+		if c.config.SeverityRules[loggerName] == nil {
+			c.config.SeverityRules[loggerName] = map[zapcore.Level]zapcore.Level{}
+		}
+
+		c.config.SeverityRules[loggerName][from] = to
+	}
+}
+
+// applySeverityRules returns the level of the entry after applying the rule of
+// the most specific matching logger name.
+func (c *core) applySeverityRules(ent zapcore.Entry) zapcore.Level {
+	name := ent.LoggerName
+	for {
+		if to, ok := c.config.SeverityRules[name][ent.Level]; ok {
+			return to
+		}
+
+		if name == "" {
+			return ent.Level
+		}
+
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[:i]
+		} else {
+			name = ""
+		}
+	}
+}
+
+// BumpErrorSeverity raises the level of entries with an error field (see
+// `zap.Error`) to at least `min`, catching real failures logged at a level
+// where nobody looks. Like severity rules, it only changes the reported
+// severity. Severity rules are applied after this bump.
+func BumpErrorSeverity(min zapcore.Level) func(*core) {
+	return func(c *core) {
+		c.config.ErrorSeverity = &min
+	}
+}
+
+// bumpErrorSeverity returns the level of the entry, raised to the minimum
+// level of entries with an error field.
+func (c *core) bumpErrorSeverity(level zapcore.Level, fields []zapcore.Field) zapcore.Level {
+	min := *c.config.ErrorSeverity
+	if level >= min {
+		return level
+	}
+
+	for i := range fields {
+		if fields[i].Type == zapcore.ErrorType && fields[i].Interface != nil {
+			return min
+		}
+	}
+
+	return level
+}
+
+// WithSeverityMapper replaces the mapping of zap levels to the severities of
+// entries sent to the Cloud Logging API, for example to map custom levels, or
+// to report `DPanic` entries as errors:
+//
+//	zapdriver.WithSeverityMapper(func(l zapcore.Level) logging.Severity {
+//	  if l == zapcore.DPanicLevel {
+//	    return logging.Error
+//	  }
+//	  return zapdriver.DefaultSeverity(l)
+//	})
+//
+// The mapper is applied after severity rules. It doesn't change the severity
+// written by the local encoder, see `EncodeLevel`.
+func WithSeverityMapper(mapper func(zapcore.Level) logging.Severity) func(*core) {
+	return func(c *core) {
+		c.config.SeverityMapper = mapper
+	}
+}
+
+// DefaultSeverity returns the severity of entries of the given level sent to
+// the Cloud Logging API, without a severity mapper. Unknown levels map to
+// `logging.Default`.
+func DefaultSeverity(level zapcore.Level) logging.Severity {
+	return logLevelSeverityGoogle[level]
+}
+
+// severity returns the severity of an entry of the given level sent to the
+// Cloud Logging API.
+func (c *core) severity(level zapcore.Level) logging.Severity {
+	if c.config.SeverityMapper != nil {
+		return c.config.SeverityMapper(level)
+	}
+
+	return DefaultSeverity(level)
+}
@@ -0,0 +1,147 @@
+package zapdriver
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestApplySeverityRules(t *testing.T) {
+	t.Parallel()
+
+	c := &core{}
+	WithSeverityRule("grpc", zapcore.ErrorLevel, zapcore.WarnLevel)(c)
+	WithSeverityRule("grpc.health", zapcore.ErrorLevel, zapcore.DebugLevel)(c)
+
+	tests := []struct {
+		name  string
+		level zapcore.Level
+		want  zapcore.Level
+	}{
+		{"grpc", zapcore.ErrorLevel, zapcore.WarnLevel},
+		{"grpc", zapcore.InfoLevel, zapcore.InfoLevel},
+		{"grpc.transport", zapcore.ErrorLevel, zapcore.WarnLevel},
+		{"grpc.health", zapcore.ErrorLevel, zapcore.DebugLevel},
+		{"grpc.health.check", zapcore.ErrorLevel, zapcore.DebugLevel},
+		{"grpcx", zapcore.ErrorLevel, zapcore.ErrorLevel},
+		{"", zapcore.ErrorLevel, zapcore.ErrorLevel},
+	}
+
+	for _, tt := range tests {
+		ent := zapcore.Entry{LoggerName: tt.name, Level: tt.level}
+		assert.Equal(t, tt.want, c.applySeverityRules(ent), "%s at %s", tt.name, tt.level)
+	}
+}
+
+func TestWriteSeverityRule(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	WithSeverityRule("grpc.health", zapcore.ErrorLevel, zapcore.WarnLevel)(core)
+
+	logger := zap.New(core)
+	logger.Named("grpc").Named("health").Error("probe failed")
+	require.NoError(t, logger.Sync())
+
+	assert.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "WARNING", entries[0].Severity.String())
+}
+
+func TestWriteBumpErrorSeverity(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	BumpErrorSeverity(zapcore.WarnLevel)(core)
+
+	logger := zap.New(core)
+	logger.Info("failed", zap.Error(errors.New("boom")))
+	logger.Info("fine", zap.Error(nil))
+	logger.Error("already", zap.Error(errors.New("boom")))
+
+	require.Equal(t, 3, logs.Len())
+	assert.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+	assert.Equal(t, zapcore.InfoLevel, logs.All()[1].Level)
+	assert.Equal(t, zapcore.ErrorLevel, logs.All()[2].Level)
+}
+
+func TestWriteSeverityMapper(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	WithSeverityMapper(func(l zapcore.Level) logging.Severity {
+		switch l {
+		case zapcore.DPanicLevel:
+			return logging.Error
+		case zapcore.Level(6):
+			return logging.Notice
+		}
+		return DefaultSeverity(l)
+	})(core)
+
+	logger := zap.New(core)
+	logger.DPanic("remapped")
+	logger.Check(zapcore.Level(6), "custom").Write()
+	logger.Info("default")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "ERROR", entries[0].Severity.String())
+	assert.Equal(t, "NOTICE", entries[1].Severity.String())
+	assert.Equal(t, "INFO", entries[2].Severity.String())
+}
+
+func TestSeverity(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	logger.Info("deployed", Severity(logging.Notice))
+	logger.With(Severity(logging.Critical)).Warn("degraded")
+	logger.Info("plain")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "NOTICE", entries[0].Severity.String())
+	assert.Equal(t, "CRITICAL", entries[1].Severity.String())
+	assert.Equal(t, "INFO", entries[2].Severity.String())
+
+	local := logs.All()
+	require.Len(t, local, 3)
+	assert.Equal(t, noticeLevel, local[0].Level)
+	assert.Equal(t, zapcore.DPanicLevel, local[1].Level)
+	assert.Equal(t, zapcore.InfoLevel, local[2].Level)
+}
+
+func TestSeverity_LocalOutput(t *testing.T) {
+	t.Parallel()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	logger := zap.New(newSplitCore(zapcore.InfoLevel, zapcore.AddSync(stdout), zapcore.AddSync(stderr)), WrapCore())
+
+	logger.Info("deployed", Severity(logging.Notice))
+	logger.Info("unknown", Severity(logging.Default))
+	logger.Warn("failing", Severity(logging.Alert))
+
+	assert.Contains(t, stdout.String(), `{"severity":"NOTICE"`)
+	assert.Contains(t, stdout.String(), `{"severity":"DEFAULT"`)
+	assert.Contains(t, stderr.String(), `{"severity":"ALERT"`)
+}
@@ -0,0 +1,82 @@
+package zapdriver
+
+import (
+	"cloud.google.com/go/logging"
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithSink also writes every entry enabled by `level` to the logger, for
+// example to centralize security-relevant entries in a separate audit project:
+//
+//	zapdriver.WrapCore(
+//	  zapdriver.WithLogger(client.Logger("app")),
+//	  zapdriver.WithSink(auditClient.Logger("audit"), zapcore.WarnLevel),
+//	)
+//
+// Sinks receive the same entry as the primary logger (see `WithLogger`), and
+// are flushed by `Sync`. A nil level uses the level of the local output.
+// Failover and routing only apply to the primary logger.
+func WithSink(logger *logging.Logger, level zapcore.LevelEnabler) func(*core) {
+	return func(c *core) {
+		c.config.Sinks = append(c.config.Sinks, &sink{logger: logger, level: level})
+	}
+}
+
+// sink is an additional Cloud Logging destination.
+type sink struct {
+	logger *logging.Logger
+	level  zapcore.LevelEnabler
+}
+
+// sinkEnabled reports whether the sink is enabled for the level.
+func (c *core) sinkEnabled(s *sink, level zapcore.Level) bool {
+	if s.level == nil {
+		return c.Core.Enabled(level)
+	}
+
+	return s.level.Enabled(level)
+}
+
+// sinksEnabled reports whether any sink is enabled for the level.
+func (c *core) sinksEnabled(level zapcore.Level) bool {
+	for _, s := range c.config.Sinks {
+		if c.sinkEnabled(s, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sendSinks sends the entry to all sinks enabled for the level. Every sink gets
+// its own copy of the labels, as the client takes ownership of them. In dry run
+// mode, nothing is sent.
+func (c *core) sendSinks(level zapcore.Level, glog *logging.Entry) error {
+	if c.config.DryRun != nil {
+		return nil
+	}
+
+	var err error
+	for _, s := range c.config.Sinks {
+		if !c.sinkEnabled(s, level) {
+			continue
+		}
+
+		e := *glog
+		if glog.Labels != nil {
+			e.Labels = make(map[string]string, len(glog.Labels))
+			for k, v := range glog.Labels {
+				e.Labels[k] = v
+			}
+		}
+
+		if c.config.SynchronousWrites {
+			err = multierr.Append(err, c.logSync(s.logger, e))
+			continue
+		}
+		s.logger.Log(e)
+	}
+
+	return err
+}
@@ -0,0 +1,48 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithSink(t *testing.T) {
+	client, server := newFakeClient(t)
+	auditClient, auditServer := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.InfoLevel)
+	c := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	WithSink(auditClient.Logger("audit"), zapcore.WarnLevel)(c)
+	WithSink(auditClient.Logger("all"), zapcore.DebugLevel)(c)
+
+	logger := zap.New(c)
+	logger.Debug("debug")
+	logger.Info("info", Label("user", "alice"))
+	logger.Warn("warn")
+	require.NoError(t, logger.Sync())
+
+	assert.Len(t, logs.All(), 2)
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "info", entries[0].GetJsonPayload().Fields["message"].GetStringValue())
+
+	byLog := map[string][]string{}
+	for _, e := range auditServer.Entries() {
+		byLog[e.LogName] = append(byLog[e.LogName], e.GetJsonPayload().Fields["message"].GetStringValue())
+	}
+	assert.Equal(t, map[string][]string{
+		"projects/test-project/logs/audit": {"warn"},
+		"projects/test-project/logs/all":   {"debug", "info", "warn"},
+	}, byLog)
+
+	for _, e := range auditServer.Entries() {
+		if e.GetJsonPayload().Fields["message"].GetStringValue() == "info" {
+			assert.Equal(t, map[string]string{"user": "alice"}, e.Labels)
+		}
+	}
+}
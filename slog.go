@@ -0,0 +1,184 @@
+//go:build go1.21
+// +build go1.21
+
+package zapdriver
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewSlogHandler returns an `slog.Handler` writing records through the core of
+// the logger, so codebases migrating to log/slog keep the same Cloud Logging
+// output, including labels, severities and Error Reporting:
+//
+//	slog.SetDefault(slog.New(zapdriver.NewSlogHandler(logger, "my-project")))
+//
+// Attribute groups become nested objects. The trace context and fields carried
+// by the context of a record are added like `Logger.Ctx` does, and the project
+// name is used to format the trace ID, see `TraceContext`.
+func NewSlogHandler(logger *zap.Logger, projectName string) slog.Handler {
+	return &slogHandler{core: logger.Core(), projectName: projectName}
+}
+
+type slogHandler struct {
+	core        zapcore.Core
+	projectName string
+
+	// groups are opened using WithGroup. Attributes added to a group are kept
+	// in the handler, as everything logged afterwards is nested in the group.
+	groups []slogGroup
+}
+
+// slogGroup is a group opened using WithGroup, with the fields added to it.
+type slogGroup struct {
+	name   string
+	fields []zap.Field
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(slogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ent := zapcore.Entry{Level: slogLevel(r.Level), Time: r.Time, Message: r.Message}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(r.PC, frame.File, frame.Line, true)
+	}
+
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	var attrs []zap.Field
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendSlogAttr(attrs, a)
+		return true
+	})
+
+	// Nest the attributes in the open groups, innermost first. Empty groups
+	// are omitted.
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		g := h.groups[i]
+		inner := append(g.fields[:len(g.fields):len(g.fields)], attrs...)
+		if len(inner) == 0 {
+			attrs = nil
+			continue
+		}
+		attrs = []zap.Field{zap.Object(g.name, slogFields(inner))}
+	}
+
+	fields := contextFields(ctx, h.projectName)
+	ce.Write(append(fields, attrs...)...)
+
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []zap.Field
+	for _, a := range attrs {
+		fields = appendSlogAttr(fields, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+
+	if len(h.groups) == 0 {
+		return &slogHandler{core: h.core.With(fields), projectName: h.projectName}
+	}
+
+	groups := append([]slogGroup{}, h.groups...)
+	last := &groups[len(groups)-1]
+	last.fields = append(last.fields[:len(last.fields):len(last.fields)], fields...)
+
+	return &slogHandler{core: h.core, projectName: h.projectName, groups: groups}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	groups := make([]slogGroup, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
+
+	return &slogHandler{core: h.core, projectName: h.projectName, groups: append(groups, slogGroup{name: name})}
+}
+
+// appendSlogAttr appends the field of an attribute. Empty attributes and groups
+// are omitted, groups without a key are inlined.
+func appendSlogAttr(fields []zap.Field, a slog.Attr) []zap.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	case slog.KindGroup:
+		var group []zap.Field
+		for _, ga := range a.Value.Group() {
+			group = appendSlogAttr(group, ga)
+		}
+		if len(group) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			return append(fields, group...)
+		}
+		return append(fields, zap.Object(a.Key, slogFields(group)))
+	}
+
+	if err, ok := a.Value.Any().(error); ok {
+		return append(fields, zap.NamedError(a.Key, err))
+	}
+
+	return append(fields, zap.Any(a.Key, a.Value.Any()))
+}
+
+// slogFields marshals the fields of a group as a nested object.
+type slogFields []zap.Field
+
+func (fields slogFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+
+	return nil
+}
+
+// slogLevel returns the zap level of an slog level. Levels above error are
+// mapped to DPanicLevel, which has the critical severity.
+func slogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	case level < slog.LevelError+4:
+		return zapcore.ErrorLevel
+	}
+
+	return zapcore.DPanicLevel
+}
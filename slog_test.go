@@ -0,0 +1,73 @@
+//go:build go1.21
+// +build go1.21
+
+package zapdriver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandler(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := slog.New(NewSlogHandler(zap.New(&core{Core: debugcore, permLabels: newLabels()}), "my-project"))
+
+	ctx := ContextWithSpanContext(context.Background(), SpanContext{TraceID: "105445aa7843bc8bf206b12000100000", SpanID: "0000000000000001"})
+	logger.With("labels.team", "payments", "service", "orders").
+		WithGroup("request").With("method", "GET").
+		WithGroup("empty").
+		ErrorContext(ctx, "failed", "status", 500, slog.Group("user", "id", 42), "error", errors.New("boom"))
+
+	require.Len(t, logs.All(), 1)
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+	assert.Equal(t, "failed", entry.Message)
+	assert.True(t, entry.Caller.Defined)
+
+	fields := entry.ContextMap()
+	assert.Equal(t, map[string]interface{}{"team": "payments"}, fields[labelsKey])
+	assert.Equal(t, "orders", fields["service"])
+	assert.Equal(t, "projects/my-project/traces/105445aa7843bc8bf206b12000100000", fields[traceKey])
+	assert.Equal(t, map[string]interface{}{
+		"method": "GET",
+		"empty": map[string]interface{}{
+			"status": int64(500),
+			"user":   map[string]interface{}{"id": int64(42)},
+			"error":  "boom",
+		},
+	}, fields["request"])
+}
+
+func TestSlogHandler_EmptyGroups(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := slog.New(NewSlogHandler(zap.New(&core{Core: debugcore, permLabels: newLabels()}), ""))
+
+	logger.WithGroup("request").Info("hello", slog.Group("nothing"))
+
+	require.Len(t, logs.All(), 1)
+	assert.NotContains(t, logs.All()[0].ContextMap(), "request")
+	assert.NotContains(t, logs.All()[0].ContextMap(), "nothing")
+}
+
+func TestSlogLevel(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, zapcore.DebugLevel, slogLevel(slog.LevelDebug))
+	assert.Equal(t, zapcore.InfoLevel, slogLevel(slog.LevelInfo))
+	assert.Equal(t, zapcore.InfoLevel, slogLevel(slog.LevelInfo+2))
+	assert.Equal(t, zapcore.WarnLevel, slogLevel(slog.LevelWarn))
+	assert.Equal(t, zapcore.ErrorLevel, slogLevel(slog.LevelError))
+	assert.Equal(t, zapcore.DPanicLevel, slogLevel(slog.LevelError+4))
+}
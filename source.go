@@ -1,8 +1,11 @@
 package zapdriver
 
 import (
+	"go/build"
+	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -51,16 +54,81 @@ func newSource(pc uintptr, file string, line int, ok bool) *source {
 		return nil
 	}
 
-	var function string
-	if fn := runtime.FuncForPC(pc); fn != nil {
-		function = fn.Name()
-	}
-
 	source := &source{
 		File:     file,
 		Line:     strconv.Itoa(line),
-		Function: function,
+		Function: functionName(pc),
 	}
 
 	return source
 }
+
+// functionName returns the fully-qualified name of the function holding the
+// program counter, or an empty string if it's unknown.
+func functionName(pc uintptr) string {
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		return fn.Name()
+	}
+
+	return ""
+}
+
+// TrimSourcePaths trims the given prefixes from the file paths of the source
+// locations of entries, so absolute paths of the build machine don't end up in
+// Cloud Logging. The first matching prefix is trimmed:
+//
+//	zapdriver.TrimSourcePaths("/home/build/src/my-app/")
+//
+// Without prefixes, the source directory of GOROOT, the source directories and
+// module caches of GOPATH are trimmed, turning a path like
+// "/go/pkg/mod/github.com/acme/lib@v1.0.0/lib.go" into
+// "github.com/acme/lib@v1.0.0/lib.go". Building with `go build -trimpath`
+// achieves the same for the main module too.
+func TrimSourcePaths(prefixes ...string) func(*core) {
+	if len(prefixes) == 0 {
+		prefixes = defaultSourcePrefixes()
+	}
+
+	trimmed := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		prefix = filepath.ToSlash(prefix)
+		if prefix == "" {
+			continue
+		}
+		if !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+
+		trimmed = append(trimmed, prefix)
+	}
+
+	return func(c *core) {
+		c.config.SourcePathPrefixes = trimmed
+	}
+}
+
+// defaultSourcePrefixes returns the directories Go source files are commonly
+// built from.
+func defaultSourcePrefixes() []string {
+	var prefixes []string
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		prefixes = append(prefixes, filepath.Join(gopath, "pkg", "mod"), filepath.Join(gopath, "src"))
+	}
+	if build.Default.GOROOT != "" {
+		prefixes = append(prefixes, filepath.Join(build.Default.GOROOT, "src"))
+	}
+
+	return prefixes
+}
+
+// sourceFile returns the file path of a source location, without the first
+// matching prefix of `TrimSourcePaths`.
+func (c *core) sourceFile(file string) string {
+	for _, prefix := range c.config.SourcePathPrefixes {
+		if strings.HasPrefix(file, prefix) {
+			return file[len(prefix):]
+		}
+	}
+
+	return file
+}
@@ -1,10 +1,17 @@
 package zapdriver
 
 import (
+	"go/build"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestSourceLocation(t *testing.T) {
@@ -13,7 +20,7 @@ func TestSourceLocation(t *testing.T) {
 	got := SourceLocation(runtime.Caller(0)).Interface.(*source)
 
 	assert.Contains(t, got.File, "zapdriver/source_test.go")
-	assert.Equal(t, "13", got.Line)
+	assert.Equal(t, "20", got.Line)
 	assert.Contains(t, got.Function, "zapdriver.TestSourceLocation")
 }
 
@@ -23,6 +30,70 @@ func TestNewSource(t *testing.T) {
 	got := newSource(runtime.Caller(0))
 
 	assert.Contains(t, got.File, "zapdriver/source_test.go")
-	assert.Equal(t, "23", got.Line)
+	assert.Equal(t, "30", got.Line)
 	assert.Contains(t, got.Function, "zapdriver.TestNewSource")
 }
+
+func TestWriteSourceLocationFunction(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core, zap.AddCaller())
+	logger.Info("hello")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	require.NotNil(t, entries[0].SourceLocation)
+	assert.Contains(t, entries[0].SourceLocation.File, "zapdriver/source_test.go")
+	assert.Equal(t, "github.com/blendle/zapdriver.TestWriteSourceLocationFunction", entries[0].SourceLocation.Function)
+}
+
+func TestTrimSourcePaths(t *testing.T) {
+	t.Parallel()
+
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(filepath.Dir(file))
+
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	TrimSourcePaths(dir)(core)
+
+	logger := zap.New(core, zap.AddCaller())
+	logger.Info("hello")
+	logger.Info("manual", SourceLocation(0, dir+"/other/file.go", 3, true))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "zapdriver/source_test.go", entries[0].SourceLocation.File)
+	assert.Equal(t, "other/file.go", entries[1].SourceLocation.File)
+
+	local := logs.All()[0].ContextMap()[sourceKey].(map[string]interface{})
+	assert.Equal(t, "zapdriver/source_test.go", local["file"])
+}
+
+func TestTrimSourcePaths_Defaults(t *testing.T) {
+	t.Parallel()
+
+	core := &core{}
+	TrimSourcePaths()(core)
+
+	gopath := filepath.SplitList(build.Default.GOPATH)[0]
+	modFile := filepath.ToSlash(filepath.Join(gopath, "pkg", "mod")) + "/github.com/acme/lib@v1.0.0/lib.go"
+	stdFile := filepath.ToSlash(filepath.Join(build.Default.GOROOT, "src")) + "/net/http/server.go"
+
+	assert.Equal(t, "github.com/acme/lib@v1.0.0/lib.go", core.sourceFile(modFile))
+	assert.Equal(t, "net/http/server.go", core.sourceFile(stdFile))
+	assert.Equal(t, "/elsewhere/main.go", core.sourceFile("/elsewhere/main.go"))
+
+	for _, prefix := range core.config.SourcePathPrefixes {
+		assert.True(t, strings.HasSuffix(prefix, "/"), prefix)
+	}
+}
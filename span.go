@@ -0,0 +1,66 @@
+package zapdriver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AutoSpanID generates a synthetic span ID for every entry that has a trace,
+// but no span ID. Without a span ID, the Logs Explorer collapses all entries of
+// a trace onto a single span.
+func AutoSpanID() func(*core) {
+	return func(c *core) {
+		c.config.AutoSpanID = true
+	}
+}
+
+// withSpanID adds a generated span ID to the fields if they, or the inherited
+// fields, contain a trace but no span ID.
+func (c *core) withSpanID(fields []zapcore.Field) []zapcore.Field {
+	hasTrace, span := false, -1
+	for i := range c.fields {
+		switch {
+		case c.fields[i].Key == traceKey && c.fields[i].String != "":
+			hasTrace = true
+		case c.fields[i].Key == spanKey && c.fields[i].String != "":
+			// An inherited span ID applies to all entries.
+			return fields
+		}
+	}
+
+	for i := range fields {
+		switch {
+		case fields[i].Key == traceKey && fields[i].String != "":
+			hasTrace = true
+		case fields[i].Key == spanKey:
+			if fields[i].String != "" {
+				return fields
+			}
+			span = i
+		}
+	}
+
+	if !hasTrace {
+		return fields
+	}
+
+	if span >= 0 {
+		out := make([]zapcore.Field, len(fields))
+		copy(out, fields)
+		out[span] = zap.String(spanKey, randomID())
+		return out
+	}
+
+	return append(fields, zap.String(spanKey, randomID()))
+}
+
+// randomID returns a random span ID of 16 hexadecimal characters.
+func randomID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
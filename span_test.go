@@ -0,0 +1,60 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithSpanID(t *testing.T) {
+	t.Parallel()
+
+	c := &core{}
+
+	fields := c.withSpanID([]zap.Field{zap.String(traceKey, "projects/p/traces/t")})
+	require.Len(t, fields, 2)
+	assert.Equal(t, spanKey, fields[1].Key)
+	assert.Len(t, fields[1].String, 16)
+
+	fields = TraceContext("t", "", false, "p")
+	out := c.withSpanID(fields)
+	assert.Len(t, out[1].String, 16)
+	assert.Empty(t, fields[1].String, "fields of the caller are modified")
+
+	fields = TraceContext("t", "span", false, "p")
+	assert.Equal(t, fields, c.withSpanID(fields))
+
+	fields = []zap.Field{zap.String("hello", "world")}
+	assert.Equal(t, fields, c.withSpanID(fields))
+}
+
+func TestWithSpanID_Inherited(t *testing.T) {
+	t.Parallel()
+
+	c := &core{fields: TraceContext("t", "span", false, "p")}
+	assert.Empty(t, c.withSpanID(nil))
+
+	c = &core{fields: []zap.Field{zap.String(traceKey, "projects/p/traces/t")}}
+	assert.Len(t, c.withSpanID(nil), 1)
+}
+
+func TestWriteAutoSpanID(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, permLabels: newLabels()}
+	AutoSpanID()(core)
+
+	logger := zap.New(core).With(zap.String(traceKey, "projects/p/traces/t"))
+	logger.Info("one")
+	logger.Info("two")
+
+	one := logs.All()[0].ContextMap()[spanKey]
+	two := logs.All()[1].ContextMap()[spanKey]
+	assert.NotEmpty(t, one)
+	assert.NotEqual(t, one, two)
+}
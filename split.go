@@ -0,0 +1,73 @@
+package zapdriver
+
+import (
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewSplitCore returns a core writing the entries enabled by `level` as JSON
+// lines understood by the Cloud Logging agent (see `NewAgentConfig`). Entries
+// below warn level are written to standard output, and warn and above to
+// standard error, as serverless runtimes like Cloud Run treat both streams
+// differently.
+func NewSplitCore(level zapcore.LevelEnabler) zapcore.Core {
+	return newSplitCore(level, zapcore.Lock(os.Stdout), zapcore.Lock(os.Stderr))
+}
+
+func newSplitCore(level zapcore.LevelEnabler, stdout, stderr zapcore.WriteSyncer) zapcore.Core {
+	enc := zapcore.NewJSONEncoder(NewAgentConfig().EncoderConfig)
+
+	low := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l < zapcore.WarnLevel && level.Enabled(l)
+	})
+	high := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= zapcore.WarnLevel && level.Enabled(l)
+	})
+
+	return zapcore.NewTee(
+		filteredCore{zapcore.NewCore(enc, stdout, low)},
+		filteredCore{zapcore.NewCore(enc.Clone(), stderr, high)},
+	)
+}
+
+// filteredCore only writes entries its level enables. A tee writes entries to
+// all of its cores, so when the zapdriver core wraps it, and writes to it
+// without checking each core first, the levels of the cores are ignored.
+type filteredCore struct {
+	zapcore.Core
+}
+
+func (c filteredCore) With(fields []zapcore.Field) zapcore.Core {
+	return filteredCore{c.Core.With(fields)}
+}
+
+func (c filteredCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(enablingLevel(ent.Level)) {
+		return nil
+	}
+
+	return c.Core.Write(ent, fields)
+}
+
+// NewSplit builds a production Logger that writes InfoLevel and above logs
+// using `NewSplitCore`.
+func NewSplit(options ...zap.Option) (*zap.Logger, error) {
+	return NewSplitWithCore(WrapCore(), options...)
+}
+
+// NewSplitWithCore is same as NewSplit but accepts a custom configured core,
+// for example to also send entries to the Cloud Logging API:
+//
+//	zapdriver.NewSplitWithCore(zapdriver.WrapCore(zapdriver.WithLogger(client.Logger("app"))))
+func NewSplitWithCore(core zap.Option, options ...zap.Option) (*zap.Logger, error) {
+	options = append([]zap.Option{
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+	}, options...)
+	options = append(options, core)
+
+	return validateLogger(zap.New(NewSplitCore(zapcore.InfoLevel), options...), nil)
+}
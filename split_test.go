@@ -0,0 +1,42 @@
+package zapdriver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSplitCore(t *testing.T) {
+	t.Parallel()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	logger := zap.New(newSplitCore(zapcore.InfoLevel, zapcore.AddSync(stdout), zapcore.AddSync(stderr)), WrapCore())
+
+	logger.Debug("dropped")
+	logger.Info("routine", zap.String("hello", "world"))
+	logger.Warn("careful")
+	logger.Error("broken")
+
+	assert.Contains(t, stdout.String(), `"severity":"INFO"`)
+	assert.Contains(t, stdout.String(), `"time":`)
+	assert.NotContains(t, stdout.String(), "dropped")
+	assert.NotContains(t, stdout.String(), "careful")
+
+	assert.Contains(t, stderr.String(), `"severity":"WARNING"`)
+	assert.Contains(t, stderr.String(), `"severity":"ERROR"`)
+	assert.NotContains(t, stderr.String(), "routine")
+}
+
+func TestNewSplit(t *testing.T) {
+	t.Parallel()
+
+	logger, err := NewSplit()
+	require.NoError(t, err)
+
+	_, ok := logger.Core().(*core)
+	assert.True(t, ok)
+}
@@ -0,0 +1,300 @@
+package zapdriver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/api/option"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// spoolReplayTimeout bounds every replayed write of the spool.
+const spoolReplayTimeout = 30 * time.Second
+
+// WithDiskSpool makes the client created by `NewClient` append the entries it
+// couldn't deliver, because Cloud Logging was unreachable, to a local file, and
+// replay them once a write succeeds again. This keeps the logs of devices with
+// intermittent connectivity.
+//
+// Entries are spooled as they were sent, including their timestamps and insert
+// IDs (see `AutoInsertID`), so Cloud Logging deduplicates entries that turn out
+// to have been delivered after all. Entries rejected for other reasons than
+// connectivity are not spooled (see `WithDeadLetterFile`). Once the file holds
+// `maxBytes`, further entries are dropped.
+//
+// The option wraps the `OnError` callback of the client created by `NewClient`,
+// it should not be replaced afterwards.
+func WithDiskSpool(path string, maxBytes int64) ClientOption {
+	return func(c *clientConfig) {
+		s := &spool{
+			path:     path,
+			maxBytes: maxBytes,
+			pending:  map[*logpb.WriteLogEntriesRequest]pendingRequest{},
+		}
+
+		c.spool = s
+		c.options = append(c.options, option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(s.intercept)))
+	}
+}
+
+// spool keeps undelivered requests in a file, and replays them.
+type spool struct {
+	path     string
+	maxBytes int64
+
+	mutex     sync.Mutex
+	replaying bool
+
+	// pending holds copies of the requests that failed because Cloud Logging
+	// was unreachable, and are still being retried by the client.
+	pending map[*logpb.WriteLogEntriesRequest]pendingRequest
+}
+
+// pendingRequest is a copy of a failing request, with the context of its last
+// attempt.
+type pendingRequest struct {
+	req *logpb.WriteLogEntriesRequest
+	ctx context.Context
+}
+
+// replayKey marks the context of replayed writes.
+type replayKey struct{}
+
+// intercept watches the outcome of every attempt to write entries, and starts
+// replaying the spool once one succeeds.
+func (s *spool) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	wreq, ok := req.(*logpb.WriteLogEntriesRequest)
+	if !ok || ctx.Value(replayKey{}) != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case err == nil:
+		delete(s.pending, wreq)
+		if !s.replaying && s.size() > 0 {
+			s.replaying = true
+			go s.replay(cc, method)
+		}
+	case !isRetriedCode(status.Code(err)):
+		delete(s.pending, wreq)
+	case ctx.Err() == nil:
+		p, ok := s.pending[wreq]
+		if !ok {
+			p.req = copyRequest(wreq)
+		}
+		p.ctx = ctx
+		s.pending[wreq] = p
+	default:
+		// The client gave up on the request, as its deadline passed.
+		delete(s.pending, wreq)
+		_ = s.append([]*logpb.WriteLogEntriesRequest{copyRequest(wreq)})
+	}
+
+	return err
+}
+
+// onError spools the failing requests the client gave up on, as their deadline
+// passed while waiting for the next attempt. Requests that are still retried
+// are kept, they might succeed yet.
+func (s *spool) onError(error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var reqs []*logpb.WriteLogEntriesRequest
+	for req, p := range s.pending {
+		if p.ctx.Err() == nil {
+			continue
+		}
+
+		delete(s.pending, req)
+		reqs = append(reqs, p.req)
+	}
+
+	_ = s.append(reqs)
+}
+
+// copyRequest returns a deep copy of a request. The client shares parts of its
+// requests, like the monitored resource, which are concurrently marshaled by
+// other writes, so requests are copied before they're encoded.
+func copyRequest(req *logpb.WriteLogEntriesRequest) *logpb.WriteLogEntriesRequest {
+	return proto.Clone(req).(*logpb.WriteLogEntriesRequest)
+}
+
+// replayPath returns the path of the file holding the requests being
+// replayed.
+func (s *spool) replayPath() string {
+	return s.path + ".replaying"
+}
+
+// size returns the size of the spool, including the requests being replayed.
+// The mutex must be held.
+func (s *spool) size() int64 {
+	var size int64
+	for _, path := range []string{s.path, s.replayPath()} {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+
+	return size
+}
+
+// append adds the requests to the spool file, as long as it has room. The
+// mutex must be held.
+func (s *spool) append(reqs []*logpb.WriteLogEntriesRequest) error {
+	buf, err := encodeRequests(reqs, s.maxBytes-s.size())
+	if err != nil || len(buf) == 0 {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(buf); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// replay writes the spooled requests, oldest first. The requests stay on disk
+// until they're delivered, so a crash during the replay loses none of them.
+// Requests that still fail are spooled again.
+func (s *spool) replay(cc *grpc.ClientConn, method string) {
+	s.mutex.Lock()
+	reqs, err := s.claim()
+	s.mutex.Unlock()
+
+	for len(reqs) > 0 && err == nil {
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), replayKey{}, true), spoolReplayTimeout)
+		err = cc.Invoke(ctx, method, reqs[0], &logpb.WriteLogEntriesResponse{})
+		cancel()
+
+		if err == nil || !isRetriedCode(status.Code(err)) {
+			reqs, err = reqs[1:], nil
+
+			s.mutex.Lock()
+			_ = s.rewrite(s.replayPath(), reqs, s.maxBytes)
+			s.mutex.Unlock()
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Requests spooled during the replay come after the ones that failed.
+	rest, _ := s.read(s.path)
+	if s.rewrite(s.path, append(reqs, rest...), s.maxBytes) == nil {
+		_ = os.Remove(s.replayPath())
+	}
+	s.replaying = false
+}
+
+// claim moves the requests of the spool file to the replay file, and returns
+// all requests of the replay file. Requests left in the replay file by an
+// interrupted replay come first. The mutex must be held.
+func (s *spool) claim() ([]*logpb.WriteLogEntriesRequest, error) {
+	reqs, err := s.read(s.replayPath())
+	if err != nil {
+		return nil, err
+	}
+
+	rest, err := s.read(s.path)
+	if err != nil || len(rest) == 0 {
+		return reqs, err
+	}
+
+	reqs = append(reqs, rest...)
+	if err := s.rewrite(s.replayPath(), reqs, s.maxBytes); err != nil {
+		return nil, err
+	}
+
+	return reqs, os.Remove(s.path)
+}
+
+// read returns the requests of a spool file. The mutex must be held.
+func (s *spool) read(path string) ([]*logpb.WriteLogEntriesRequest, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var reqs []*logpb.WriteLogEntriesRequest
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, int(s.maxBytes)+1)
+	for scanner.Scan() {
+		req := &logpb.WriteLogEntriesRequest{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(scanner.Bytes()), req); err != nil {
+			continue
+		}
+		reqs = append(reqs, req)
+	}
+
+	return reqs, scanner.Err()
+}
+
+// rewrite atomically replaces a spool file by the requests that fit in limit
+// bytes, removing it if there are none. The mutex must be held.
+func (s *spool) rewrite(path string, reqs []*logpb.WriteLogEntriesRequest, limit int64) error {
+	buf, err := encodeRequests(reqs, limit)
+	if err != nil {
+		return err
+	}
+
+	if len(buf) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// encodeRequests returns the requests as JSON lines, as long as they fit in
+// limit bytes.
+func encodeRequests(reqs []*logpb.WriteLogEntriesRequest, limit int64) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	m := jsonpb.Marshaler{}
+
+	for _, req := range reqs {
+		line := &bytes.Buffer{}
+		if err := m.Marshal(line, req); err != nil {
+			return nil, err
+		}
+		line.WriteByte('\n')
+
+		if int64(buf.Len()+line.Len()) > limit {
+			break
+		}
+		buf.Write(line.Bytes())
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,190 @@
+package zapdriver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWithDiskSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zapdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "spool.jsonl")
+	addr, server := newFakeServer(t)
+	server.setError(status.Error(codes.Unavailable, "offline"))
+
+	client, lg, err := NewCloudLogger(context.Background(), "test-project", "app",
+		WithEndpoint(addr),
+		WithClientOptions(
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithInsecure()),
+		),
+		WithWriteTimeout(50*time.Millisecond),
+		WithDiskSpool(path, 1<<20),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	timestamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	lg.Log(logging.Entry{Payload: "offline", Timestamp: timestamp, InsertID: "abc"})
+	require.Error(t, lg.Flush())
+
+	// The client reports the failure asynchronously.
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+			break
+		}
+		require.True(t, time.Now().Before(deadline), "entry never spooled")
+		time.Sleep(time.Millisecond)
+	}
+
+	server.setError(nil)
+	lg.Log(logging.Entry{Payload: "online"})
+	require.NoError(t, lg.Flush())
+
+	// An attempt that timed out on the client may still reach the server once
+	// it's back online, so the replay is the entry following the online one.
+	var replayed *logpb.LogEntry
+	for deadline := time.Now().Add(5 * time.Second); replayed == nil; {
+		require.True(t, time.Now().Before(deadline), "spool never replayed")
+		time.Sleep(time.Millisecond)
+
+		entries := server.Entries()
+		for i := 1; i < len(entries); i++ {
+			if entries[i-1].GetTextPayload() == "online" {
+				replayed = entries[i]
+			}
+		}
+	}
+
+	assert.Equal(t, "offline", replayed.GetTextPayload())
+	assert.Equal(t, "abc", replayed.InsertId)
+	assert.Equal(t, timestamp.Unix(), replayed.Timestamp.Seconds)
+}
+
+func TestSpool_MaxBytes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zapdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := &spool{path: filepath.Join(dir, "spool.jsonl"), maxBytes: 200}
+
+	var reqs []*logpb.WriteLogEntriesRequest
+	for _, msg := range []string{"one", "two", "three", "four", "five"} {
+		reqs = append(reqs, &logpb.WriteLogEntriesRequest{
+			LogName: "projects/p/logs/app",
+			Entries: []*logpb.LogEntry{{Payload: &logpb.LogEntry_TextPayload{TextPayload: msg}}},
+		})
+	}
+
+	require.NoError(t, s.append(reqs[:2]))
+	require.NoError(t, s.append(reqs[2:]))
+	assert.True(t, s.size() <= s.maxBytes)
+
+	spooled, err := s.read(s.path)
+	require.NoError(t, err)
+	require.NotEmpty(t, spooled)
+	assert.True(t, len(spooled) < len(reqs))
+	for i, req := range spooled {
+		assert.Equal(t, reqs[i].Entries[0].GetTextPayload(), req.Entries[0].GetTextPayload())
+	}
+}
+
+func TestSpool_ReplayKeepsUndelivered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zapdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := &spool{path: filepath.Join(dir, "spool.jsonl"), maxBytes: 1 << 20}
+
+	var reqs []*logpb.WriteLogEntriesRequest
+	for _, msg := range []string{"one", "two", "three"} {
+		reqs = append(reqs, &logpb.WriteLogEntriesRequest{
+			LogName: "projects/p/logs/app",
+			Entries: []*logpb.LogEntry{{Payload: &logpb.LogEntry_TextPayload{TextPayload: msg}}},
+		})
+	}
+
+	// A replay interrupted after delivering the first request.
+	require.NoError(t, s.rewrite(s.replayPath(), reqs[:2], s.maxBytes))
+	require.NoError(t, s.append(reqs[2:]))
+
+	var calls int
+	var onDisk []*logpb.WriteLogEntriesRequest
+	interceptor := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if calls++; calls == 1 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		// Crash while replaying the second request.
+		onDisk, _ = s.read(s.replayPath())
+		return status.Error(codes.Unavailable, "offline")
+	}
+
+	addr, server := newFakeServer(t)
+	cc, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithUnaryInterceptor(interceptor))
+	require.NoError(t, err)
+	defer cc.Close()
+
+	s.replay(cc, "/google.logging.v2.LoggingServiceV2/WriteLogEntries")
+
+	require.Len(t, server.Entries(), 1)
+	assert.Equal(t, "one", server.Entries()[0].GetTextPayload())
+
+	require.Len(t, onDisk, 2)
+	assert.Equal(t, "two", onDisk[0].Entries[0].GetTextPayload())
+	assert.Equal(t, "three", onDisk[1].Entries[0].GetTextPayload())
+
+	spooled, err := s.read(s.path)
+	require.NoError(t, err)
+	require.Len(t, spooled, 2)
+	assert.Equal(t, "two", spooled[0].Entries[0].GetTextPayload())
+
+	_, err = os.Stat(s.replayPath())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSpool_OnErrorKeepsRetried(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zapdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := &spool{
+		path:     filepath.Join(dir, "spool.jsonl"),
+		maxBytes: 1 << 20,
+		pending:  map[*logpb.WriteLogEntriesRequest]pendingRequest{},
+	}
+
+	req := &logpb.WriteLogEntriesRequest{
+		LogName: "projects/p/logs/app",
+		Entries: []*logpb.LogEntry{{Payload: &logpb.LogEntry_TextPayload{TextPayload: "retried"}}},
+	}
+	unavailable := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_ = s.intercept(ctx, "", req, nil, nil, unavailable)
+	s.onError(nil)
+	assert.Zero(t, s.size(), "a write still being retried is spooled")
+
+	cancel()
+	s.onError(nil)
+	spooled, err := s.read(s.path)
+	require.NoError(t, err)
+	assert.Len(t, spooled, 1)
+}
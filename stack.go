@@ -0,0 +1,78 @@
+package zapdriver
+
+import (
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	stackFramesKey = "stack_frames"
+	stacktraceKey  = "stacktrace"
+)
+
+// StructuredStacks adds the stack trace captured by zap (see
+// `zap.AddStacktrace`) as an array of frame objects, with the function, file
+// and line of every frame, making stacks queryable. The flat stack trace is
+// also added to the payload sent to Cloud Logging.
+func StructuredStacks() func(*core) {
+	return func(c *core) {
+		c.config.StructuredStacks = true
+	}
+}
+
+// stackFrame is a single frame of a stack trace.
+type stackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaller interface.
+func (f stackFrame) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("function", f.Function)
+	enc.AddString("file", f.File)
+	enc.AddInt("line", f.Line)
+
+	return nil
+}
+
+type stackFrames []stackFrame
+
+// MarshalLogArray implements zapcore.ArrayMarshaler interface.
+func (s stackFrames) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, f := range s {
+		_ = enc.AppendObject(f)
+	}
+
+	return nil
+}
+
+// parseStack parses a stack trace as formatted by zap, a line with the function
+// followed by a tab-indented line with the file and line number, for every
+// frame.
+func parseStack(stack string) stackFrames {
+	lines := strings.Split(strings.TrimSpace(stack), "\n")
+
+	frames := make(stackFrames, 0, len(lines)/2)
+	for i := 0; i+1 < len(lines); i += 2 {
+		frame := stackFrame{Function: lines[i], File: strings.TrimSpace(lines[i+1])}
+
+		if j := strings.LastIndex(frame.File, ":"); j >= 0 {
+			if line, err := strconv.Atoi(frame.File[j+1:]); err == nil {
+				frame.File, frame.Line = frame.File[:j], line
+			}
+		}
+
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+// stackFramesField returns the field holding the frames of the stack trace.
+func stackFramesField(stack string) zapcore.Field {
+	return zap.Array(stackFramesKey, parseStack(stack))
+}
@@ -0,0 +1,49 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseStack(t *testing.T) {
+	t.Parallel()
+
+	stack := "main.handle\n\t/app/main.go:42\nmain.main\n\t/app/main.go:10"
+
+	want := stackFrames{
+		{Function: "main.handle", File: "/app/main.go", Line: 42},
+		{Function: "main.main", File: "/app/main.go", Line: 10},
+	}
+	assert.Equal(t, want, parseStack(stack))
+}
+
+func TestWriteStructuredStacks(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	StructuredStacks()(core)
+
+	logger := zap.New(core, zap.AddStacktrace(zapcore.ErrorLevel))
+	logger.Info("no stack")
+	logger.Error("stack")
+	require.NoError(t, logger.Sync())
+
+	assert.NotContains(t, logs.All()[0].ContextMap(), stackFramesKey)
+
+	frames := logs.All()[1].ContextMap()[stackFramesKey].([]interface{})
+	require.NotEmpty(t, frames)
+	assert.Contains(t, frames[0].(map[string]interface{})["function"], "TestWriteStructuredStacks")
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+
+	payload := entries[1].GetJsonPayload().Fields
+	assert.NotEmpty(t, payload[stacktraceKey].GetStringValue())
+	assert.NotEmpty(t, payload[stackFramesKey].GetListValue().Values)
+}
@@ -0,0 +1,151 @@
+package zapdriver
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Stats is a snapshot of the internal counters of a logger, to inspect its
+// health without a metrics stack (see `Metrics` for Prometheus).
+type Stats struct {
+	// Entries is the number of entries processed by the core.
+	Entries uint64
+
+	// LabelMerges is the number of times the labels of an entry, or of a child
+	// logger, were merged with the inherited ones.
+	LabelMerges uint64
+
+	// Flushes is the number of times the Cloud Logging loggers were flushed.
+	Flushes uint64
+
+	// Errors is the number of failures to deliver entries, and LastError and
+	// LastErrorTime describe the most recent one.
+	Errors        uint64
+	LastError     string
+	LastErrorTime time.Time
+
+	// LastSent is the last time entries were delivered to Cloud Logging: a
+	// synchronous write succeeded, a flush of logged entries succeeded, or
+	// `HealthCheck` passed.
+	LastSent time.Time
+}
+
+// StatsOf returns the stats of the logger. They are zero if the logger doesn't
+// use the zapdriver core.
+//
+// Failures reported through the `OnError` callback of the client are only
+// counted for the loggers created by `NewCloudProduction` and
+// `NewCloudDevelopment`.
+func StatsOf(logger *zap.Logger) Stats {
+	c, ok := logger.Core().(*core)
+	if !ok {
+		return Stats{}
+	}
+
+	return c.config.Stats.snapshot()
+}
+
+// PublishStats publishes the stats of the logger as the expvar variable `name`,
+// so they are served by the "/debug/vars" handler. Like `expvar.Publish`, it
+// panics if the name is already in use.
+func PublishStats(name string, logger *zap.Logger) {
+	expvar.Publish(name, expvar.Func(func() interface{} { return StatsOf(logger) }))
+}
+
+// stats holds the counters of a core, and is shared by its children. Its
+// methods are no-ops on nil stats, as cores built by hand have none.
+type stats struct {
+	// The counters are accessed atomically, and kept first for alignment.
+	entries     uint64
+	labelMerges uint64
+	flushes     uint64
+	errors      uint64
+
+	// pending is the number of entries logged since the last flush.
+	pending uint64
+
+	mutex         sync.Mutex
+	lastError     string
+	lastErrorTime time.Time
+	lastSent      time.Time
+}
+
+func (s *stats) entry() {
+	if s != nil {
+		atomic.AddUint64(&s.entries, 1)
+	}
+}
+
+func (s *stats) labelMerge() {
+	if s != nil {
+		atomic.AddUint64(&s.labelMerges, 1)
+	}
+}
+
+func (s *stats) flush() {
+	if s != nil {
+		atomic.AddUint64(&s.flushes, 1)
+	}
+}
+
+// logged records an entry handed to a Cloud Logging logger.
+func (s *stats) logged() {
+	if s != nil {
+		atomic.AddUint64(&s.pending, 1)
+	}
+}
+
+// flushed records the outcome of a flush, which delivered the pending entries
+// if it succeeded.
+func (s *stats) flushed(err error) {
+	if s != nil && atomic.SwapUint64(&s.pending, 0) > 0 && err == nil {
+		s.sent()
+	}
+}
+
+// sent records that entries were delivered.
+func (s *stats) sent() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	s.lastSent = time.Now()
+	s.mutex.Unlock()
+}
+
+func (s *stats) error(err error) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.errors, 1)
+
+	s.mutex.Lock()
+	s.lastError = err.Error()
+	s.lastErrorTime = time.Now()
+	s.mutex.Unlock()
+}
+
+func (s *stats) snapshot() Stats {
+	if s == nil {
+		return Stats{}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return Stats{
+		Entries:       atomic.LoadUint64(&s.entries),
+		LabelMerges:   atomic.LoadUint64(&s.labelMerges),
+		Flushes:       atomic.LoadUint64(&s.flushes),
+		Errors:        atomic.LoadUint64(&s.errors),
+		LastError:     s.lastError,
+		LastErrorTime: s.lastErrorTime,
+		LastSent:      s.lastSent,
+	}
+}
@@ -0,0 +1,77 @@
+package zapdriver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestStatsOf(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore, WrapCore(WithLogger(client.Logger("app")), SynchronousWrites()))
+
+	child := logger.With(Label("one", "1"))
+	child.Info("hello", Label("two", "2"))
+	logger.Info("world")
+	require.NoError(t, logger.Sync())
+
+	server.setError(errors.New("unavailable"))
+	logger.Info("lost")
+
+	stats := StatsOf(logger)
+	assert.Equal(t, uint64(3), stats.Entries)
+	assert.Equal(t, uint64(2), stats.LabelMerges)
+	assert.Equal(t, uint64(1), stats.Flushes)
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.Contains(t, stats.LastError, "unavailable")
+	assert.False(t, stats.LastErrorTime.IsZero())
+
+	// Child loggers share the stats of their parent.
+	assert.Equal(t, stats, StatsOf(child))
+
+	assert.Equal(t, Stats{}, StatsOf(zap.NewNop()))
+}
+
+func TestStatsOf_ClientErrors(t *testing.T) {
+	addr, server := newFakeServer(t)
+	server.setError(errors.New("unavailable"))
+
+	logger, cleanup, err := NewCloudProduction(context.Background(), "test-project", "app",
+		ClientOptions(WithEmulator(addr)),
+	)
+	require.NoError(t, err)
+	defer cleanup()
+
+	logger.Info("lost")
+	_ = logger.Sync()
+
+	// The client reports errors asynchronously.
+	deadline := time.Now().Add(5 * time.Second)
+	for StatsOf(logger).Errors == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NotZero(t, StatsOf(logger).Errors)
+}
+
+func TestPublishStats(t *testing.T) {
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore, WrapCore())
+	logger.Info("hello")
+
+	PublishStats("zapdriver_test_stats", logger)
+
+	var stats Stats
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("zapdriver_test_stats").String()), &stats))
+	assert.Equal(t, uint64(1), stats.Entries)
+}
@@ -0,0 +1,100 @@
+package zapdriver
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// stdLogLabel is the label added to entries written through the standard
+// library logger.
+const stdLogLabel = "stdlog"
+
+// NewStdLog returns a standard library logger writing every line through the
+// logger at the level, with the `source=stdlog` label, for example for the
+// `ErrorLog` of an `http.Server`. It returns an error for invalid levels.
+func NewStdLog(logger *zap.Logger, level zapcore.Level) (*log.Logger, error) {
+	return zap.NewStdLogAt(stdLogger(logger), level)
+}
+
+// RedirectStdLog redirects the output of the global standard library logger,
+// used by many third-party packages, through the logger at the level, like
+// NewStdLog. It returns a function restoring the original output.
+func RedirectStdLog(logger *zap.Logger, level zapcore.Level) (func(), error) {
+	return zap.RedirectStdLogAt(stdLogger(logger), level)
+}
+
+// NewStdLogWriter returns a writer logging every line written to it through the
+// logger at the level, like NewStdLog, for packages that accept an io.Writer.
+// Every write must hold complete lines. Empty lines are ignored.
+func NewStdLogWriter(logger *zap.Logger, level zapcore.Level) io.Writer {
+	return &stdLogWriter{
+		logger: stdLogger(logger).WithOptions(zap.AddCallerSkip(1)),
+		level:  func(string) zapcore.Level { return level },
+		split:  true,
+	}
+}
+
+// NewHTTPErrorLog returns a standard library logger for the `ErrorLog` of an
+// `http.Server`, writing the errors of the server through the logger, with the
+// `source=http` label:
+//
+//	server := &http.Server{ErrorLog: zapdriver.NewHTTPErrorLog(logger)}
+//
+// Panics of handlers are logged at error level, with their stack trace in the
+// same entry, all other errors, such as failed TLS handshakes, at warn level.
+func NewHTTPErrorLog(logger *zap.Logger) *log.Logger {
+	// The logger is called through `log.Logger.Printf`, which adds two frames.
+	w := &stdLogWriter{
+		logger: logger.With(Label("source", "http")).WithOptions(zap.AddCallerSkip(3)),
+		level:  httpErrorLevel,
+	}
+
+	return log.New(w, "", 0)
+}
+
+// httpErrorLevel returns the level of an error logged by an http.Server.
+func httpErrorLevel(line string) zapcore.Level {
+	if strings.HasPrefix(line, "http: panic serving") {
+		return zapcore.ErrorLevel
+	}
+
+	return zapcore.WarnLevel
+}
+
+func stdLogger(logger *zap.Logger) *zap.Logger {
+	return logger.With(Label("source", stdLogLabel))
+}
+
+// stdLogWriter logs every line written to it, at the level returned by level.
+// Without split, every write is logged as a single entry instead.
+type stdLogWriter struct {
+	logger *zap.Logger
+	level  func(line string) zapcore.Level
+	split  bool
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	lines := [][]byte{bytes.TrimRight(p, "\n")}
+	if w.split {
+		lines = bytes.Split(p, []byte("\n"))
+	}
+
+	for _, line := range lines {
+		line = bytes.TrimRight(line, "\r")
+		if len(line) == 0 {
+			continue
+		}
+
+		msg := string(line)
+		if ce := w.logger.Check(w.level(msg), msg); ce != nil {
+			ce.Write()
+		}
+	}
+
+	return len(p), nil
+}
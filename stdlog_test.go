@@ -0,0 +1,78 @@
+package zapdriver
+
+import (
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewStdLog(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	std, err := NewStdLog(zap.New(&core{Core: debugcore, permLabels: newLabels()}, zap.AddCaller()), zapcore.WarnLevel)
+	require.NoError(t, err)
+
+	std.Printf("connection reset by %s", "peer")
+
+	require.Len(t, logs.All(), 1)
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.WarnLevel, entry.Level)
+	assert.Equal(t, "connection reset by peer", entry.Message)
+	assert.Contains(t, entry.Caller.File, "stdlog_test.go")
+	assert.Equal(t, map[string]interface{}{"source": "stdlog"}, entry.ContextMap()[labelsKey])
+}
+
+func TestNewStdLogWriter(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	w := NewStdLogWriter(zap.New(&core{Core: debugcore, permLabels: newLabels()}, zap.AddCaller()), zapcore.InfoLevel)
+
+	n, err := fmt.Fprint(w, "first\r\n\nsecond\n")
+	require.NoError(t, err)
+	assert.Equal(t, 15, n)
+
+	require.Len(t, logs.All(), 2)
+	assert.Equal(t, "first", logs.All()[0].Message)
+	assert.Equal(t, "second", logs.All()[1].Message)
+	assert.Contains(t, logs.All()[1].Caller.File, "fmt/print.go")
+	assert.Equal(t, map[string]interface{}{"source": "stdlog"}, logs.All()[1].ContextMap()[labelsKey])
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	restore, err := RedirectStdLog(zap.New(&core{Core: debugcore, permLabels: newLabels()}, zap.AddCaller()), zapcore.ErrorLevel)
+	require.NoError(t, err)
+
+	log.Print("from a dependency")
+	restore()
+
+	require.Len(t, logs.All(), 1)
+	assert.Equal(t, zapcore.ErrorLevel, logs.All()[0].Level)
+	assert.Contains(t, logs.All()[0].Caller.File, "stdlog_test.go")
+}
+
+func TestNewHTTPErrorLog(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	std := NewHTTPErrorLog(zap.New(&core{Core: debugcore, permLabels: newLabels()}, zap.AddCaller()))
+
+	std.Printf("http: TLS handshake error from %s: EOF", "10.0.0.1:1234")
+	std.Printf("http: panic serving %s: %v\n%s", "10.0.0.1:1234", "boom", "goroutine 1 [running]:\nmain.handler()")
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Contains(t, entries[0].Caller.File, "stdlog_test.go")
+	assert.Equal(t, map[string]interface{}{"source": "http"}, entries[0].ContextMap()[labelsKey])
+	assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+	assert.Equal(t, "http: panic serving 10.0.0.1:1234: boom\ngoroutine 1 [running]:\nmain.handler()", entries[1].Message)
+}
@@ -0,0 +1,204 @@
+package zapdriver
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	messageTemplateKey = "message_template"
+	argsKey            = "args"
+)
+
+// SugaredLogger wraps a `zap.SugaredLogger`, and adds support for passing
+// groups of fields, such as the ones returned by `LabelPairs` and
+// `TraceContext`, inline with the other key/value pairs:
+//
+//	zapdriver.S(logger).Infow("Order placed.",
+//	  "order_id", id,
+//	  zapdriver.LabelPairs("tenant", tenant, "region", region),
+//	)
+//
+// Its `Printf`-style methods, such as `Infof`, also add the raw template and
+// arguments as the "message_template" and "args" fields, so entries can be
+// grouped by template instead of by rendered message.
+type SugaredLogger struct {
+	*zap.SugaredLogger
+
+	// skipped is the same logger, but skipping the extra stack frame of the
+	// wrapper methods.
+	skipped *zap.SugaredLogger
+
+	// core is the core of the logger, checked before templates are formatted.
+	core zapcore.Core
+}
+
+// S returns a sugared version of the logger.
+func S(logger *zap.Logger) *SugaredLogger {
+	return &SugaredLogger{
+		SugaredLogger: logger.Sugar(),
+		skipped:       logger.WithOptions(zap.AddCallerSkip(1)).Sugar(),
+		core:          logger.Core(),
+	}
+}
+
+// LabelPairs returns a label field for every key/value pair. A trailing key
+// without a value is ignored.
+func LabelPairs(keysAndValues ...string) []zap.Field {
+	fields := make([]zap.Field, 0, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fields = append(fields, Label(keysAndValues[i], keysAndValues[i+1]))
+	}
+
+	return fields
+}
+
+// With adds a variadic number of fields to the logging context.
+func (s *SugaredLogger) With(args ...interface{}) *SugaredLogger {
+	args = flattenFields(args)
+	skipped := s.skipped.With(args...)
+
+	return &SugaredLogger{
+		SugaredLogger: s.SugaredLogger.With(args...),
+		skipped:       skipped,
+		core:          skipped.Desugar().Core(),
+	}
+}
+
+// Debugw logs a message with some additional context.
+func (s *SugaredLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	s.skipped.Debugw(msg, flattenFields(keysAndValues)...)
+}
+
+// Infow logs a message with some additional context.
+func (s *SugaredLogger) Infow(msg string, keysAndValues ...interface{}) {
+	s.skipped.Infow(msg, flattenFields(keysAndValues)...)
+}
+
+// Warnw logs a message with some additional context.
+func (s *SugaredLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	s.skipped.Warnw(msg, flattenFields(keysAndValues)...)
+}
+
+// Errorw logs a message with some additional context.
+func (s *SugaredLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	s.skipped.Errorw(msg, flattenFields(keysAndValues)...)
+}
+
+// DPanicw logs a message with some additional context. In development, the
+// logger then panics.
+func (s *SugaredLogger) DPanicw(msg string, keysAndValues ...interface{}) {
+	s.skipped.DPanicw(msg, flattenFields(keysAndValues)...)
+}
+
+// Panicw logs a message with some additional context, then panics.
+func (s *SugaredLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	s.skipped.Panicw(msg, flattenFields(keysAndValues)...)
+}
+
+// Fatalw logs a message with some additional context, then calls os.Exit.
+func (s *SugaredLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	s.skipped.Fatalw(msg, flattenFields(keysAndValues)...)
+}
+
+// Debugf uses fmt.Sprintf to log a templated message, and adds the template
+// and arguments as fields.
+func (s *SugaredLogger) Debugf(template string, args ...interface{}) {
+	if s.enabled(zapcore.DebugLevel) {
+		s.skipped.Debugw(fmt.Sprintf(template, args...), templateFields(template, args)...)
+	}
+}
+
+// Infof uses fmt.Sprintf to log a templated message, and adds the template
+// and arguments as fields.
+func (s *SugaredLogger) Infof(template string, args ...interface{}) {
+	if s.enabled(zapcore.InfoLevel) {
+		s.skipped.Infow(fmt.Sprintf(template, args...), templateFields(template, args)...)
+	}
+}
+
+// Warnf uses fmt.Sprintf to log a templated message, and adds the template
+// and arguments as fields.
+func (s *SugaredLogger) Warnf(template string, args ...interface{}) {
+	if s.enabled(zapcore.WarnLevel) {
+		s.skipped.Warnw(fmt.Sprintf(template, args...), templateFields(template, args)...)
+	}
+}
+
+// Errorf uses fmt.Sprintf to log a templated message, and adds the template
+// and arguments as fields.
+func (s *SugaredLogger) Errorf(template string, args ...interface{}) {
+	if s.enabled(zapcore.ErrorLevel) {
+		s.skipped.Errorw(fmt.Sprintf(template, args...), templateFields(template, args)...)
+	}
+}
+
+// DPanicf uses fmt.Sprintf to log a templated message, and adds the template
+// and arguments as fields. In development, the logger then panics.
+func (s *SugaredLogger) DPanicf(template string, args ...interface{}) {
+	if s.enabled(zapcore.DPanicLevel) {
+		s.skipped.DPanicw(fmt.Sprintf(template, args...), templateFields(template, args)...)
+	}
+}
+
+// Panicf uses fmt.Sprintf to log a templated message, and adds the template
+// and arguments as fields, then panics.
+func (s *SugaredLogger) Panicf(template string, args ...interface{}) {
+	if s.enabled(zapcore.PanicLevel) {
+		s.skipped.Panicw(fmt.Sprintf(template, args...), templateFields(template, args)...)
+	}
+}
+
+// Fatalf uses fmt.Sprintf to log a templated message, and adds the template
+// and arguments as fields, then calls os.Exit.
+func (s *SugaredLogger) Fatalf(template string, args ...interface{}) {
+	if s.enabled(zapcore.FatalLevel) {
+		s.skipped.Fatalw(fmt.Sprintf(template, args...), templateFields(template, args)...)
+	}
+}
+
+// enabled reports whether entries of the level are logged, so templates of
+// disabled levels aren't formatted. Entries of level DPanic and above are
+// always passed on, as they might panic or exit even when disabled.
+func (s *SugaredLogger) enabled(lvl zapcore.Level) bool {
+	return lvl >= zapcore.DPanicLevel || s.core.Enabled(lvl)
+}
+
+// templateFields returns the key/value pairs holding the template and
+// arguments of a templated message.
+func templateFields(template string, args []interface{}) []interface{} {
+	return []interface{}{messageTemplateKey, template, argsKey, args}
+}
+
+// flattenFields expands all field slices in the arguments into separate
+// fields.
+func flattenFields(args []interface{}) []interface{} {
+	var n, groups int
+	for _, arg := range args {
+		if fields, ok := arg.([]zap.Field); ok {
+			n += len(fields)
+			groups++
+		}
+	}
+
+	if groups == 0 {
+		return args
+	}
+
+	out := make([]interface{}, 0, len(args)-groups+n)
+	for _, arg := range args {
+		fields, ok := arg.([]zap.Field)
+		if !ok {
+			out = append(out, arg)
+			continue
+		}
+
+		for _, f := range fields {
+			out = append(out, f)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,86 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLabelPairs(t *testing.T) {
+	t.Parallel()
+
+	want := []zap.Field{Label("one", "1"), Label("two", "2")}
+
+	assert.Equal(t, want, LabelPairs("one", "1", "two", "2", "dangling"))
+}
+
+func TestSugaredLogger(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+	}, zap.AddCaller())
+
+	S(logger).
+		With(LabelPairs("component", "orders")).
+		Infow("Order placed.", "order_id", 42, LabelPairs("tenant", "acme", "region", "eu"))
+
+	require.Equal(t, 1, logs.Len())
+
+	entry := logs.All()[0]
+	assert.Equal(t, int64(42), entry.ContextMap()["order_id"])
+	assert.Equal(t, map[string]interface{}{
+		"component": "orders",
+		"tenant":    "acme",
+		"region":    "eu",
+	}, entry.ContextMap()[labelsKey])
+	assert.Contains(t, entry.Caller.File, "sugar_test.go")
+}
+
+func TestSugaredLogger_Infof(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+	}, zap.AddCaller())
+
+	S(logger).Infof("user %s placed %d orders", "alice", 3)
+
+	require.Equal(t, 1, logs.Len())
+
+	entry := logs.All()[0]
+	assert.Equal(t, "user alice placed 3 orders", entry.Message)
+	assert.Equal(t, "user %s placed %d orders", entry.ContextMap()[messageTemplateKey])
+	assert.Equal(t, []interface{}{"alice", 3}, entry.ContextMap()[argsKey])
+	assert.Contains(t, entry.Caller.File, "sugar_test.go")
+}
+
+type countingStringer int
+
+func (c *countingStringer) String() string {
+	*c++
+	return "formatted"
+}
+
+func TestSugaredLogger_DisabledLevel(t *testing.T) {
+	infocore, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(&core{
+		Core:       infocore,
+		permLabels: newLabels(),
+	})
+
+	var formatted countingStringer
+	S(logger).Debugf("value %s", &formatted)
+	S(logger).With("component", "orders").Debugf("value %s", &formatted)
+
+	assert.Zero(t, formatted)
+	assert.Zero(t, logs.Len())
+
+	S(logger).Infof("value %s", &formatted)
+	assert.Equal(t, countingStringer(1), formatted)
+	assert.Equal(t, 1, logs.Len())
+}
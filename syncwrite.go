@@ -0,0 +1,37 @@
+package zapdriver
+
+import "time"
+
+// SynchronousWrites sends every entry to Cloud Logging before `Write` returns,
+// instead of buffering it in the client, and returns the error of the API call
+// from `Write`. Use it in Cloud Functions and short-lived jobs, where the
+// instance can be frozen or stopped before buffered entries are flushed.
+//
+// Every entry is a separate API call, so this is slow for chatty services. The
+// client retries unavailable errors until the call times out, so every call is
+// bounded by the write timeout, 30 seconds unless set using `SyncWriteTimeout`.
+// Entries written asynchronously (see `Async`) still don't return errors.
+func SynchronousWrites() func(*core) {
+	return func(c *core) {
+		c.config.SynchronousWrites = true
+	}
+}
+
+// SyncWriteTimeout bounds every synchronous write (see `SynchronousWrites`),
+// and every attempt of `WithRetry`, so a Cloud Logging outage doesn't block the
+// writing goroutine. `Write` returns the error of a call that timed out. Entries
+// buffered by the client are bounded by `WithWriteTimeout` instead.
+func SyncWriteTimeout(timeout time.Duration) func(*core) {
+	return func(c *core) {
+		c.config.SyncWriteTimeout = timeout
+	}
+}
+
+// writeTimeout returns the timeout of synchronous writes.
+func (c *core) writeTimeout() time.Duration {
+	if c.config.SyncWriteTimeout > 0 {
+		return c.config.SyncWriteTimeout
+	}
+
+	return defaultWriteTimeout
+}
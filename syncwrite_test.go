@@ -0,0 +1,72 @@
+package zapdriver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWriteSynchronous(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	SynchronousWrites()(core)
+
+	require.NoError(t, core.Write(zapcore.Entry{Message: "hello"}, nil))
+
+	// The entry was sent without flushing the client.
+	require.Len(t, server.Entries(), 1)
+	assert.Equal(t, "hello", server.Entries()[0].GetJsonPayload().Fields["message"].GetStringValue())
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestWriteSynchronous_Error(t *testing.T) {
+	client, server := newFakeClient(t)
+	server.setError(errors.New("unavailable"))
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	SynchronousWrites()(core)
+
+	err := core.Write(zapcore.Entry{Message: "hello"}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unavailable")
+
+	// The entry is still written to the wrapped core.
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestWriteSynchronous_Timeout(t *testing.T) {
+	client, server := newFakeClient(t)
+	server.setError(status.Error(codes.Unavailable, "unavailable"))
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	c := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	SynchronousWrites()(c)
+	SyncWriteTimeout(200 * time.Millisecond)(c)
+
+	start := time.Now()
+	err := c.Write(zapcore.Entry{Message: "hello"}, nil)
+	require.Error(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second, time.Since(start))
+}
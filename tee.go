@@ -0,0 +1,74 @@
+package zapdriver
+
+import (
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithCloudLevel sends only the entries enabled by `level` to the Cloud Logging
+// API, independently of the level of the local output. Entries enabled by
+// either level are logged, for example to write debug entries to a local
+// console, while only sending info entries and above to the API:
+//
+//	logger := zap.New(
+//	  zapdriver.NewConsoleCore(zapcore.DebugLevel),
+//	  zapdriver.WrapCore(
+//	    zapdriver.WithLogger(client.Logger("app")),
+//	    zapdriver.WithCloudLevel(zapcore.InfoLevel),
+//	  ),
+//	)
+func WithCloudLevel(level zapcore.LevelEnabler) func(*core) {
+	return func(c *core) {
+		c.config.CloudLevel = level
+	}
+}
+
+// NewConsoleCore returns a core writing entries enabled by `level` to standard
+// error in a human-readable format.
+func NewConsoleCore(level zapcore.LevelEnabler) zapcore.Core {
+	config := NewDevelopmentEncoderConfig()
+	config.EncodeLevel = zapcore.CapitalColorLevelEncoder
+
+	return zapcore.NewCore(zapcore.NewConsoleEncoder(config), zapcore.Lock(os.Stderr), level)
+}
+
+// Enabled reports whether entries of the given level are written locally, to
+// the Cloud Logging API, or to a sink. Level overrides matching the labels and
+// fields of the core enable their level too.
+func (c *core) Enabled(level zapcore.Level) bool {
+	if c.override != nil && c.override.Enabled(level) {
+		return true
+	}
+
+	return c.enabled(level)
+}
+
+// enabled is the same as Enabled, without the level overrides.
+func (c *core) enabled(level zapcore.Level) bool {
+	if c.config.Level != nil && !c.config.Level.Enabled(level) {
+		return false
+	}
+
+	if c.Core.Enabled(level) {
+		return true
+	}
+
+	if c.config.CloudLevel != nil && c.config.CloudLevel.Enabled(level) {
+		return true
+	}
+
+	return c.sinksEnabled(level)
+}
+
+// cloudEnabled reports whether an entry of the given level is sent to the Cloud
+// Logging API.
+func (c *core) cloudEnabled(level zapcore.Level) bool {
+	if c.config.CloudLevel == nil {
+		// Sinks can enable levels the local output doesn't, which are not sent
+		// to the primary logger.
+		return len(c.config.Sinks) == 0 || c.Core.Enabled(level)
+	}
+
+	return c.config.CloudLevel.Enabled(level)
+}
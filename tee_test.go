@@ -0,0 +1,57 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithCloudLevel(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore, WrapCore(
+		WithLogger(client.Logger("app")),
+		WithCloudLevel(zapcore.InfoLevel),
+	))
+
+	logger.Debug("local only")
+	logger.Info("both")
+	require.NoError(t, logger.Sync())
+
+	assert.Equal(t, 2, logs.Len())
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "both", entries[0].GetJsonPayload().Fields["message"].GetStringValue())
+}
+
+func TestWithCloudLevel_BelowLocalLevel(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	infocore, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(infocore, WrapCore(
+		WithLogger(client.Logger("app")),
+		WithCloudLevel(zapcore.DebugLevel),
+	))
+
+	logger.Debug("cloud only")
+	logger.Info("both")
+	require.NoError(t, logger.Sync())
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "both", logs.All()[0].Message)
+	assert.Len(t, server.Entries(), 2)
+}
+
+func TestNewConsoleCore(t *testing.T) {
+	t.Parallel()
+
+	c := NewConsoleCore(zapcore.WarnLevel)
+	assert.False(t, c.Enabled(zapcore.InfoLevel))
+	assert.True(t, c.Enabled(zapcore.WarnLevel))
+}
@@ -0,0 +1,188 @@
+package zapdriver
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap"
+)
+
+// tenantLabel is the label identifying the tenant of an entry.
+const tenantLabel = "tenant"
+
+// Tenant adds the label identifying the tenant an entry belongs to, which the
+// tenant options of the core (`WithTenantLabels`, `WithTenantRouting` and
+// `WithTenantQuota`) act on:
+//
+//	logger.With(zapdriver.Tenant(tenantID)).Info("Invoice sent.")
+func Tenant(id string) zap.Field {
+	return Label(tenantLabel, id)
+}
+
+// ContextWithTenant returns a copy of ctx carrying the tenant, which loggers
+// returned by `Logger.Ctx` add as the "tenant" label (see `Tenant`).
+func ContextWithTenant(ctx context.Context, id string) context.Context {
+	return ContextWithLabels(ctx, map[string]string{tenantLabel: id})
+}
+
+// TenantFromContext returns the tenant added to ctx using `ContextWithTenant`.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	id, ok := LabelsFromContext(ctx)[tenantLabel]
+
+	return id, ok && id != ""
+}
+
+// WithTenantLabels adds the labels returned by `labels` for the tenant of every
+// entry with a tenant (see `Tenant`), for example its plan or region. Labels
+// set on the entry with the same key take precedence. `labels` is called for
+// every entry, so it should be fast, and safe for concurrent use.
+func WithTenantLabels(labels func(tenant string) map[string]string) func(*core) {
+	return func(c *core) {
+		c.config.TenantLabels = labels
+	}
+}
+
+// WithTenantRouting writes the entries of every tenant (see `Tenant`) to a log
+// named after it, isolating the logs of tenants from each other:
+//
+//	zapdriver.WithTenantRouting(client, "app-{tenant}", 100)
+//
+// Loggers are created lazily on `client`, using `opts`. Once `maxLogs`
+// distinct logs exist, entries of new tenants are written to the default
+// logger instead, as are entries without a tenant.
+//
+// `WithLogNameRouting` and `WithLoggerNameRouting` take precedence over it, and
+// it takes precedence over `WithFieldRouting`.
+func WithTenantRouting(client *logging.Client, template string, maxLogs int, opts ...logging.LoggerOption) func(*core) {
+	return func(c *core) {
+		c.config.Tenants = &fieldRouter{
+			client:   client,
+			template: template,
+			maxLogs:  maxLogs,
+			opts:     opts,
+			loggers:  map[string]*logging.Logger{},
+		}
+	}
+}
+
+// WithTenantQuota sends at most `maxEntries` entries, and `maxBytes` bytes of
+// entries, every `per` to Cloud Logging for every tenant (see `Tenant`), so a
+// single tenant can't exhaust the Cloud Logging quota or budget. A limit of
+// zero disables it. Entries over the quota are still written to the wrapped
+// core, and counted by `TenantQuotaDrops`.
+func WithTenantQuota(maxEntries int, maxBytes int64, per time.Duration) func(*core) {
+	return func(c *core) {
+		c.config.TenantQuota = &tenantQuota{
+			maxEntries: uint64(maxEntries),
+			maxBytes:   maxBytes,
+			per:        per,
+			usage:      map[string]*tenantUsage{},
+		}
+	}
+}
+
+// TenantQuotaDrops returns the number of entries that weren't sent to Cloud
+// Logging, as their tenant exceeded its quota (see `WithTenantQuota`). It
+// returns 0 if the logger doesn't use the zapdriver core, or has no tenant
+// quota.
+func TenantQuotaDrops(logger *zap.Logger) uint64 {
+	c, ok := logger.Core().(*core)
+	if !ok || c.config.TenantQuota == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&c.config.TenantQuota.dropped)
+}
+
+// tenantQuota counts the entries and bytes sent per tenant, in periods of
+// `per`.
+type tenantQuota struct {
+	// dropped counts all dropped entries. It's accessed atomically, and kept
+	// first for alignment.
+	dropped uint64
+
+	maxEntries uint64
+	maxBytes   int64
+	per        time.Duration
+
+	mutex  sync.Mutex
+	period int64
+	usage  map[string]*tenantUsage
+}
+
+// tenantUsage is the usage of a tenant in the current period.
+type tenantUsage struct {
+	entries uint64
+	bytes   int64
+}
+
+// allow reports whether the entry of the tenant is within its quota, and
+// records its usage if so.
+func (q *tenantQuota) allow(tenant string, ent *logging.Entry) bool {
+	var size int64
+	if q.maxBytes > 0 {
+		size = int64(payloadSize(ent.Payload) + metadataSize(ent))
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	period := int64(0)
+	if q.per > 0 {
+		period = ent.Timestamp.UnixNano() / int64(q.per)
+	}
+	if period != q.period {
+		q.period = period
+		q.usage = map[string]*tenantUsage{}
+	}
+
+	usage, ok := q.usage[tenant]
+	if !ok {
+		usage = &tenantUsage{}
+		q.usage[tenant] = usage
+	}
+
+	if (q.maxEntries > 0 && usage.entries >= q.maxEntries) || (q.maxBytes > 0 && usage.bytes+size > q.maxBytes) {
+		atomic.AddUint64(&q.dropped, 1)
+		return false
+	}
+
+	usage.entries++
+	usage.bytes += size
+
+	return true
+}
+
+// stampsTenantLabels reports whether `stampTenantLabels` adds labels to the
+// labels of an entry.
+func (c *core) stampsTenantLabels(lbls *labels) bool {
+	return c.config.TenantLabels != nil && lbls.store[tenantLabel] != ""
+}
+
+// stampTenantLabels adds the labels of the tenant to the labels of an entry.
+func (c *core) stampTenantLabels(lbls *labels) {
+	if !c.stampsTenantLabels(lbls) {
+		return
+	}
+
+	for k, v := range c.config.TenantLabels(lbls.store[tenantLabel]) {
+		if _, ok := lbls.store[k]; !ok {
+			lbls.store[k] = v
+		}
+	}
+}
+
+// allowTenant reports whether the entry is within the quota of its tenant.
+func (c *core) allowTenant(ent *logging.Entry) bool {
+	tenant := ent.Labels[tenantLabel]
+	if tenant == "" || c.config.TenantQuota.allow(tenant, ent) {
+		return true
+	}
+
+	c.config.Metrics.drop(dropTenantQuota)
+
+	return false
+}
@@ -0,0 +1,106 @@
+package zapdriver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTenantFromContext(t *testing.T) {
+	t.Parallel()
+
+	_, ok := TenantFromContext(context.Background())
+	assert.False(t, ok)
+
+	id, ok := TenantFromContext(ContextWithTenant(context.Background(), "acme"))
+	assert.True(t, ok)
+	assert.Equal(t, "acme", id)
+}
+
+func TestWithTenantLabels(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	c := &core{Core: debugcore, permLabels: newLabels()}
+	WithTenantLabels(func(tenant string) map[string]string {
+		return map[string]string{"plan": "pro-" + tenant, "region": "eu"}
+	})(c)
+
+	logger := NewLogger(zap.New(c), "my-project")
+	tenantLogger := logger.With(Tenant("acme"))
+	tenantLogger.Info("inherited")
+	tenantLogger.Info("explicit", Label("region", "us"))
+	logger.Ctx(ContextWithTenant(context.Background(), "globex")).Info("context")
+	logger.Info("none")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 4)
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "plan": "pro-acme", "region": "eu"}, entries[0].ContextMap()[labelsKey])
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "plan": "pro-acme", "region": "us"}, entries[1].ContextMap()[labelsKey])
+	assert.Equal(t, map[string]interface{}{"tenant": "globex", "plan": "pro-globex", "region": "eu"}, entries[2].ContextMap()[labelsKey])
+	assert.NotContains(t, entries[3].ContextMap(), labelsKey)
+
+	// The inherited labels are shared, and must not be modified.
+	assert.Equal(t, map[string]string{"tenant": "acme"}, tenantLogger.Core().(*core).permLabels.store)
+}
+
+func TestWriteTenantRouting(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	WithTenantRouting(client, "app-{tenant}", 1)(core)
+
+	logger := zap.New(core)
+	logger.With(Tenant("acme")).Info("routed")
+	logger.Info("capped", Tenant("globex"))
+	logger.Info("default")
+	require.NoError(t, logger.Sync())
+
+	logNames := map[string]string{}
+	for _, e := range server.Entries() {
+		logNames[e.GetJsonPayload().Fields["message"].GetStringValue()] = e.LogName
+	}
+
+	assert.Equal(t, map[string]string{
+		"routed":  "projects/test-project/logs/app-acme",
+		"capped":  "projects/test-project/logs/app",
+		"default": "projects/test-project/logs/app",
+	}, logNames)
+}
+
+func TestWriteTenantQuota(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	WithTenantQuota(2, 2000, time.Hour)(core)
+
+	logger := zap.New(core)
+	acme := logger.With(Tenant("acme"))
+	acme.Info("first")
+	acme.Info("second")
+	acme.Info("over entries")
+	logger.Info("large", Tenant("globex"), zap.String("data", strings.Repeat("x", 2000)))
+	logger.Info("small", Tenant("globex"))
+	logger.Info("none")
+	logger.Info("none")
+	logger.Info("none")
+	require.NoError(t, logger.Sync())
+
+	var messages []string
+	for _, e := range server.Entries() {
+		messages = append(messages, e.GetJsonPayload().Fields["message"].GetStringValue())
+	}
+
+	assert.ElementsMatch(t, []string{"first", "second", "small", "none", "none", "none"}, messages)
+	assert.Equal(t, uint64(2), TenantQuotaDrops(logger))
+	assert.Equal(t, 8, logs.Len())
+}
@@ -0,0 +1,64 @@
+package zapdriver
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Timed logs the start of an operation, and returns a function logging its end
+// with the elapsed duration and outcome. Both entries are grouped using the
+// operation field (see `Operation`), with a generated operation ID.
+//
+//	defer zapdriver.Timed(logger, "rebuild-index")()
+//
+// A panic in the operation is logged at error level with outcome "panic", and
+// then continues.
+func Timed(logger *zap.Logger, name string) func() {
+	return timed(logger, name, nil)
+}
+
+// TimedError is the same as Timed, but also reports the error of the operation,
+// through the error it returns.
+//
+//	func rebuild() (err error) {
+//		defer zapdriver.TimedError(logger, "rebuild-index", &err)()
+//		...
+//	}
+func TimedError(logger *zap.Logger, name string, err *error) func() {
+	return timed(logger, name, err)
+}
+
+func timed(logger *zap.Logger, name string, errp *error) func() {
+	id := randomID()
+	start := time.Now()
+
+	// Report the caller of Timed as the source of both entries.
+	logger.WithOptions(zap.AddCallerSkip(2)).Info(name+" started", OperationStart(id, name))
+	logger = logger.WithOptions(zap.AddCallerSkip(1))
+
+	return func() {
+		level, outcome := zapcore.InfoLevel, "success"
+		fields := []zap.Field{OperationEnd(id, name), zap.Duration("elapsed", time.Since(start))}
+
+		r := recover()
+		switch {
+		case r != nil:
+			level, outcome = zapcore.ErrorLevel, "panic"
+			fields = append(fields, zap.Any("panic", r))
+		case errp != nil && *errp != nil:
+			level, outcome = zapcore.ErrorLevel, "error"
+			fields = append(fields, zap.Error(*errp))
+		}
+
+		fields = append(fields, zap.String("outcome", outcome))
+		if ce := logger.Check(level, name+" finished"); ce != nil {
+			ce.Write(fields...)
+		}
+
+		if r != nil {
+			panic(r)
+		}
+	}
+}
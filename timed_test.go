@@ -0,0 +1,87 @@
+package zapdriver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTimed(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore)
+
+	func() {
+		defer Timed(logger, "rebuild-index")()
+	}()
+
+	require.Equal(t, 2, logs.Len())
+
+	start, end := logs.All()[0], logs.All()[1]
+	assert.Equal(t, "rebuild-index started", start.Message)
+	assert.Equal(t, "rebuild-index finished", end.Message)
+	assert.Equal(t, zapcore.InfoLevel, end.Level)
+
+	startOp := start.ContextMap()[operationKey].(map[string]interface{})
+	endOp := end.ContextMap()[operationKey].(map[string]interface{})
+	assert.Equal(t, startOp["id"], endOp["id"])
+	assert.Equal(t, true, startOp["first"])
+	assert.Equal(t, true, endOp["last"])
+
+	assert.Equal(t, "success", end.ContextMap()["outcome"])
+	assert.Contains(t, end.ContextMap(), "elapsed")
+}
+
+func TestTimedError(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore)
+
+	_ = func() (err error) {
+		defer TimedError(logger, "rebuild-index", &err)()
+		return errors.New("boom")
+	}()
+
+	end := logs.All()[1]
+	assert.Equal(t, zapcore.ErrorLevel, end.Level)
+	assert.Equal(t, "error", end.ContextMap()["outcome"])
+	assert.Equal(t, "boom", end.ContextMap()["error"])
+}
+
+func TestTimed_Panic(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		defer Timed(logger, "rebuild-index")()
+		panic("boom")
+	})
+
+	end := logs.All()[1]
+	assert.Equal(t, zapcore.ErrorLevel, end.Level)
+	assert.Equal(t, "panic", end.ContextMap()["outcome"])
+}
+
+func TestTimed_Caller(t *testing.T) {
+	t.Parallel()
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(debugcore, zap.AddCaller())
+
+	func() {
+		defer Timed(logger, "rebuild-index")()
+	}()
+
+	for _, entry := range logs.All() {
+		assert.Contains(t, entry.Caller.File, "timed_test.go")
+	}
+}
@@ -2,10 +2,17 @@ package zapdriver
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// TraceHeader is the HTTP header carrying the trace context of requests served
+// on Google Cloud.
+const TraceHeader = "X-Cloud-Trace-Context"
+
 const (
 	traceKey        = "logging.googleapis.com/trace"
 	spanKey         = "logging.googleapis.com/spanId"
@@ -22,3 +29,131 @@ func TraceContext(trace string, spanId string, sampled bool, projectName string)
 		zap.Bool(traceSampledKey, sampled),
 	}
 }
+
+// TraceContextFromHeader parses the value of an "X-Cloud-Trace-Context" header,
+// formatted as "TRACE_ID/SPAN_ID;o=OPTIONS", into the fields added by
+// `TraceContext`:
+//
+//	logger.Info("Request received.",
+//	  zapdriver.TraceContextFromHeader(r.Header.Get(zapdriver.TraceHeader), "my-project")...,
+//	)
+//
+// The decimal span ID of the header is converted to the hexadecimal form used
+// by Cloud Logging. It returns no fields if the header has no trace ID.
+func TraceContextFromHeader(header, projectName string) []zap.Field {
+	trace, spanId, sampled, ok := parseTraceHeader(header)
+	if !ok {
+		return nil
+	}
+
+	return TraceContext(trace, spanId, sampled, projectName)
+}
+
+// parseTraceHeader parses the value of an "X-Cloud-Trace-Context" header.
+func parseTraceHeader(header string) (trace, spanId string, sampled, ok bool) {
+	header = strings.TrimSpace(header)
+
+	if i := strings.Index(header, ";"); i >= 0 {
+		sampled = strings.TrimSpace(header[i+1:]) == "o=1"
+		header = header[:i]
+	}
+
+	trace = header
+	if i := strings.Index(header, "/"); i >= 0 {
+		trace = header[:i]
+
+		if id, err := strconv.ParseUint(header[i+1:], 10, 64); err == nil && id != 0 {
+			spanId = fmt.Sprintf("%016x", id)
+		}
+	}
+
+	return trace, spanId, sampled, trace != ""
+}
+
+// ProjectID sets the project used to turn bare trace IDs into the trace
+// resource names Cloud Logging expects. When a trace field (see `TraceContext`)
+// holds only the 32 hexadecimal characters of a trace ID, or was built without
+// a project name, the trace of the entry sent to the API becomes
+// "projects/<id>/traces/<trace ID>".
+//
+// `NewCloudProduction` and `NewCloudDevelopment` set it to the project of the
+// client. Without a project, the one of the resource detected by
+// `AutoDetectResource` is used.
+func ProjectID(id string) func(*core) {
+	return func(c *core) {
+		c.config.ProjectID = id
+	}
+}
+
+// traceName returns the resource name of the trace, prefixing bare trace IDs
+// with the project of the core, if known.
+func (c *core) traceName(trace string) string {
+	id := strings.TrimPrefix(trace, "projects//traces/")
+	if !isTraceID(id) {
+		return trace
+	}
+
+	project := c.config.ProjectID
+	if project == "" && c.config.Resource != nil {
+		project = c.config.Resource.Labels["project_id"]
+	}
+	if project == "" {
+		return trace
+	}
+
+	return "projects/" + project + "/traces/" + id
+}
+
+// isTraceID reports whether s is a bare trace ID of 32 hexadecimal characters.
+func isTraceID(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// OmitUnsampledTraces removes the trace, span ID and sampling decision fields
+// (see `TraceContext`) from entries of traces that are not sampled, reducing the
+// size of entries, and keeping them out of the trace-scoped views of the Logs
+// Explorer, which only show sampled traces. Fields without sampling decision
+// are kept.
+func OmitUnsampledTraces() func(*core) {
+	return func(c *core) {
+		c.config.OmitUnsampledTraces = true
+	}
+}
+
+// omitUnsampledTrace returns the fields without the trace fields if they hold
+// an unsampled trace. The original slice is returned otherwise.
+func omitUnsampledTrace(fields []zapcore.Field) []zapcore.Field {
+	unsampled := false
+	for i := range fields {
+		if fields[i].Key == traceSampledKey && fields[i].Type == zapcore.BoolType {
+			unsampled = fields[i].Integer == 0
+		}
+	}
+	if !unsampled {
+		return fields
+	}
+
+	out := make([]zapcore.Field, 0, len(fields))
+	for i := range fields {
+		switch fields[i].Key {
+		case traceKey, spanKey, traceSampledKey:
+			continue
+		}
+
+		out = append(out, fields[i])
+	}
+
+	return out
+}
@@ -4,7 +4,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
 )
 
 func TestTraceContext(t *testing.T) {
@@ -17,3 +21,143 @@ func TestTraceContext(t *testing.T) {
 		zap.Bool(traceSampledKey, true),
 	})
 }
+
+func TestWriteTraceContext(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+
+	logger := zap.New(core)
+	logger.With(TraceContext("abc", "span", false, "my-project")...).Info("inherited")
+	logger.Info("per entry", TraceContext("def", "span2", true, "my-project")...)
+	logger.Info("untraced")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "projects/my-project/traces/abc", entries[0].Trace)
+	assert.Equal(t, "span", entries[0].SpanId)
+	assert.False(t, entries[0].TraceSampled)
+
+	assert.Equal(t, "projects/my-project/traces/def", entries[1].Trace)
+	assert.Equal(t, "span2", entries[1].SpanId)
+	assert.True(t, entries[1].TraceSampled)
+
+	assert.Empty(t, entries[2].Trace)
+}
+
+func TestTraceContextFromHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		header string
+		want   []zap.Field
+	}{
+		"sampled": {
+			"105445aa7843bc8bf206b120001000/123;o=1",
+			TraceContext("105445aa7843bc8bf206b120001000", "000000000000007b", true, "my-project"),
+		},
+		"not sampled": {
+			"105445aa7843bc8bf206b120001000/123;o=0",
+			TraceContext("105445aa7843bc8bf206b120001000", "000000000000007b", false, "my-project"),
+		},
+		"trace only": {
+			"105445aa7843bc8bf206b120001000",
+			TraceContext("105445aa7843bc8bf206b120001000", "", false, "my-project"),
+		},
+		"invalid span": {
+			"105445aa7843bc8bf206b120001000/abc",
+			TraceContext("105445aa7843bc8bf206b120001000", "", false, "my-project"),
+		},
+		"empty":    {"", nil},
+		"no trace": {"/123;o=1", nil},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, TraceContextFromHeader(tt.header, "my-project"))
+		})
+	}
+}
+
+func TestWriteOmitUnsampledTraces(t *testing.T) {
+	t.Parallel()
+
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	OmitUnsampledTraces()(core)
+
+	logger := zap.New(core)
+	logger.With(TraceContext("abc", "span", false, "my-project")...).Info("inherited")
+	logger.Info("per entry", TraceContext("def", "span2", false, "my-project")...)
+	logger.Info("sampled", TraceContext("ghi", "span3", true, "my-project")...)
+	logger.Info("no decision", zap.String(traceKey, "projects/my-project/traces/jkl"))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 4)
+
+	assert.Empty(t, entries[0].Trace)
+	assert.Empty(t, entries[0].SpanId)
+	assert.Empty(t, entries[1].Trace)
+	assert.Equal(t, "projects/my-project/traces/ghi", entries[2].Trace)
+	assert.True(t, entries[2].TraceSampled)
+	assert.Equal(t, "projects/my-project/traces/jkl", entries[3].Trace)
+
+	for _, entry := range logs.All()[:2] {
+		assert.NotContains(t, entry.ContextMap(), traceKey)
+		assert.NotContains(t, entry.ContextMap(), spanKey)
+		assert.NotContains(t, entry.ContextMap(), traceSampledKey)
+	}
+}
+
+func TestWriteTraceContext_ProjectID(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	c := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	ProjectID("my-project")(c)
+
+	trace := "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	logger := zap.New(c)
+	logger.Info("bare", zap.String(traceKey, trace))
+	logger.Info("no project", TraceContext(trace, "span", true, "")...)
+	logger.Info("full", TraceContext(trace, "span", true, "other-project")...)
+	logger.Info("custom", zap.String(traceKey, "abc"))
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 4)
+
+	assert.Equal(t, "projects/my-project/traces/"+trace, entries[0].Trace)
+	assert.Equal(t, "projects/my-project/traces/"+trace, entries[1].Trace)
+	assert.Equal(t, "projects/other-project/traces/"+trace, entries[2].Trace)
+	assert.Equal(t, "abc", entries[3].Trace)
+}
+
+func TestTraceName(t *testing.T) {
+	t.Parallel()
+
+	trace := "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	c := &core{}
+	assert.Equal(t, trace, c.traceName(trace))
+
+	c.config.Resource = &mrpb.MonitoredResource{Type: "gce_instance", Labels: map[string]string{"project_id": "detected"}}
+	assert.Equal(t, "projects/detected/traces/"+trace, c.traceName(trace))
+
+	c.config.ProjectID = "configured"
+	assert.Equal(t, "projects/configured/traces/"+trace, c.traceName(trace))
+	assert.Equal(t, "projects/configured/traces/"+trace, c.traceName("projects//traces/"+trace))
+	assert.Equal(t, "projects//traces/abc", c.traceName("projects//traces/abc"))
+	assert.Equal(t, trace+"0", c.traceName(trace+"0"))
+}
@@ -0,0 +1,92 @@
+package zapdriver
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// SpanContext identifies the span an entry belongs to.
+type SpanContext struct {
+	// TraceID is the hexadecimal trace ID, for example
+	// "4bf92f3577b34da6a3ce929d0e0e4736".
+	TraceID string
+
+	// SpanID is the hexadecimal span ID, for example "00f067aa0ba902b7".
+	SpanID string
+
+	// Sampled reports whether the trace is sampled.
+	Sampled bool
+}
+
+// SpanContextExtractor returns the span context of the active span in ctx, and
+// whether there is one.
+type SpanContextExtractor func(ctx context.Context) (SpanContext, bool)
+
+var spanContextExtractors struct {
+	sync.RWMutex
+	list []SpanContextExtractor
+}
+
+// RegisterSpanContextExtractor makes `TraceFromContext` read the active span
+// using e, which is how tracing libraries are integrated without this package
+// depending on them. For OpenTelemetry:
+//
+//	zapdriver.RegisterSpanContextExtractor(func(ctx context.Context) (zapdriver.SpanContext, bool) {
+//	  sc := trace.SpanContextFromContext(ctx)
+//	  return zapdriver.SpanContext{
+//	    TraceID: sc.TraceID().String(),
+//	    SpanID:  sc.SpanID().String(),
+//	    Sampled: sc.IsSampled(),
+//	  }, sc.IsValid()
+//	})
+//
+// Extractors are tried in the order they were registered.
+func RegisterSpanContextExtractor(e SpanContextExtractor) {
+	spanContextExtractors.Lock()
+	defer spanContextExtractors.Unlock()
+
+	spanContextExtractors.list = append(spanContextExtractors.list, e)
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of ctx carrying sc, for code that
+// propagates the trace context itself, for example from the
+// "X-Cloud-Trace-Context" header.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the active span in ctx,
+// using the registered extractors, or the span context added using
+// `ContextWithSpanContext`.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	spanContextExtractors.RLock()
+	extractors := spanContextExtractors.list
+	spanContextExtractors.RUnlock()
+
+	for _, e := range extractors {
+		if sc, ok := e(ctx); ok && sc.TraceID != "" {
+			return sc, true
+		}
+	}
+
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+
+	return sc, ok && sc.TraceID != ""
+}
+
+// TraceFromContext returns the fields added by `TraceContext` for the active
+// span in ctx, or no fields if there is none:
+//
+//	logger.Info("Order placed.", zapdriver.TraceFromContext(ctx, "my-project")...)
+func TraceFromContext(ctx context.Context, projectName string) []zap.Field {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	return TraceContext(sc.TraceID, sc.SpanID, sc.Sampled, projectName)
+}
@@ -0,0 +1,28 @@
+package zapdriver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSpanKey struct{}
+
+func TestTraceFromContext(t *testing.T) {
+	RegisterSpanContextExtractor(func(ctx context.Context) (SpanContext, bool) {
+		sc, ok := ctx.Value(testSpanKey{}).(SpanContext)
+		return sc, ok
+	})
+
+	manual := SpanContext{TraceID: "abc", SpanID: "0000000000000001", Sampled: true}
+	extracted := SpanContext{TraceID: "def", SpanID: "0000000000000002"}
+
+	ctx := ContextWithSpanContext(context.Background(), manual)
+	assert.Equal(t, TraceContext("abc", "0000000000000001", true, "my-project"), TraceFromContext(ctx, "my-project"))
+
+	ctx = context.WithValue(ctx, testSpanKey{}, extracted)
+	assert.Equal(t, TraceContext("def", "0000000000000002", false, "my-project"), TraceFromContext(ctx, "my-project"))
+
+	assert.Nil(t, TraceFromContext(context.Background(), "my-project"))
+}
@@ -0,0 +1,122 @@
+package zapdriver
+
+import (
+	"sync"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// traceQuotaMessage is the message of the entry marking the suppression of
+	// further entries of a trace.
+	traceQuotaMessage = "zapdriver: further entries of this trace are suppressed"
+
+	// traceQuotaTraces is the number of traces the quota keeps track of, before
+	// forgetting the least recent ones.
+	traceQuotaTraces = 10000
+)
+
+// WithTraceQuota caps the number of entries of a single trace (see
+// `TraceContext`) sent to Cloud Logging at `max`, so one pathological request
+// can't blow through the ingestion budget. Once the quota of a trace is
+// exceeded, a single entry marks the suppression of its further entries.
+// Suppressed entries are still written to the wrapped core.
+func WithTraceQuota(max int) func(*core) {
+	return func(c *core) {
+		c.config.TraceQuota = &traceQuota{
+			max:     max,
+			current: map[string]int{},
+		}
+	}
+}
+
+// traceQuota counts the entries per trace. To bound its memory, it keeps two
+// generations of counts, dropping the older one when the current one is full.
+type traceQuota struct {
+	max int
+
+	mutex    sync.Mutex
+	current  map[string]int
+	previous map[string]int
+}
+
+// count records an entry of the trace, and returns the number of entries of
+// the trace so far.
+func (q *traceQuota) count(trace string) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	n, ok := q.current[trace]
+	if !ok {
+		n = q.previous[trace]
+
+		if len(q.current) >= traceQuotaTraces {
+			q.previous, q.current = q.current, make(map[string]int, traceQuotaTraces)
+		}
+	}
+
+	n++
+	q.current[trace] = n
+
+	return n
+}
+
+// traceOf returns the trace of an entry, if any.
+func traceOf(inherited, fields []zapcore.Field) string {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == traceKey && fields[i].Type == zapcore.StringType {
+			return fields[i].String
+		}
+	}
+
+	for i := len(inherited) - 1; i >= 0; i-- {
+		if inherited[i].Key == traceKey && inherited[i].Type == zapcore.StringType {
+			return inherited[i].String
+		}
+	}
+
+	return ""
+}
+
+// allowTrace reports whether the entry is within the quota of its trace. It
+// sends the suppression marker, like any other entry of the zap logger, when
+// the entry is the first to exceed it.
+func (c *core) allowTrace(ent zapcore.Entry, glog *logging.Entry, fields []zapcore.Field) bool {
+	q := c.config.TraceQuota
+
+	trace := traceOf(c.fields, fields)
+	if trace == "" {
+		return true
+	}
+
+	n := q.count(trace)
+	if n <= q.max {
+		return true
+	}
+
+	if n == q.max+1 {
+		marker := logging.Entry{
+			Timestamp:    glog.Timestamp,
+			Severity:     logging.Notice,
+			Labels:       map[string]string{},
+			Trace:        glog.Trace,
+			SpanID:       glog.SpanID,
+			TraceSampled: glog.TraceSampled,
+			Resource:     glog.Resource,
+			Payload: map[string]interface{}{
+				c.messageKey():     traceQuotaMessage,
+				"suppressed_after": q.max,
+			},
+		}
+		for k, v := range glog.Labels {
+			marker.Labels[k] = v
+		}
+
+		ent.Level = noticeLevel
+		ent.Message = traceQuotaMessage
+		c.handleError(c.send(ent, &marker, fields))
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTraceQuota_Generations(t *testing.T) {
+	t.Parallel()
+
+	q := &traceQuota{max: 1, current: map[string]int{}}
+
+	assert.Equal(t, 1, q.count("first"))
+	for i := 0; i < traceQuotaTraces; i++ {
+		q.count(string(rune(i + 1000)))
+	}
+
+	assert.Equal(t, 2, q.count("first"), "the previous generation is forgotten too soon")
+}
+
+func TestWriteTraceQuota(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	WithTraceQuota(2)(core)
+
+	logger := zap.New(core)
+	request := logger.With(TraceContext("abc", "span", true, "project")...)
+	for i := 0; i < 5; i++ {
+		request.Info("hello")
+	}
+	logger.Info("untraced")
+	require.NoError(t, logger.Sync())
+
+	assert.Equal(t, 6, logs.Len())
+
+	entries := server.Entries()
+	require.Len(t, entries, 4)
+	assert.Equal(t, traceQuotaMessage, entries[2].GetJsonPayload().Fields["message"].GetStringValue())
+	assert.Equal(t, "projects/project/traces/abc", entries[2].Trace)
+	assert.Equal(t, "span", entries[2].SpanId)
+	assert.Equal(t, "untraced", entries[3].GetJsonPayload().Fields["message"].GetStringValue())
+}
+
+func TestWriteTraceQuota_DryRun(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, _ := observer.New(zapcore.DebugLevel)
+	c := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	WithTraceQuota(1)(c)
+	DryRun(func(error) {})(c)
+
+	hooked := 0
+	WithBeforeWrite(func(*logging.Entry, zapcore.Entry) error {
+		hooked++
+		return nil
+	})(c)
+
+	logger := zap.New(c).With(TraceContext("abc", "span", true, "project")...)
+	for i := 0; i < 3; i++ {
+		logger.Info("hello")
+	}
+	require.NoError(t, logger.Sync())
+
+	assert.Empty(t, server.Entries())
+	assert.Equal(t, 2, hooked, "the marker skipped the hooks")
+}
@@ -0,0 +1,243 @@
+package zapdriver
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Names of the built-in stages of the transformer pipeline.
+const (
+	LabelsStage         = "labels"
+	SourceLocationStage = "sourceLocation"
+	ServiceContextStage = "serviceContext"
+	ErrorReportStage    = "errorReport"
+)
+
+// EntryTransformer is a stage of the pipeline decorating the entries of the
+// core, before they're written (see `WithTransformers`).
+type EntryTransformer struct {
+	// Name identifies the stage, so it can be removed, or other stages can be
+	// inserted around it.
+	Name string
+
+	// Transform modifies the entry.
+	Transform func(*PendingEntry)
+}
+
+// PendingEntry is an entry going through the transformer pipeline.
+type PendingEntry struct {
+	// Entry is the zap entry.
+	Entry zapcore.Entry
+
+	// Fields are written to Cloud Logging, and to the wrapped core.
+	Fields []zapcore.Field
+
+	// LocalFields are only written to the wrapped core. The built-in stages add
+	// the special fields read by the Logging agent here, as Cloud Logging
+	// entries hold their labels and source location natively.
+	LocalFields []zapcore.Field
+
+	core   *core
+	labels *labels
+}
+
+// HasField reports whether the entry has a field with the key, in its fields or
+// local fields.
+func (e *PendingEntry) HasField(key string) bool {
+	for _, set := range [][]zapcore.Field{e.Fields, e.LocalFields} {
+		for i := range set {
+			if set[i].Key == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Label returns the value of a label of the entry, and whether it's set.
+func (e *PendingEntry) Label(key string) (string, bool) {
+	v, ok := e.labels.store[key]
+	return v, ok
+}
+
+// SetLabel sets a label of the entry.
+func (e *PendingEntry) SetLabel(key, value string) {
+	e.ownLabels()
+	e.labels.store[key] = value
+}
+
+// DeleteLabel removes a label from the entry.
+func (e *PendingEntry) DeleteLabel(key string) {
+	if _, ok := e.labels.store[key]; !ok {
+		return
+	}
+
+	e.ownLabels()
+	delete(e.labels.store, key)
+}
+
+// ownLabels copies the labels of the entry, if they're shared with the core.
+func (e *PendingEntry) ownLabels() {
+	if e.labels == e.core.permLabels || e.labels == e.core.config.CommonLabels {
+		e.labels = e.labels.clone()
+	}
+}
+
+// Transformers is a pipeline of entry transformers.
+type Transformers []EntryTransformer
+
+// DefaultTransformers returns the built-in pipeline, which adds the special
+// fields read by the Logging agent to the local fields of the entry, in order:
+//
+//	"labels"          the labels of the entry
+//	"sourceLocation"  the source location, see `SourceLocation`
+//	"serviceContext"  the service context, if `ServiceName` is set
+//	"errorReport"     the error report, if `ReportAllErrors` is set
+//
+// Fields set by the caller are never overwritten.
+func DefaultTransformers() Transformers {
+	return Transformers{
+		{Name: LabelsStage, Transform: transformLabels},
+		{Name: SourceLocationStage, Transform: transformSourceLocation},
+		{Name: ServiceContextStage, Transform: transformServiceContext},
+		{Name: ErrorReportStage, Transform: transformErrorReport},
+	}
+}
+
+var defaultTransformers = DefaultTransformers()
+
+// Without returns the pipeline without the named stages.
+func (t Transformers) Without(names ...string) Transformers {
+	out := make(Transformers, 0, len(t))
+stages:
+	for _, stage := range t {
+		for _, name := range names {
+			if stage.Name == name {
+				continue stages
+			}
+		}
+		out = append(out, stage)
+	}
+
+	return out
+}
+
+// Before returns the pipeline with the stages inserted before the named one, or
+// appended if there's no such stage.
+func (t Transformers) Before(name string, stages ...EntryTransformer) Transformers {
+	return t.insert(t.index(name), stages)
+}
+
+// After returns the pipeline with the stages inserted after the named one, or
+// appended if there's no such stage.
+func (t Transformers) After(name string, stages ...EntryTransformer) Transformers {
+	i := t.index(name)
+	if i < len(t) {
+		i++
+	}
+
+	return t.insert(i, stages)
+}
+
+// index returns the index of the named stage, or the length of the pipeline.
+func (t Transformers) index(name string) int {
+	for i, stage := range t {
+		if stage.Name == name {
+			return i
+		}
+	}
+
+	return len(t)
+}
+
+func (t Transformers) insert(i int, stages []EntryTransformer) Transformers {
+	out := make(Transformers, 0, len(t)+len(stages))
+	out = append(out, t[:i]...)
+	out = append(out, stages...)
+
+	return append(out, t[i:]...)
+}
+
+// WithTransformers replaces the pipeline decorating the entries of the core,
+// which is `DefaultTransformers()` by default. Stages can be removed, reordered,
+// or added, for example to tag every entry with a data classification:
+//
+//	zapdriver.WithTransformers(zapdriver.DefaultTransformers().Before(zapdriver.LabelsStage,
+//	  zapdriver.EntryTransformer{Name: "classification", Transform: func(e *zapdriver.PendingEntry) {
+//	    e.SetLabel("classification", classify(e.Entry.LoggerName))
+//	  }},
+//	)...)
+//
+// The pipeline runs after the labels of the entry are merged with the inherited
+// ones, and before the entry is written to Cloud Logging and to the wrapped
+// core. Stages changing labels must run before the "labels" stage to affect the
+// local output.
+func WithTransformers(transformers ...EntryTransformer) func(*core) {
+	return func(c *core) {
+		c.config.Transformers = append(Transformers{}, transformers...)
+	}
+}
+
+// pendingPool holds the entries going through the pipeline, so the pipeline
+// doesn't allocate per entry.
+var pendingPool = sync.Pool{
+	New: func() interface{} { return &PendingEntry{} },
+}
+
+// releasePending returns an entry to the pool, keeping the array of its local
+// fields. Its local fields must have been copied.
+func releasePending(e *PendingEntry) {
+	local := e.LocalFields
+	for i := range local {
+		local[i] = zapcore.Field{}
+	}
+	*e = PendingEntry{LocalFields: local[:0]}
+	pendingPool.Put(e)
+}
+
+// transform runs the pipeline of the core.
+func (c *core) transform(e *PendingEntry) {
+	pipeline := c.config.Transformers
+	if pipeline == nil {
+		pipeline = defaultTransformers
+	}
+
+	for _, stage := range pipeline {
+		stage.Transform(e)
+	}
+}
+
+func transformLabels(e *PendingEntry) {
+	if len(e.labels.store) > 0 && !e.HasField(labelsKey) {
+		e.LocalFields = append(e.LocalFields, labelsField(e.labels))
+	}
+}
+
+func transformSourceLocation(e *PendingEntry) {
+	if !e.HasField(sourceKey) {
+		e.LocalFields = e.core.withSourceLocation(e.Entry, e.LocalFields)
+	}
+}
+
+func transformServiceContext(e *PendingEntry) {
+	if name := e.core.config.ServiceName; name != "" && !e.HasField(serviceContextKey) {
+		e.LocalFields = e.core.withServiceContext(name, e.LocalFields)
+	}
+}
+
+func transformErrorReport(e *PendingEntry) {
+	if !e.core.config.ReportAllErrors || !zapcore.ErrorLevel.Enabled(e.Entry.Level) {
+		return
+	}
+
+	if !e.HasField(contextKey) {
+		e.LocalFields = e.core.withErrorReport(e.Entry, e.LocalFields)
+	}
+	if e.core.config.ServiceName == "" && !e.HasField(serviceContextKey) {
+		// A service name was not set but error report needs it
+		// So attempt to add a generic service name
+		e.LocalFields = e.core.withServiceContext("unknown", e.LocalFields)
+	}
+}
@@ -0,0 +1,77 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func stageNames(t Transformers) []string {
+	var names []string
+	for _, stage := range t {
+		names = append(names, stage.Name)
+	}
+
+	return names
+}
+
+func TestTransformers(t *testing.T) {
+	t.Parallel()
+
+	custom := EntryTransformer{Name: "custom", Transform: func(*PendingEntry) {}}
+	pipeline := DefaultTransformers()
+
+	assert.Equal(t, []string{"labels", "sourceLocation", "serviceContext", "errorReport"}, stageNames(pipeline))
+	assert.Equal(t, []string{"labels", "errorReport"}, stageNames(pipeline.Without(SourceLocationStage, ServiceContextStage)))
+	assert.Equal(t, []string{"custom", "labels", "sourceLocation", "serviceContext", "errorReport"}, stageNames(pipeline.Before(LabelsStage, custom)))
+	assert.Equal(t, []string{"labels", "sourceLocation", "serviceContext", "errorReport", "custom"}, stageNames(pipeline.After(ErrorReportStage, custom)))
+	assert.Equal(t, []string{"labels", "sourceLocation", "serviceContext", "errorReport", "custom"}, stageNames(pipeline.Before("unknown", custom)))
+
+	// The original pipeline is left untouched.
+	assert.Len(t, pipeline, 4)
+}
+
+func TestWithTransformers(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{
+		Core:       debugcore,
+		lg:         client.Logger("app"),
+		permLabels: newLabels(),
+	}
+	ServiceName("orders")(core)
+	WithTransformers(DefaultTransformers().Without(ServiceContextStage).Before(LabelsStage,
+		EntryTransformer{Name: "classification", Transform: func(e *PendingEntry) {
+			if _, ok := e.Label("user"); ok {
+				e.SetLabel("classification", "personal")
+			}
+			e.DeleteLabel("internal")
+			e.Fields = append(e.Fields, zap.String("stage", "ran"))
+		}},
+	)...)(core)
+
+	logger := zap.New(core).With(Label("internal", "1"))
+	logger.Info("hello", Label("user", "42"))
+	logger.Info("world")
+	require.NoError(t, logger.Sync())
+
+	entries := server.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, map[string]string{"user": "42", "classification": "personal"}, entries[0].Labels)
+	assert.Empty(t, entries[1].Labels)
+	assert.Equal(t, "ran", entries[0].GetJsonPayload().Fields["stage"].GetStringValue())
+
+	local := logs.AllUntimed()
+	require.Len(t, local, 2)
+	assert.Equal(t, "ran", local[0].ContextMap()["stage"])
+	assert.Equal(t, map[string]interface{}{"user": "42", "classification": "personal"}, local[0].ContextMap()[labelsKey])
+	assert.NotContains(t, local[0].ContextMap(), serviceContextKey)
+
+	// The permanent labels of the logger are left untouched.
+	assert.Equal(t, map[string]string{"internal": "1"}, InheritedLabels(logger))
+}
@@ -0,0 +1,107 @@
+package zapdriver
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	truncatedKey      = "truncated"
+	truncatedSizesKey = "truncated_sizes"
+)
+
+// WithFieldSizeLimit truncates the message, and string fields of an entry
+// longer than `maxBytes` bytes. Truncated entries get a "truncated" field set
+// to true, and a "truncated_sizes" field holding the original size in bytes of
+// every trimmed key, so readers know data was cut, and how much.
+func WithFieldSizeLimit(maxBytes int) func(*core) {
+	return func(c *core) {
+		c.config.FieldSizeLimit = maxBytes
+	}
+}
+
+// truncation records the keys trimmed from an entry.
+type truncation map[string]int
+
+// add records that the value of the key was trimmed from its original size.
+func (t truncation) add(key string, size int) {
+	t[key] = size
+}
+
+// fields returns the fields describing the truncation, if any.
+func (t truncation) fields() []zapcore.Field {
+	if len(t) == 0 {
+		return nil
+	}
+
+	return []zapcore.Field{zap.Bool(truncatedKey, true), zap.Object(truncatedSizesKey, t)}
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaller interface.
+func (t truncation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		enc.AddInt(k, t[k])
+	}
+
+	return nil
+}
+
+// truncateFields truncates the message and string fields longer than `max`
+// bytes. The original slice is returned if nothing was truncated.
+func truncateFields(ent *zapcore.Entry, fields []zapcore.Field, max int, messageKey string) []zapcore.Field {
+	t := truncation{}
+
+	if len(ent.Message) > max {
+		t.add(messageKey, len(ent.Message))
+		ent.Message = truncateString(ent.Message, max)
+	}
+
+	var out []zapcore.Field
+	for i := range fields {
+		if fields[i].Type != zapcore.StringType || len(fields[i].String) <= max {
+			continue
+		}
+
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+
+		t.add(fields[i].Key, len(fields[i].String))
+		out[i].String = truncateString(fields[i].String, max)
+	}
+
+	if out == nil {
+		out = fields
+	}
+
+	return append(out, t.fields()...)
+}
+
+// truncateString cuts the string to at most `max` bytes, without splitting a
+// multi-byte character.
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	s = s[:max]
+	for len(s) > 0 {
+		r, size := utf8.DecodeLastRuneInString(s)
+		if r != utf8.RuneError || size != 1 {
+			break
+		}
+		s = s[:len(s)-1]
+	}
+
+	return s
+}
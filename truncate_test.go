@@ -0,0 +1,63 @@
+package zapdriver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTruncateString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "hello", truncateString("hello", 10))
+	assert.Equal(t, "hel", truncateString("hello", 3))
+	assert.Equal(t, "h", truncateString("hé", 2))
+	assert.Equal(t, "hé", truncateString("hé", 3))
+}
+
+func TestTruncateFields(t *testing.T) {
+	t.Parallel()
+
+	ent := zapcore.Entry{Message: "short"}
+	fields := []zapcore.Field{zap.String("body", strings.Repeat("a", 20)), zap.Int("n", 1)}
+
+	out := truncateFields(&ent, fields, 8, "message")
+	require.Len(t, out, 4)
+	assert.Equal(t, zap.String("body", "aaaaaaaa"), out[0])
+	assert.Equal(t, zap.Bool(truncatedKey, true), out[2])
+	assert.Equal(t, truncation{"body": 20}, out[3].Interface)
+	assert.Len(t, fields[0].String, 20, "fields of the caller are modified")
+
+	fields = []zapcore.Field{zap.String("body", "fine")}
+	assert.Equal(t, fields, truncateFields(&ent, fields, 8, "message"))
+}
+
+func TestWriteFieldSizeLimit(t *testing.T) {
+	client, server := newFakeClient(t)
+
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := &core{Core: debugcore, lg: client.Logger("app"), permLabels: newLabels()}
+	WithFieldSizeLimit(4)(core)
+
+	logger := zap.New(core)
+	logger.Info("hello world", zap.String("body", "abcdefgh"))
+	require.NoError(t, logger.Sync())
+
+	entry := logs.All()[0]
+	assert.Equal(t, "hell", entry.Message)
+	assert.Equal(t, "abcd", entry.ContextMap()["body"])
+	assert.Equal(t, true, entry.ContextMap()[truncatedKey])
+	assert.Equal(t, map[string]interface{}{"body": 8, "message": 11}, entry.ContextMap()[truncatedSizesKey])
+
+	entries := server.Entries()
+	require.Len(t, entries, 1)
+
+	payload := entries[0].GetJsonPayload().Fields
+	assert.Equal(t, "hell", payload["message"].GetStringValue())
+	assert.Equal(t, float64(8), payload[truncatedSizesKey].GetStructValue().Fields["body"].GetNumberValue())
+}
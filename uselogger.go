@@ -0,0 +1,34 @@
+package zapdriver
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// UseLogger returns a `zap.Option` making a child logger send its entries to
+// another Cloud Logging logger than its parent, for example to write the logs
+// of a subsystem to a log of its own:
+//
+//	audit := logger.WithOptions(zapdriver.UseLogger(client.Logger("audit")))
+//
+// The child keeps the fields, labels and options of its parent. Destinations
+// and routing options still take precedence over the logger, like they do over
+// the one set using `WithLogger`. Syncing the child flushes the logger, syncing
+// the parent doesn't.
+//
+// The option does nothing for loggers that don't use the zapdriver core.
+func UseLogger(logger EntryLogger) zap.Option {
+	logger = nilLogger(logger)
+
+	return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		zc, ok := c.(*core)
+		if !ok {
+			return c
+		}
+
+		clone := *zc
+		clone.lg = logger
+
+		return &clone
+	})
+}
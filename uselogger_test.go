@@ -0,0 +1,52 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestUseLogger(t *testing.T) {
+	t.Parallel()
+
+	parentLg, childLg := &recordingLogger{}, &recordingLogger{}
+
+	observed, logs := observer.New(zapcore.DebugLevel)
+	parent := zap.New(observed, WrapCore(WithLogger(parentLg), ServiceName("service"))).
+		With(Label("team", "core"), zap.String("user", "alice"))
+	child := parent.WithOptions(UseLogger(childLg)).With(Label("part", "audit"))
+
+	parent.Info("parent")
+	child.Info("child")
+	require.NoError(t, child.Sync())
+
+	require.Len(t, parentLg.entries, 1)
+	assert.Equal(t, map[string]string{"team": "core"}, parentLg.entries[0].Labels)
+
+	require.Len(t, childLg.entries, 1)
+	e := childLg.entries[0]
+	assert.Equal(t, map[string]string{"team": "core", "part": "audit"}, e.Labels)
+	assert.Equal(t, "alice", e.Payload.(map[string]interface{})["user"])
+	assert.Equal(t, "child", e.Payload.(map[string]interface{})["message"])
+	assert.Equal(t, 1, childLg.flushes)
+	assert.Equal(t, 0, parentLg.flushes)
+
+	require.Len(t, logs.All(), 2)
+	assert.Equal(t, "alice", logs.All()[1].ContextMap()["user"])
+}
+
+func TestUseLogger_OtherCore(t *testing.T) {
+	t.Parallel()
+
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed).WithOptions(UseLogger(&recordingLogger{}))
+
+	logger.Info("hello")
+
+	assert.Len(t, logs.All(), 1)
+	assert.Equal(t, observed, logger.Core())
+}
@@ -0,0 +1,41 @@
+package zapdriver
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// UTC normalizes the timestamp of every entry, and all time fields, to UTC
+// regardless of the timezone of the host, so fleets spanning multiple regions
+// produce consistent timestamps.
+func UTC() func(*core) {
+	return func(c *core) {
+		c.config.UTC = true
+	}
+}
+
+// utcFields returns the fields with all time fields converted to UTC. The
+// original slice is returned if there are no time fields.
+func utcFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i := range fields {
+		if fields[i].Type != zapcore.TimeType {
+			continue
+		}
+
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+
+		// Time fields store the nanoseconds since the epoch, and their location.
+		out[i].Interface = time.UTC
+	}
+
+	if out == nil {
+		return fields
+	}
+
+	return out
+}
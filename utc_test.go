@@ -0,0 +1,47 @@
+package zapdriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestUTCFields(t *testing.T) {
+	t.Parallel()
+
+	amsterdam := time.FixedZone("CET", 3600)
+	at := time.Date(2020, 2, 22, 13, 0, 0, 0, amsterdam)
+
+	fields := utcFields([]zapcore.Field{zap.String("hello", "world"), zap.Time("at", at)})
+
+	assert.Equal(t, zap.String("hello", "world"), fields[0])
+	assert.Equal(t, time.UTC, ToInterface(fields[1]).(time.Time).Location())
+	assert.True(t, at.Equal(ToInterface(fields[1]).(time.Time)))
+}
+
+func TestWriteUTC(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	core := zapcore.Core(&core{
+		Core:       debugcore,
+		permLabels: newLabels(),
+		config: driverConfig{
+			UTC: true,
+		},
+	})
+
+	at := time.Date(2020, 2, 22, 13, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	core = core.With([]zapcore.Field{zap.Time("started", at)})
+	err := core.Write(zapcore.Entry{Time: at}, []zapcore.Field{zap.Time("at", at)})
+	require.NoError(t, err)
+
+	entry := logs.All()[0]
+	assert.Equal(t, time.UTC, entry.Time.Location())
+	assert.Equal(t, time.UTC, entry.ContextMap()["started"].(time.Time).Location())
+	assert.Equal(t, time.UTC, entry.ContextMap()["at"].(time.Time).Location())
+}
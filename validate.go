@@ -0,0 +1,242 @@
+package zapdriver
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+// Validate applies the zapdriver core options to an empty core, and returns
+// all problems found in the resulting configuration as a single error. The
+// logger constructors of this package validate their core automatically.
+//
+// The empty core has no local output, so options that need somewhere to send
+// entries, like `ReportAllErrors`, also need `WithLogger` or
+// `WithErrorReporting` to pass.
+func Validate(options ...func(*core)) error {
+	c := &core{permLabels: newLabels()}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c.validate()
+}
+
+// validate returns an error describing every contradictory or invalid option
+// of the core.
+func (c *core) validate() error {
+	var err error
+
+	for i, f := range c.config.Failovers {
+		switch {
+		case f == nil:
+			err = multierr.Append(err, fmt.Errorf("zapdriver: failover %d is nil", i))
+		case f.secondary == nil:
+			err = multierr.Append(err, fmt.Errorf("zapdriver: failover %d has no secondary logger", i))
+		case f.secondary == c.lg:
+			err = multierr.Append(err, fmt.Errorf("zapdriver: failover %d uses the primary logger as secondary logger", i))
+		}
+	}
+
+	if s := c.config.LogNameSampling; s != nil {
+		for name, rate := range s.rates {
+			if rate < 0 || rate > 1 {
+				err = multierr.Append(err, fmt.Errorf("zapdriver: sampling rate %v of logger %q is not between 0 and 1", rate, name))
+			}
+		}
+	}
+
+	if r := c.config.LogNames; r != nil && r.client == nil {
+		err = multierr.Append(err, errors.New("zapdriver: log name routing has no client"))
+	}
+
+	if r := c.config.Router; r != nil {
+		if r.client == nil {
+			err = multierr.Append(err, errors.New("zapdriver: field routing has no client"))
+		}
+		if r.key == "" {
+			err = multierr.Append(err, errors.New("zapdriver: field routing has no key"))
+		}
+		if isLabelKey(r.key) {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: field routing key %q collides with the label prefix", r.key))
+		}
+		if !hasPlaceholder(r.template) {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: field routing template %q does not contain {value} or {tenant}", r.template))
+		}
+		if r.maxLogs < 1 {
+			err = multierr.Append(err, errors.New("zapdriver: field routing allows no logs"))
+		}
+	}
+
+	if r := c.config.LoggerNames; r != nil {
+		if r.client == nil {
+			err = multierr.Append(err, errors.New("zapdriver: logger name routing has no client"))
+		}
+		if !hasPlaceholder(r.template) {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: logger name routing template %q does not contain {value}", r.template))
+		}
+		if r.maxLogs < 1 {
+			err = multierr.Append(err, errors.New("zapdriver: logger name routing allows no logs"))
+		}
+	}
+
+	if r := c.config.Tenants; r != nil {
+		if r.client == nil {
+			err = multierr.Append(err, errors.New("zapdriver: tenant routing has no client"))
+		}
+		if !hasPlaceholder(r.template) {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: tenant routing template %q does not contain {value} or {tenant}", r.template))
+		}
+		if r.maxLogs < 1 {
+			err = multierr.Append(err, errors.New("zapdriver: tenant routing allows no logs"))
+		}
+	}
+
+	if q := c.config.TenantQuota; q != nil && q.per <= 0 {
+		err = multierr.Append(err, fmt.Errorf("zapdriver: tenant quota period %v is not positive", q.per))
+	}
+
+	for _, o := range c.config.LevelOverrides {
+		if o.invalid || o.key == "" {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: level override %q is not of the form key=value", o.key))
+		}
+	}
+
+	if f := c.config.Flusher; f != nil && f.interval <= 0 {
+		err = multierr.Append(err, fmt.Errorf("zapdriver: flush interval %v is not positive", f.interval))
+	}
+
+	if g := c.config.Cardinality; g != nil {
+		if g.limit < 1 {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: label cardinality limit %d is not positive", g.limit))
+		}
+		if g.action != CardinalityWarn && g.action != CardinalityDemote {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: unknown cardinality action %d", g.action))
+		}
+	}
+
+	if a := c.config.Async; a != nil {
+		if a.workers < 1 {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: async writer has %d workers", a.workers))
+		}
+		if a.batchSize < 1 {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: async batch size %d is not positive", a.batchSize))
+		}
+	}
+
+	for i, w := range c.config.Watermarks {
+		switch {
+		case c.config.Async == nil:
+			err = multierr.Append(err, fmt.Errorf("zapdriver: queue watermark %d requires Async", i))
+		case w.threshold <= 0 || w.threshold > 1:
+			err = multierr.Append(err, fmt.Errorf("zapdriver: queue watermark %d has threshold %v outside (0, 1]", i, w.threshold))
+		case w.callback == nil:
+			err = multierr.Append(err, fmt.Errorf("zapdriver: queue watermark %d has no callback", i))
+		}
+	}
+
+	if c.config.ReportAllErrors && c.lg == nil && c.config.ErrorReporting == nil && (c.Core == nil || !c.Core.Enabled(zapcore.ErrorLevel)) {
+		err = multierr.Append(err, errors.New("zapdriver: ReportAllErrors has no Cloud Logging client, Error Reporting client or local output to report errors to"))
+	}
+
+	if r := c.config.Resource; r != nil {
+		err = multierr.Append(err, validateResource(r))
+	}
+
+	if k := c.config.MessageKey; collidesWithLabels(k) {
+		err = multierr.Append(err, fmt.Errorf("zapdriver: message key %q collides with the label prefix", k))
+	}
+	if k := c.config.PayloadNamespace; collidesWithLabels(k) {
+		err = multierr.Append(err, fmt.Errorf("zapdriver: payload namespace %q collides with the label prefix", k))
+	}
+	if l := c.config.CommonLabels; l != nil {
+		for k := range l.store {
+			if collidesWithLabels(k) {
+				err = multierr.Append(err, fmt.Errorf("zapdriver: common label %q collides with the label prefix", k))
+			}
+		}
+	}
+
+	if c.config.FieldSizeLimit < 0 {
+		err = multierr.Append(err, fmt.Errorf("zapdriver: field size limit %d is negative", c.config.FieldSizeLimit))
+	}
+
+	if q := c.config.TraceQuota; q != nil && q.max < 1 {
+		err = multierr.Append(err, fmt.Errorf("zapdriver: trace quota %d is not positive", q.max))
+	}
+
+	switch c.config.LabelPrecedence {
+	case EntryLabelsWin, InheritedLabelsWin:
+	default:
+		err = multierr.Append(err, fmt.Errorf("zapdriver: unknown label precedence %d", c.config.LabelPrecedence))
+	}
+
+	return err
+}
+
+// resourceLabelKeys are the labels of the monitored resource types detected by
+// `AutoDetectResource`. The API rejects entries with other labels.
+var resourceLabelKeys = map[string][]string{
+	"global":             {"project_id"},
+	"gce_instance":       {"project_id", "instance_id", "zone"},
+	"k8s_container":      {"project_id", "location", "cluster_name", "namespace_name", "pod_name", "container_name"},
+	"cloud_run_revision": {"project_id", "service_name", "revision_name", "configuration_name", "location"},
+	"gae_app":            {"project_id", "module_id", "version_id", "zone"},
+	"cloud_function":     {"project_id", "function_name", "region"},
+}
+
+// validateResource returns an error for every problem of the monitored
+// resource the API would reject.
+func validateResource(r *mrpb.MonitoredResource) error {
+	if r.Type == "" {
+		return errors.New("zapdriver: monitored resource has no type")
+	}
+
+	var err error
+	for key := range r.Labels {
+		if key == "" {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: monitored resource %q has a label without key", r.Type))
+			continue
+		}
+
+		keys, ok := resourceLabelKeys[r.Type]
+		if !ok {
+			continue
+		}
+		known := false
+		for _, k := range keys {
+			known = known || k == key
+		}
+		if !known {
+			err = multierr.Append(err, fmt.Errorf("zapdriver: monitored resource %q has no label %q", r.Type, key))
+		}
+	}
+
+	return err
+}
+
+// collidesWithLabels reports whether a key is taken for a label, or for the
+// labels of the entry.
+func collidesWithLabels(key string) bool {
+	return isLabelKey(key) || key == labelsKey
+}
+
+// validateLogger validates the zapdriver core of a logger built by one of the
+// constructors.
+func validateLogger(logger *zap.Logger, err error) (*zap.Logger, error) {
+	if err != nil {
+		return logger, err
+	}
+
+	if c, ok := logger.Core().(*core); ok {
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return logger, nil
+}
@@ -0,0 +1,104 @@
+package zapdriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Validate(ReportAllErrors(true), ServiceName("my service"), WithLogger(&recordingLogger{})))
+}
+
+func TestValidate_ReportAllErrors(t *testing.T) {
+	t.Parallel()
+
+	err := Validate(ReportAllErrors(true))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ReportAllErrors has no Cloud Logging client")
+
+	// Errors written to the local output reach Error Reporting through the
+	// Cloud Logging agent.
+	_, err = NewProduction(WrapCore(ReportAllErrors(true)))
+	assert.NoError(t, err)
+}
+
+func TestValidate_Resource(t *testing.T) {
+	t.Parallel()
+
+	resource := func(r *mrpb.MonitoredResource) func(*core) {
+		return func(c *core) { c.config.Resource = r }
+	}
+
+	assert.NoError(t, Validate(resource(&mrpb.MonitoredResource{
+		Type:   "gce_instance",
+		Labels: map[string]string{"project_id": "p", "instance_id": "1", "zone": "europe-west1-b"},
+	})))
+	assert.NoError(t, Validate(resource(&mrpb.MonitoredResource{
+		Type:   "custom_resource",
+		Labels: map[string]string{"anything": "goes"},
+	})))
+
+	err := Validate(resource(&mrpb.MonitoredResource{Labels: map[string]string{"zone": "europe-west1-b"}}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "monitored resource has no type")
+
+	err = Validate(resource(&mrpb.MonitoredResource{
+		Type:   "gce_instance",
+		Labels: map[string]string{"project_id": "p", "region": "europe-west1", "": "x"},
+	}))
+	require.Error(t, err)
+	assert.Len(t, multierr.Errors(err), 2)
+	assert.Contains(t, err.Error(), `monitored resource "gce_instance" has no label "region"`)
+	assert.Contains(t, err.Error(), `monitored resource "gce_instance" has a label without key`)
+}
+
+func TestValidate_LabelPrefix(t *testing.T) {
+	t.Parallel()
+
+	err := Validate(
+		MessageKey("labels.message"),
+		WithPayloadNamespace(labelsKey),
+		WithCommonLabels(map[string]string{"labels.team": "core", "region": "eu"}),
+	)
+	require.Error(t, err)
+
+	assert.Len(t, multierr.Errors(err), 3)
+	assert.Contains(t, err.Error(), `message key "labels.message" collides with the label prefix`)
+	assert.Contains(t, err.Error(), `payload namespace "logging.googleapis.com/labels" collides with the label prefix`)
+	assert.Contains(t, err.Error(), `common label "labels.team" collides with the label prefix`)
+}
+
+func TestValidate_Aggregated(t *testing.T) {
+	t.Parallel()
+
+	err := Validate(
+		WithFailover(NewFailover(nil, 1, time.Minute)),
+		WithLogNameSampling(map[string]float64{"access": 5}),
+		WithFieldRouting(nil, "labels.tenant", "app", 0),
+		WithLabelPrecedence(LabelPrecedence(42)),
+		WithFlushEvery(0),
+		WithLabelCardinalityLimit(0, CardinalityWarn),
+		Async(0, 1),
+		OnQueueWatermark(2, func(float64) {}),
+		WithLoggerNameRouting(nil, "app", 0),
+	)
+	require.Error(t, err)
+
+	assert.Len(t, multierr.Errors(err), 14)
+	assert.Contains(t, err.Error(), "failover 0 has no secondary logger")
+	assert.Contains(t, err.Error(), `sampling rate 5 of logger "access"`)
+	assert.Contains(t, err.Error(), `key "labels.tenant" collides with the label prefix`)
+	assert.Contains(t, err.Error(), "unknown label precedence 42")
+	assert.Contains(t, err.Error(), "flush interval 0s is not positive")
+	assert.Contains(t, err.Error(), "label cardinality limit 0 is not positive")
+	assert.Contains(t, err.Error(), "async writer has 0 workers")
+	assert.Contains(t, err.Error(), "queue watermark 0 has threshold 2 outside (0, 1]")
+	assert.Contains(t, err.Error(), `logger name routing template "app" does not contain {value}`)
+}
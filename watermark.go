@@ -0,0 +1,46 @@
+package zapdriver
+
+import (
+	"sync"
+)
+
+// OnQueueWatermark calls `callback` when the utilization of the async queue
+// (see `Async`) rises to `threshold` or above, for example 0.8 for 80%, or 1
+// when the queue is full and writes start to block. The callback is called
+// again after the utilization dropped below the threshold, and rises again.
+// This lets services shed load or alert before entries are delayed.
+//
+// The callback receives the utilization of the queue. It is called on the
+// goroutine writing the entry, so it should return quickly, and it must not log
+// using the same logger.
+func OnQueueWatermark(threshold float64, callback func(utilization float64)) func(*core) {
+	return func(c *core) {
+		c.config.Watermarks = append(c.config.Watermarks, &watermark{
+			threshold: threshold,
+			callback:  callback,
+		})
+	}
+}
+
+// watermark tracks whether the utilization of the async queue is above a
+// threshold.
+type watermark struct {
+	threshold float64
+	callback  func(float64)
+
+	mutex sync.Mutex
+	above bool
+}
+
+// observe records the current utilization, and calls the callback when it
+// crosses the threshold.
+func (w *watermark) observe(utilization float64) {
+	w.mutex.Lock()
+	crossed := !w.above && utilization >= w.threshold
+	w.above = utilization >= w.threshold
+	w.mutex.Unlock()
+
+	if crossed {
+		w.callback(utilization)
+	}
+}
@@ -0,0 +1,65 @@
+package zapdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWatermark(t *testing.T) {
+	t.Parallel()
+
+	var calls []float64
+	w := &watermark{threshold: 0.8, callback: func(u float64) { calls = append(calls, u) }}
+
+	w.observe(0.5)
+	w.observe(0.8)
+	w.observe(0.9)
+	w.observe(0.2)
+	w.observe(1)
+
+	assert.Equal(t, []float64{0.8, 1}, calls)
+}
+
+// blockingCore blocks all writes until it is released. If started is set, it
+// receives a value when a write starts.
+type blockingCore struct {
+	zapcore.Core
+	release chan struct{}
+	started chan struct{}
+}
+
+func (c *blockingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.started != nil {
+		c.started <- struct{}{}
+	}
+	<-c.release
+	return c.Core.Write(ent, fields)
+}
+
+func TestWriteQueueWatermark(t *testing.T) {
+	debugcore, logs := observer.New(zapcore.DebugLevel)
+	blocking := &blockingCore{Core: debugcore, release: make(chan struct{})}
+
+	var calls []float64
+	core := &core{Core: blocking, permLabels: newLabels()}
+	Async(1, 1)(core)
+	AsyncQueue(4, QueueBlock)(core)
+	OnQueueWatermark(0.5, func(u float64) { calls = append(calls, u) })(core)
+
+	logger := zap.New(core)
+	for i := 0; i < 4; i++ {
+		logger.Info("hello")
+	}
+
+	require.Len(t, calls, 1)
+	assert.True(t, calls[0] >= 0.5)
+
+	close(blocking.release)
+	require.NoError(t, Close(logger))
+	assert.Equal(t, 4, logs.Len())
+}
@@ -0,0 +1,72 @@
+package zapdrivertest
+
+import (
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/logging"
+)
+
+// AssertLogged fails the test if no entry with the severity and message was
+// recorded, and returns the first one otherwise.
+func (r *Recorder) AssertLogged(t testing.TB, severity logging.Severity, message string) logging.Entry {
+	t.Helper()
+
+	for _, e := range r.FilterMessage(message) {
+		if e.Severity == severity {
+			return e
+		}
+	}
+
+	t.Errorf("no %v entry with message %q was logged, got %d entries", severity, message, r.Len())
+	return logging.Entry{}
+}
+
+// AssertNotLogged fails the test if an entry with the message was recorded.
+func (r *Recorder) AssertNotLogged(t testing.TB, message string) {
+	t.Helper()
+
+	if n := len(r.FilterMessage(message)); n > 0 {
+		t.Errorf("expected no entry with message %q, got %d", message, n)
+	}
+}
+
+// AssertLabel fails the test if the entry doesn't have the label.
+func AssertLabel(t testing.TB, e logging.Entry, key, value string) {
+	t.Helper()
+
+	got, ok := e.Labels[key]
+	if !ok {
+		t.Errorf("entry %q has no label %q", Message(e), key)
+		return
+	}
+	if got != value {
+		t.Errorf("label %q of entry %q is %q, expected %q", key, Message(e), got, value)
+	}
+}
+
+// AssertField fails the test if the payload of the entry doesn't have the field.
+// Values are compared as written to the payload, for example an integer field
+// is an int64.
+func AssertField(t testing.TB, e logging.Entry, key string, value interface{}) {
+	t.Helper()
+
+	got, ok := Payload(e)[key]
+	if !ok {
+		t.Errorf("entry %q has no field %q", Message(e), key)
+		return
+	}
+	if !reflect.DeepEqual(got, value) {
+		t.Errorf("field %q of entry %q is %#v, expected %#v", key, Message(e), got, value)
+	}
+}
+
+// AssertTrace fails the test if the entry doesn't belong to the trace, in the
+// "projects/<project>/traces/<trace>" format.
+func AssertTrace(t testing.TB, e logging.Entry, trace string) {
+	t.Helper()
+
+	if e.Trace != trace {
+		t.Errorf("trace of entry %q is %q, expected %q", Message(e), e.Trace, trace)
+	}
+}
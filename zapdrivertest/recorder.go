@@ -0,0 +1,125 @@
+// Package zapdrivertest records the entries a zapdriver logger sends to Cloud
+// Logging, so the output of a service can be tested without a client:
+//
+//	logger, rec := zapdrivertest.NewLogger(t)
+//	logger.Info("user created", zapdriver.Label("user", "42"))
+//
+//	e := rec.AssertLogged(t, logging.Info, "user created")
+//	zapdrivertest.AssertLabel(t, e, "user", "42")
+package zapdrivertest
+
+import (
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/blendle/zapdriver"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// Recorder records the entries of a logger. It's safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []logging.Entry
+}
+
+// NewRecorder returns an empty recorder. Use its `Record` method as entry hook
+// to record the entries of a core with other options:
+//
+//	rec := zapdrivertest.NewRecorder()
+//	logger := zap.New(core, zapdriver.WrapCore(
+//	  zapdriver.ServiceName("my-service"),
+//	  zapdriver.WithEntryHook(rec.Record),
+//	))
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// NewLogger returns a logger recording every entry, at all levels, and writing
+// it to the test log.
+func NewLogger(t testing.TB, options ...zap.Option) (*zap.Logger, *Recorder) {
+	rec := NewRecorder()
+	options = append([]zap.Option{zapdriver.WrapCore(zapdriver.WithEntryHook(rec.Record))}, options...)
+
+	return zaptest.NewLogger(t, zaptest.WrapOptions(options...)), rec
+}
+
+// Record records the entry.
+func (r *Recorder) Record(e logging.Entry) {
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	r.mu.Unlock()
+}
+
+// Log records the entry, so the recorder can be used as the `EntryLogger` of a
+// core:
+//
+//	logger := zap.New(core, zapdriver.WrapCore(zapdriver.WithLogger(rec)))
+func (r *Recorder) Log(e logging.Entry) {
+	r.Record(e)
+}
+
+// Flush implements `EntryLogger`, there's nothing to flush.
+func (r *Recorder) Flush() error {
+	return nil
+}
+
+// Entries returns the recorded entries, in the order they were written.
+func (r *Recorder) Entries() []logging.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]logging.Entry, len(r.entries))
+	copy(entries, r.entries)
+
+	return entries
+}
+
+// Len returns the number of recorded entries.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.entries)
+}
+
+// Reset forgets the recorded entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.entries = nil
+	r.mu.Unlock()
+}
+
+// FilterMessage returns the recorded entries with the message.
+func (r *Recorder) FilterMessage(message string) []logging.Entry {
+	return r.filter(func(e logging.Entry) bool { return Message(e) == message })
+}
+
+// FilterSeverity returns the recorded entries with the severity.
+func (r *Recorder) FilterSeverity(severity logging.Severity) []logging.Entry {
+	return r.filter(func(e logging.Entry) bool { return e.Severity == severity })
+}
+
+func (r *Recorder) filter(match func(logging.Entry) bool) []logging.Entry {
+	var entries []logging.Entry
+	for _, e := range r.Entries() {
+		if match(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// Payload returns the structured payload of the entry, or nil if it has none.
+func Payload(e logging.Entry) map[string]interface{} {
+	payload, _ := e.Payload.(map[string]interface{})
+	return payload
+}
+
+// Message returns the message of the entry.
+func Message(e logging.Entry) string {
+	msg, _ := Payload(e)["message"].(string)
+	return msg
+}
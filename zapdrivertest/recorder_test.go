@@ -0,0 +1,138 @@
+package zapdrivertest
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/blendle/zapdriver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewLogger(t *testing.T) {
+	t.Parallel()
+
+	logger, rec := NewLogger(t)
+
+	req, err := http.NewRequest("GET", "http://example.com/users", nil)
+	require.NoError(t, err)
+
+	logger.Info("user created",
+		zapdriver.Label("user", "42"),
+		zap.Int("count", 3),
+		zapdriver.TraceContext("105445aa7843bc8bf206b12000100000", "0000000000000001", true, "test-project")[0],
+		zapdriver.HTTP(zapdriver.NewHTTP(req, nil)),
+	)
+	logger.Warn("quota low")
+
+	require.Equal(t, 2, rec.Len())
+
+	e := rec.AssertLogged(t, logging.Info, "user created")
+	AssertLabel(t, e, "user", "42")
+	AssertField(t, e, "count", int64(3))
+	AssertTrace(t, e, "projects/test-project/traces/105445aa7843bc8bf206b12000100000")
+	require.NotNil(t, e.HTTPRequest)
+	assert.Equal(t, "/users", e.HTTPRequest.Request.URL.Path)
+
+	rec.AssertLogged(t, logging.Warning, "quota low")
+	rec.AssertNotLogged(t, "user deleted")
+
+	assert.Len(t, rec.FilterSeverity(logging.Warning), 1)
+	assert.Len(t, rec.FilterMessage("user created"), 1)
+
+	rec.Reset()
+	assert.Equal(t, 0, rec.Len())
+}
+
+func TestRecorder_Record(t *testing.T) {
+	t.Parallel()
+
+	rec := NewRecorder()
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core, zapdriver.WrapCore(
+		zapdriver.WithCommonLabels(map[string]string{"env": "test"}),
+		zapdriver.WithEntryHook(rec.Record),
+	))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Info("hello", zap.String("key", "value"))
+		}()
+	}
+	wg.Wait()
+	logger.Debug("disabled")
+
+	entries := rec.Entries()
+	require.Len(t, entries, 10)
+	assert.Equal(t, 10, logs.Len())
+
+	for _, e := range entries {
+		// The payload is not reused by later entries.
+		AssertField(t, e, "key", "value")
+		AssertLabel(t, e, "env", "test")
+		assert.Equal(t, "hello", Message(e))
+	}
+}
+
+func TestAssertions_Fail(t *testing.T) {
+	t.Parallel()
+
+	rec := NewRecorder()
+	rec.Record(logging.Entry{
+		Severity: logging.Info,
+		Labels:   map[string]string{"one": "1"},
+		Payload:  map[string]interface{}{"message": "hello", "key": "value"},
+	})
+
+	ft := &fakeT{TB: t}
+	rec.AssertLogged(ft, logging.Error, "hello")
+	rec.AssertNotLogged(ft, "hello")
+	AssertLabel(ft, rec.Entries()[0], "one", "2")
+	AssertLabel(ft, rec.Entries()[0], "two", "2")
+	AssertField(ft, rec.Entries()[0], "key", "other")
+	AssertTrace(ft, rec.Entries()[0], "projects/p/traces/t")
+
+	assert.Equal(t, []string{
+		`no Error entry with message "hello" was logged, got 1 entries`,
+		`expected no entry with message "hello", got 1`,
+		`label "one" of entry "hello" is "1", expected "2"`,
+		`entry "hello" has no label "two"`,
+		`field "key" of entry "hello" is "value", expected "other"`,
+		`trace of entry "hello" is "", expected "projects/p/traces/t"`,
+	}, ft.errors)
+}
+
+// fakeT records the failures of assertions, instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder_EntryLogger(t *testing.T) {
+	t.Parallel()
+
+	rec := NewRecorder()
+	observed, _ := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed, zapdriver.WrapCore(zapdriver.WithLogger(rec)))
+
+	logger.Info("user created", zapdriver.Label("user", "42"))
+	require.NoError(t, logger.Sync())
+
+	e := rec.AssertLogged(t, logging.Info, "user created")
+	AssertLabel(t, e, "user", "42")
+}